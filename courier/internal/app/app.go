@@ -1,163 +1,238 @@
-// 0. на go структру конфига, который будет парсить переменные среды.
-// Три разных комплекта перемных среды. .env1
-// docker - единица деплоя
-
 package app
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
-	"net/http"
 	"os"
 	"strconv"
-	"time"
-
-	"courier/internal/repository"
-	"courier/internal/service"
-	"courier/internal/usecase"
 
 	"github.com/Kabanya/YAFDS/pkg/app"
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/config"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/metrics"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
 	pkg_repository "github.com/Kabanya/YAFDS/pkg/repository"
-	"github.com/Kabanya/YAFDS/pkg/utils"
+	"github.com/Kabanya/YAFDS/pkg/router"
+	"github.com/Kabanya/YAFDS/pkg/server"
+	"github.com/Kabanya/YAFDS/pkg/tracing"
+	pkg_usecase "github.com/Kabanya/YAFDS/pkg/usecase"
+	"github.com/Kabanya/YAFDS/pkg/user"
 
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 )
 
-func Run() {
-	utils.InitFileLogger("courier_log_info.txt")
-	logger, err := utils.Logger()
+func Run() error {
+	var cfg Config
+	cfgErr := config.Load(&cfg, config.Options{})
+	if config.PrintRequested(os.Args[1:]) {
+		config.Fprint(os.Stdout, &cfg)
+		return cfgErr
+	}
+	if openapi.PrintRequested(os.Args[1:]) {
+		return openapi.Fprint(os.Stdout, apiDocument())
+	}
+
+	if cfgErr != nil {
+		return cfgErr
+	}
+
+	logger, closeLog, err := logging.Setup(cfg.Log)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+		return err
 	}
-	logger.Println("courier service started")
+	defer closeLog()
 
-	// Load environment variables from .env
-	err = utils.LoadEnv(utils.PathToEnv)
+	closeTracing, err := tracing.Setup(cfg.Trace, "courier")
 	if err != nil {
-		logger.Printf("Failed to load .env file: %v", err)
-		panic(err)
+		return err
 	}
+	defer closeTracing()
+	logger.Info("courier service started")
 
 	// Connection to db
-	dbName := os.Getenv("COURIER_DB")
-	if dbName == "" {
-		dbName = "courier_db"
-	}
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), dbName)
-	db, err := sql.Open("postgres", connStr)
+	db, err := tracing.Open("postgres", cfg.Postgres.DSN(cfg.DBName), "courier_db")
 	if err != nil {
-		logger.Printf("Failed to open database: %v", err)
-		panic(err)
+		logger.Error("Failed to open database", "error", err)
+		return err
 	}
 	defer db.Close()
 
 	err = db.Ping()
 	if err != nil {
-		logger.Printf("Failed to ping database: %v", err)
-		panic(err)
+		logger.Error("Failed to ping database", "error", err)
+		return err
 	}
-	logger.Println("Successfully connected to database")
+	logger.Info("Successfully connected to database")
 
-	ordersDBName := os.Getenv("ORDER_DB")
-	if ordersDBName == "" {
-		ordersDBName = "order_db"
-	}
-	ordersConnStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), ordersDBName)
-	ordersDB, err := sql.Open("postgres", ordersConnStr)
+	ordersDB, err := tracing.Open("postgres", cfg.Postgres.DSN(cfg.OrdersDB), "order_db")
 	if err != nil {
-		logger.Printf("Failed to open orders database: %v", err)
-		panic(err)
+		logger.Error("Failed to open orders database", "error", err)
+		return err
 	}
 	defer ordersDB.Close()
 
 	if err := ordersDB.Ping(); err != nil {
-		logger.Printf("Failed to ping orders database: %v", err)
-		panic(err)
+		logger.Error("Failed to ping orders database", "error", err)
+		return err
 	}
-	logger.Println("Successfully connected to orders database")
-
-	userRepository := repository.NewUser(db)
-	logger.Println("Initialized user repository")
+	logger.Info("Successfully connected to orders database")
 
 	ordersRepository := pkg_repository.NewPostgresRepository(ordersDB, db, db)
-	logger.Println("Initialized orders repository")
-
-	redisDB := 0
-	if redisDBStr := os.Getenv("REDIS_DB"); redisDBStr != "" {
-		if parsed, err := strconv.Atoi(redisDBStr); err == nil {
-			redisDB = parsed
-		} else {
-			logger.Printf("Invalid REDIS_DB value '%s', defaulting to 0: %v", redisDBStr, err)
-		}
-	}
+	logger.Info("Initialized orders repository")
 
 	redisClient := redis.NewClient(&redis.Options{
-		Addr:     os.Getenv("REDIS_ADDRESS"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-		DB:       redisDB,
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
 	})
 	if err := redisClient.Ping(context.Background()).Err(); err != nil {
-		logger.Printf("Failed to connect to Redis: %v", err)
-		panic(err)
+		logger.Error("Failed to connect to Redis", "error", err)
+		return err
 	}
 	defer redisClient.Close()
-	logger.Println("Successfully connected to Redis")
-
-	sessionTTL := utils.TimeTtl30Minutes
-	if ttlStr := os.Getenv("SESSION_TTL"); ttlStr != "" {
-		var parsed time.Duration
-		if d, err := time.ParseDuration(ttlStr); err == nil {
-			parsed = d
-		} else if sec, err := strconv.ParseInt(ttlStr, 10, 64); err == nil {
-			parsed = time.Duration(sec) * time.Second
+	logger.Info("Successfully connected to Redis")
+
+	health := server.NewHealth().
+		Add("courier_db", server.PingDB(db)).
+		Add("order_db", server.PingDB(ordersDB)).
+		Add("redis", server.PingRedis(redisClient))
+
+	metrics.InstrumentDB("courier_db", db)
+	metrics.InstrumentDB("order_db", ordersDB)
+	metrics.InstrumentRedis(redisClient)
+
+	port := strconv.Itoa(cfg.Port)
+	addr := ":" + port
+	routes := router.New()
+	doc := apiDocument()
+	srv := server.New(addr, router.Chain(routes,
+		logging.Middleware,
+		router.CORS(cfg.HTTP.AllowedOrigins()),
+		router.LimitBody(cfg.HTTP.MaxBodyBytes),
+		tracing.Middleware,
+		metrics.Middleware,
+		openapi.Validate(doc, cfg.OpenAPI),
+		router.Recover,
+	), cfg.HTTP).WithHealth(health)
+
+	passwordPolicy := auth.DefaultPasswordPolicy
+	passwordPolicy.MinLength = cfg.Auth.PasswordMinLength
+	if breachedPath := cfg.Auth.BreachedPasswordsFile; breachedPath != "" {
+		breached, err := auth.LoadBreachedPasswords(breachedPath)
+		if err != nil {
+			logger.Warn("Breached password check disabled", "error", err)
 		} else {
-			logger.Printf("Invalid SESSION_TTL '%s', using default %v: %v", ttlStr, sessionTTL, err)
-			parsed = 0
-		}
-		if parsed > 0 {
-			sessionTTL = parsed
+			passwordPolicy.Breached = breached
+			logger.Info("Loaded breached passwords", "count", breached.Len(), "file", breachedPath)
 		}
 	}
-	if sessionTTL <= 0 {
-		logger.Printf("SESSION_TTL must be positive, using default %v", utils.TimeTtl30Minutes)
-		sessionTTL = utils.TimeTtl30Minutes
+
+	// Opaque Redis sessions by default; with AUTH_KEYS_DIR the services issue
+	// signed access tokens that any of them can verify with the shared keys.
+	var sessionManager auth.SessionManager = auth.NewRedisSessionManager(redisClient, "courier")
+	var signingKeys *auth.KeySet
+	if keysDir := cfg.Auth.KeysDir; keysDir != "" {
+		keys, err := auth.LoadOrCreateKeySet(keysDir)
+		if err != nil {
+			logger.Error("Failed to load signing keys", "dir", keysDir, "error", err)
+			return err
+		}
+		jwtSessions, err := auth.NewJWTSessionManager(auth.JWTConfig{
+			Keys:    keys,
+			Refresh: auth.NewRedisRefreshStore(redisClient),
+			Issuer:  "yafds-courier",
+			Role:    "courier",
+		})
+		if err != nil {
+			logger.Error("Failed to initialize token sessions", "error", err)
+			return err
+		}
+		sessionManager, signingKeys = jwtSessions, keys
+		logger.Info("Sessions: signed access tokens", "keys_dir", keysDir)
 	}
 
-	userService := service.NewUserService(userRepository, redisClient, sessionTTL)
-	logger.Println("Initialized user service")
+	var smtpChannel *notify.SMTPChannel
+	if cfg.SMTP.Addr != "" {
+		smtpChannel = notify.NewSMTPChannel(notify.SMTPConfig{
+			Addr:     cfg.SMTP.Addr,
+			From:     cfg.SMTP.From,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+		})
+	}
+	// Reset tokens go out only by email, to the recovery email the user
+	// confirmed; without SMTP both flows stay off instead of leaving tokens
+	// in logs or sink files.
+	var mailer user.Mailer
+	if smtpChannel != nil {
+		mailer = notify.NewAccountMailer(notify.NewPostgresPreferences(ordersDB), nil, smtpChannel)
+	} else {
+		logger.Warn("Password reset and recovery email disabled: SMTP_ADDR not set")
+	}
 
-	userUseCase := usecase.NewUserUseCase(userService)
-	logger.Println("Initialized user usecase")
+	userService, err := user.NewService(user.Config{
+		Schema:     courierSchema,
+		DB:         db,
+		Redis:      redisClient,
+		Sessions:   sessionManager,
+		SessionTTL: cfg.Auth.SessionTTL,
+		Validator:  auth.NewPolicyValidator(passwordPolicy),
+		Mailer:     mailer,
+		MFAStore:   auth.NewPostgresMFAStore(db, "COURIER_MFA"),
+		MFAIssuer:  "YAFDS Courier",
+	})
+	if err != nil {
+		logger.Error("Failed to initialize user service", "error", err)
+		return err
+	}
+	logger.Info("Initialized user service")
 
-	handler := NewHandler(userUseCase)
-	logger.Println("Initialized handler")
+	// Couriers only read earnings here, tips are paid from the customer service.
+	tipUseCase := pkg_usecase.NewTipUseCase(ordersRepository, pkg_repository.NewTipRepository(ordersDB, db), nil)
+	logger.Info("Initialized tip usecase")
 
 	// registry endpoints
-	http.HandleFunc("/health", handler.Health)
-	http.HandleFunc("/register", handler.Register)
-	http.HandleFunc("/login", handler.Login)
-	http.HandleFunc("/orders", app.NewListHandler(ordersRepository))
-
-	port := os.Getenv("COURIER_PORT")
-	if port == "" {
-		port = "8090"
+	health.Mount(routes)
+	routes.Handle("GET /metrics", metrics.Handler())
+	routes.Handle("GET /openapi.json", doc.Handler())
+	routes.Handle("GET /docs", doc.DocsHandler("/openapi.json"))
+	routes.Handle("GET /docs/{file}", openapi.DocsAssetsHandler())
+	user.NewHandler(userService).Mount(routes)
+	if signingKeys != nil {
+		routes.HandleFunc("GET /.well-known/jwks.json", app.NewJWKSHandler(signingKeys))
+	}
+	routes.HandleFunc("GET /orders", app.NewListHandler(ordersRepository))
+	routes.HandleFunc("GET /earnings", app.NewCourierEarningsHandler(tipUseCase))
+	for _, pattern := range doc.Missing(routes.Patterns()) {
+		logger.Warn("Route missing from the OpenAPI document", "route", pattern)
 	}
-	addr := ":" + port
-	logger.Println("Endpoints registered:")
-	logger.Printf("  POST http://localhost:%s/register - Register user with password", port)
-	logger.Printf("  POST http://localhost:%s/login - Login user with password", port)
-	logger.Printf("  GET  http://localhost:%s/orders - List orders", port)
-	logger.Printf("Starting HTTP server on %s", addr)
 
-	err = http.ListenAndServe(addr, nil)
+	logger.Debug("Endpoint", "route", "GET /livez", "description", "Process is up (also GET /health)")
+	logger.Debug("Endpoint", "route", "GET /readyz", "description", "Dependency status and latencies")
+	logger.Debug("Endpoint", "route", "GET /metrics", "description", "Prometheus metrics")
+	logger.Debug("Endpoint", "route", "GET /openapi.json", "description", "OpenAPI document")
+	logger.Debug("Endpoint", "route", "GET /docs", "description", "API documentation page")
+	logger.Debug("Endpoint", "route", "POST /register", "description", "Register user with password")
+	logger.Debug("Endpoint", "route", "POST /login", "description", "Login user with password")
+	logger.Debug("Endpoint", "route", "POST /login/mfa", "description", "Finish a two-factor login with mfa_token and code")
+	logger.Debug("Endpoint", "route", "POST /mfa/enroll", "description", "Start two-factor setup (Authorization: Bearer <token>)")
+	logger.Debug("Endpoint", "route", "POST /mfa/confirm", "description", "Enable two-factor login with a first code")
+	logger.Debug("Endpoint", "route", "POST /mfa/disable", "description", "Disable two-factor login with a code")
+	logger.Debug("Endpoint", "route", "POST /token/refresh", "description", "Exchange a refresh token (AUTH_KEYS_DIR only)")
+	logger.Debug("Endpoint", "route", "POST /password/change", "description", "Change password (Authorization: Bearer <token>)")
+	logger.Debug("Endpoint", "route", "POST /password/reset", "description", "Request a password reset token")
+	logger.Debug("Endpoint", "route", "POST /password/reset/confirm", "description", "Set a new password with a reset token")
+	logger.Debug("Endpoint", "route", "GET /orders", "description", "List orders")
+	logger.Debug("Endpoint", "route", "GET /earnings?courier_id=<uuid>&from=&to=", "description", "Completed orders and tips")
+	logger.Info("Starting HTTP server", "addr", addr)
+
+	err = srv.Run(context.Background())
 	if err != nil {
-		logger.Printf("Server error: %v", err)
+		logger.Error("Server error", "error", err)
 	}
 
-	logger.Println("Process of courier is finished")
-	utils.CloseLogger()
+	logger.Info("Process of courier is finished")
+	return err
 }
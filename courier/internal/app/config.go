@@ -0,0 +1,20 @@
+package app
+
+import "github.com/Kabanya/YAFDS/pkg/config"
+
+// Config is everything the courier service reads at startup; run the
+// server with --print-config to see the values it would use.
+type Config struct {
+	Port     int    `env:"COURIER_PORT" default:"8090" min:"1" max:"65535"`
+	DBName   string `env:"COURIER_DB" default:"courier_db"`
+	OrdersDB string `env:"ORDER_DB" default:"order_db"`
+
+	Postgres config.Postgres
+	SMTP     config.SMTP
+	Redis    config.Redis
+	Auth     config.Auth
+	HTTP     config.HTTP
+	Log      config.Log
+	Trace    config.Trace
+	OpenAPI  config.OpenAPI
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"github.com/Kabanya/YAFDS/pkg/app"
+	"github.com/Kabanya/YAFDS/pkg/metrics"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+	"github.com/Kabanya/YAFDS/pkg/server"
+	"github.com/Kabanya/YAFDS/pkg/user"
+)
+
+// apiDocument describes every route Run mounts.
+func apiDocument() *openapi.Document {
+	doc := openapi.New("YAFDS courier API", "1.0.0")
+	doc.AddAll(server.Operations)
+	doc.Add("GET /metrics", metrics.Operation)
+	doc.AddAll(openapi.Operations)
+	doc.AddAll(user.Operations(courierSchema))
+	doc.AddAll(app.Operations,
+		"GET /.well-known/jwks.json",
+		"GET /orders",
+		"GET /earnings",
+	)
+	return doc
+}
@@ -0,0 +1,17 @@
+package app
+
+import "github.com/Kabanya/YAFDS/pkg/user"
+
+// courierSchema maps the COURIERS table for the shared user module. New
+// couriers start active at 0,0 until the app reports their location.
+var courierSchema = user.Schema{
+	Table:  "COURIERS",
+	Entity: "courier",
+	Fields: []user.Field{
+		{Column: "transport_type", Default: "bicycle"},
+	},
+	Insert: []user.Value{
+		{Column: "is_active", Value: true},
+		{Column: "geolocation", Value: "0,0"},
+	},
+}
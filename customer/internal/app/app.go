@@ -1,15 +1,10 @@
-// 0. на go структру конфига, который будет парсить переменные среды.
-// Три разных комплекта перемных среды. .env1
-// docker - единица деплоя
-
 package app
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -19,183 +14,359 @@ import (
 
 	orderapp "github.com/Kabanya/YAFDS/pkg/app"
 	"github.com/Kabanya/YAFDS/pkg/app/clients"
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/config"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/metrics"
+	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
 	orderrepo "github.com/Kabanya/YAFDS/pkg/repository"
-	"github.com/Kabanya/YAFDS/pkg/utils"
+	"github.com/Kabanya/YAFDS/pkg/router"
+	"github.com/Kabanya/YAFDS/pkg/scheduler"
+	"github.com/Kabanya/YAFDS/pkg/server"
+	"github.com/Kabanya/YAFDS/pkg/tracing"
+	orderusecase "github.com/Kabanya/YAFDS/pkg/usecase"
+	"github.com/Kabanya/YAFDS/pkg/user"
+	"github.com/Kabanya/YAFDS/pkg/webhook"
 
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 )
 
-func Run() {
-	utils.InitFileLogger("customer_log_info.txt")
-	logger, err := utils.Logger()
-	if err != nil {
-		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+func Run() error {
+	var cfg Config
+	cfgErr := config.Load(&cfg, config.Options{})
+	if config.PrintRequested(os.Args[1:]) {
+		config.Fprint(os.Stdout, &cfg)
+		return cfgErr
+	}
+	if openapi.PrintRequested(os.Args[1:]) {
+		return openapi.Fprint(os.Stdout, apiDocument())
+	}
+
+	if cfgErr != nil {
+		return cfgErr
 	}
-	logger.Println("Customer service started")
 
-	// Load environment variables from .env
-	err = utils.LoadEnv(utils.PathToEnv)
+	logger, closeLog, err := logging.Setup(cfg.Log)
 	if err != nil {
-		logger.Printf("Failed to load .env file: %v", err)
-		panic(err)
+		return err
 	}
+	defer closeLog()
 
-	customerDBName := os.Getenv("CUSTOMER_DB")
-	if customerDBName == "" {
-		customerDBName = "customer_db"
+	closeTracing, err := tracing.Setup(cfg.Trace, "customer")
+	if err != nil {
+		return err
 	}
+	defer closeTracing()
+	logger.Info("Customer service started")
 
 	// Connection to db (customers)
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), customerDBName)
-	db, err := sql.Open("postgres", connStr)
+	db, err := tracing.Open("postgres", cfg.Postgres.DSN(cfg.DBName), "customer_db")
 	if err != nil {
-		logger.Printf("Failed to open database: %v", err)
-		panic(err)
+		logger.Error("Failed to open database", "error", err)
+		return err
 	}
 	defer db.Close()
 
 	err = db.Ping()
 	if err != nil {
-		logger.Printf("Failed to ping database: %v", err)
-		panic(err)
+		logger.Error("Failed to ping database", "error", err)
+		return err
 	}
-	logger.Println("Successfully connected to database")
+	logger.Info("Successfully connected to database")
 
-	ordersDBName := os.Getenv("ORDER_DB")
-	if ordersDBName == "" {
-		ordersDBName = "order_db"
-	}
-	ordersConnStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), ordersDBName)
-	ordersDB, err := sql.Open("postgres", ordersConnStr)
+	ordersDB, err := tracing.Open("postgres", cfg.Postgres.DSN(cfg.OrdersDB), "order_db")
 	if err != nil {
-		logger.Printf("Failed to open orders database: %v", err)
-		panic(err)
+		logger.Error("Failed to open orders database", "error", err)
+		return err
 	}
 	defer ordersDB.Close()
 
 	if err := ordersDB.Ping(); err != nil {
-		logger.Printf("Failed to ping orders database: %v", err)
-		panic(err)
+		logger.Error("Failed to ping orders database", "error", err)
+		return err
 	}
-	logger.Println("Successfully connected to orders database")
+	logger.Info("Successfully connected to orders database")
 
-	courierDBName := os.Getenv("COURIER_DB")
-	if courierDBName == "" {
-		courierDBName = "courier_db"
-	}
-	courierConnStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), courierDBName)
-	courierDB, err := sql.Open("postgres", courierConnStr)
+	courierDB, err := tracing.Open("postgres", cfg.Postgres.DSN(cfg.CouriersDB), "courier_db")
 	if err != nil {
-		logger.Printf("Failed to open courier database: %v", err)
-		panic(err)
+		logger.Error("Failed to open courier database", "error", err)
+		return err
 	}
 	defer courierDB.Close()
 
 	if err := courierDB.Ping(); err != nil {
-		logger.Printf("Failed to ping courier database: %v", err)
-		panic(err)
+		logger.Error("Failed to ping courier database", "error", err)
+		return err
 	}
-	logger.Println("Successfully connected to courier database")
-
-	userRepository := repository.NewUserRepo(db)
-	logger.Println("Initialized user repository")
+	logger.Info("Successfully connected to courier database")
 
 	ordersRepository := orderrepo.NewPostgresRepository(ordersDB, db, courierDB)
-	logger.Println("Initialized orders repository")
-
-	restaurantAPIURL := os.Getenv("RESTAURANT_API_URL")
-	if restaurantAPIURL == "" {
-		restaurantAPIURL = "http://localhost:8092"
-	}
-	restaurantClient := clients.NewHTTPRestaurantClient(restaurantAPIURL)
-	logger.Printf("Initialized restaurant client with base URL: %s", restaurantAPIURL)
+	logger.Info("Initialized orders repository")
 
-	redisDB := 0
-	if redisDBStr := os.Getenv("REDIS_DB"); redisDBStr != "" {
-		if parsed, err := strconv.Atoi(redisDBStr); err == nil {
-			redisDB = parsed
-		} else {
-			logger.Printf("Invalid REDIS_DB value '%s', defaulting to 0: %v", redisDBStr, err)
-		}
-	}
+	restaurantClient := clients.NewHTTPRestaurantClient(cfg.RestaurantAPIURL).WithServiceToken(cfg.Auth.ServiceToken)
+	logger.Info("Initialized restaurant client", "base_url", cfg.RestaurantAPIURL)
 
 	redisClient := redis.NewClient(&redis.Options{
-		Addr:     os.Getenv("REDIS_ADDRESS"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-		DB:       redisDB,
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
 	})
 	if err := redisClient.Ping(context.Background()).Err(); err != nil {
-		logger.Printf("Failed to connect to Redis: %v", err)
-		panic(err)
+		logger.Error("Failed to connect to Redis", "error", err)
+		return err
 	}
 	defer redisClient.Close()
-	logger.Println("Successfully connected to Redis")
-
-	sessionTTL := utils.TimeTtl30Minutes
-	if ttlStr := os.Getenv("SESSION_TTL"); ttlStr != "" {
-		var parsed time.Duration
-		if d, err := time.ParseDuration(ttlStr); err == nil {
-			parsed = d
-		} else if sec, err := strconv.ParseInt(ttlStr, 10, 64); err == nil {
-			parsed = time.Duration(sec) * time.Second
+	logger.Info("Successfully connected to Redis")
+
+	health := server.NewHealth().
+		Add("customer_db", server.PingDB(db)).
+		Add("order_db", server.PingDB(ordersDB)).
+		Add("courier_db", server.PingDB(courierDB)).
+		Add("redis", server.PingRedis(redisClient)).
+		Add("restaurant_api", server.PingHTTP(cfg.RestaurantAPIURL+"/livez"))
+
+	metrics.InstrumentDB("customer_db", db)
+	metrics.InstrumentDB("order_db", ordersDB)
+	metrics.InstrumentDB("courier_db", courierDB)
+	metrics.InstrumentRedis(redisClient)
+
+	port := strconv.Itoa(cfg.Port)
+	addr := ":" + port
+	routes := router.New()
+	doc := apiDocument()
+	srv := server.New(addr, router.Chain(routes,
+		logging.Middleware,
+		router.CORS(cfg.HTTP.AllowedOrigins()),
+		router.LimitBody(cfg.HTTP.MaxBodyBytes),
+		tracing.Middleware,
+		metrics.Middleware,
+		openapi.Validate(doc, cfg.OpenAPI),
+		router.Recover,
+	), cfg.HTTP).WithHealth(health)
+
+	passwordPolicy := auth.DefaultPasswordPolicy
+	passwordPolicy.MinLength = cfg.Auth.PasswordMinLength
+	if breachedPath := cfg.Auth.BreachedPasswordsFile; breachedPath != "" {
+		breached, err := auth.LoadBreachedPasswords(breachedPath)
+		if err != nil {
+			logger.Warn("Breached password check disabled", "error", err)
 		} else {
-			logger.Printf("Invalid SESSION_TTL '%s', using default %v: %v", ttlStr, sessionTTL, err)
-			parsed = 0
+			passwordPolicy.Breached = breached
+			logger.Info("Loaded breached passwords", "count", breached.Len(), "file", breachedPath)
+		}
+	}
+
+	// Opaque Redis sessions by default; with AUTH_KEYS_DIR the services issue
+	// signed access tokens that any of them can verify with the shared keys.
+	var sessionManager auth.SessionManager = auth.NewRedisSessionManager(redisClient, "customer")
+	var signingKeys *auth.KeySet
+	if keysDir := cfg.Auth.KeysDir; keysDir != "" {
+		keys, err := auth.LoadOrCreateKeySet(keysDir)
+		if err != nil {
+			logger.Error("Failed to load signing keys", "dir", keysDir, "error", err)
+			return err
 		}
-		if parsed > 0 {
-			sessionTTL = parsed
+		jwtSessions, err := auth.NewJWTSessionManager(auth.JWTConfig{
+			Keys:    keys,
+			Refresh: auth.NewRedisRefreshStore(redisClient),
+			Issuer:  "yafds-customer",
+			Role:    "customer",
+		})
+		if err != nil {
+			logger.Error("Failed to initialize token sessions", "error", err)
+			return err
 		}
+		sessionManager, signingKeys = jwtSessions, keys
+		logger.Info("Sessions: signed access tokens", "keys_dir", keysDir)
+	}
+
+	authn := auth.NewAuthenticator(sessionManager, nil)
+
+	walletLogin := auth.WalletLoginConfig{
+		Domain:  cfg.WalletDomain,
+		URI:     cfg.WalletURI,
+		ChainID: cfg.WalletChainID,
+	}
+	if walletLogin.Domain == "" {
+		walletLogin.Domain = fmt.Sprintf("localhost:%d", cfg.Port)
 	}
-	if sessionTTL <= 0 {
-		logger.Printf("SESSION_TTL must be positive, using default %v", utils.TimeTtl30Minutes)
-		sessionTTL = utils.TimeTtl30Minutes
+
+	var smtpChannel *notify.SMTPChannel
+	if cfg.SMTP.Addr != "" {
+		smtpChannel = notify.NewSMTPChannel(notify.SMTPConfig{
+			Addr:     cfg.SMTP.Addr,
+			From:     cfg.SMTP.From,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+		})
+	}
+	// Reset tokens go out only by email, to the recovery email the user
+	// confirmed; without SMTP both flows stay off instead of leaving tokens
+	// in logs or sink files.
+	var mailer user.Mailer
+	if smtpChannel != nil {
+		mailer = notify.NewAccountMailer(notify.NewPostgresPreferences(ordersDB), nil, smtpChannel)
+	} else {
+		logger.Warn("Password reset and recovery email disabled: SMTP_ADDR not set")
+	}
+
+	userService, err := user.NewService(user.Config{
+		Schema:      customerSchema,
+		DB:          db,
+		Redis:       redisClient,
+		Sessions:    sessionManager,
+		SessionTTL:  cfg.Auth.SessionTTL,
+		Validator:   auth.NewPolicyValidator(passwordPolicy),
+		Mailer:      mailer,
+		WalletLogin: &walletLogin,
+	})
+	if err != nil {
+		logger.Error("Failed to initialize user service", "error", err)
+		return err
 	}
+	logger.Info("Initialized user service")
 
-	userService := service.NewUserService(userRepository, redisClient, sessionTTL)
-	logger.Println("Initialized user service")
+	notifySinkDir := cfg.NotifySinkDir
+	notificationPreferences := notify.NewPostgresPreferences(ordersDB)
+	notifier := notify.New(notificationPreferences, notify.DefaultTemplates(), notify.NewPostgresQueue(ordersDB)).
+		Register(notify.NewFileChannel(notify.ChannelSMS, filepath.Join(notifySinkDir, "sms.jsonl"))).
+		Register(notify.NewFileChannel(notify.ChannelPush, filepath.Join(notifySinkDir, "push.jsonl")))
+	if smtpChannel != nil {
+		notifier.Register(smtpChannel)
+		logger.Info("Notifications: email via SMTP", "addr", cfg.SMTP.Addr)
+	} else {
+		notifier.Register(notify.NewFileChannel(notify.ChannelEmail, filepath.Join(notifySinkDir, "email.jsonl")))
+		logger.Info("Notifications: SMTP_ADDR not set, email goes to files", "dir", notifySinkDir)
+	}
+	srv.Go("notifier", func(ctx context.Context) { notifier.Run(ctx, notify.DefaultInterval) })
+	logger.Info("Initialized notification sender")
 
-	userUseCase := usecase.NewUserUseCase(userService)
-	logger.Println("Initialized user usecase")
+	webhookDispatcher := webhook.NewDispatcher(webhook.NewPostgresStore(ordersDB))
+	srv.Go("webhooks", func(ctx context.Context) { webhookDispatcher.Run(ctx, webhook.DefaultInterval) })
+	logger.Info("Initialized webhook dispatcher")
 
 	walletClient := clients.NewStubWalletClient()
-	_ = walletClient //[[maybe_unused]]
-	// orderUseCase := orderusecase.NewOrderUseCase(ordersRepository, walletClient)
-	logger.Println("Initialized order usecase")
+	tipUseCase := orderusecase.NewTipUseCase(ordersRepository, orderrepo.NewTipRepository(ordersDB, courierDB), walletClient)
+	srv.Go("tips", func(ctx context.Context) { tipUseCase.Run(ctx, orderusecase.DefaultTipSettleInterval) })
+	logger.Info("Initialized tip usecase")
+
+	orderUseCase := orderusecase.NewOrderUseCase(ordersRepository, walletClient,
+		orderusecase.WithRestaurantNotifier(restaurantClient),
+		orderusecase.WithMenuClient(restaurantClient),
+		orderusecase.WithEventNotifier(notifier),
+		orderusecase.WithEventNotifier(webhookDispatcher),
+		orderusecase.WithCancellationPolicy(orderusecase.NewCancellationPolicy(cfg.KitchenRefundRate)),
+		orderusecase.WithTipVoider(tipUseCase),
+	)
+	logger.Info("Initialized order usecase")
+
+	reviewRepository := orderrepo.NewReviewRepository(ordersDB)
+	reviewUseCase := orderusecase.NewReviewUseCase(ordersRepository, reviewRepository)
+	logger.Info("Initialized review usecase")
+
+	releaser := scheduler.New(ordersRepository, cfg.SchedulerInterval).
+		OnRelease(func(ctx context.Context, order models.Order) {
+			data := map[string]any{"order_id": order.ID.String()}
+			if order.DeliverAt != nil {
+				data["deliver_at"] = order.DeliverAt.Format(time.RFC3339)
+			}
+			if err := notifier.Notify(ctx, notify.Notification{Event: notify.EventOrderReleased, UserID: order.RestaurantID, RestaurantID: order.RestaurantID, Data: data}); err != nil {
+				logger.Error("Failed to queue release notification", "order_id", order.ID, "error", err)
+			}
+			if err := webhookDispatcher.Publish(ctx, order.RestaurantID, notify.EventOrderReleased, data); err != nil {
+				logger.Error("Failed to queue release webhook", "order_id", order.ID, "error", err)
+			}
+		})
+	srv.Go("scheduler", releaser.Run)
+	logger.Info("Initialized scheduled orders releaser")
 
-	handler := NewHandler(userUseCase, db)
-	logger.Println("Initialized handler")
+	cartRepository := repository.NewCartRepo(redisClient, cfg.CartTTL)
+	cartService := service.NewCartService(cartRepository, restaurantClient, ordersRepository)
+	cartUseCase := usecase.NewCartUseCase(cartService)
+	logger.Info("Initialized cart usecase", "ttl", cfg.CartTTL)
+
+	handler := NewHandler(cartUseCase)
+	logger.Info("Initialized handler")
 
 	// registry endpoints
-	http.HandleFunc("/health", handler.Health)
-	http.HandleFunc("/register", handler.Register)
-	http.HandleFunc("/login", handler.Login)
-	http.HandleFunc("/orders", orderapp.NewOrderHandler(ordersRepository, restaurantClient))
-	// http.HandleFunc("/orders/", orderapp.NewOrderActionHandler(ordersRepository, restaurantClient, orderUseCase))
-	http.HandleFunc("/couriers", orderapp.NewCouriersHandler(courierDB))
-	http.HandleFunc("/restaurants", orderapp.NewRestaurantsHandler(db))
-	http.HandleFunc("/menu", orderapp.NewRestaurantMenuHandler(restaurantClient))
-
-	logger.Println("Endpoints registered:")
-	logger.Println("  POST http://localhost:8091/register - Register user with password")
-	logger.Println("  POST http://localhost:8091/login - Login user with password")
-	logger.Println("  POST/GET http://localhost:8091/orders - Create/List orders")
-	logger.Println("  POST http://localhost:8091/orders/{order_id}/pay - Pay for order")
-	logger.Println("  POST http://localhost:8091/orders/{order_id}/accept - Accept order")
-	logger.Println("  POST http://localhost:8091/orders/{order_id}/items - Add order item")
-	logger.Println("  GET http://localhost:8091/couriers - List active couriers")
-	logger.Println("  GET http://localhost:8091/restaurants - List active restaurants")
-	logger.Println("  GET http://localhost:8091/menu?restaurant_id=<uuid> - Show restaurant menu items")
-	logger.Println("Starting HTTP server on :8091")
-
-	err = http.ListenAndServe(":8091", nil)
+	health.Mount(routes)
+	routes.Handle("GET /metrics", metrics.Handler())
+	routes.Handle("GET /openapi.json", doc.Handler())
+	routes.Handle("GET /docs", doc.DocsHandler("/openapi.json"))
+	routes.Handle("GET /docs/{file}", openapi.DocsAssetsHandler())
+	user.NewHandler(userService).Mount(routes)
+	if signingKeys != nil {
+		routes.HandleFunc("GET /.well-known/jwks.json", orderapp.NewJWKSHandler(signingKeys))
+	}
+	routes.HandleFunc("POST /orders", orderapp.NewCreateHandler(ordersRepository, restaurantClient, restaurantClient))
+	routes.HandleFunc("GET /orders", orderapp.NewListHandler(ordersRepository))
+	routes.HandleFunc("POST /orders/{order_id}/pay", orderapp.NewPayHandler(orderUseCase, tipUseCase))
+	routes.HandleFunc("POST /orders/{order_id}/tip", orderapp.NewTipHandler(tipUseCase))
+	routes.HandleFunc("POST /orders/{order_id}/cancel", orderapp.NewCancelHandler(orderUseCase))
+	routes.HandleFunc("POST /orders/{order_id}/reorder", orderapp.NewReorderHandler(orderUseCase))
+	orderReview := orderapp.NewOrderReviewHandler(reviewUseCase)
+	routes.HandleFunc("GET /orders/{order_id}/review", orderReview)
+	routes.HandleFunc("POST /orders/{order_id}/review", orderReview)
+	routes.HandleFunc("POST /orders/{order_id}/accept", orderapp.NewAcceptHandler(ordersRepository, restaurantClient, restaurantClient))
+	routes.HandleFunc("POST /orders/{order_id}/items", orderapp.NewAddOrderItemHandler(ordersRepository, restaurantClient))
+	routes.HandleFunc("PATCH /orders/{order_id}/items", orderapp.NewReplaceOrderItemsHandler(ordersRepository, restaurantClient))
+	routes.HandleFunc("PATCH /orders/{order_id}/items/{restaurant_item_id}", orderapp.NewUpdateOrderItemHandler(ordersRepository, restaurantClient))
+	routes.HandleFunc("DELETE /orders/{order_id}/items/{restaurant_item_id}", orderapp.NewRemoveOrderItemHandler(ordersRepository))
+	routes.HandleFunc("GET /cart", handler.Cart)
+	routes.HandleFunc("DELETE /cart", handler.Cart)
+	routes.HandleFunc("POST /cart/items", handler.CartItems)
+	routes.HandleFunc("PATCH /cart/items", handler.CartItems)
+	routes.HandleFunc("DELETE /cart/items", handler.CartItems)
+	routes.HandleFunc("POST /cart/checkout", handler.CartCheckout)
+	routes.HandleFunc("GET /couriers", orderapp.NewCouriersHandler(courierDB, reviewRepository))
+	routes.HandleFunc("GET /restaurants", orderapp.NewRestaurantsHandler(db, reviewRepository))
+	routes.HandleFunc("GET /menu", orderapp.NewRestaurantMenuHandler(restaurantClient, reviewRepository))
+	routes.HandleFunc("GET /reviews", orderapp.NewReviewsHandler(reviewUseCase))
+	preferences := authn.RequireSession(orderapp.NewNotificationPreferencesHandler(notificationPreferences))
+	routes.HandleFunc("GET /notifications/preferences", preferences)
+	routes.HandleFunc("PUT /notifications/preferences", preferences)
+	for _, pattern := range doc.Missing(routes.Patterns()) {
+		logger.Warn("Route missing from the OpenAPI document", "route", pattern)
+	}
+
+	logger.Debug("Endpoint", "route", "GET /livez", "description", "Process is up (also GET /health)")
+	logger.Debug("Endpoint", "route", "GET /readyz", "description", "Dependency status and latencies")
+	logger.Debug("Endpoint", "route", "GET /metrics", "description", "Prometheus metrics")
+	logger.Debug("Endpoint", "route", "GET /openapi.json", "description", "OpenAPI document")
+	logger.Debug("Endpoint", "route", "GET /docs", "description", "API documentation page")
+	logger.Debug("Endpoint", "route", "POST /register", "description", "Register user with password")
+	logger.Debug("Endpoint", "route", "POST /login", "description", "Login user with password")
+	logger.Debug("Endpoint", "route", "POST /login/wallet/challenge", "description", "Get a message to sign with the wallet")
+	logger.Debug("Endpoint", "route", "POST /login/wallet", "description", "Login with a signed wallet challenge")
+	logger.Debug("Endpoint", "route", "POST/GET /orders", "description", "Create/List orders (deliver_at schedules, ?scheduled=true lists scheduled)")
+	logger.Debug("Endpoint", "route", "POST /orders/{order_id}/pay", "description", "Pay for order (optional tip)")
+	logger.Debug("Endpoint", "route", "POST /orders/{order_id}/tip", "description", "Tip courier (paid orders, or within 24h after completion)")
+	logger.Debug("Endpoint", "route", "POST /orders/{order_id}/cancel", "description", "Cancel order with refund policy")
+	logger.Debug("Endpoint", "route", "POST /orders/{order_id}/reorder", "description", "Order again from a completed order")
+	logger.Debug("Endpoint", "route", "GET/POST /orders/{order_id}/review", "description", "Show/leave review of completed order")
+	logger.Debug("Endpoint", "route", "POST /orders/{order_id}/accept", "description", "Accept order")
+	logger.Debug("Endpoint", "route", "POST /orders/{order_id}/items", "description", "Add order item")
+	logger.Debug("Endpoint", "route", "PATCH /orders/{order_id}/items", "description", "Replace order items")
+	logger.Debug("Endpoint", "route", "PATCH /orders/{order_id}/items/{restaurant_item_id}", "description", "Change item quantity")
+	logger.Debug("Endpoint", "route", "DELETE /orders/{order_id}/items/{restaurant_item_id}", "description", "Remove order item")
+	logger.Debug("Endpoint", "route", "GET/DELETE /cart?customer_id=<uuid>", "description", "Show (revalidated)/clear cart")
+	logger.Debug("Endpoint", "route", "POST/PATCH/DELETE /cart/items", "description", "Add/update/remove cart line")
+	logger.Debug("Endpoint", "route", "POST /cart/checkout", "description", "Convert cart into order")
+	logger.Debug("Endpoint", "route", "GET /couriers", "description", "List active couriers")
+	logger.Debug("Endpoint", "route", "GET /restaurants", "description", "List active restaurants")
+	logger.Debug("Endpoint", "route", "GET /menu?restaurant_id=<uuid>", "description", "Show restaurant menu items")
+	logger.Debug("Endpoint", "route", "GET /reviews?restaurant_id=<uuid>", "description", "List restaurant reviews")
+	logger.Debug("Endpoint", "route", "GET/PUT /notifications/preferences", "description", "Show/replace notification contacts and channels (Authorization: Bearer <token>)")
+	logger.Info("Starting HTTP server", "addr", addr)
+
+	err = srv.Run(context.Background())
 	if err != nil {
-		logger.Printf("Server error: %v", err)
+		logger.Error("Server error", "error", err)
 	}
 
-	logger.Println("Process of customer is finished")
-	utils.CloseLogger()
+	logger.Info("Process of customer is finished")
+	return err
 }
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"customer/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	pkgmodels "github.com/Kabanya/YAFDS/pkg/models"
+	orderrepo "github.com/Kabanya/YAFDS/pkg/repository"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+type cartConflictResponse struct {
+	pkgmodels.ErrorResponce
+	Cart models.CartView `json:"cart"`
+}
+
+// Cart shows (GET) or drops (DELETE) the customer's cart
+func (h *Handler) Cart(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := customerIDFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		view, err := h.cartUseCase.Get(r.Context(), customerID)
+		if err != nil {
+			writeCartError(w, err, view)
+			return
+		}
+		utils.WriteJSON(w, view, http.StatusOK)
+	case http.MethodDelete:
+		if err := h.cartUseCase.Clear(r.Context(), customerID); err != nil {
+			writeCartError(w, err, models.CartView{})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CartItems adds (POST), changes quantity of (PATCH) or removes (DELETE) a cart line
+func (h *Handler) CartItems(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req models.AddCartItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		customerID, err := uuid.Parse(req.CustomerID)
+		if err != nil {
+			utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		restaurantID, err := uuid.Parse(req.RestaurantID)
+		if err != nil {
+			utils.WriteError(w, "restaurant_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		itemID, err := uuid.Parse(req.RestaurantItemID)
+		if err != nil {
+			utils.WriteError(w, "restaurant_item_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		view, err := h.cartUseCase.AddItem(r.Context(), customerID, restaurantID, itemID, req.Quantity)
+		if err != nil {
+			writeCartError(w, err, view)
+			return
+		}
+		utils.WriteJSON(w, view, http.StatusOK)
+	case http.MethodPatch:
+		var req models.UpdateCartItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		customerID, err := uuid.Parse(req.CustomerID)
+		if err != nil {
+			utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		itemID, err := uuid.Parse(req.RestaurantItemID)
+		if err != nil {
+			utils.WriteError(w, "restaurant_item_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		view, err := h.cartUseCase.UpdateItem(r.Context(), customerID, itemID, req.Quantity)
+		if err != nil {
+			writeCartError(w, err, view)
+			return
+		}
+		utils.WriteJSON(w, view, http.StatusOK)
+	case http.MethodDelete:
+		customerID, ok := customerIDFromQuery(w, r)
+		if !ok {
+			return
+		}
+		itemID, err := uuid.Parse(r.URL.Query().Get("restaurant_item_id"))
+		if err != nil {
+			utils.WriteError(w, "restaurant_item_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		view, err := h.cartUseCase.RemoveItem(r.Context(), customerID, itemID)
+		if err != nil {
+			writeCartError(w, err, view)
+			return
+		}
+		utils.WriteJSON(w, view, http.StatusOK)
+	}
+}
+
+// CartCheckout converts the cart into an order
+func (h *Handler) CartCheckout(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var req models.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
+		return
+	}
+	courierID, err := uuid.Parse(req.CourierID)
+	if err != nil {
+		utils.WriteError(w, "courier_id must be UUID", http.StatusBadRequest)
+		return
+	}
+
+	order, view, err := h.cartUseCase.Checkout(r.Context(), customerID, courierID, req.AcceptPriceChanges)
+	if err != nil {
+		writeCartError(w, err, view)
+		logger.Info("checkout failed", "customer_id", customerID, "error", err)
+		return
+	}
+
+	utils.WriteJSON(w, order, http.StatusCreated)
+	logger.Info("cart checked out", "customer_id", customerID, "order_id", order.ID)
+}
+
+func customerIDFromQuery(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	customerIDStr := r.URL.Query().Get("customer_id")
+	if customerIDStr == "" {
+		utils.WriteError(w, "customer_id is required", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	customerID, err := uuid.Parse(customerIDStr)
+	if err != nil {
+		utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return customerID, true
+}
+
+func writeCartError(w http.ResponseWriter, err error, view models.CartView) {
+	switch {
+	case errors.Is(err, models.ErrCartUnavailable), errors.Is(err, models.ErrCartPriceChanged):
+		utils.WriteJSON(w, cartConflictResponse{
+			ErrorResponce: utils.ErrorBody(w, err.Error(), http.StatusConflict),
+			Cart:          view,
+		}, http.StatusConflict)
+	case errors.Is(err, models.ErrCartRestaurantMismatch):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, models.ErrCartNotFound), errors.Is(err, models.ErrCartItemNotFound):
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrCartEmpty),
+		errors.Is(err, models.ErrCartInvalidQuantity),
+		errors.Is(err, models.ErrCartItemNotOnMenu):
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, orderrepo.ErrCustomerNotFound):
+		utils.WriteError(w, "customer_id not found", http.StatusBadRequest)
+	case errors.Is(err, orderrepo.ErrCourierNotFound):
+		utils.WriteError(w, "courier_id not found", http.StatusBadRequest)
+	default:
+		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
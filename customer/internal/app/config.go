@@ -0,0 +1,39 @@
+package app
+
+import (
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/config"
+)
+
+// Config is everything the customer service reads at startup; run the
+// server with --print-config to see the values it would use.
+type Config struct {
+	Port       int    `env:"CUSTOMER_PORT" default:"8091" min:"1" max:"65535"`
+	DBName     string `env:"CUSTOMER_DB" default:"customer_db"`
+	OrdersDB   string `env:"ORDER_DB" default:"order_db"`
+	CouriersDB string `env:"COURIER_DB" default:"courier_db"`
+
+	RestaurantAPIURL string `env:"RESTAURANT_API_URL" default:"http://localhost:8092"`
+
+	// WalletDomain defaults to localhost on Port.
+	WalletDomain  string `env:"WALLET_LOGIN_DOMAIN"`
+	WalletURI     string `env:"WALLET_LOGIN_URI"`
+	WalletChainID int    `env:"WALLET_LOGIN_CHAIN_ID" default:"1" min:"1"`
+
+	KitchenRefundRate float64       `env:"CANCEL_KITCHEN_REFUND_RATE" default:"0.5" min:"0" max:"1"`
+	SchedulerInterval time.Duration `env:"SCHEDULER_INTERVAL" default:"30s" min:"1s"`
+	CartTTL           time.Duration `env:"CART_TTL" default:"24h" min:"1m"`
+
+	// NotifySinkDir receives SMS, push and, without SMTPAddr, email as JSON lines.
+	NotifySinkDir string `env:"NOTIFY_SINK_DIR" default:"notifications"`
+
+	Postgres config.Postgres
+	SMTP     config.SMTP
+	Redis    config.Redis
+	Auth     config.Auth
+	HTTP     config.HTTP
+	Log      config.Log
+	Trace    config.Trace
+	OpenAPI  config.OpenAPI
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"customer/models"
+	"net/http"
+
+	orderapp "github.com/Kabanya/YAFDS/pkg/app"
+	"github.com/Kabanya/YAFDS/pkg/metrics"
+	pkgmodels "github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+	"github.com/Kabanya/YAFDS/pkg/server"
+	"github.com/Kabanya/YAFDS/pkg/user"
+)
+
+// apiDocument describes every route Run mounts.
+func apiDocument() *openapi.Document {
+	doc := openapi.New("YAFDS customer API", "1.0.0")
+	doc.AddAll(server.Operations)
+	doc.Add("GET /metrics", metrics.Operation)
+	doc.AddAll(openapi.Operations)
+	doc.AddAll(user.Operations(customerSchema))
+	doc.AddAll(orderapp.Operations,
+		"GET /.well-known/jwks.json",
+		"POST /orders",
+		"GET /orders",
+		"POST /orders/{order_id}/pay",
+		"POST /orders/{order_id}/tip",
+		"POST /orders/{order_id}/cancel",
+		"POST /orders/{order_id}/reorder",
+		"GET /orders/{order_id}/review",
+		"POST /orders/{order_id}/review",
+		"POST /orders/{order_id}/accept",
+		"POST /orders/{order_id}/items",
+		"PATCH /orders/{order_id}/items",
+		"PATCH /orders/{order_id}/items/{restaurant_item_id}",
+		"DELETE /orders/{order_id}/items/{restaurant_item_id}",
+		"GET /couriers",
+		"GET /restaurants",
+		"GET /menu",
+		"GET /reviews",
+		"GET /notifications/preferences",
+		"PUT /notifications/preferences",
+	)
+	doc.AddAll(cartOperations)
+	return doc
+}
+
+var cartOperations = map[string]openapi.Op{
+	"GET /cart": {
+		Summary:   "Show cart revalidated against the menu",
+		Query:     []openapi.Param{customerIDParam},
+		Responses: cartResponses(http.StatusOK, models.CartView{}),
+	},
+	"DELETE /cart": {
+		Summary:   "Clear cart",
+		Query:     []openapi.Param{customerIDParam},
+		Responses: cartResponses(http.StatusNoContent, nil),
+	},
+	"POST /cart/items": {
+		Summary:   "Add cart line",
+		Body:      models.AddCartItemRequest{},
+		Responses: cartResponses(http.StatusOK, models.CartView{}),
+	},
+	"PATCH /cart/items": {
+		Summary:   "Change quantity of cart line",
+		Body:      models.UpdateCartItemRequest{},
+		Responses: cartResponses(http.StatusOK, models.CartView{}),
+	},
+	"DELETE /cart/items": {
+		Summary: "Remove cart line",
+		Query: []openapi.Param{
+			customerIDParam,
+			{Name: "restaurant_item_id", Required: true, Schema: openapi.UUID()},
+		},
+		Responses: cartResponses(http.StatusOK, models.CartView{}),
+	},
+	"POST /cart/checkout": {
+		Summary:     "Convert cart into order",
+		Description: "Unavailable items, or changed prices without accept_price_changes, are answered with 409 and the revalidated cart.",
+		Body:        models.CheckoutRequest{},
+		Responses:   cartResponses(http.StatusCreated, pkgmodels.Order{}),
+	},
+}
+
+var customerIDParam = openapi.Param{Name: "customer_id", Required: true, Schema: openapi.UUID()}
+
+// cartResponses adds the answers of writeCartError to the success response.
+func cartResponses(status int, body any) map[int]any {
+	return map[int]any{
+		status:              body,
+		http.StatusNotFound: pkgmodels.ErrorResponce{},
+		http.StatusConflict: cartConflictResponse{},
+	}
+}
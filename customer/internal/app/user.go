@@ -0,0 +1,12 @@
+package app
+
+import "github.com/Kabanya/YAFDS/pkg/user"
+
+// customerSchema maps the CUSTOMERS table for the shared user module.
+var customerSchema = user.Schema{
+	Table:  "CUSTOMERS",
+	Entity: "customer",
+	Fields: []user.Field{
+		{Column: "address", Required: true},
+	},
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"customer/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// корзины живут только в redis, в postgres попадает уже заказ
+
+type CartRepo interface {
+	Load(ctx context.Context, customerID uuid.UUID) (models.Cart, error)
+	Update(ctx context.Context, customerID uuid.UUID, fn func(*models.Cart) error) (models.Cart, error)
+	Delete(ctx context.Context, customerID uuid.UUID) error
+}
+
+type cartRepo struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// cartUpdateRetries bounds optimistic retries when two requests race on one cart.
+const cartUpdateRetries = 5
+
+func NewCartRepo(client *redis.Client, ttl time.Duration) *cartRepo {
+	return &cartRepo{client: client, ttl: ttl}
+}
+
+func cartKey(customerID uuid.UUID) string {
+	return fmt.Sprintf("cart:%s", customerID)
+}
+
+func (r *cartRepo) Load(ctx context.Context, customerID uuid.UUID) (models.Cart, error) {
+	if r.client == nil {
+		return models.Cart{}, errors.New("cart repository not fully initialized")
+	}
+	return r.load(ctx, r.client, customerID)
+}
+
+// Update applies fn to the stored cart (an empty one if missing) under
+// WATCH, so concurrent edits retry instead of overwriting each other.
+// Every successful write refreshes the TTL.
+func (r *cartRepo) Update(ctx context.Context, customerID uuid.UUID, fn func(*models.Cart) error) (models.Cart, error) {
+	if r.client == nil {
+		return models.Cart{}, errors.New("cart repository not fully initialized")
+	}
+	key := cartKey(customerID)
+
+	var updated models.Cart
+	txf := func(tx *redis.Tx) error {
+		cart, err := r.load(ctx, tx, customerID)
+		if errors.Is(err, models.ErrCartNotFound) {
+			cart = models.Cart{CustomerID: customerID}
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(&cart); err != nil {
+			return err
+		}
+		cart.UpdatedAt = time.Now().UTC()
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if len(cart.Lines) == 0 {
+				pipe.Del(ctx, key)
+				return nil
+			}
+			data, err := json.Marshal(cart)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, key, data, r.ttl)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		updated = cart
+		return nil
+	}
+
+	for i := 0; i < cartUpdateRetries; i++ {
+		err := r.client.Watch(ctx, txf, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		return updated, err
+	}
+	return models.Cart{}, errors.New("cart update conflicted too many times")
+}
+
+func (r *cartRepo) Delete(ctx context.Context, customerID uuid.UUID) error {
+	if r.client == nil {
+		return errors.New("cart repository not fully initialized")
+	}
+	return r.client.Del(ctx, cartKey(customerID)).Err()
+}
+
+func (r *cartRepo) load(ctx context.Context, cmd redis.Cmdable, customerID uuid.UUID) (models.Cart, error) {
+	data, err := cmd.Get(ctx, cartKey(customerID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return models.Cart{}, models.ErrCartNotFound
+	}
+	if err != nil {
+		return models.Cart{}, err
+	}
+	var cart models.Cart
+	if err := json.Unmarshal(data, &cart); err != nil {
+		return models.Cart{}, fmt.Errorf("decode cart: %w", err)
+	}
+	return cart, nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"customer/internal/repository"
+	"customer/models"
+	"errors"
+	"math"
+
+	"github.com/Kabanya/YAFDS/pkg/app/clients"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	ordermodels "github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+	orderusecase "github.com/Kabanya/YAFDS/pkg/usecase"
+
+	"github.com/google/uuid"
+)
+
+type CartService interface {
+	Get(ctx context.Context, customerID uuid.UUID) (models.CartView, error)
+	AddItem(ctx context.Context, customerID, restaurantID, restaurantItemID uuid.UUID, quantity int) (models.CartView, error)
+	UpdateItem(ctx context.Context, customerID, restaurantItemID uuid.UUID, quantity int) (models.CartView, error)
+	RemoveItem(ctx context.Context, customerID, restaurantItemID uuid.UUID) (models.CartView, error)
+	Clear(ctx context.Context, customerID uuid.UUID) error
+	Checkout(ctx context.Context, customerID, courierID uuid.UUID, acceptPriceChanges bool) (ordermodels.Order, models.CartView, error)
+}
+
+type cartService struct {
+	repo       repository.CartRepo
+	menuClient clients.RestaurantMenuClient
+	orders     repositoryModels.Order
+}
+
+func NewCartService(repo repository.CartRepo, menuClient clients.RestaurantMenuClient, orders repositoryModels.Order) CartService {
+	return &cartService{repo: repo, menuClient: menuClient, orders: orders}
+}
+
+func (s *cartService) Get(ctx context.Context, customerID uuid.UUID) (models.CartView, error) {
+	cart, err := s.repo.Load(ctx, customerID)
+	if err != nil {
+		return models.CartView{}, err
+	}
+	return s.revalidate(ctx, cart)
+}
+
+func (s *cartService) AddItem(ctx context.Context, customerID, restaurantID, restaurantItemID uuid.UUID, quantity int) (models.CartView, error) {
+	if quantity <= 0 {
+		return models.CartView{}, models.ErrCartInvalidQuantity
+	}
+	menu, err := s.menu(ctx, restaurantID)
+	if err != nil {
+		return models.CartView{}, err
+	}
+	menuItem, ok := menu[restaurantItemID]
+	if !ok {
+		return models.CartView{}, models.ErrCartItemNotOnMenu
+	}
+
+	cart, err := s.repo.Update(ctx, customerID, func(cart *models.Cart) error {
+		if len(cart.Lines) > 0 && cart.RestaurantID != restaurantID {
+			return models.ErrCartRestaurantMismatch
+		}
+		cart.RestaurantID = restaurantID
+		for i := range cart.Lines {
+			if cart.Lines[i].RestaurantItemID == restaurantItemID {
+				// Adding again means the customer has seen the current price.
+				cart.Lines[i].Quantity += quantity
+				cart.Lines[i].Price = menuItem.Price
+				cart.Lines[i].Name = menuItem.Name
+				return nil
+			}
+		}
+		cart.Lines = append(cart.Lines, models.CartLine{
+			RestaurantItemID: restaurantItemID,
+			Name:             menuItem.Name,
+			Price:            menuItem.Price,
+			Quantity:         quantity,
+		})
+		return nil
+	})
+	if err != nil {
+		return models.CartView{}, err
+	}
+	return s.viewWithMenu(cart, menu), nil
+}
+
+func (s *cartService) UpdateItem(ctx context.Context, customerID, restaurantItemID uuid.UUID, quantity int) (models.CartView, error) {
+	if quantity <= 0 {
+		return models.CartView{}, models.ErrCartInvalidQuantity
+	}
+	cart, err := s.repo.Update(ctx, customerID, func(cart *models.Cart) error {
+		for i := range cart.Lines {
+			if cart.Lines[i].RestaurantItemID == restaurantItemID {
+				cart.Lines[i].Quantity = quantity
+				return nil
+			}
+		}
+		return models.ErrCartItemNotFound
+	})
+	if err != nil {
+		return models.CartView{}, err
+	}
+	return s.revalidate(ctx, cart)
+}
+
+func (s *cartService) RemoveItem(ctx context.Context, customerID, restaurantItemID uuid.UUID) (models.CartView, error) {
+	cart, err := s.repo.Update(ctx, customerID, func(cart *models.Cart) error {
+		for i := range cart.Lines {
+			if cart.Lines[i].RestaurantItemID == restaurantItemID {
+				cart.Lines = append(cart.Lines[:i], cart.Lines[i+1:]...)
+				return nil
+			}
+		}
+		return models.ErrCartItemNotFound
+	})
+	if err != nil {
+		return models.CartView{}, err
+	}
+	if len(cart.Lines) == 0 {
+		return models.CartView{CustomerID: customerID, Lines: []models.CartLineView{}, UpdatedAt: cart.UpdatedAt}, nil
+	}
+	return s.revalidate(ctx, cart)
+}
+
+func (s *cartService) Clear(ctx context.Context, customerID uuid.UUID) error {
+	return s.repo.Delete(ctx, customerID)
+}
+
+// Checkout turns the cart into an order priced from the current menu.
+// Sold-out lines always block; price changes block until the customer
+// accepts them. The returned view explains a refusal.
+func (s *cartService) Checkout(ctx context.Context, customerID, courierID uuid.UUID, acceptPriceChanges bool) (ordermodels.Order, models.CartView, error) {
+	if s.orders == nil {
+		return ordermodels.Order{}, models.CartView{}, errors.New("orders repository not initialized")
+	}
+	cart, err := s.repo.Load(ctx, customerID)
+	if err != nil {
+		return ordermodels.Order{}, models.CartView{}, err
+	}
+	if len(cart.Lines) == 0 {
+		return ordermodels.Order{}, models.CartView{}, models.ErrCartEmpty
+	}
+	view, err := s.revalidate(ctx, cart)
+	if err != nil {
+		return ordermodels.Order{}, models.CartView{}, err
+	}
+	if view.Unavailable {
+		return ordermodels.Order{}, view, models.ErrCartUnavailable
+	}
+	if view.PriceChanged && !acceptPriceChanges {
+		return ordermodels.Order{}, view, models.ErrCartPriceChanged
+	}
+
+	items := make([]repositoryModels.OrderItemInput, 0, len(view.Lines))
+	for _, line := range view.Lines {
+		items = append(items, repositoryModels.OrderItemInput{
+			RestaurantItemID: line.RestaurantItemID,
+			Price:            line.CurrentPrice,
+			Quantity:         line.Quantity,
+		})
+	}
+	order, err := s.orders.CreateWithItems(ctx, ordermodels.Order{
+		CustomerID:   customerID,
+		CourierID:    courierID,
+		RestaurantID: cart.RestaurantID,
+	}, items)
+	if err != nil {
+		return ordermodels.Order{}, view, err
+	}
+	orderusecase.RecordOrderCreated(orderusecase.OrderSourceCart)
+
+	// The order exists now; a stale cart is only an annoyance.
+	if err := s.repo.Delete(ctx, customerID); err != nil {
+		logging.FromContext(ctx).Warn("cart: failed to clear cart after checkout", "customer_id", customerID, "error", err)
+	}
+	return order, view, nil
+}
+
+func (s *cartService) menu(ctx context.Context, restaurantID uuid.UUID) (map[uuid.UUID]ordermodels.MenuItem, error) {
+	if s.menuClient == nil {
+		return nil, errors.New("menu service unavailable")
+	}
+	items, err := s.menuClient.GetMenuItems(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	menu := make(map[uuid.UUID]ordermodels.MenuItem, len(items))
+	for _, item := range items {
+		menu[item.OrderItemID] = item
+	}
+	return menu, nil
+}
+
+func (s *cartService) revalidate(ctx context.Context, cart models.Cart) (models.CartView, error) {
+	menu, err := s.menu(ctx, cart.RestaurantID)
+	if err != nil {
+		return models.CartView{}, err
+	}
+	return s.viewWithMenu(cart, menu), nil
+}
+
+func (s *cartService) viewWithMenu(cart models.Cart, menu map[uuid.UUID]ordermodels.MenuItem) models.CartView {
+	view := models.CartView{
+		CustomerID:   cart.CustomerID,
+		RestaurantID: cart.RestaurantID,
+		Lines:        make([]models.CartLineView, 0, len(cart.Lines)),
+		UpdatedAt:    cart.UpdatedAt,
+	}
+	for _, line := range cart.Lines {
+		lineView := models.CartLineView{
+			RestaurantItemID: line.RestaurantItemID,
+			Name:             line.Name,
+			Price:            line.Price,
+			CurrentPrice:     line.Price,
+			Quantity:         line.Quantity,
+		}
+		menuItem, ok := menu[line.RestaurantItemID]
+		if !ok || menuItem.Quantity <= 0 {
+			lineView.SoldOut = true
+		} else {
+			lineView.CurrentPrice = menuItem.Price
+			lineView.Available = menuItem.Quantity
+			lineView.PriceChanged = math.Abs(menuItem.Price-line.Price) > 1e-9
+			lineView.InsufficientStock = line.Quantity > menuItem.Quantity
+		}
+
+		view.PriceChanged = view.PriceChanged || lineView.PriceChanged
+		view.Unavailable = view.Unavailable || lineView.SoldOut || lineView.InsufficientStock
+		view.Total += lineView.CurrentPrice * float64(lineView.Quantity)
+		view.Lines = append(view.Lines, lineView)
+	}
+	return view
+}
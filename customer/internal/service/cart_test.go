@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"customer/models"
+	"errors"
+	"testing"
+
+	ordermodels "github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+// memoryCartRepo keeps carts the way the redis repo does: an update of a
+// missing cart starts from an empty one and an emptied cart is removed.
+type memoryCartRepo struct {
+	carts map[uuid.UUID]models.Cart
+}
+
+func newMemoryCartRepo() *memoryCartRepo {
+	return &memoryCartRepo{carts: make(map[uuid.UUID]models.Cart)}
+}
+
+func (r *memoryCartRepo) Load(ctx context.Context, customerID uuid.UUID) (models.Cart, error) {
+	cart, ok := r.carts[customerID]
+	if !ok {
+		return models.Cart{}, models.ErrCartNotFound
+	}
+	cart.Lines = append([]models.CartLine(nil), cart.Lines...)
+	return cart, nil
+}
+
+func (r *memoryCartRepo) Update(ctx context.Context, customerID uuid.UUID, fn func(*models.Cart) error) (models.Cart, error) {
+	cart, err := r.Load(ctx, customerID)
+	if errors.Is(err, models.ErrCartNotFound) {
+		cart = models.Cart{CustomerID: customerID}
+	}
+	if err := fn(&cart); err != nil {
+		return models.Cart{}, err
+	}
+	if len(cart.Lines) == 0 {
+		delete(r.carts, customerID)
+	} else {
+		r.carts[customerID] = cart
+	}
+	return cart, nil
+}
+
+func (r *memoryCartRepo) Delete(ctx context.Context, customerID uuid.UUID) error {
+	delete(r.carts, customerID)
+	return nil
+}
+
+type fakeMenuClient struct {
+	menus map[uuid.UUID][]ordermodels.MenuItem
+}
+
+func (c *fakeMenuClient) GetMenuItems(ctx context.Context, restaurantID uuid.UUID) ([]ordermodels.MenuItem, error) {
+	return c.menus[restaurantID], nil
+}
+
+func (c *fakeMenuClient) setPrice(restaurantID, itemID uuid.UUID, price float64) {
+	for i := range c.menus[restaurantID] {
+		if c.menus[restaurantID][i].OrderItemID == itemID {
+			c.menus[restaurantID][i].Price = price
+		}
+	}
+}
+
+type fakeOrders struct {
+	repositoryModels.Order
+	created []repositoryModels.OrderItemInput
+}
+
+func (o *fakeOrders) CreateWithItems(ctx context.Context, order ordermodels.Order, items []repositoryModels.OrderItemInput) (ordermodels.Order, error) {
+	o.created = items
+	order.ID = uuid.New()
+	return order, nil
+}
+
+type cartFixture struct {
+	service    CartService
+	repo       *memoryCartRepo
+	menu       *fakeMenuClient
+	orders     *fakeOrders
+	customer   uuid.UUID
+	restaurant uuid.UUID
+	other      uuid.UUID
+	soup       uuid.UUID
+	bread      uuid.UUID
+	pizza      uuid.UUID
+}
+
+func newCartFixture() cartFixture {
+	f := cartFixture{
+		repo:       newMemoryCartRepo(),
+		orders:     &fakeOrders{},
+		customer:   uuid.New(),
+		restaurant: uuid.New(),
+		other:      uuid.New(),
+		soup:       uuid.New(),
+		bread:      uuid.New(),
+		pizza:      uuid.New(),
+	}
+	f.menu = &fakeMenuClient{menus: map[uuid.UUID][]ordermodels.MenuItem{
+		f.restaurant: {
+			{OrderItemID: f.soup, Name: "Soup", Price: 5, Quantity: 10},
+			{OrderItemID: f.bread, Name: "Bread", Price: 1.5, Quantity: 10},
+		},
+		f.other: {
+			{OrderItemID: f.pizza, Name: "Pizza", Price: 12, Quantity: 10},
+		},
+	}}
+	f.service = NewCartService(f.repo, f.menu, f.orders)
+	return f
+}
+
+func TestCartAddItemMergesLines(t *testing.T) {
+	f := newCartFixture()
+	ctx := context.Background()
+
+	if _, err := f.service.AddItem(ctx, f.customer, f.restaurant, f.soup, 1); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	if _, err := f.service.AddItem(ctx, f.customer, f.restaurant, f.bread, 2); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	view, err := f.service.AddItem(ctx, f.customer, f.restaurant, f.soup, 2)
+	if err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+
+	if len(view.Lines) != 2 {
+		t.Fatalf("lines = %+v, want soup and bread", view.Lines)
+	}
+	if view.Lines[0].RestaurantItemID != f.soup || view.Lines[0].Quantity != 3 {
+		t.Errorf("soup line = %+v, want quantity 3", view.Lines[0])
+	}
+	if view.Total != 18 {
+		t.Errorf("total = %v, want 18", view.Total)
+	}
+
+	if _, err := f.service.AddItem(ctx, f.customer, f.restaurant, uuid.New(), 1); !errors.Is(err, models.ErrCartItemNotOnMenu) {
+		t.Errorf("AddItem() of unknown item error = %v, want ErrCartItemNotOnMenu", err)
+	}
+	if _, err := f.service.AddItem(ctx, f.customer, f.restaurant, f.soup, 0); !errors.Is(err, models.ErrCartInvalidQuantity) {
+		t.Errorf("AddItem() of zero error = %v, want ErrCartInvalidQuantity", err)
+	}
+}
+
+func TestCartSingleRestaurant(t *testing.T) {
+	f := newCartFixture()
+	ctx := context.Background()
+
+	if _, err := f.service.AddItem(ctx, f.customer, f.restaurant, f.soup, 1); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	if _, err := f.service.AddItem(ctx, f.customer, f.other, f.pizza, 1); !errors.Is(err, models.ErrCartRestaurantMismatch) {
+		t.Fatalf("AddItem() from another restaurant error = %v, want ErrCartRestaurantMismatch", err)
+	}
+
+	// Once the cart is emptied another restaurant may be picked.
+	if _, err := f.service.RemoveItem(ctx, f.customer, f.soup); err != nil {
+		t.Fatalf("RemoveItem() error = %v", err)
+	}
+	view, err := f.service.AddItem(ctx, f.customer, f.other, f.pizza, 1)
+	if err != nil {
+		t.Fatalf("AddItem() after emptying error = %v", err)
+	}
+	if view.RestaurantID != f.other {
+		t.Errorf("restaurant = %s, want %s", view.RestaurantID, f.other)
+	}
+}
+
+func TestCartCheckoutPriceChange(t *testing.T) {
+	f := newCartFixture()
+	ctx := context.Background()
+	courier := uuid.New()
+
+	if _, err := f.service.AddItem(ctx, f.customer, f.restaurant, f.soup, 2); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	f.menu.setPrice(f.restaurant, f.soup, 6)
+
+	_, view, err := f.service.Checkout(ctx, f.customer, courier, false)
+	if !errors.Is(err, models.ErrCartPriceChanged) {
+		t.Fatalf("Checkout() error = %v, want ErrCartPriceChanged", err)
+	}
+	if !view.PriceChanged || !view.Lines[0].PriceChanged || view.Lines[0].Price != 5 || view.Lines[0].CurrentPrice != 6 {
+		t.Errorf("view = %+v, want soup flagged from 5 to 6", view)
+	}
+	if f.orders.created != nil {
+		t.Fatalf("order created despite the price change")
+	}
+
+	order, _, err := f.service.Checkout(ctx, f.customer, courier, true)
+	if err != nil {
+		t.Fatalf("Checkout() with accepted prices error = %v", err)
+	}
+	if order.RestaurantID != f.restaurant || order.CustomerID != f.customer {
+		t.Errorf("order = %+v", order)
+	}
+	if len(f.orders.created) != 1 || f.orders.created[0].Price != 6 || f.orders.created[0].Quantity != 2 {
+		t.Errorf("order items = %+v, want 2 soups at 6", f.orders.created)
+	}
+	if _, err := f.repo.Load(ctx, f.customer); !errors.Is(err, models.ErrCartNotFound) {
+		t.Errorf("cart after checkout error = %v, want ErrCartNotFound", err)
+	}
+}
+
+func TestCartCheckoutUnavailable(t *testing.T) {
+	f := newCartFixture()
+	ctx := context.Background()
+
+	if _, err := f.service.AddItem(ctx, f.customer, f.restaurant, f.bread, 11); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	// Sold-out lines block even when price changes are accepted.
+	_, view, err := f.service.Checkout(ctx, f.customer, uuid.New(), true)
+	if !errors.Is(err, models.ErrCartUnavailable) {
+		t.Fatalf("Checkout() error = %v, want ErrCartUnavailable", err)
+	}
+	if !view.Lines[0].InsufficientStock {
+		t.Errorf("line = %+v, want insufficient stock", view.Lines[0])
+	}
+
+	if _, _, err := f.service.Checkout(ctx, uuid.New(), uuid.New(), true); !errors.Is(err, models.ErrCartNotFound) {
+		t.Errorf("Checkout() of missing cart error = %v, want ErrCartNotFound", err)
+	}
+}
@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"customer/internal/service"
+	"customer/models"
+
+	ordermodels "github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+type CartUseCase interface {
+	Get(ctx context.Context, customerID uuid.UUID) (models.CartView, error)
+	AddItem(ctx context.Context, customerID, restaurantID, restaurantItemID uuid.UUID, quantity int) (models.CartView, error)
+	UpdateItem(ctx context.Context, customerID, restaurantItemID uuid.UUID, quantity int) (models.CartView, error)
+	RemoveItem(ctx context.Context, customerID, restaurantItemID uuid.UUID) (models.CartView, error)
+	Clear(ctx context.Context, customerID uuid.UUID) error
+	Checkout(ctx context.Context, customerID, courierID uuid.UUID, acceptPriceChanges bool) (ordermodels.Order, models.CartView, error)
+}
+
+type cartUseCase struct {
+	service service.CartService
+}
+
+func NewCartUseCase(service service.CartService) CartUseCase {
+	return &cartUseCase{service: service}
+}
+
+func (u *cartUseCase) Get(ctx context.Context, customerID uuid.UUID) (models.CartView, error) {
+	return u.service.Get(ctx, customerID)
+}
+
+func (u *cartUseCase) AddItem(ctx context.Context, customerID, restaurantID, restaurantItemID uuid.UUID, quantity int) (models.CartView, error) {
+	return u.service.AddItem(ctx, customerID, restaurantID, restaurantItemID, quantity)
+}
+
+func (u *cartUseCase) UpdateItem(ctx context.Context, customerID, restaurantItemID uuid.UUID, quantity int) (models.CartView, error) {
+	return u.service.UpdateItem(ctx, customerID, restaurantItemID, quantity)
+}
+
+func (u *cartUseCase) RemoveItem(ctx context.Context, customerID, restaurantItemID uuid.UUID) (models.CartView, error) {
+	return u.service.RemoveItem(ctx, customerID, restaurantItemID)
+}
+
+func (u *cartUseCase) Clear(ctx context.Context, customerID uuid.UUID) error {
+	return u.service.Clear(ctx, customerID)
+}
+
+func (u *cartUseCase) Checkout(ctx context.Context, customerID, courierID uuid.UUID, acceptPriceChanges bool) (ordermodels.Order, models.CartView, error) {
+	return u.service.Checkout(ctx, customerID, courierID, acceptPriceChanges)
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cart is the server-side basket a customer assembles before checkout.
+// All lines belong to the same restaurant.
+type Cart struct {
+	CustomerID   uuid.UUID  `json:"customer_id"`
+	RestaurantID uuid.UUID  `json:"restaurant_id"`
+	Lines        []CartLine `json:"lines"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// CartLine keeps the price seen when the item was added so a later menu
+// change can be flagged instead of silently applied.
+type CartLine struct {
+	RestaurantItemID uuid.UUID `json:"restaurant_item_id"`
+	Name             string    `json:"name"`
+	Price            float64   `json:"price"`
+	Quantity         int       `json:"quantity"`
+}
+
+// CartView is a cart revalidated against the current restaurant menu.
+type CartView struct {
+	CustomerID   uuid.UUID      `json:"customer_id"`
+	RestaurantID uuid.UUID      `json:"restaurant_id"`
+	Lines        []CartLineView `json:"lines"`
+	Total        float64        `json:"total"`
+	PriceChanged bool           `json:"price_changed"`
+	Unavailable  bool           `json:"unavailable"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+type CartLineView struct {
+	RestaurantItemID  uuid.UUID `json:"restaurant_item_id"`
+	Name              string    `json:"name"`
+	Price             float64   `json:"price"`
+	CurrentPrice      float64   `json:"current_price"`
+	Quantity          int       `json:"quantity"`
+	Available         int       `json:"available"`
+	PriceChanged      bool      `json:"price_changed"`
+	SoldOut           bool      `json:"sold_out"`
+	InsufficientStock bool      `json:"insufficient_stock"`
+}
+
+type AddCartItemRequest struct {
+	CustomerID       string `json:"customer_id" required:"true" format:"uuid"`
+	RestaurantID     string `json:"restaurant_id" required:"true" format:"uuid"`
+	RestaurantItemID string `json:"restaurant_item_id" required:"true" format:"uuid"`
+	Quantity         int    `json:"quantity" required:"true" min:"1"`
+}
+
+type UpdateCartItemRequest struct {
+	CustomerID       string `json:"customer_id" required:"true" format:"uuid"`
+	RestaurantItemID string `json:"restaurant_item_id" required:"true" format:"uuid"`
+	Quantity         int    `json:"quantity" required:"true" min:"1"`
+}
+
+type CheckoutRequest struct {
+	CustomerID         string `json:"customer_id" required:"true" format:"uuid"`
+	CourierID          string `json:"courier_id" required:"true" format:"uuid"`
+	AcceptPriceChanges bool   `json:"accept_price_changes"`
+}
+
+var (
+	ErrCartNotFound           = errors.New("cart not found")
+	ErrCartEmpty              = errors.New("cart is empty")
+	ErrCartItemNotFound       = errors.New("cart item not found")
+	ErrCartItemNotOnMenu      = errors.New("item is not on the restaurant menu")
+	ErrCartRestaurantMismatch = errors.New("cart already holds items from another restaurant")
+	ErrCartInvalidQuantity    = errors.New("quantity must be positive")
+	ErrCartUnavailable        = errors.New("cart has sold-out or short-stocked items")
+	ErrCartPriceChanged       = errors.New("cart prices changed since items were added")
+)
@@ -110,11 +110,13 @@ export default function Dashboard() {
       const endpoint = query ? `${apiBase}/orders?${query}` : `${apiBase}/orders`
 
       try {
-        const response = await fetch(endpoint, { signal: controller.signal })
+        // Restaurant order lists need the session (or an API key).
+        const headers = role === 'restaurant' && user?.token ? { Authorization: `Bearer ${user.token}` } : {}
+        const response = await fetch(endpoint, { headers, signal: controller.signal })
         const data = await response.json()
 
         if (!response.ok) {
-          throw new Error(data?.error || 'Не удалось получить заказы')
+          throw new Error(data?.error_message || 'Не удалось получить заказы')
         }
 
         setOrders(Array.isArray(data) ? data : [])
@@ -143,7 +145,7 @@ export default function Dashboard() {
         const data = await response.json()
 
         if (!response.ok) {
-          throw new Error(data?.error || 'Не удалось получить курьеров')
+          throw new Error(data?.error_message || 'Не удалось получить курьеров')
         }
 
         setCouriers(Array.isArray(data) ? data : [])
@@ -172,7 +174,7 @@ export default function Dashboard() {
         const data = await response.json()
 
         if (!response.ok) {
-          throw new Error(data?.error || 'Не удалось получить рестораны')
+          throw new Error(data?.error_message || 'Не удалось получить рестораны')
         }
 
         setRestaurants(Array.isArray(data) ? data : [])
@@ -205,7 +207,7 @@ export default function Dashboard() {
       const data = await response.json()
 
       if (!response.ok) {
-        throw new Error(data?.error || 'Не удалось получить меню')
+        throw new Error(data?.error_message || 'Не удалось получить меню')
       }
 
       setMenuItems(Array.isArray(data) ? data : [])
@@ -275,7 +277,7 @@ export default function Dashboard() {
       })
       const data = await response.json()
       if (!response.ok) {
-        throw new Error(data.error || 'Failed to create order')
+        throw new Error(data.error_message || 'Failed to create order')
       }
       setCreateOrderModal(false)
       setSelectedCourier('')
@@ -317,7 +319,7 @@ export default function Dashboard() {
       const data = await response.json()
 
       if (!response.ok) {
-        throw new Error(data?.error || 'Не удалось получить меню ресторана')
+        throw new Error(data?.error_message || 'Не удалось получить меню ресторана')
       }
 
       setAddItemMenu(Array.isArray(data) ? data : [])
@@ -366,14 +368,13 @@ export default function Dashboard() {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
-          restaurant_id: addItemRestaurantId,
           restaurant_item_id: selected[0].restaurant_item_id,
           quantity: selected[0].quantity
         })
       })
       const data = await response.json()
       if (!response.ok) {
-        throw new Error(data?.error || 'Failed to add item')
+        throw new Error(data?.error_message || 'Failed to add item')
       }
 
       setAddItemSuccess('Item added to order')
@@ -400,7 +401,7 @@ export default function Dashboard() {
       const data = await response.json()
 
       if (!response.ok) {
-        throw new Error(data?.error || 'Не удалось получить меню ресторана')
+        throw new Error(data?.error_message || 'Не удалось получить меню ресторана')
       }
 
       const menu = Array.isArray(data) ? data : []
@@ -502,7 +503,7 @@ export default function Dashboard() {
     try {
       const response = await fetch(`${apiBase}/menu/upload`, {
         method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
+        headers: { 'Content-Type': 'application/json', Authorization: `Bearer ${user?.token}` },
         body: JSON.stringify({
           restaurant_id: restaurantId,
           name: menuForm.name.trim(),
@@ -513,7 +514,7 @@ export default function Dashboard() {
       })
       const data = await response.json()
       if (!response.ok) {
-        throw new Error(data?.error || data?.error_message || 'Failed to upload menu item')
+        throw new Error(data?.error_message || 'Failed to upload menu item')
       }
       setMenuSaveSuccess('Menu item uploaded')
       setMenuForm({ name: '', price: '', quantity: '', description: '' })
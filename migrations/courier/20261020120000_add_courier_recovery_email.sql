@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE COURIERS ADD COLUMN recovery_email TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE COURIERS DROP COLUMN recovery_email;
+-- +goose StatementEnd
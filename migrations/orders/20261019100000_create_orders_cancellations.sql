@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE ORDERS_CANCELLATIONS (
+  order_id UUID PRIMARY KEY,
+  previous_status TEXT NOT NULL,
+  reason_code TEXT NOT NULL,
+  comment TEXT NOT NULL DEFAULT '',
+  refund_amount NUMERIC NOT NULL,
+  refund_status TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ORDERS_CANCELLATIONS;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ORDERS ADD COLUMN restaurant_id UUID;
+ALTER TABLE ORDERS ADD COLUMN deliver_at TIMESTAMP;
+ALTER TABLE ORDERS ADD COLUMN release_at TIMESTAMP;
+CREATE INDEX orders_restaurant_deliver_at_idx ON ORDERS (restaurant_id, deliver_at) WHERE deliver_at IS NOT NULL;
+CREATE INDEX orders_scheduled_release_at_idx ON ORDERS (release_at) WHERE status = 'CUSTOMER_SCHEDULED';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX orders_scheduled_release_at_idx;
+DROP INDEX orders_restaurant_deliver_at_idx;
+ALTER TABLE ORDERS DROP COLUMN release_at;
+ALTER TABLE ORDERS DROP COLUMN deliver_at;
+ALTER TABLE ORDERS DROP COLUMN restaurant_id;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE REVIEWS (
+  order_id UUID PRIMARY KEY,
+  customer_id UUID NOT NULL,
+  restaurant_id UUID NOT NULL,
+  courier_id UUID NOT NULL,
+  food_rating SMALLINT NOT NULL CHECK (food_rating BETWEEN 1 AND 5),
+  delivery_rating SMALLINT NOT NULL CHECK (delivery_rating BETWEEN 1 AND 5),
+  comment TEXT NOT NULL DEFAULT '',
+  reply TEXT,
+  replied_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX reviews_restaurant_idx ON REVIEWS (restaurant_id, created_at DESC);
+
+CREATE TABLE REVIEWS_DISHES (
+  order_id UUID NOT NULL REFERENCES REVIEWS (order_id) ON DELETE CASCADE,
+  restaurant_item_id UUID NOT NULL,
+  rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  PRIMARY KEY (order_id, restaurant_item_id)
+);
+
+CREATE TABLE RATING_AGGREGATES (
+  subject_type TEXT NOT NULL,
+  subject_id UUID NOT NULL,
+  ratings_count INTEGER NOT NULL DEFAULT 0,
+  ratings_sum BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (subject_type, subject_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE RATING_AGGREGATES;
+DROP TABLE REVIEWS_DISHES;
+DROP TABLE REVIEWS;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE ORDERS_TIPS (
+  emp_id UUID PRIMARY KEY,
+  order_id UUID NOT NULL,
+  customer_id UUID NOT NULL,
+  courier_id UUID NOT NULL,
+  amount NUMERIC NOT NULL CHECK (amount > 0),
+  stage TEXT NOT NULL,
+  status TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  paid_out_at TIMESTAMP
+);
+
+-- Failed debits do not count, so the customer can try again.
+CREATE UNIQUE INDEX orders_tips_order_idx ON ORDERS_TIPS (order_id) WHERE status <> 'FAILED';
+CREATE INDEX orders_tips_courier_idx ON ORDERS_TIPS (courier_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ORDERS_TIPS;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE NOTIFICATION_CONTACTS (
+  user_id UUID PRIMARY KEY,
+  locale TEXT NOT NULL DEFAULT 'en',
+  email TEXT NOT NULL DEFAULT '',
+  phone TEXT NOT NULL DEFAULT '',
+  push_token TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMP NOT NULL
+);
+
+-- channels is a comma separated list; an empty string mutes the event.
+CREATE TABLE NOTIFICATION_PREFERENCES (
+  user_id UUID NOT NULL REFERENCES NOTIFICATION_CONTACTS (user_id) ON DELETE CASCADE,
+  event_type TEXT NOT NULL,
+  channels TEXT NOT NULL,
+  PRIMARY KEY (user_id, event_type)
+);
+
+CREATE TABLE NOTIFICATIONS_OUTBOX (
+  emp_id UUID PRIMARY KEY,
+  user_id UUID NOT NULL,
+  event_type TEXT NOT NULL,
+  channel TEXT NOT NULL,
+  recipient TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  body TEXT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP NOT NULL,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL,
+  sent_at TIMESTAMP
+);
+
+CREATE INDEX notifications_outbox_due_idx ON NOTIFICATIONS_OUTBOX (next_attempt_at) WHERE status = 'PENDING';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE NOTIFICATIONS_OUTBOX;
+DROP TABLE NOTIFICATION_PREFERENCES;
+DROP TABLE NOTIFICATION_CONTACTS;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE WEBHOOK_SUBSCRIPTIONS (
+  emp_id UUID PRIMARY KEY,
+  restaurant_id UUID NOT NULL,
+  url TEXT NOT NULL,
+  events TEXT NOT NULL DEFAULT '',
+  secret TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_subscriptions_restaurant_idx ON WEBHOOK_SUBSCRIPTIONS (restaurant_id);
+
+-- Deliveries outlive their subscription so the log stays readable.
+CREATE TABLE WEBHOOK_DELIVERIES (
+  emp_id UUID PRIMARY KEY,
+  subscription_id UUID NOT NULL,
+  event_id UUID NOT NULL,
+  event_type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP NOT NULL,
+  response_code INTEGER NOT NULL DEFAULT 0,
+  response_body TEXT NOT NULL DEFAULT '',
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL,
+  delivered_at TIMESTAMP
+);
+
+CREATE INDEX webhook_deliveries_subscription_idx ON WEBHOOK_DELIVERIES (subscription_id, created_at DESC);
+CREATE INDEX webhook_deliveries_due_idx ON WEBHOOK_DELIVERIES (next_attempt_at) WHERE status = 'PENDING';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE WEBHOOK_DELIVERIES;
+DROP TABLE WEBHOOK_SUBSCRIPTIONS;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ORDERS ADD COLUMN slot_start TIMESTAMP;
+ALTER TABLE ORDERS ADD COLUMN slot_end TIMESTAMP;
+ALTER TABLE ORDERS ADD COLUMN slot_capacity INT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE ORDERS DROP COLUMN slot_capacity;
+ALTER TABLE ORDERS DROP COLUMN slot_end;
+ALTER TABLE ORDERS DROP COLUMN slot_start;
+-- +goose StatementEnd
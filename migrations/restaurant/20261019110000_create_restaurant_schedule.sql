@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE RESTAURANTS ADD COLUMN slot_minutes INT NOT NULL DEFAULT 30;
+ALTER TABLE RESTAURANTS ADD COLUMN slot_capacity INT NOT NULL DEFAULT 0;
+ALTER TABLE RESTAURANTS ADD COLUMN prep_lead_minutes INT NOT NULL DEFAULT 30;
+
+CREATE TABLE RESTAURANT_OPENING_HOURS (
+  restaurant_id UUID NOT NULL,
+  weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+  opens TEXT NOT NULL,
+  closes TEXT NOT NULL,
+  PRIMARY KEY (restaurant_id, weekday)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE RESTAURANT_OPENING_HOURS;
+ALTER TABLE RESTAURANTS DROP COLUMN prep_lead_minutes;
+ALTER TABLE RESTAURANTS DROP COLUMN slot_capacity;
+ALTER TABLE RESTAURANTS DROP COLUMN slot_minutes;
+-- +goose StatementEnd
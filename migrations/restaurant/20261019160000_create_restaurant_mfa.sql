@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE RESTAURANT_MFA (
+  user_id UUID PRIMARY KEY,
+  secret BYTEA NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  backup_codes TEXT NOT NULL DEFAULT '',
+  last_step BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE RESTAURANT_MFA;
+-- +goose StatementEnd
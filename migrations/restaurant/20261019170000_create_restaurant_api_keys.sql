@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE RESTAURANT_API_KEYS (
+  emp_id UUID PRIMARY KEY,
+  restaurant_id UUID NOT NULL,
+  name TEXT NOT NULL,
+  prefix TEXT NOT NULL UNIQUE,
+  key_hash TEXT NOT NULL,
+  scopes TEXT NOT NULL,
+  rate_limit INT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ,
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX restaurant_api_keys_restaurant_idx ON RESTAURANT_API_KEYS (restaurant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE RESTAURANT_API_KEYS;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE RESTAURANT_STOCK_RESERVATIONS (
+  order_id UUID NOT NULL,
+  order_item_id UUID NOT NULL,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  reserved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  released_at TIMESTAMPTZ,
+  PRIMARY KEY (order_id, order_item_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE RESTAURANT_STOCK_RESERVATIONS;
+-- +goose StatementEnd
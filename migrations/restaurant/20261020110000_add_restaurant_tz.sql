@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE RESTAURANTS ADD COLUMN tz TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE RESTAURANTS DROP COLUMN tz;
+-- +goose StatementEnd
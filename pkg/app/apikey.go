@@ -0,0 +1,136 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/router"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name" required:"true"`
+	Scopes []string `json:"scopes" required:"true" min:"1"`
+	// ExpiresIn is a Go duration such as "720h"; empty never expires.
+	ExpiresIn string `json:"expires_in"`
+	// RateLimit is requests per minute; 0 uses the default and more than
+	// auth.MaxAPIKeyRateLimit is rejected.
+	RateLimit int `json:"rate_limit" min:"0" max:"1200"`
+}
+
+type createAPIKeyResponse struct {
+	Key    string      `json:"key"`
+	APIKey auth.APIKey `json:"api_key"`
+}
+
+// NewAPIKeysHandler lists (GET) or creates (POST) the caller's API keys. It
+// must be mounted behind Authenticator.RequireSession; the full key is only
+// shown in the POST response.
+func NewAPIKeysHandler(keys *auth.APIKeys) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+
+		principal, ok := auth.PrincipalFrom(r.Context())
+		if !ok {
+			utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			list, err := keys.List(r.Context(), principal.UserID)
+			if err != nil {
+				logger.Error("apikey: list failed", "error", err)
+				utils.WriteError(w, "failed to list api keys", http.StatusInternalServerError)
+				return
+			}
+			utils.WriteJSON(w, list, http.StatusOK)
+		case http.MethodPost:
+			var req createAPIKeyRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			var ttl time.Duration
+			if req.ExpiresIn != "" {
+				parsed, err := time.ParseDuration(req.ExpiresIn)
+				if err != nil || parsed <= 0 {
+					utils.WriteError(w, "expires_in must be a positive duration such as 720h", http.StatusBadRequest)
+					return
+				}
+				ttl = parsed
+			}
+			for i, scope := range req.Scopes {
+				req.Scopes[i] = strings.ToLower(strings.TrimSpace(scope))
+			}
+			raw, key, err := keys.Create(r.Context(), auth.APIKeyInput{
+				OwnerID:   principal.UserID,
+				Name:      req.Name,
+				Scopes:    req.Scopes,
+				TTL:       ttl,
+				RateLimit: req.RateLimit,
+			})
+			if err != nil {
+				if errors.Is(err, auth.ErrInvalidAPIKeyRequest) {
+					utils.WriteError(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				logger.Error("apikey: create failed", "error", err)
+				utils.WriteError(w, "failed to create api key", http.StatusInternalServerError)
+				return
+			}
+			utils.WriteJSON(w, createAPIKeyResponse{Key: raw, APIKey: key}, http.StatusCreated)
+		}
+	}
+}
+
+// NewRevokeAPIKeyHandler serves DELETE /api-keys/{key_id}, which revokes
+// the key at once. Revoked keys stay listed with revoked_at set.
+func NewRevokeAPIKeyHandler(keys *auth.APIKeys) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		principal, ok := auth.PrincipalFrom(r.Context())
+		if !ok {
+			utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+			return
+		}
+		keyID, ok := router.UUIDParam(w, r, "key_id")
+		if !ok {
+			return
+		}
+		if err := keys.Revoke(r.Context(), principal.UserID, keyID); err != nil {
+			if errors.Is(err, auth.ErrAPIKeyNotFound) {
+				utils.WriteError(w, "api key not found", http.StatusNotFound)
+				return
+			}
+			logger.Error("apikey: revoke failed", "error", err)
+			utils.WriteError(w, "failed to revoke api key", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// actsFor rejects requests whose credentials belong to another restaurant.
+// Requests without a principal, e.g. on a route mounted without the auth
+// middleware, are rejected too.
+func actsFor(w http.ResponseWriter, r *http.Request, ownerID uuid.UUID) bool {
+	if _, ok := auth.PrincipalFrom(r.Context()); !ok {
+		utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+		return false
+	}
+	if !auth.ActsFor(r.Context(), ownerID) {
+		utils.WriteError(w, "credentials do not belong to this restaurant", http.StatusForbidden)
+		return false
+	}
+	return true
+}
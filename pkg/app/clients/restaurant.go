@@ -1,16 +1,22 @@
 package clients
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/metrics"
 	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/tracing"
+	"github.com/Kabanya/YAFDS/pkg/usecase"
 
 	"github.com/google/uuid"
 )
@@ -19,11 +25,16 @@ type RestaurantMenuClient interface {
 	GetMenuItems(ctx context.Context, restaurantID uuid.UUID) ([]models.MenuItem, error)
 }
 
+type RestaurantScheduleClient interface {
+	GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error)
+}
+
 type HTTPRestaurantClient struct {
-	baseURL    string
-	httpClient *http.Client
-	mu         sync.RWMutex
-	cache      map[uuid.UUID]cachedMenu
+	baseURL      string
+	serviceToken string
+	httpClient   *http.Client
+	mu           sync.RWMutex
+	cache        map[uuid.UUID]cachedMenu
 }
 
 type cachedMenu struct {
@@ -33,6 +44,14 @@ type cachedMenu struct {
 
 const menuCacheTTL = 10 * time.Minute
 
+var (
+	menuCacheLookups = metrics.NewCounterVec("restaurant_menu_cache_lookups_total",
+		"Menu lookups in the restaurant client cache, by result (hit or miss).", "result")
+	restaurantRequests = metrics.NewHistogramVec("restaurant_client_request_duration_seconds",
+		"Latency of calls to the restaurant service, by endpoint and status (error if none came back).",
+		metrics.DefBuckets, "endpoint", "status")
+)
+
 func NewHTTPRestaurantClient(baseURL string) *HTTPRestaurantClient {
 	trimmed := strings.TrimRight(strings.TrimSpace(baseURL), "/")
 	if trimmed == "" {
@@ -41,17 +60,27 @@ func NewHTTPRestaurantClient(baseURL string) *HTTPRestaurantClient {
 	return &HTTPRestaurantClient{
 		baseURL: trimmed,
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: tracing.Transport(nil),
 		},
 		cache: make(map[uuid.UUID]cachedMenu),
 	}
 }
 
+// WithServiceToken authenticates the calls only other services may make,
+// stock reservations and cancellation notices.
+func (c *HTTPRestaurantClient) WithServiceToken(token string) *HTTPRestaurantClient {
+	c.serviceToken = token
+	return c
+}
+
 func (c *HTTPRestaurantClient) GetMenuItems(ctx context.Context, restaurantID uuid.UUID) ([]models.MenuItem, error) {
 	now := time.Now().UTC()
 	if items, ok := c.getCachedMenu(restaurantID, now); ok {
+		menuCacheLookups.With("hit").Inc()
 		return items, nil
 	}
+	menuCacheLookups.With("miss").Inc()
 
 	items, err := c.fetchMenuItems(ctx, restaurantID)
 	if err != nil {
@@ -99,21 +128,19 @@ func (c *HTTPRestaurantClient) fetchMenuItems(ctx context.Context, restaurantID
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, "/menu/show")
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		var errBody struct {
-			Error string `json:"error"`
-		}
+		var errBody models.ErrorResponce
 		_ = json.NewDecoder(resp.Body).Decode(&errBody)
-		if errBody.Error == "" {
-			errBody.Error = resp.Status
+		if errBody.ErrorMessage == "" {
+			errBody.ErrorMessage = resp.Status
 		}
-		return nil, fmt.Errorf("restaurant menu request failed: %s", errBody.Error)
+		return nil, fmt.Errorf("restaurant menu request failed: %s", errBody.ErrorMessage)
 	}
 
 	var items []models.MenuItem
@@ -122,3 +149,111 @@ func (c *HTTPRestaurantClient) fetchMenuItems(ctx context.Context, restaurantID
 	}
 	return items, nil
 }
+
+// GetSchedule is not cached: slot capacity decisions need current opening hours.
+func (c *HTTPRestaurantClient) GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error) {
+	endpoint, err := url.Parse(c.baseURL + "/schedule")
+	if err != nil {
+		return models.RestaurantSchedule{}, err
+	}
+	query := endpoint.Query()
+	query.Set("restaurant_id", restaurantID.String())
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return models.RestaurantSchedule{}, err
+	}
+
+	resp, err := c.do(req, "/schedule")
+	if err != nil {
+		return models.RestaurantSchedule{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return models.RestaurantSchedule{}, fmt.Errorf("restaurant schedule request failed: %s", resp.Status)
+	}
+
+	var schedule models.RestaurantSchedule
+	if err := json.NewDecoder(resp.Body).Decode(&schedule); err != nil {
+		return models.RestaurantSchedule{}, err
+	}
+	return schedule, nil
+}
+
+// ReserveStock takes the items of an accepted order off the menu. The
+// restaurant reads the items from the order itself; usecase.ErrOutOfStock
+// means the menu cannot cover them.
+func (c *HTTPRestaurantClient) ReserveStock(ctx context.Context, orderID uuid.UUID) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/orders/"+orderID.String()+"/reserve", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(auth.ServiceTokenHeader, c.serviceToken)
+
+	resp, err := c.do(req, "/orders/{order_id}/reserve")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return usecase.ErrOutOfStock
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("restaurant stock reservation failed: %s", resp.Status)
+	}
+
+	// Stock went down, so the cached menu is stale.
+	c.mu.Lock()
+	c.cache = make(map[uuid.UUID]cachedMenu)
+	c.mu.Unlock()
+	return nil
+}
+
+// OrderCancelled tells the restaurant service to drop the order and, when the
+// kitchen had already taken it, return the stock it reserved.
+func (c *HTTPRestaurantClient) OrderCancelled(ctx context.Context, event usecase.OrderCancelledEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/orders/cancelled", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(auth.ServiceTokenHeader, c.serviceToken)
+
+	resp, err := c.do(req, "/orders/cancelled")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("restaurant cancel notification failed: %s", resp.Status)
+	}
+
+	// Stock went back up, so the cached menu is stale.
+	if event.ReleaseStock {
+		c.mu.Lock()
+		c.cache = make(map[uuid.UUID]cachedMenu)
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// do sends req and records its latency under endpoint, the path without
+// the query so restaurant IDs do not become label values.
+func (c *HTTPRestaurantClient) do(req *http.Request, endpoint string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	restaurantRequests.With(endpoint, status).ObserveSince(start)
+	return resp, err
+}
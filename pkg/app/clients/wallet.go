@@ -6,11 +6,18 @@ import (
 	"math/rand"
 	"time"
 
-	"github.com/Kabanya/YAFDS/pkg/utils"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type WalletClient interface {
 	CheckAndDebit(ctx context.Context, walletAddress string, amount float64) (bool, error)
+	Refund(ctx context.Context, walletAddress string, amount float64) error
+	Credit(ctx context.Context, walletAddress string, amount float64) error
 }
 
 type stubWalletClient struct{}
@@ -19,9 +26,23 @@ func NewStubWalletClient() WalletClient {
 	return &stubWalletClient{}
 }
 
+// startWalletSpan wraps a wallet call so a slow debit shows up in the
+// order's trace; the address is masked as in the logs.
+func startWalletSpan(ctx context.Context, operation, walletAddress string, amount float64) (context.Context, trace.Span) {
+	return otel.Tracer("github.com/Kabanya/YAFDS/pkg/app/clients").Start(ctx, "wallet "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("wallet.address", logging.MaskWallets(walletAddress)),
+			attribute.Float64("wallet.amount", amount),
+		),
+	)
+}
+
 func (c *stubWalletClient) CheckAndDebit(ctx context.Context, walletAddress string, amount float64) (bool, error) {
-	logger, _ := utils.Logger()
-	logger.Printf("Wallet: checking balance and debiting %f from %s", amount, walletAddress)
+	ctx, span := startWalletSpan(ctx, "CheckAndDebit", walletAddress, amount)
+	defer span.End()
+	logger := logging.FromContext(ctx).With("wallet_address", walletAddress, "amount", amount)
+	logger.Debug("wallet: checking balance and debiting")
 
 	// Simulate wallet service delay
 	time.Sleep(10 * time.Millisecond)
@@ -29,7 +50,8 @@ func (c *stubWalletClient) CheckAndDebit(ctx context.Context, walletAddress stri
 	// For demonstration purposes, if wallet address contains "empty", return false (insufficient funds)
 	// Otherwise, 95% chance of success
 	if walletAddress == "0x_empty" {
-		logger.Printf("Wallet: insufficient funds for %s", walletAddress)
+		logger.Info("wallet: insufficient funds")
+		span.SetAttributes(attribute.Bool("wallet.debited", false))
 		return false, nil
 	}
 
@@ -37,10 +59,40 @@ func (c *stubWalletClient) CheckAndDebit(ctx context.Context, walletAddress stri
 	seed := time.Now().UnixNano()
 	r := rand.New(rand.NewSource(seed))
 	if r.Float64() < 0.05 {
-		logger.Printf("Wallet: random failure for %s", walletAddress)
-		return false, fmt.Errorf("wallet service temporary error")
+		logger.Warn("wallet: random failure")
+		err := fmt.Errorf("wallet service temporary error")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, err
 	}
 
-	logger.Printf("Wallet: successfully debited %f from %s", amount, walletAddress)
+	logger.Info("wallet: debited")
+	span.SetAttributes(attribute.Bool("wallet.debited", true))
 	return true, nil
 }
+
+func (c *stubWalletClient) Refund(ctx context.Context, walletAddress string, amount float64) error {
+	ctx, span := startWalletSpan(ctx, "Refund", walletAddress, amount)
+	defer span.End()
+	logger := logging.FromContext(ctx).With("wallet_address", walletAddress, "amount", amount)
+	logger.Debug("wallet: refunding")
+
+	// Simulate wallet service delay
+	time.Sleep(10 * time.Millisecond)
+
+	logger.Info("wallet: refunded")
+	return nil
+}
+
+func (c *stubWalletClient) Credit(ctx context.Context, walletAddress string, amount float64) error {
+	ctx, span := startWalletSpan(ctx, "Credit", walletAddress, amount)
+	defer span.End()
+	logger := logging.FromContext(ctx).With("wallet_address", walletAddress, "amount", amount)
+	logger.Debug("wallet: crediting")
+
+	// Simulate wallet service delay
+	time.Sleep(10 * time.Millisecond)
+
+	logger.Info("wallet: credited")
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/Kabanya/YAFDS/pkg/models"
 	"github.com/Kabanya/YAFDS/pkg/repository"
 	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+	"github.com/Kabanya/YAFDS/pkg/usecase"
 
 	"github.com/google/uuid"
 )
@@ -36,66 +37,161 @@ type App struct {
 	repo Repository
 }
 
-type errorResponse struct {
-	Error string `json:"error"`
-}
-
 type courierResponse struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	ID     uuid.UUID            `json:"id"`
+	Name   string               `json:"name"`
+	Rating models.RatingSummary `json:"rating"`
 }
 
 type restaurantResponse struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	ID     uuid.UUID            `json:"id"`
+	Name   string               `json:"name"`
+	Rating models.RatingSummary `json:"rating"`
 }
 
 type createRequest struct {
-	CustomerID   string                   `json:"customer_id"`
-	CourierID    string                   `json:"courier_id"`
-	RestaurantID string                   `json:"restaurant_id"`
-	Status       string                   `json:"status"`
-	Items        []createOrderItemRequest `json:"items"`
+	CustomerID   string `json:"customer_id" required:"true" format:"uuid"`
+	CourierID    string `json:"courier_id" required:"true" format:"uuid"`
+	RestaurantID string `json:"restaurant_id" required:"true" format:"uuid"`
+	Status       string `json:"status"`
+	// DeliverAt schedules the order; empty delivers as soon as possible.
+	DeliverAt string                   `json:"deliver_at" format:"date-time"`
+	Items     []createOrderItemRequest `json:"items" required:"true" min:"1"`
 }
 
 type createOrderItemRequest struct {
-	RestaurantItemID string `json:"restaurant_item_id"`
-	Quantity         int    `json:"quantity"`
+	RestaurantItemID string `json:"restaurant_item_id" required:"true" format:"uuid"`
+	Quantity         int    `json:"quantity" required:"true" min:"1"`
 }
 
 type acceptOrderItemRequest struct {
-	RestaurantItemID string  `json:"restaurant_item_id"`
-	Price            float64 `json:"price"`
-	Quantity         int     `json:"quantity"`
+	RestaurantItemID string  `json:"restaurant_item_id" required:"true" format:"uuid"`
+	Price            float64 `json:"price" required:"true"`
+	Quantity         int     `json:"quantity" required:"true" min:"1"`
 }
 
 type acceptOrderRequest struct {
-	CustomerID   string                   `json:"customer_id"`
-	CourierID    string                   `json:"courier_id"`
-	RestaurantID string                   `json:"restaurant_id"`
-	Items        []acceptOrderItemRequest `json:"items"`
+	CustomerID   string                   `json:"customer_id" required:"true" format:"uuid"`
+	CourierID    string                   `json:"courier_id" required:"true" format:"uuid"`
+	RestaurantID string                   `json:"restaurant_id" required:"true" format:"uuid"`
+	Items        []acceptOrderItemRequest `json:"items" required:"true" min:"1"`
 }
 
+// The item requests take no restaurant_id: items always come from the
+// menu of the order's own restaurant.
 type addOrderItemRequest struct {
-	RestaurantID     string `json:"restaurant_id"`
-	RestaurantItemID string `json:"restaurant_item_id"`
-	Quantity         int    `json:"quantity"`
+	RestaurantItemID string `json:"restaurant_item_id" required:"true" format:"uuid"`
+	Quantity         int    `json:"quantity" required:"true" min:"1"`
+}
+
+// addOrderItemResponse reports the line after the add; Quantity counts
+// what the order already held.
+type addOrderItemResponse struct {
+	OrderID          uuid.UUID `json:"order_id"`
+	RestaurantItemID uuid.UUID `json:"restaurant_item_id"`
+	Quantity         int       `json:"quantity"`
+	Price            float64   `json:"price"`
+}
+
+type replaceOrderItemsRequest struct {
+	Items []createOrderItemRequest `json:"items" required:"true" min:"1"`
+}
+
+type updateOrderItemRequest struct {
+	Quantity int `json:"quantity" required:"true" min:"1"`
+}
+
+type orderItemsResponse struct {
+	OrderID uuid.UUID          `json:"order_id"`
+	Items   []models.OrderItem `json:"items"`
+	Total   float64            `json:"total"`
 }
 
 type payOrderRequest struct {
-	CustomerID string `json:"customer_id"`
+	CustomerID string  `json:"customer_id" required:"true" format:"uuid"`
+	Tip        float64 `json:"tip"`
+}
+
+// payOrderResponse carries the tip sent along with the payment, or why it
+// failed; the order is paid either way.
+type payOrderResponse struct {
+	OrderID  uuid.UUID   `json:"order_id"`
+	Status   string      `json:"status"`
+	Tip      *models.Tip `json:"tip,omitempty"`
+	TipError string      `json:"tip_error,omitempty"`
+}
+
+type tipOrderRequest struct {
+	CustomerID string  `json:"customer_id" required:"true" format:"uuid"`
+	Amount     float64 `json:"amount" required:"true"`
+}
+
+type cancelOrderRequest struct {
+	CustomerID string `json:"customer_id" required:"true" format:"uuid"`
+	// ReasonCode is one of the models.CancellationReason values, in any case.
+	ReasonCode string `json:"reason_code" required:"true"`
+	Comment    string `json:"comment"`
+}
+
+type reorderRequest struct {
+	CustomerID string `json:"customer_id" required:"true" format:"uuid"`
+	// CourierID defaults to the courier of the old order.
+	CourierID string `json:"courier_id" format:"uuid"`
+}
+
+type reorderConflictResponse struct {
+	models.ErrorResponce
+	Changes []usecase.ReorderLineDiff `json:"changes"`
+}
+
+// payFailedResponse reports the status the order was left in when the
+// customer could not pay.
+type payFailedResponse struct {
+	models.ErrorResponce
+	OrderID uuid.UUID `json:"order_id"`
+	Status  string    `json:"status"`
+}
+
+type createReviewRequest struct {
+	CustomerID     string              `json:"customer_id" required:"true" format:"uuid"`
+	FoodRating     int                 `json:"food_rating" required:"true" min:"1" max:"5"`
+	DeliveryRating int                 `json:"delivery_rating" required:"true" min:"1" max:"5"`
+	Dishes         []models.DishRating `json:"dishes"`
+	Comment        string              `json:"comment"`
+}
+
+type replyReviewRequest struct {
+	OrderID      string `json:"order_id" required:"true" format:"uuid"`
+	RestaurantID string `json:"restaurant_id" required:"true" format:"uuid"`
+	Reply        string `json:"reply" required:"true"`
 }
 
 type menuItemResponse struct {
-	OrderItemID  uuid.UUID `json:"order_item_id"`
-	RestaurantID uuid.UUID `json:"restaurant_id"`
-	Name         string    `json:"name"`
-	Price        float64   `json:"price"`
-	Description  string    `json:"description"`
+	OrderItemID  uuid.UUID            `json:"order_item_id"`
+	RestaurantID uuid.UUID            `json:"restaurant_id"`
+	Name         string               `json:"name"`
+	Price        float64              `json:"price"`
+	Description  string               `json:"description"`
+	Rating       models.RatingSummary `json:"rating"`
 }
 
 type RestaurantMenuClient interface {
 	GetMenuItems(ctx context.Context, restaurantID uuid.UUID) ([]models.MenuItem, error)
 }
 
+// StockReserver takes the items of an accepted order off the restaurant's
+// menu; usecase.ErrOutOfStock means it cannot cover them.
+type StockReserver interface {
+	ReserveStock(ctx context.Context, orderID uuid.UUID) error
+}
+
+type RestaurantScheduleClient interface {
+	GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error)
+}
+
+// RatingsReader serves the aggregated ratings shown next to restaurants, couriers and dishes.
+type RatingsReader interface {
+	Summaries(ctx context.Context, subject models.RatingSubject, ids []uuid.UUID) (map[uuid.UUID]models.RatingSummary, error)
+}
+
 const itemNotAvailableError = "ITEM_NOT_AVAILABLE"
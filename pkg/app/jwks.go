@@ -0,0 +1,29 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+)
+
+// NewJWKSHandler publishes the public keys that verify access tokens so
+// other services can check them without calling this one per request.
+func NewJWKSHandler(keys *auth.KeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+
+		body, err := keys.JWKS()
+		if err != nil {
+			logger.Error("auth: encode jwks failed", "error", err)
+			utils.WriteError(w, "failed to encode key set", http.StatusInternalServerError)
+			return
+		}
+		// Keys change rarely; a short cache keeps rotation quick to pick up.
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}
+}
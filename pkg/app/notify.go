@@ -0,0 +1,96 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// NewNotificationPreferencesHandler shows (GET ?user_id=) or replaces (PUT)
+// a user's contact details and per-event channels. Mount it behind
+// Authenticator.RequireSession; the session must belong to user_id.
+func NewNotificationPreferencesHandler(store notify.PreferenceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+
+		if store == nil {
+			utils.WriteError(w, "notification preferences unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+			if err != nil {
+				utils.WriteError(w, "user_id must be UUID", http.StatusBadRequest)
+				return
+			}
+			if !ownUser(w, r, userID) {
+				return
+			}
+			prefs, err := store.Get(r.Context(), userID)
+			if err != nil {
+				if errors.Is(err, notify.ErrNoPreferences) {
+					utils.WriteError(w, err.Error(), http.StatusNotFound)
+					return
+				}
+				logger.Error("notify: load preferences failed", "error", err)
+				utils.WriteError(w, "failed to load preferences", http.StatusInternalServerError)
+				return
+			}
+			utils.WriteJSON(w, prefs, http.StatusOK)
+		case http.MethodPut:
+			var prefs notify.Preferences
+			if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+				utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			if prefs.UserID == uuid.Nil {
+				utils.WriteError(w, "user_id is required", http.StatusBadRequest)
+				return
+			}
+			if !ownUser(w, r, prefs.UserID) {
+				return
+			}
+			if prefs.Locale == "" {
+				prefs.Locale = notify.DefaultLocale
+			}
+			for event, kinds := range prefs.Channels {
+				for _, kind := range kinds {
+					if !kind.Valid() {
+						utils.WriteError(w, "channels."+string(event)+" has unknown channel "+string(kind), http.StatusBadRequest)
+						return
+					}
+				}
+			}
+			if err := store.Save(r.Context(), prefs); err != nil {
+				logger.Error("notify: save preferences failed", "error", err)
+				utils.WriteError(w, "failed to save preferences", http.StatusInternalServerError)
+				return
+			}
+			utils.WriteJSON(w, prefs, http.StatusOK)
+		}
+	}
+}
+
+// ownUser rejects requests whose session belongs to another user than
+// userID, and requests without a session.
+func ownUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	if _, ok := auth.PrincipalFrom(r.Context()); !ok {
+		utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+		return false
+	}
+	if !auth.ActsFor(r.Context(), userID) {
+		utils.WriteError(w, "session does not belong to this user", http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+
+	"github.com/google/uuid"
+)
+
+func TestNotificationPreferencesNeedOwnSession(t *testing.T) {
+	ctx := context.Background()
+	owner, other := uuid.New(), uuid.New()
+	store := notify.NewMemoryPreferences()
+	_ = store.Save(ctx, notify.Preferences{UserID: owner, Locale: notify.DefaultLocale, Email: "owner@example.com"})
+	handler := NewNotificationPreferencesHandler(store)
+
+	serve := func(method string, principal *auth.Principal) int {
+		var r *http.Request
+		if method == http.MethodGet {
+			r = httptest.NewRequest(method, "/notifications/preferences?user_id="+owner.String(), nil)
+		} else {
+			body := `{"user_id":"` + owner.String() + `","email":"attacker@example.com"}`
+			r = httptest.NewRequest(method, "/notifications/preferences", strings.NewReader(body))
+		}
+		if principal != nil {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), *principal))
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		if code := serve(method, nil); code != http.StatusUnauthorized {
+			t.Errorf("%s without a session = %d, want 401", method, code)
+		}
+		if code := serve(method, &auth.Principal{UserID: other}); code != http.StatusForbidden {
+			t.Errorf("%s by another user = %d, want 403", method, code)
+		}
+	}
+	if prefs, _ := store.Get(ctx, owner); prefs.Email != "owner@example.com" {
+		t.Fatalf("email changed by another user to %q", prefs.Email)
+	}
+	if code := serve(http.MethodGet, &auth.Principal{UserID: owner}); code != http.StatusOK {
+		t.Errorf("GET by owner = %d, want 200", code)
+	}
+	if code := serve(http.MethodPut, &auth.Principal{UserID: owner}); code != http.StatusOK {
+		t.Errorf("PUT by owner = %d, want 200", code)
+	}
+}
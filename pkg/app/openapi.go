@@ -0,0 +1,282 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+	"github.com/Kabanya/YAFDS/pkg/usecase"
+	"github.com/Kabanya/YAFDS/pkg/webhook"
+)
+
+// Operations documents the handlers of this package by the route each
+// service mounts them on. A service adds the ones it mounts to its
+// document with openapi.Document.AddAll.
+var Operations = map[string]openapi.Op{
+	"GET /.well-known/jwks.json": {
+		Summary:     "Public keys that verify access tokens",
+		Description: "Only served when signed access tokens are enabled (AUTH_KEYS_DIR).",
+		Responses:   map[int]any{http.StatusOK: &openapi.Schema{Type: "object"}},
+	},
+
+	"POST /orders": {
+		Summary:     "Create order",
+		Description: "Prices come from the restaurant menu. With deliver_at the order is scheduled into a slot of the restaurant's opening hours.",
+		Body:        createRequest{},
+		Responses: map[int]any{
+			http.StatusCreated:             models.Order{},
+			http.StatusConflict:            models.ErrorResponce{},
+			http.StatusUnprocessableEntity: models.ErrorResponce{},
+			http.StatusBadGateway:          models.ErrorResponce{},
+		},
+	},
+	"GET /orders": {
+		Summary: "List orders",
+		Query: []openapi.Param{
+			{Name: "customer_id", Schema: openapi.UUID()},
+			{Name: "courier_id", Schema: openapi.UUID()},
+			{Name: "status", Description: "Order status, e.g. CUSTOMER_PAID"},
+			{Name: "scheduled", Description: "Only orders still held for their delivery slot", Schema: openapi.Boolean()},
+		},
+		Responses: map[int]any{http.StatusOK: []models.Order{}},
+	},
+	"POST /orders/{order_id}/pay": {
+		Summary:     "Pay for order",
+		Description: "A tip sent along is charged once the order is paid and never fails the payment.",
+		Body:        payOrderRequest{},
+		Responses: map[int]any{
+			http.StatusOK:              payOrderResponse{},
+			http.StatusPaymentRequired: payFailedResponse{},
+			http.StatusConflict:        models.ErrorResponce{},
+		},
+	},
+	"POST /orders/{order_id}/tip": {
+		Summary:     "Tip courier",
+		Description: "Paid orders, or within 24 hours after completion. 202 means the customer is charged and the payout to the courier is retried.",
+		Body:        tipOrderRequest{},
+		Responses: map[int]any{
+			http.StatusCreated:         models.Tip{},
+			http.StatusAccepted:        models.Tip{},
+			http.StatusPaymentRequired: models.ErrorResponce{},
+			http.StatusForbidden:       models.ErrorResponce{},
+			http.StatusNotFound:        models.ErrorResponce{},
+			http.StatusConflict:        models.ErrorResponce{},
+		},
+	},
+	"POST /orders/{order_id}/cancel": {
+		Summary:     "Cancel order with refund policy",
+		Description: "A tip given before delivery is refunded as well. 202 means the order is cancelled but the refund failed and is followed up.",
+		Body:        cancelOrderRequest{},
+		Responses: map[int]any{
+			http.StatusOK:        usecase.CancelResult{},
+			http.StatusAccepted:  usecase.CancelResult{},
+			http.StatusForbidden: models.ErrorResponce{},
+			http.StatusNotFound:  models.ErrorResponce{},
+			http.StatusConflict:  models.ErrorResponce{},
+		},
+	},
+	"POST /orders/{order_id}/reorder": {
+		Summary: "Order again from a completed order",
+		Body:    reorderRequest{},
+		Responses: map[int]any{
+			http.StatusCreated:    usecase.ReorderResult{},
+			http.StatusForbidden:  models.ErrorResponce{},
+			http.StatusNotFound:   models.ErrorResponce{},
+			http.StatusConflict:   reorderConflictResponse{},
+			http.StatusBadGateway: models.ErrorResponce{},
+		},
+	},
+	"GET /orders/{order_id}/review": {
+		Summary:   "Show review of order",
+		Responses: map[int]any{http.StatusOK: models.Review{}, http.StatusNotFound: models.ErrorResponce{}},
+	},
+	"POST /orders/{order_id}/review": {
+		Summary: "Leave review of completed order",
+		Body:    createReviewRequest{},
+		Responses: map[int]any{
+			http.StatusCreated:   models.Review{},
+			http.StatusForbidden: models.ErrorResponce{},
+			http.StatusNotFound:  models.ErrorResponce{},
+			http.StatusConflict:  models.ErrorResponce{},
+		},
+	},
+	"POST /orders/{order_id}/accept": {
+		Summary:     "Accept order",
+		Description: "The kitchen takes the paid order and reserves its items, or denies it when an item is unknown or out of stock.",
+		Body:        acceptOrderRequest{},
+		Responses:   map[int]any{http.StatusOK: repositoryModels.AcceptResult{}, http.StatusBadGateway: models.ErrorResponce{}},
+	},
+	"POST /orders/{order_id}/items": {
+		Summary:   "Add order item",
+		Body:      addOrderItemRequest{},
+		Responses: orderItemsResponses(http.StatusCreated, addOrderItemResponse{}),
+	},
+	"PATCH /orders/{order_id}/items": {
+		Summary:   "Replace order items",
+		Body:      replaceOrderItemsRequest{},
+		Responses: orderItemsResponses(http.StatusOK, orderItemsResponse{}),
+	},
+	"PATCH /orders/{order_id}/items/{restaurant_item_id}": {
+		Summary:   "Change item quantity",
+		Body:      updateOrderItemRequest{},
+		Responses: orderItemsResponses(http.StatusOK, orderItemsResponse{}),
+	},
+	"DELETE /orders/{order_id}/items/{restaurant_item_id}": {
+		Summary:   "Remove order item",
+		Responses: orderItemsResponses(http.StatusOK, orderItemsResponse{}),
+	},
+
+	"GET /couriers": {
+		Summary:   "List active couriers",
+		Responses: map[int]any{http.StatusOK: []courierResponse{}},
+	},
+	"GET /restaurants": {
+		Summary:   "List active restaurants",
+		Responses: map[int]any{http.StatusOK: []restaurantResponse{}},
+	},
+	"GET /menu": {
+		Summary:   "Show restaurant menu items",
+		Query:     []openapi.Param{{Name: "restaurant_id", Required: true, Schema: openapi.UUID()}},
+		Responses: map[int]any{http.StatusOK: []menuItemResponse{}, http.StatusBadGateway: models.ErrorResponce{}},
+	},
+	"GET /reviews": {
+		Summary: "List restaurant reviews",
+		Query: []openapi.Param{
+			{Name: "restaurant_id", Required: true, Schema: openapi.UUID()},
+			{Name: "limit", Schema: openapi.Integer(0)},
+			{Name: "offset", Schema: openapi.Integer(0)},
+		},
+		Responses: map[int]any{http.StatusOK: []models.Review{}},
+	},
+	"POST /reviews/reply": {
+		Summary:     "Reply to a review",
+		Description: "Needs the reviews:write scope.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Body:        replyReviewRequest{},
+		Responses: map[int]any{
+			http.StatusOK:           models.Review{},
+			http.StatusUnauthorized: models.ErrorResponce{},
+			http.StatusForbidden:    models.ErrorResponce{},
+			http.StatusNotFound:     models.ErrorResponce{},
+			http.StatusConflict:     models.ErrorResponce{},
+		},
+	},
+	"GET /earnings": {
+		Summary: "Completed orders and tips of a courier",
+		Query: []openapi.Param{
+			{Name: "courier_id", Required: true, Schema: openapi.UUID()},
+			{Name: "from", Schema: openapi.DateTime()},
+			{Name: "to", Schema: openapi.DateTime()},
+		},
+		Responses: map[int]any{http.StatusOK: models.CourierEarnings{}},
+	},
+
+	"GET /notifications/preferences": {
+		Summary:     "Show notification contacts and channels",
+		Description: "Only for the session's own user_id.",
+		Security:    []string{openapi.Bearer},
+		Query:       []openapi.Param{{Name: "user_id", Required: true, Schema: openapi.UUID()}},
+		Responses: map[int]any{
+			http.StatusOK:           notify.Preferences{},
+			http.StatusUnauthorized: models.ErrorResponce{},
+			http.StatusForbidden:    models.ErrorResponce{},
+			http.StatusNotFound:     models.ErrorResponce{},
+		},
+	},
+	"PUT /notifications/preferences": {
+		Summary:     "Replace notification contacts and channels",
+		Description: "Only for the session's own user_id. Password reset tokens never go to these contacts, only to the confirmed recovery email.",
+		Security:    []string{openapi.Bearer},
+		Body:        notify.Preferences{},
+		Responses: map[int]any{
+			http.StatusOK:           notify.Preferences{},
+			http.StatusUnauthorized: models.ErrorResponce{},
+			http.StatusForbidden:    models.ErrorResponce{},
+		},
+	},
+
+	"GET /api-keys": {
+		Summary:   "List API keys",
+		Security:  []string{openapi.Bearer},
+		Responses: map[int]any{http.StatusOK: []auth.APIKey{}, http.StatusUnauthorized: models.ErrorResponce{}},
+	},
+	"POST /api-keys": {
+		Summary:     "Create API key",
+		Description: "The key is only shown in this response.",
+		Security:    []string{openapi.Bearer},
+		Body:        createAPIKeyRequest{},
+		Responses:   map[int]any{http.StatusCreated: createAPIKeyResponse{}, http.StatusUnauthorized: models.ErrorResponce{}},
+	},
+	"DELETE /api-keys/{key_id}": {
+		Summary:  "Revoke API key",
+		Security: []string{openapi.Bearer},
+		Responses: map[int]any{
+			http.StatusNoContent:    nil,
+			http.StatusUnauthorized: models.ErrorResponce{},
+			http.StatusNotFound:     models.ErrorResponce{},
+		},
+	},
+
+	"GET /webhooks": {
+		Summary:     "List webhook subscriptions",
+		Description: "Needs the webhooks:write scope.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Query:       []openapi.Param{{Name: "restaurant_id", Required: true, Schema: openapi.UUID()}},
+		Responses:   webhookResponses(http.StatusOK, []webhook.Subscription{}),
+	},
+	"POST /webhooks": {
+		Summary:     "Create webhook subscription",
+		Description: "Needs the webhooks:write scope. The signing secret is only shown in this response.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Body:        createWebhookRequest{},
+		Responses:   webhookResponses(http.StatusCreated, webhook.Subscription{}),
+	},
+	"DELETE /webhooks/{webhook_id}": {
+		Summary:     "Delete webhook subscription",
+		Description: "Needs the webhooks:write scope.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Responses:   webhookResponses(http.StatusNoContent, nil),
+	},
+	"POST /webhooks/{webhook_id}/test": {
+		Summary:     "Send test event",
+		Description: "Needs the webhooks:write scope.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Responses:   webhookResponses(http.StatusOK, webhook.Delivery{}),
+	},
+	"GET /webhooks/{webhook_id}/deliveries": {
+		Summary:     "Delivery log",
+		Description: "Needs the webhooks:write scope.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Query:       []openapi.Param{{Name: "limit", Schema: openapi.Integer(1)}},
+		Responses:   webhookResponses(http.StatusOK, []webhook.Delivery{}),
+	},
+	"POST /webhooks/deliveries/{delivery_id}/redeliver": {
+		Summary:     "Redeliver an event",
+		Description: "Needs the webhooks:write scope.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Responses:   webhookResponses(http.StatusOK, webhook.Delivery{}),
+	},
+}
+
+// orderItemsResponses are what the order item handlers answer besides
+// success: an item not on the menu or out of stock is a 409.
+func orderItemsResponses(status int, body any) map[int]any {
+	return map[int]any{
+		status:                body,
+		http.StatusNotFound:   models.ErrorResponce{},
+		http.StatusConflict:   models.ErrorResponce{},
+		http.StatusBadGateway: models.ErrorResponce{},
+	}
+}
+
+func webhookResponses(status int, body any) map[int]any {
+	return map[int]any{
+		status:                  body,
+		http.StatusUnauthorized: models.ErrorResponce{},
+		http.StatusForbidden:    models.ErrorResponce{},
+		http.StatusNotFound:     models.ErrorResponce{},
+	}
+}
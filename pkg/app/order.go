@@ -1,16 +1,20 @@
 package app
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Kabanya/YAFDS/pkg/logging"
 	"github.com/Kabanya/YAFDS/pkg/models"
 	"github.com/Kabanya/YAFDS/pkg/repository"
 	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+	"github.com/Kabanya/YAFDS/pkg/router"
 	"github.com/Kabanya/YAFDS/pkg/usecase"
 	"github.com/Kabanya/YAFDS/pkg/utils"
 
@@ -23,45 +27,10 @@ type Repository = repositoryModels.Order
 // type Filter = repository.Filter
 // type Order = repository.Order
 
-func NewOrderHandler(repo Repository, menuClient RestaurantMenuClient) http.HandlerFunc {
-	create := NewCreateHandler(repo, menuClient)
-	list := NewListHandler(repo)
-
+func NewCreateHandler(repo Repository, menuClient RestaurantMenuClient, scheduleClient RestaurantScheduleClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodPost:
-			create(w, r)
-		case http.MethodGet:
-			list(w, r)
-		case http.MethodOptions:
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-			w.WriteHeader(http.StatusOK)
-		default:
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			_ = json.NewEncoder(w).Encode(errorResponse{Error: "method not allowed"})
-		}
-	}
-}
-
-func NewCreateHandler(repo Repository, menuClient RestaurantMenuClient) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger, _ := utils.Logger()
+		logger := logging.FromContext(r.Context())
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		if r.Method != http.MethodPost {
-			utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
 
 		var req createRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -95,7 +64,7 @@ func NewCreateHandler(repo Repository, menuClient RestaurantMenuClient) http.Han
 
 		menuItems, err := menuClient.GetMenuItems(r.Context(), restaurantID)
 		if err != nil {
-			logger.Printf("orders: fetch menu items failed: %v", err)
+			logger.Error("orders: fetch menu items failed", "error", err)
 			utils.WriteError(w, "failed to fetch restaurant menu", http.StatusBadGateway)
 			return
 		}
@@ -131,14 +100,51 @@ func NewCreateHandler(repo Repository, menuClient RestaurantMenuClient) http.Han
 			})
 		}
 
-		created, err := repo.CreateWithItems(r.Context(), models.Order{
-			CustomerID: customerID,
-			CourierID:  courierID,
-			Status:     req.Status,
-		}, items)
+		order := models.Order{
+			CustomerID:   customerID,
+			CourierID:    courierID,
+			RestaurantID: restaurantID,
+			Status:       req.Status,
+		}
+		var created models.Order
+		if req.DeliverAt == "" {
+			created, err = repo.CreateWithItems(r.Context(), order, items)
+		} else {
+			deliverAt, parseErr := time.Parse(time.RFC3339, req.DeliverAt)
+			if parseErr != nil {
+				utils.WriteError(w, "deliver_at must be RFC3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			if scheduleClient == nil {
+				utils.WriteError(w, "schedule service unavailable", http.StatusInternalServerError)
+				return
+			}
+			schedule, scheduleErr := scheduleClient.GetSchedule(r.Context(), restaurantID)
+			if scheduleErr != nil {
+				logger.Error("orders: fetch restaurant schedule failed", "error", scheduleErr)
+				utils.WriteError(w, "failed to fetch restaurant schedule", http.StatusBadGateway)
+				return
+			}
+			slot, planErr := usecase.PlanSlot(schedule, deliverAt, time.Now().UTC())
+			if planErr != nil {
+				utils.WriteError(w, planErr.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			// Scheduled orders are held until the scheduler releases them.
+			order.Status = string(models.OrderStatusCustomerCreated)
+			created, err = repo.CreateScheduled(r.Context(), order, items, repositoryModels.Slot{
+				Start:     slot.Start,
+				End:       slot.End,
+				Capacity:  slot.Capacity,
+				DeliverAt: deliverAt,
+				ReleaseAt: slot.ReleaseAt,
+			})
+		}
 		if err != nil {
-			logger.Printf("orders: create failed: %v", err)
+			logger.Error("orders: create failed", "error", err)
 			switch {
+			case errors.Is(err, repository.ErrSlotFull):
+				utils.WriteError(w, err.Error(), http.StatusConflict)
 			case errors.Is(err, ErrCustomerNotFound):
 				utils.WriteError(w, "customer_id not found", http.StatusBadRequest)
 			case errors.Is(err, ErrCourierNotFound):
@@ -148,27 +154,20 @@ func NewCreateHandler(repo Repository, menuClient RestaurantMenuClient) http.Han
 			}
 			return
 		}
+		if req.DeliverAt == "" {
+			usecase.RecordOrderCreated(usecase.OrderSourceAPI)
+		} else {
+			usecase.RecordOrderCreated(usecase.OrderSourceScheduled)
+		}
 
 		utils.WriteJSON(w, created, http.StatusCreated)
 	}
 }
 
-func NewRestaurantMenuHandler(menuClient RestaurantMenuClient) http.HandlerFunc {
+func NewRestaurantMenuHandler(menuClient RestaurantMenuClient, ratings RatingsReader) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger, _ := utils.Logger()
+		logger := logging.FromContext(r.Context())
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		if r.Method != http.MethodGet {
-			utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
 		if menuClient == nil {
 			utils.WriteError(w, "menu service unavailable", http.StatusInternalServerError)
 			return
@@ -187,11 +186,17 @@ func NewRestaurantMenuHandler(menuClient RestaurantMenuClient) http.HandlerFunc
 
 		items, err := menuClient.GetMenuItems(r.Context(), restaurantID)
 		if err != nil {
-			logger.Printf("menu: fetch restaurant items failed: %v", err)
+			logger.Error("menu: fetch restaurant items failed", "error", err)
 			utils.WriteError(w, "failed to fetch restaurant menu", http.StatusBadGateway)
 			return
 		}
 
+		itemIDs := make([]uuid.UUID, 0, len(items))
+		for _, item := range items {
+			itemIDs = append(itemIDs, item.OrderItemID)
+		}
+		itemRatings := loadRatings(r.Context(), ratings, models.RatingSubjectDish, itemIDs)
+
 		response := make([]menuItemResponse, 0, len(items))
 		for _, item := range items {
 			response = append(response, menuItemResponse{
@@ -200,6 +205,7 @@ func NewRestaurantMenuHandler(menuClient RestaurantMenuClient) http.HandlerFunc
 				Name:         item.Name,
 				Price:        item.Price,
 				Description:  item.Description,
+				Rating:       itemRatings[item.OrderItemID],
 			})
 		}
 
@@ -209,20 +215,8 @@ func NewRestaurantMenuHandler(menuClient RestaurantMenuClient) http.HandlerFunc
 
 func NewListHandler(repo Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger, _ := utils.Logger()
+		logger := logging.FromContext(r.Context())
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		if r.Method != http.MethodGet {
-			utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
 
 		var filter Filter
 		if v := r.URL.Query().Get("customer_id"); v != "" {
@@ -244,10 +238,18 @@ func NewListHandler(repo Repository) http.HandlerFunc {
 		if v := r.URL.Query().Get("status"); v != "" {
 			filter.Status = v
 		}
+		if v := r.URL.Query().Get("scheduled"); v != "" {
+			scheduled, err := strconv.ParseBool(v)
+			if err != nil {
+				utils.WriteError(w, "scheduled must be boolean", http.StatusBadRequest)
+				return
+			}
+			filter.Scheduled = scheduled
+		}
 
 		orders, err := repo.List(r.Context(), filter)
 		if err != nil {
-			logger.Printf("orders: list failed: %v", err)
+			logger.Error("orders: list failed", "error", err)
 			utils.WriteError(w, "failed to fetch orders", http.StatusInternalServerError)
 			return
 		}
@@ -256,34 +258,20 @@ func NewListHandler(repo Repository) http.HandlerFunc {
 	}
 }
 
-func NewAcceptHandler(repo Repository) http.HandlerFunc {
+// NewAcceptHandler serves POST /orders/{order_id}/accept, where the kitchen
+// takes or denies a paid order against the restaurant's current menu. A taken
+// order reserves its items; when the restaurant cannot cover them after all
+// the order is denied.
+func NewAcceptHandler(repo Repository, menuClient RestaurantMenuClient, stock StockReserver) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger, _ := utils.Logger()
+		logger := logging.FromContext(r.Context())
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		if r.Method != http.MethodPost {
-			utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
 			return
 		}
-
-		path := strings.TrimPrefix(r.URL.Path, "/orders/")
-		path = strings.Trim(path, "/")
-		parts := strings.Split(path, "/")
-		if len(parts) != 2 || parts[1] != "accept" {
-			utils.WriteError(w, "not found", http.StatusNotFound)
-			return
-		}
-
-		orderID, err := uuid.Parse(parts[0])
-		if err != nil {
-			utils.WriteError(w, "order_id must be UUID", http.StatusBadRequest)
+		if menuClient == nil || stock == nil {
+			utils.WriteError(w, "menu service unavailable", http.StatusInternalServerError)
 			return
 		}
 
@@ -303,12 +291,30 @@ func NewAcceptHandler(repo Repository) http.HandlerFunc {
 			utils.WriteError(w, "courier_id must be UUID", http.StatusBadRequest)
 			return
 		}
+		restaurantID, err := uuid.Parse(req.RestaurantID)
+		if err != nil {
+			utils.WriteError(w, "restaurant_id must be UUID", http.StatusBadRequest)
+			return
+		}
 		if len(req.Items) == 0 {
 			utils.WriteError(w, "items must not be empty", http.StatusBadRequest)
 			return
 		}
 
+		menuItems, err := menuClient.GetMenuItems(r.Context(), restaurantID)
+		if err != nil {
+			logger.Error("orders: fetch menu items failed", "error", err)
+			utils.WriteError(w, "failed to fetch restaurant menu", http.StatusBadGateway)
+			return
+		}
+		menuByID := make(map[uuid.UUID]models.MenuItem, len(menuItems))
+		for _, item := range menuItems {
+			menuByID[item.OrderItemID] = item
+		}
+
 		items := make([]repositoryModels.OrderItemInput, 0, len(req.Items))
+		status := models.OrderStatusKitchenAccepted
+		denial := ""
 		for i, item := range req.Items {
 			restaurantItemID, err := uuid.Parse(item.RestaurantItemID)
 			if err != nil {
@@ -323,6 +329,16 @@ func NewAcceptHandler(repo Repository) http.HandlerFunc {
 				utils.WriteError(w, "items["+strconv.Itoa(i)+"].price must be positive", http.StatusBadRequest)
 				return
 			}
+			menuItem, ok := menuByID[restaurantItemID]
+			switch {
+			case !ok:
+				status, denial = models.OrderStatusKitchenDenied, usecase.DenialUnknownItem
+			case menuItem.Quantity <= 0 || item.Quantity > menuItem.Quantity:
+				status = models.OrderStatusKitchenDenied
+				if denial == "" {
+					denial = usecase.DenialOutOfStock
+				}
+			}
 			items = append(items, repositoryModels.OrderItemInput{
 				RestaurantItemID: restaurantItemID,
 				Price:            item.Price,
@@ -335,9 +351,10 @@ func NewAcceptHandler(repo Repository) http.HandlerFunc {
 			CustomerID: customerID,
 			CourierID:  courierID,
 			Items:      items,
+			Status:     status,
 		})
 		if err != nil {
-			logger.Printf("orders: accept failed: %v", err)
+			logger.Error("orders: accept failed", "error", err)
 			switch {
 			case errors.Is(err, ErrCustomerNotFound):
 				utils.WriteError(w, "customer_id not found", http.StatusBadRequest)
@@ -349,280 +366,622 @@ func NewAcceptHandler(repo Repository) http.HandlerFunc {
 			return
 		}
 
+		if !accepted.Replayed && models.OrderStatus(accepted.Status) == models.OrderStatusKitchenAccepted {
+			if err := stock.ReserveStock(r.Context(), orderID); err != nil {
+				if !errors.Is(err, usecase.ErrOutOfStock) {
+					// Back to paid so the kitchen can accept again once the
+					// restaurant service answers.
+					logger.Error("orders: reserve stock failed", "order_id", orderID, "error", err)
+					if err := repo.UpdateStatus(r.Context(), orderID, models.OrderStatusCustomerPaid); err != nil {
+						logger.Error("orders: reset accepted order failed", "order_id", orderID, "error", err)
+					}
+					utils.WriteError(w, "failed to reserve stock", http.StatusBadGateway)
+					return
+				}
+				if err := repo.UpdateStatus(r.Context(), orderID, models.OrderStatusKitchenDenied); err != nil {
+					logger.Error("orders: deny order without stock failed", "order_id", orderID, "error", err)
+					utils.WriteError(w, "failed to accept order", http.StatusInternalServerError)
+					return
+				}
+				accepted.Status, denial = string(models.OrderStatusKitchenDenied), usecase.DenialOutOfStock
+			}
+		}
+
+		if !accepted.Replayed {
+			usecase.RecordTransition(models.OrderStatusCustomerPaid, models.OrderStatus(accepted.Status))
+			if denial != "" {
+				usecase.RecordKitchenDenied(denial)
+			}
+		}
+
 		utils.WriteJSON(w, accepted, http.StatusOK)
 	}
 }
 
-func NewOrderActionHandler(repo Repository, menuClient RestaurantMenuClient, orderUC usecase.OrderUseCase) http.HandlerFunc {
+// NewPayHandler serves POST /orders/{order_id}/pay. A tip sent along is
+// charged once the order is paid and never fails the payment.
+func NewPayHandler(orderUC usecase.OrderUseCase, tipUC usecase.TipUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger, _ := utils.Logger()
+		logger := logging.FromContext(r.Context())
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == http.MethodOptions {
-			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-			w.WriteHeader(http.StatusOK)
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		if orderUC == nil {
+			utils.WriteError(w, "order usecase unavailable", http.StatusInternalServerError)
 			return
 		}
 
-		path := strings.TrimPrefix(r.URL.Path, "/orders/")
-		path = strings.Trim(path, "/")
-		parts := strings.Split(path, "/")
-		if len(parts) != 2 {
-			utils.WriteError(w, "not found", http.StatusNotFound)
+		var req payOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
 			return
 		}
 
-		orderID, err := uuid.Parse(parts[0])
+		customerID, err := uuid.Parse(req.CustomerID)
 		if err != nil {
-			utils.WriteError(w, "order_id must be UUID", http.StatusBadRequest)
+			utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
 			return
 		}
 
-		switch parts[1] {
-		case "pay":
-			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-			if r.Method != http.MethodPost {
-				utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		newStatus, err := orderUC.Pay(r.Context(), orderID, customerID)
+		if err != nil {
+			logger.Error("orders: pay failed", "error", err)
+			if errors.Is(err, usecase.ErrInsufficientFunds) {
+				utils.WriteJSON(w, payFailedResponse{
+					ErrorResponce: utils.ErrorBody(w, err.Error(), http.StatusPaymentRequired),
+					OrderID:       orderID,
+					Status:        string(newStatus),
+				}, http.StatusPaymentRequired)
 				return
 			}
-			if orderUC == nil {
-				utils.WriteError(w, "order usecase unavailable", http.StatusInternalServerError)
+			if errors.Is(err, usecase.ErrPaymentInProgress) || errors.Is(err, usecase.ErrInvalidStatusTransition) ||
+				errors.Is(err, repository.ErrSlotFull) {
+				utils.WriteError(w, err.Error(), http.StatusConflict)
 				return
 			}
+			utils.WriteError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-			var req payOrderRequest
-			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-				utils.WriteError(w, "invalid request body", http.StatusBadRequest)
-				return
-			}
+		response := payOrderResponse{OrderID: orderID, Status: string(newStatus)}
+		if req.Tip == 0 {
+			utils.WriteJSON(w, response, http.StatusOK)
+			return
+		}
 
-			customerID, err := uuid.Parse(req.CustomerID)
-			if err != nil {
-				utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
-				return
+		// The order is paid either way; a failed tip is reported next to the status.
+		if tipUC == nil {
+			response.TipError = "tip usecase unavailable"
+		} else if tip, err := tipUC.Tip(r.Context(), usecase.TipInput{OrderID: orderID, CustomerID: customerID, Amount: req.Tip}); err != nil {
+			logger.Error("orders: tip at checkout failed", "error", err)
+			response.TipError = err.Error()
+			if tip.ID != uuid.Nil {
+				response.Tip = &tip
 			}
+		} else {
+			response.Tip = &tip
+		}
+		utils.WriteJSON(w, response, http.StatusOK)
+	}
+}
 
-			newStatus, err := orderUC.Pay(r.Context(), orderID, customerID)
-			if err != nil {
-				logger.Printf("orders: pay failed: %v", err)
-				if errors.Is(err, usecase.ErrInsufficientFunds) {
-					utils.WriteJSON(w, map[string]string{
-						"order_id": orderID.String(),
-						"status":   string(newStatus),
-						"error":    err.Error(),
-					}, http.StatusPaymentRequired)
-					return
-				}
-				utils.WriteError(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
+// NewCancelHandler serves POST /orders/{order_id}/cancel.
+func NewCancelHandler(orderUC usecase.OrderUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		if orderUC == nil {
+			utils.WriteError(w, "order usecase unavailable", http.StatusInternalServerError)
+			return
+		}
 
-			utils.WriteJSON(w, map[string]string{
-				"order_id": orderID.String(),
-				"status":   string(newStatus),
-			}, http.StatusOK)
+		var req cancelOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		customerID, err := uuid.Parse(req.CustomerID)
+		if err != nil {
+			utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
+			return
+		}
 
-		case "accept":
-			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-			if r.Method != http.MethodPost {
-				utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-				return
-			}
-			if menuClient == nil {
-				utils.WriteError(w, "menu service unavailable", http.StatusInternalServerError)
-				return
+		result, err := orderUC.Cancel(r.Context(), usecase.CancelInput{
+			OrderID:    orderID,
+			CustomerID: customerID,
+			ReasonCode: models.CancellationReason(strings.ToUpper(strings.TrimSpace(req.ReasonCode))),
+			Comment:    req.Comment,
+		})
+		if err != nil {
+			logger.Error("orders: cancel failed", "error", err)
+			switch {
+			case errors.Is(err, usecase.ErrRefundFailed):
+				// The order is cancelled; the refund stays FAILED in ORDERS_CANCELLATIONS for follow-up.
+				utils.WriteJSON(w, result, http.StatusAccepted)
+			case errors.Is(err, usecase.ErrInvalidReasonCode):
+				utils.WriteError(w, "reason_code is invalid", http.StatusBadRequest)
+			case errors.Is(err, repository.ErrOrderNotFound):
+				utils.WriteError(w, "order_id not found", http.StatusNotFound)
+			case errors.Is(err, usecase.ErrOrderNotOwned):
+				utils.WriteError(w, err.Error(), http.StatusForbidden)
+			case errors.Is(err, usecase.ErrCancellationNotAllowed), errors.Is(err, repository.ErrStatusChanged):
+				utils.WriteError(w, err.Error(), http.StatusConflict)
+			default:
+				utils.WriteError(w, "failed to cancel order", http.StatusInternalServerError)
 			}
+			return
+		}
 
-			var req acceptOrderRequest
-			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-				utils.WriteError(w, "invalid request body", http.StatusBadRequest)
-				return
-			}
+		utils.WriteJSON(w, result, http.StatusOK)
+	}
+}
 
-			customerID, err := uuid.Parse(req.CustomerID)
-			if err != nil {
-				utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
-				return
-			}
-			courierID, err := uuid.Parse(req.CourierID)
+// NewReorderHandler serves POST /orders/{order_id}/reorder, which places a
+// new order with the lines of an old one still on the menu.
+func NewReorderHandler(orderUC usecase.OrderUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		if orderUC == nil {
+			utils.WriteError(w, "order usecase unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		var req reorderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		customerID, err := uuid.Parse(req.CustomerID)
+		if err != nil {
+			utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		courierID := uuid.Nil
+		if req.CourierID != "" {
+			courierID, err = uuid.Parse(req.CourierID)
 			if err != nil {
 				utils.WriteError(w, "courier_id must be UUID", http.StatusBadRequest)
 				return
 			}
-			restaurantID, err := uuid.Parse(req.RestaurantID)
-			if err != nil {
-				utils.WriteError(w, "restaurant_id must be UUID", http.StatusBadRequest)
-				return
-			}
-			if len(req.Items) == 0 {
-				utils.WriteError(w, "items must not be empty", http.StatusBadRequest)
-				return
-			}
+		}
 
-			menuItems, err := menuClient.GetMenuItems(r.Context(), restaurantID)
-			if err != nil {
-				logger.Printf("orders: fetch menu items failed: %v", err)
+		result, err := orderUC.Reorder(r.Context(), usecase.ReorderInput{
+			OrderID:    orderID,
+			CustomerID: customerID,
+			CourierID:  courierID,
+		})
+		if err != nil {
+			logger.Error("orders: reorder failed", "error", err)
+			switch {
+			case errors.Is(err, usecase.ErrNothingToReorder):
+				utils.WriteJSON(w, reorderConflictResponse{
+					ErrorResponce: utils.ErrorBody(w, err.Error(), http.StatusConflict),
+					Changes:       result.Changes,
+				}, http.StatusConflict)
+			case errors.Is(err, repository.ErrOrderNotFound):
+				utils.WriteError(w, "order_id not found", http.StatusNotFound)
+			case errors.Is(err, usecase.ErrOrderNotOwned):
+				utils.WriteError(w, err.Error(), http.StatusForbidden)
+			case errors.Is(err, usecase.ErrReorderNotAllowed), errors.Is(err, usecase.ErrOrderHasNoRestaurant):
+				utils.WriteError(w, err.Error(), http.StatusConflict)
+			case errors.Is(err, usecase.ErrMenuUnavailable):
 				utils.WriteError(w, "failed to fetch restaurant menu", http.StatusBadGateway)
-				return
-			}
-			menuByID := make(map[uuid.UUID]models.MenuItem, len(menuItems))
-			for _, item := range menuItems {
-				menuByID[item.OrderItemID] = item
+			case errors.Is(err, ErrCourierNotFound):
+				utils.WriteError(w, "courier_id not found", http.StatusBadRequest)
+			default:
+				utils.WriteError(w, "failed to reorder", http.StatusInternalServerError)
 			}
+			return
+		}
 
-			items := make([]repositoryModels.OrderItemInput, 0, len(req.Items))
-			status := models.OrderStatusKitchenAccepted
-			for i, item := range req.Items {
-				restaurantItemID, err := uuid.Parse(item.RestaurantItemID)
-				if err != nil {
-					utils.WriteError(w, "items["+strconv.Itoa(i)+"].restaurant_item_id must be UUID", http.StatusBadRequest)
-					return
-				}
-				if item.Quantity <= 0 {
-					utils.WriteError(w, "items["+strconv.Itoa(i)+"].quantity must be positive", http.StatusBadRequest)
-					return
-				}
-				if item.Price <= 0 {
-					utils.WriteError(w, "items["+strconv.Itoa(i)+"].price must be positive", http.StatusBadRequest)
-					return
-				}
-				menuItem, ok := menuByID[restaurantItemID]
-				if !ok || menuItem.Quantity <= 0 || item.Quantity > menuItem.Quantity {
-					status = models.OrderStatusKitchenDenied
-				}
-				items = append(items, repositoryModels.OrderItemInput{
-					RestaurantItemID: restaurantItemID,
-					Price:            item.Price,
-					Quantity:         item.Quantity,
-				})
-			}
-
-			accepted, err := repo.Accept(r.Context(), repositoryModels.AcceptInput{
-				OrderID:    orderID,
-				CustomerID: customerID,
-				CourierID:  courierID,
-				Items:      items,
-				Status:     status,
-			})
-			if err != nil {
-				logger.Printf("orders: accept failed: %v", err)
-				switch {
-				case errors.Is(err, ErrCustomerNotFound):
-					utils.WriteError(w, "customer_id not found", http.StatusBadRequest)
-				case errors.Is(err, ErrCourierNotFound):
-					utils.WriteError(w, "courier_id not found", http.StatusBadRequest)
-				default:
-					utils.WriteError(w, "failed to accept order", http.StatusInternalServerError)
-				}
-				return
-			}
+		utils.WriteJSON(w, result, http.StatusCreated)
+	}
+}
 
-			utils.WriteJSON(w, accepted, http.StatusOK)
-		case "items":
-			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-			if r.Method != http.MethodPost {
-				utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-				return
+// NewTipHandler serves POST /orders/{order_id}/tip.
+func NewTipHandler(tipUC usecase.TipUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		if tipUC == nil {
+			utils.WriteError(w, "tip usecase unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		var req tipOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		customerID, err := uuid.Parse(req.CustomerID)
+		if err != nil {
+			utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
+			return
+		}
+
+		tip, err := tipUC.Tip(r.Context(), usecase.TipInput{OrderID: orderID, CustomerID: customerID, Amount: req.Amount})
+		if err != nil {
+			logger.Error("orders: tip failed", "error", err)
+			switch {
+			case errors.Is(err, usecase.ErrTipPayoutFailed):
+				// Customer is debited; the tip stays DEBITED in ORDERS_TIPS until paid out.
+				utils.WriteJSON(w, tip, http.StatusAccepted)
+			case errors.Is(err, usecase.ErrInvalidTipAmount):
+				utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, usecase.ErrInsufficientFunds):
+				utils.WriteError(w, err.Error(), http.StatusPaymentRequired)
+			case errors.Is(err, repository.ErrOrderNotFound):
+				utils.WriteError(w, "order_id not found", http.StatusNotFound)
+			case errors.Is(err, usecase.ErrOrderNotOwned):
+				utils.WriteError(w, err.Error(), http.StatusForbidden)
+			case errors.Is(err, usecase.ErrTipNotAllowed), errors.Is(err, usecase.ErrTipWindowClosed), errors.Is(err, repository.ErrTipExists):
+				utils.WriteError(w, err.Error(), http.StatusConflict)
+			case errors.Is(err, usecase.ErrWalletUnavailable):
+				utils.WriteError(w, err.Error(), http.StatusBadGateway)
+			default:
+				utils.WriteError(w, "failed to tip courier", http.StatusInternalServerError)
 			}
-			if menuClient == nil {
-				utils.WriteError(w, "menu service unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteJSON(w, tip, http.StatusCreated)
+	}
+}
+
+// NewOrderReviewHandler shows (GET) or leaves (POST) the review of
+// /orders/{order_id}/review.
+func NewOrderReviewHandler(reviewUC usecase.ReviewUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		if reviewUC == nil {
+			utils.WriteError(w, "review usecase unavailable", http.StatusInternalServerError)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			review, err := reviewUC.Get(r.Context(), orderID)
+			if err != nil {
+				writeReviewError(w, err)
 				return
 			}
-
-			var req addOrderItemRequest
+			utils.WriteJSON(w, review, http.StatusOK)
+		case http.MethodPost:
+			var req createReviewRequest
 			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 				utils.WriteError(w, "invalid request body", http.StatusBadRequest)
 				return
 			}
-			restaurantID, err := uuid.Parse(req.RestaurantID)
+			customerID, err := uuid.Parse(req.CustomerID)
 			if err != nil {
-				utils.WriteError(w, "restaurant_id must be UUID", http.StatusBadRequest)
+				utils.WriteError(w, "customer_id must be UUID", http.StatusBadRequest)
 				return
 			}
-			restaurantItemID, err := uuid.Parse(req.RestaurantItemID)
+			review, err := reviewUC.Create(r.Context(), usecase.CreateReviewInput{
+				OrderID:        orderID,
+				CustomerID:     customerID,
+				FoodRating:     req.FoodRating,
+				DeliveryRating: req.DeliveryRating,
+				Dishes:         req.Dishes,
+				Comment:        req.Comment,
+			})
 			if err != nil {
-				utils.WriteError(w, "restaurant_item_id must be UUID", http.StatusBadRequest)
+				logger.Error("orders: review failed", "error", err)
+				writeReviewError(w, err)
 				return
 			}
-			if req.Quantity <= 0 {
-				utils.WriteError(w, "quantity must be positive", http.StatusBadRequest)
-				return
+			utils.WriteJSON(w, review, http.StatusCreated)
+		}
+	}
+}
+
+// NewAddOrderItemHandler serves POST /orders/{order_id}/items, adding one
+// menu item to an order the kitchen has not accepted yet.
+func NewAddOrderItemHandler(repo Repository, menuClient RestaurantMenuClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		if menuClient == nil {
+			utils.WriteError(w, "menu service unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		var req addOrderItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		restaurantItemID, err := uuid.Parse(req.RestaurantItemID)
+		if err != nil {
+			utils.WriteError(w, "restaurant_item_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		if req.Quantity <= 0 {
+			utils.WriteError(w, "quantity must be positive", http.StatusBadRequest)
+			return
+		}
+
+		restaurantID, ok := orderRestaurant(w, r, repo, orderID)
+		if !ok {
+			return
+		}
+		menuByID, err := fetchMenuByID(r.Context(), menuClient, restaurantID)
+		if err != nil {
+			logger.Error("orders: fetch menu items failed", "error", err)
+			utils.WriteError(w, "failed to fetch restaurant menu", http.StatusBadGateway)
+			return
+		}
+		current, err := repo.ListItems(r.Context(), orderID)
+		if err != nil {
+			logger.Error("orders: list items failed", "error", err)
+			utils.WriteError(w, "failed to add order item", http.StatusInternalServerError)
+			return
+		}
+		quantity := req.Quantity
+		for _, item := range current {
+			if item.RestaurantItemID == restaurantItemID {
+				quantity += item.Quantity
 			}
+		}
+		menuItem, ok := menuByID[restaurantItemID]
+		if !ok || menuItem.Quantity <= 0 || quantity > menuItem.Quantity {
+			utils.WriteError(w, itemNotAvailableError, http.StatusConflict)
+			return
+		}
+
+		if err := repo.AddItem(r.Context(), orderID, repositoryModels.OrderItemInput{
+			RestaurantItemID: menuItem.OrderItemID,
+			Price:            menuItem.Price,
+			Quantity:         req.Quantity,
+		}); err != nil {
+			logger.Error("orders: add item failed", "error", err)
+			writeOrderItemsError(w, err, "failed to add order item")
+			return
+		}
 
-			menuItems, err := menuClient.GetMenuItems(r.Context(), restaurantID)
+		utils.WriteJSON(w, addOrderItemResponse{
+			OrderID:          orderID,
+			RestaurantItemID: menuItem.OrderItemID,
+			Quantity:         quantity,
+			Price:            menuItem.Price,
+		}, http.StatusCreated)
+	}
+}
+
+// NewReplaceOrderItemsHandler serves PATCH /orders/{order_id}/items, which
+// replaces every line of the order.
+func NewReplaceOrderItemsHandler(repo Repository, menuClient RestaurantMenuClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		if menuClient == nil {
+			utils.WriteError(w, "menu service unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		var req replaceOrderItemsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if len(req.Items) == 0 {
+			utils.WriteError(w, "items must not be empty", http.StatusBadRequest)
+			return
+		}
+
+		requested := make([]repositoryModels.OrderItemInput, 0, len(req.Items))
+		for i, item := range req.Items {
+			itemID, err := uuid.Parse(item.RestaurantItemID)
 			if err != nil {
-				logger.Printf("orders: fetch menu items failed: %v", err)
-				utils.WriteError(w, "failed to fetch restaurant menu", http.StatusBadGateway)
+				utils.WriteError(w, "items["+strconv.Itoa(i)+"].restaurant_item_id must be UUID", http.StatusBadRequest)
 				return
 			}
-			menuByID := make(map[uuid.UUID]models.MenuItem, len(menuItems))
-			for _, item := range menuItems {
-				menuByID[item.OrderItemID] = item
-			}
-			menuItem, ok := menuByID[restaurantItemID]
-			if !ok || menuItem.Quantity <= 0 || req.Quantity > menuItem.Quantity {
-				utils.WriteError(w, itemNotAvailableError, http.StatusConflict)
+			if item.Quantity <= 0 {
+				utils.WriteError(w, "items["+strconv.Itoa(i)+"].quantity must be positive", http.StatusBadRequest)
 				return
 			}
+			requested = append(requested, repositoryModels.OrderItemInput{RestaurantItemID: itemID, Quantity: item.Quantity})
+		}
 
-			if err := repo.AddItem(r.Context(), orderID, repositoryModels.OrderItemInput{
-				RestaurantItemID: menuItem.OrderItemID,
-				Price:            menuItem.Price,
-				Quantity:         req.Quantity,
-			}); err != nil {
-				logger.Printf("orders: add item failed: %v", err)
-				switch {
-				case errors.Is(err, repository.ErrOrderNotFound):
-					utils.WriteError(w, "order_id not found", http.StatusNotFound)
-				default:
-					utils.WriteError(w, "failed to add order item", http.StatusInternalServerError)
-				}
+		restaurantID, ok := orderRestaurant(w, r, repo, orderID)
+		if !ok {
+			return
+		}
+		menuByID, err := fetchMenuByID(r.Context(), menuClient, restaurantID)
+		if err != nil {
+			logger.Error("orders: fetch menu items failed", "error", err)
+			utils.WriteError(w, "failed to fetch restaurant menu", http.StatusBadGateway)
+			return
+		}
+		items := repositoryModels.MergeItems(requested)
+		for i, item := range items {
+			menuItem, ok := menuByID[item.RestaurantItemID]
+			if !ok || menuItem.Quantity <= 0 || item.Quantity > menuItem.Quantity {
+				utils.WriteError(w, itemNotAvailableError, http.StatusConflict)
 				return
 			}
+			items[i].Price = menuItem.Price
+		}
 
-			utils.WriteJSON(w, map[string]any{
-				"order_id":           orderID,
-				"restaurant_item_id": menuItem.OrderItemID,
-				"quantity":           req.Quantity,
-				"price":              menuItem.Price,
-			}, http.StatusCreated)
-		default:
-			utils.WriteError(w, "not found", http.StatusNotFound)
+		if err := repo.ReplaceItems(r.Context(), orderID, items); err != nil {
+			logger.Error("orders: replace items failed", "error", err)
+			writeOrderItemsError(w, err, "failed to replace order items")
+			return
 		}
+		writeOrderItems(w, r, repo, orderID)
 	}
 }
 
-func NewCouriersHandler(db *sql.DB) http.HandlerFunc {
+// NewUpdateOrderItemHandler serves PATCH
+// /orders/{order_id}/items/{restaurant_item_id}, changing its quantity.
+func NewUpdateOrderItemHandler(repo Repository, menuClient RestaurantMenuClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger, _ := utils.Logger()
+		logger := logging.FromContext(r.Context())
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		restaurantItemID, ok := router.UUIDParam(w, r, "restaurant_item_id")
+		if !ok {
+			return
+		}
+		if menuClient == nil {
+			utils.WriteError(w, "menu service unavailable", http.StatusInternalServerError)
+			return
+		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		var req updateOrderItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Quantity <= 0 {
+			utils.WriteError(w, "quantity must be positive", http.StatusBadRequest)
+			return
+		}
+
+		restaurantID, ok := orderRestaurant(w, r, repo, orderID)
+		if !ok {
+			return
+		}
+		menuByID, err := fetchMenuByID(r.Context(), menuClient, restaurantID)
+		if err != nil {
+			logger.Error("orders: fetch menu items failed", "error", err)
+			utils.WriteError(w, "failed to fetch restaurant menu", http.StatusBadGateway)
+			return
+		}
+		menuItem, ok := menuByID[restaurantItemID]
+		if !ok || menuItem.Quantity <= 0 || req.Quantity > menuItem.Quantity {
+			utils.WriteError(w, itemNotAvailableError, http.StatusConflict)
+			return
+		}
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
+		if err := repo.UpdateItemQuantity(r.Context(), orderID, repositoryModels.OrderItemInput{
+			RestaurantItemID: restaurantItemID,
+			Price:            menuItem.Price,
+			Quantity:         req.Quantity,
+		}); err != nil {
+			logger.Error("orders: update item failed", "error", err)
+			writeOrderItemsError(w, err, "failed to update order item")
+			return
+		}
+		writeOrderItems(w, r, repo, orderID)
+	}
+}
+
+// NewRemoveOrderItemHandler serves DELETE
+// /orders/{order_id}/items/{restaurant_item_id}.
+func NewRemoveOrderItemHandler(repo Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		orderID, ok := router.UUIDParam(w, r, "order_id")
+		if !ok {
+			return
+		}
+		restaurantItemID, ok := router.UUIDParam(w, r, "restaurant_item_id")
+		if !ok {
 			return
 		}
-		if r.Method != http.MethodGet {
-			utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		if err := repo.RemoveItem(r.Context(), orderID, restaurantItemID); err != nil {
+			logger.Error("orders: remove item failed", "error", err)
+			writeOrderItemsError(w, err, "failed to remove order item")
 			return
 		}
+		writeOrderItems(w, r, repo, orderID)
+	}
+}
+
+func writeOrderItems(w http.ResponseWriter, r *http.Request, repo Repository, orderID uuid.UUID) {
+	logger := logging.FromContext(r.Context())
+	items, err := repo.ListItems(r.Context(), orderID)
+	if err != nil {
+		logger.Error("orders: list items failed", "error", err)
+		utils.WriteError(w, "failed to fetch order items", http.StatusInternalServerError)
+		return
+	}
+	var total float64
+	for _, item := range items {
+		total += item.Price * float64(item.Quantity)
+	}
+	utils.WriteJSON(w, orderItemsResponse{OrderID: orderID, Items: items, Total: total}, http.StatusOK)
+}
+
+func writeOrderItemsError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		utils.WriteError(w, "order_id not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrOrderItemNotFound):
+		utils.WriteError(w, "restaurant_item_id not found in order", http.StatusNotFound)
+	case errors.Is(err, repository.ErrOrderNotEditable):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	default:
+		utils.WriteError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// orderRestaurant returns the restaurant of the order, whose menu is the
+// only one its items may come from. It answers the request itself when
+// there is none.
+func orderRestaurant(w http.ResponseWriter, r *http.Request, repo Repository, orderID uuid.UUID) (uuid.UUID, bool) {
+	order, err := repo.Get(r.Context(), orderID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("orders: load order failed", "error", err)
+		writeOrderItemsError(w, err, "failed to load order")
+		return uuid.Nil, false
+	}
+	if order.RestaurantID == uuid.Nil {
+		utils.WriteError(w, usecase.ErrOrderHasNoRestaurant.Error(), http.StatusConflict)
+		return uuid.Nil, false
+	}
+	return order.RestaurantID, true
+}
+
+func fetchMenuByID(ctx context.Context, menuClient RestaurantMenuClient, restaurantID uuid.UUID) (map[uuid.UUID]models.MenuItem, error) {
+	menuItems, err := menuClient.GetMenuItems(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	menuByID := make(map[uuid.UUID]models.MenuItem, len(menuItems))
+	for _, item := range menuItems {
+		menuByID[item.OrderItemID] = item
+	}
+	return menuByID, nil
+}
+
+func NewCouriersHandler(db *sql.DB, ratings RatingsReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
 
 		rows, err := db.QueryContext(r.Context(), "SELECT emp_id, name FROM COURIERS WHERE is_active = TRUE")
 		if err != nil {
-			logger.Printf("orders: list couriers failed: %v", err)
+			logger.Error("orders: list couriers failed", "error", err)
 			utils.WriteError(w, "failed to fetch couriers", http.StatusInternalServerError)
 			return
 		}
@@ -632,7 +991,7 @@ func NewCouriersHandler(db *sql.DB) http.HandlerFunc {
 		for rows.Next() {
 			var c courierResponse
 			if err := rows.Scan(&c.ID, &c.Name); err != nil {
-				logger.Printf("orders: scan couriers failed: %v", err)
+				logger.Error("orders: scan couriers failed", "error", err)
 				utils.WriteError(w, "failed to fetch couriers", http.StatusInternalServerError)
 				return
 			}
@@ -640,36 +999,33 @@ func NewCouriersHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		if err := rows.Err(); err != nil {
-			logger.Printf("orders: iterate couriers failed: %v", err)
+			logger.Error("orders: iterate couriers failed", "error", err)
 			utils.WriteError(w, "failed to fetch couriers", http.StatusInternalServerError)
 			return
 		}
 
+		ids := make([]uuid.UUID, 0, len(couriers))
+		for _, c := range couriers {
+			ids = append(ids, c.ID)
+		}
+		summaries := loadRatings(r.Context(), ratings, models.RatingSubjectCourier, ids)
+		for i := range couriers {
+			couriers[i].Rating = summaries[couriers[i].ID]
+		}
+
 		utils.WriteJSON(w, couriers, http.StatusOK)
 	}
 }
 
-func NewRestaurantsHandler(db *sql.DB) http.HandlerFunc {
+func NewRestaurantsHandler(db *sql.DB, ratings RatingsReader) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger, _ := utils.Logger()
+		logger := logging.FromContext(r.Context())
 
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		if r.Method != http.MethodGet {
-			utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
 
 		rows, err := db.QueryContext(r.Context(), "SELECT emp_id, name FROM RESTAURANTS WHERE status = TRUE")
 		if err != nil {
-			logger.Printf("orders: list restaurants failed: %v", err)
+			logger.Error("orders: list restaurants failed", "error", err)
 			utils.WriteError(w, "failed to fetch restaurants", http.StatusInternalServerError)
 			return
 		}
@@ -679,7 +1035,7 @@ func NewRestaurantsHandler(db *sql.DB) http.HandlerFunc {
 		for rows.Next() {
 			var res restaurantResponse
 			if err := rows.Scan(&res.ID, &res.Name); err != nil {
-				logger.Printf("orders: scan restaurants failed: %v", err)
+				logger.Error("orders: scan restaurants failed", "error", err)
 				utils.WriteError(w, "failed to fetch restaurants", http.StatusInternalServerError)
 				return
 			}
@@ -687,11 +1043,33 @@ func NewRestaurantsHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		if err := rows.Err(); err != nil {
-			logger.Printf("orders: iterate restaurants failed: %v", err)
+			logger.Error("orders: iterate restaurants failed", "error", err)
 			utils.WriteError(w, "failed to fetch restaurants", http.StatusInternalServerError)
 			return
 		}
 
+		ids := make([]uuid.UUID, 0, len(restaurants))
+		for _, res := range restaurants {
+			ids = append(ids, res.ID)
+		}
+		summaries := loadRatings(r.Context(), ratings, models.RatingSubjectRestaurant, ids)
+		for i := range restaurants {
+			restaurants[i].Rating = summaries[restaurants[i].ID]
+		}
+
 		utils.WriteJSON(w, restaurants, http.StatusOK)
 	}
 }
+
+// loadRatings is best effort: listings still render when ratings are unavailable.
+func loadRatings(ctx context.Context, ratings RatingsReader, subject models.RatingSubject, ids []uuid.UUID) map[uuid.UUID]models.RatingSummary {
+	if ratings == nil || len(ids) == 0 {
+		return nil
+	}
+	summaries, err := ratings.Summaries(ctx, subject, ids)
+	if err != nil {
+		logging.FromContext(ctx).Error("ratings: load summaries failed", "subject", subject, "error", err)
+		return nil
+	}
+	return summaries
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+// itemOrders serves one order to the item handlers; the embedded
+// interface panics on anything else.
+type itemOrders struct {
+	Repository
+	order models.Order
+	added []repositoryModels.OrderItemInput
+}
+
+func (r *itemOrders) Get(ctx context.Context, orderID uuid.UUID) (models.Order, error) {
+	return r.order, nil
+}
+
+func (r *itemOrders) ListItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error) {
+	return nil, nil
+}
+
+func (r *itemOrders) AddItem(ctx context.Context, orderID uuid.UUID, item repositoryModels.OrderItemInput) error {
+	r.added = append(r.added, item)
+	return nil
+}
+
+type menus map[uuid.UUID][]models.MenuItem
+
+func (m menus) GetMenuItems(ctx context.Context, restaurantID uuid.UUID) ([]models.MenuItem, error) {
+	return m[restaurantID], nil
+}
+
+func TestAddOrderItemUsesOrderRestaurant(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+	ownItem, foreignItem := uuid.New(), uuid.New()
+	repo := &itemOrders{order: models.Order{ID: uuid.New(), RestaurantID: own}}
+	handler := NewAddOrderItemHandler(repo, menus{
+		own:   {{OrderItemID: ownItem, Price: 10, Quantity: 5}},
+		other: {{OrderItemID: foreignItem, Price: 0.01, Quantity: 5}},
+	})
+	add := func(item uuid.UUID) int {
+		body := `{"restaurant_id":"` + other.String() + `","restaurant_item_id":"` + item.String() + `","quantity":1}`
+		req := httptest.NewRequest(http.MethodPost, "/orders/"+repo.order.ID.String()+"/items", strings.NewReader(body))
+		req.SetPathValue("order_id", repo.order.ID.String())
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	if code := add(foreignItem); code != http.StatusConflict {
+		t.Errorf("item from another restaurant's menu: %d, want %d", code, http.StatusConflict)
+	}
+	if code := add(ownItem); code != http.StatusCreated {
+		t.Errorf("item from the order's restaurant: %d, want %d", code, http.StatusCreated)
+	}
+	if len(repo.added) != 1 || repo.added[0].RestaurantItemID != ownItem || repo.added[0].Price != 10 {
+		t.Errorf("added %+v", repo.added)
+	}
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/repository"
+	"github.com/Kabanya/YAFDS/pkg/usecase"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// NewReviewsHandler lists reviews of a restaurant, newest first.
+func NewReviewsHandler(reviewUC usecase.ReviewUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if reviewUC == nil {
+			utils.WriteError(w, "review usecase unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		query := r.URL.Query()
+		restaurantID, err := uuid.Parse(query.Get("restaurant_id"))
+		if err != nil {
+			utils.WriteError(w, "restaurant_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		limit := 0
+		if limitStr := query.Get("limit"); limitStr != "" {
+			if limit, err = strconv.Atoi(limitStr); err != nil || limit < 0 {
+				utils.WriteError(w, "limit must be non-negative integer", http.StatusBadRequest)
+				return
+			}
+		}
+		offset := 0
+		if offsetStr := query.Get("offset"); offsetStr != "" {
+			if offset, err = strconv.Atoi(offsetStr); err != nil || offset < 0 {
+				utils.WriteError(w, "offset must be non-negative integer", http.StatusBadRequest)
+				return
+			}
+		}
+
+		reviews, err := reviewUC.ListForRestaurant(r.Context(), restaurantID, limit, offset)
+		if err != nil {
+			logger.Error("reviews: list failed", "error", err)
+			utils.WriteError(w, "failed to fetch reviews", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, reviews, http.StatusOK)
+	}
+}
+
+// NewReviewReplyHandler lets a restaurant answer a review of one of its orders.
+// Replying again overwrites the previous reply. The caller must act for the
+// restaurant named in the body.
+func NewReviewReplyHandler(reviewUC usecase.ReviewUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if reviewUC == nil {
+			utils.WriteError(w, "review usecase unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		var req replyReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		orderID, err := uuid.Parse(req.OrderID)
+		if err != nil {
+			utils.WriteError(w, "order_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		restaurantID, err := uuid.Parse(req.RestaurantID)
+		if err != nil {
+			utils.WriteError(w, "restaurant_id must be UUID", http.StatusBadRequest)
+			return
+		}
+		if !actsFor(w, r, restaurantID) {
+			return
+		}
+
+		review, err := reviewUC.Reply(r.Context(), orderID, restaurantID, req.Reply)
+		if err != nil {
+			logger.Error("reviews: reply failed", "error", err)
+			writeReviewError(w, err)
+			return
+		}
+		utils.WriteJSON(w, review, http.StatusOK)
+	}
+}
+
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidRating),
+		errors.Is(err, usecase.ErrUnknownDish),
+		errors.Is(err, usecase.ErrDuplicateDish),
+		errors.Is(err, usecase.ErrReviewTooLong),
+		errors.Is(err, usecase.ErrEmptyReply):
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrOrderNotOwned):
+		utils.WriteError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, repository.ErrOrderNotFound), errors.Is(err, repository.ErrReviewNotFound):
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecase.ErrReviewNotAllowed),
+		errors.Is(err, usecase.ErrOrderHasNoRestaurant),
+		errors.Is(err, repository.ErrReviewExists):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	default:
+		utils.WriteError(w, "failed to process review", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/usecase"
+
+	"github.com/google/uuid"
+)
+
+// replyRecorder remembers replies; the embedded interface panics on anything else.
+type replyRecorder struct {
+	usecase.ReviewUseCase
+	replies int
+}
+
+func (u *replyRecorder) Reply(ctx context.Context, orderID uuid.UUID, restaurantID uuid.UUID, reply string) (models.Review, error) {
+	u.replies++
+	return models.Review{OrderID: orderID, RestaurantID: restaurantID}, nil
+}
+
+func TestReviewReplyChecksRestaurant(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	reviews := &replyRecorder{}
+	handler := NewReviewReplyHandler(reviews)
+
+	serve := func(principal *auth.Principal) int {
+		body := `{"order_id":"` + uuid.NewString() + `","restaurant_id":"` + owner.String() + `","reply":"Thanks!"}`
+		r := httptest.NewRequest(http.MethodPost, "/reviews/reply", strings.NewReader(body))
+		if principal != nil {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), *principal))
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	if code := serve(nil); code != http.StatusUnauthorized {
+		t.Errorf("reply without credentials = %d, want 401", code)
+	}
+	if code := serve(&auth.Principal{UserID: other}); code != http.StatusForbidden {
+		t.Errorf("reply by another restaurant = %d, want 403", code)
+	}
+	if reviews.replies != 0 {
+		t.Fatalf("replies stored for foreign callers: %d", reviews.replies)
+	}
+	if code := serve(&auth.Principal{UserID: owner}); code != http.StatusOK {
+		t.Errorf("reply by owner = %d, want 200", code)
+	}
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/usecase"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const defaultEarningsPeriod = 30 * 24 * time.Hour
+
+// NewCourierEarningsHandler shows completed orders and tips of a courier.
+// from/to are RFC3339 and default to the last 30 days.
+func NewCourierEarningsHandler(tipUC usecase.TipUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if tipUC == nil {
+			utils.WriteError(w, "tip usecase unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		query := r.URL.Query()
+		courierID, err := uuid.Parse(query.Get("courier_id"))
+		if err != nil {
+			utils.WriteError(w, "courier_id must be UUID", http.StatusBadRequest)
+			return
+		}
+
+		to := time.Now().UTC()
+		if toStr := query.Get("to"); toStr != "" {
+			if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+				utils.WriteError(w, "to must be RFC3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+		from := to.Add(-defaultEarningsPeriod)
+		if fromStr := query.Get("from"); fromStr != "" {
+			if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+				utils.WriteError(w, "from must be RFC3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+		if !from.Before(to) {
+			utils.WriteError(w, "from must be before to", http.StatusBadRequest)
+			return
+		}
+
+		earnings, err := tipUC.Earnings(r.Context(), courierID, from, to)
+		if err != nil {
+			logger.Error("couriers: earnings failed", "error", err)
+			utils.WriteError(w, "failed to fetch earnings", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, earnings, http.StatusOK)
+	}
+}
@@ -0,0 +1,209 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+	"github.com/Kabanya/YAFDS/pkg/router"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+	"github.com/Kabanya/YAFDS/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+type createWebhookRequest struct {
+	RestaurantID string `json:"restaurant_id" required:"true" format:"uuid"`
+	URL          string `json:"url" required:"true"`
+	// Events subscribes to some events only; empty means all of them.
+	Events []notify.EventType `json:"events"`
+}
+
+// NewWebhooksHandler lists (GET ?restaurant_id=) or creates (POST) webhook
+// subscriptions. The signing secret is only shown in the POST response.
+func NewWebhooksHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+
+		if dispatcher == nil {
+			utils.WriteError(w, "webhooks unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			restaurantID, err := uuid.Parse(r.URL.Query().Get("restaurant_id"))
+			if err != nil {
+				utils.WriteError(w, "restaurant_id must be UUID", http.StatusBadRequest)
+				return
+			}
+			if !actsFor(w, r, restaurantID) {
+				return
+			}
+			subs, err := dispatcher.Subscriptions(r.Context(), restaurantID)
+			if err != nil {
+				logger.Error("webhook: list subscriptions failed", "error", err)
+				utils.WriteError(w, "failed to list webhooks", http.StatusInternalServerError)
+				return
+			}
+			utils.WriteJSON(w, subs, http.StatusOK)
+		case http.MethodPost:
+			var req createWebhookRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			restaurantID, err := uuid.Parse(req.RestaurantID)
+			if err != nil {
+				utils.WriteError(w, "restaurant_id must be UUID", http.StatusBadRequest)
+				return
+			}
+			if !actsFor(w, r, restaurantID) {
+				return
+			}
+			for i, event := range req.Events {
+				req.Events[i] = notify.EventType(strings.ToUpper(strings.TrimSpace(string(event))))
+			}
+			sub, err := dispatcher.Subscribe(r.Context(), webhook.Subscription{
+				RestaurantID: restaurantID,
+				URL:          strings.TrimSpace(req.URL),
+				Events:       req.Events,
+			})
+			if err != nil {
+				if errors.Is(err, webhook.ErrInvalidURL) || errors.Is(err, webhook.ErrPrivateURL) || errors.Is(err, webhook.ErrInvalidEvent) {
+					utils.WriteError(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				logger.Error("webhook: subscribe failed", "error", err)
+				utils.WriteError(w, "failed to create webhook", http.StatusInternalServerError)
+				return
+			}
+			utils.WriteJSON(w, sub, http.StatusCreated)
+		}
+	}
+}
+
+// NewDeleteWebhookHandler serves DELETE /webhooks/{webhook_id}.
+func NewDeleteWebhookHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		subscriptionID, ok := router.UUIDParam(w, r, "webhook_id")
+		if !ok || !ownsSubscription(w, r, dispatcher, subscriptionID) {
+			return
+		}
+		if err := dispatcher.Unsubscribe(r.Context(), subscriptionID); err != nil {
+			if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+				utils.WriteError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			logger.Error("webhook: unsubscribe failed", "error", err)
+			utils.WriteError(w, "failed to delete webhook", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// NewTestWebhookHandler serves POST /webhooks/{webhook_id}/test, which
+// sends a sample event at once.
+func NewTestWebhookHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscriptionID, ok := router.UUIDParam(w, r, "webhook_id")
+		if !ok || !ownsSubscription(w, r, dispatcher, subscriptionID) {
+			return
+		}
+		delivery, err := dispatcher.SendTest(r.Context(), subscriptionID)
+		writeDeliveryResult(w, logging.FromContext(r.Context()), delivery, err)
+	}
+}
+
+// NewWebhookDeliveriesHandler serves GET
+// /webhooks/{webhook_id}/deliveries?limit=, newest first.
+func NewWebhookDeliveriesHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		subscriptionID, ok := router.UUIDParam(w, r, "webhook_id")
+		if !ok || !ownsSubscription(w, r, dispatcher, subscriptionID) {
+			return
+		}
+		limit := 0
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			var err error
+			if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+				utils.WriteError(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+		deliveries, err := dispatcher.Deliveries(r.Context(), subscriptionID, limit)
+		if err != nil {
+			if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+				utils.WriteError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			logger.Error("webhook: list deliveries failed", "error", err)
+			utils.WriteError(w, "failed to list deliveries", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, deliveries, http.StatusOK)
+	}
+}
+
+// NewRedeliverHandler serves POST /webhooks/deliveries/{delivery_id}/redeliver,
+// which sends a logged delivery's payload again.
+func NewRedeliverHandler(dispatcher *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logging.FromContext(r.Context())
+		deliveryID, ok := router.UUIDParam(w, r, "delivery_id")
+		if !ok {
+			return
+		}
+		previous, err := dispatcher.Delivery(r.Context(), deliveryID)
+		if err != nil {
+			writeDeliveryResult(w, logger, webhook.Delivery{}, err)
+			return
+		}
+		if !ownsSubscription(w, r, dispatcher, previous.SubscriptionID) {
+			return
+		}
+		delivery, err := dispatcher.Redeliver(r.Context(), deliveryID)
+		writeDeliveryResult(w, logger, delivery, err)
+	}
+}
+
+// ownsSubscription loads the subscription and checks that the caller acts
+// for its restaurant. Another restaurant's subscription gets 403 after the
+// lookup, the same as for the list and create endpoints.
+func ownsSubscription(w http.ResponseWriter, r *http.Request, dispatcher *webhook.Dispatcher, subscriptionID uuid.UUID) bool {
+	sub, err := dispatcher.Subscription(r.Context(), subscriptionID)
+	if err != nil {
+		if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+			utils.WriteError(w, err.Error(), http.StatusNotFound)
+			return false
+		}
+		logging.FromContext(r.Context()).Error("webhook: load subscription failed", "error", err)
+		utils.WriteError(w, "failed to load webhook", http.StatusInternalServerError)
+		return false
+	}
+	return actsFor(w, r, sub.RestaurantID)
+}
+
+// writeDeliveryResult reports a synchronous attempt. A receiver error is not
+// a failure of this request: the delivery log entry is returned either way.
+func writeDeliveryResult(w http.ResponseWriter, logger *slog.Logger, delivery webhook.Delivery, err error) {
+	if err != nil {
+		if errors.Is(err, webhook.ErrSubscriptionNotFound) || errors.Is(err, webhook.ErrDeliveryNotFound) {
+			utils.WriteError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		logger.Error("webhook: delivery failed", "error", err)
+		utils.WriteError(w, "failed to deliver webhook", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, delivery, http.StatusOK)
+}
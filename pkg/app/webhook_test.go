@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+// memoryWebhookStore keeps just what the management handlers read; the
+// embedded interface panics on anything else.
+type memoryWebhookStore struct {
+	webhook.Store
+	subs       map[uuid.UUID]webhook.Subscription
+	deliveries map[uuid.UUID]webhook.Delivery
+}
+
+func (s *memoryWebhookStore) GetSubscription(ctx context.Context, id uuid.UUID) (webhook.Subscription, error) {
+	sub, ok := s.subs[id]
+	if !ok {
+		return webhook.Subscription{}, webhook.ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (s *memoryWebhookStore) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	if _, ok := s.subs[id]; !ok {
+		return webhook.ErrSubscriptionNotFound
+	}
+	delete(s.subs, id)
+	return nil
+}
+
+func (s *memoryWebhookStore) GetDelivery(ctx context.Context, id uuid.UUID) (webhook.Delivery, error) {
+	d, ok := s.deliveries[id]
+	if !ok {
+		return webhook.Delivery{}, webhook.ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+func (s *memoryWebhookStore) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]webhook.Delivery, error) {
+	var list []webhook.Delivery
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+func TestWebhookHandlersCheckOwner(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	sub := webhook.Subscription{ID: uuid.New(), RestaurantID: owner, URL: "https://pos.example.com/hook"}
+	delivery := webhook.Delivery{ID: uuid.New(), SubscriptionID: sub.ID, EventID: uuid.New()}
+	store := &memoryWebhookStore{
+		subs:       map[uuid.UUID]webhook.Subscription{sub.ID: sub},
+		deliveries: map[uuid.UUID]webhook.Delivery{delivery.ID: delivery},
+	}
+	dispatcher := webhook.NewDispatcher(store)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /webhooks/{webhook_id}", NewDeleteWebhookHandler(dispatcher))
+	mux.HandleFunc("POST /webhooks/{webhook_id}/test", NewTestWebhookHandler(dispatcher))
+	mux.HandleFunc("GET /webhooks/{webhook_id}/deliveries", NewWebhookDeliveriesHandler(dispatcher))
+	mux.HandleFunc("POST /webhooks/deliveries/{delivery_id}/redeliver", NewRedeliverHandler(dispatcher))
+
+	serve := func(method, path string, principal *auth.Principal) int {
+		r := httptest.NewRequest(method, path, nil)
+		if principal != nil {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), *principal))
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	routes := []struct{ method, path string }{
+		{http.MethodDelete, "/webhooks/" + sub.ID.String()},
+		{http.MethodPost, "/webhooks/" + sub.ID.String() + "/test"},
+		{http.MethodGet, "/webhooks/" + sub.ID.String() + "/deliveries"},
+		{http.MethodPost, "/webhooks/deliveries/" + delivery.ID.String() + "/redeliver"},
+	}
+	for _, route := range routes {
+		if code := serve(route.method, route.path, &auth.Principal{UserID: other}); code != http.StatusForbidden {
+			t.Errorf("%s %s by another restaurant = %d, want 403", route.method, route.path, code)
+		}
+		if code := serve(route.method, route.path, nil); code != http.StatusUnauthorized {
+			t.Errorf("%s %s without credentials = %d, want 401", route.method, route.path, code)
+		}
+	}
+	if _, ok := store.subs[sub.ID]; !ok {
+		t.Fatal("subscription deleted by another restaurant")
+	}
+
+	if code := serve(http.MethodGet, "/webhooks/"+sub.ID.String()+"/deliveries", &auth.Principal{UserID: owner}); code != http.StatusOK {
+		t.Errorf("deliveries by owner = %d, want 200", code)
+	}
+	if code := serve(http.MethodDelete, "/webhooks/"+uuid.NewString(), &auth.Principal{UserID: owner}); code != http.StatusNotFound {
+		t.Errorf("delete of unknown webhook = %d, want 404", code)
+	}
+	if code := serve(http.MethodDelete, "/webhooks/"+sub.ID.String(), &auth.Principal{UserID: owner}); code != http.StatusNoContent {
+		t.Errorf("delete by owner = %d, want 204", code)
+	}
+}
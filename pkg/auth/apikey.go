@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an API key can carry. Sessions of the owner implicitly have all.
+const (
+	ScopeMenuRead      = "menu:read"
+	ScopeMenuWrite     = "menu:write"
+	ScopeOrdersRead    = "orders:read"
+	ScopeScheduleWrite = "schedule:write"
+	ScopeWebhooksWrite = "webhooks:write"
+	ScopeReviewsWrite  = "reviews:write"
+)
+
+var KnownScopes = []string{ScopeMenuRead, ScopeMenuWrite, ScopeOrdersRead, ScopeScheduleWrite, ScopeWebhooksWrite, ScopeReviewsWrite}
+
+var (
+	ErrInvalidAPIKey  = errors.New("auth: invalid, expired or revoked api key")
+	ErrAPIKeyNotFound = errors.New("auth: api key not found")
+	// ErrInvalidAPIKeyRequest wraps the reasons Create rejects its input.
+	ErrInvalidAPIKeyRequest = errors.New("auth: invalid api key request")
+)
+
+const (
+	// APIKeyPrefix marks a bearer token as an API key rather than a session.
+	APIKeyPrefix = "yafds_"
+	// DefaultAPIKeyRateLimit is requests per minute when a key sets none.
+	DefaultAPIKeyRateLimit = 120
+	// MaxAPIKeyRateLimit is the most requests per minute a key may ask for.
+	MaxAPIKeyRateLimit = 1200
+	apiKeyRateWindow   = time.Minute
+	// lastUsedGranularity keeps busy keys from writing on every request.
+	lastUsedGranularity = time.Minute
+)
+
+// APIKey is a stored key. Only Prefix is ever shown again after creation;
+// Hash is the SHA-256 of the full key.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	OwnerID    uuid.UUID  `json:"owner_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+func (k APIKey) usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	// APIKeyByPrefix returns ErrAPIKeyNotFound for unknown prefixes.
+	APIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error)
+	ListAPIKeys(ctx context.Context, ownerID uuid.UUID) ([]APIKey, error)
+	// RevokeAPIKey returns ErrAPIKeyNotFound unless the owner has a live key with the ID.
+	RevokeAPIKey(ctx context.Context, ownerID, id uuid.UUID, at time.Time) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// RateLimiter caps requests per key within a time window.
+type RateLimiter interface {
+	// Allow returns a *ThrottleError once key has used up limit in the window.
+	Allow(ctx context.Context, key string, limit int, window time.Duration) error
+}
+
+// APIKeyInput describes a key to create. A zero TTL never expires.
+type APIKeyInput struct {
+	OwnerID   uuid.UUID
+	Name      string
+	Scopes    []string
+	TTL       time.Duration
+	RateLimit int
+}
+
+// APIKeys mints and checks machine-to-machine keys.
+type APIKeys struct {
+	store   APIKeyStore
+	limiter RateLimiter
+	now     func() time.Time
+}
+
+// NewAPIKeys uses limiter for per-key rate limits; nil disables them.
+func NewAPIKeys(store APIKeyStore, limiter RateLimiter) *APIKeys {
+	return &APIKeys{store: store, limiter: limiter, now: time.Now}
+}
+
+// Create stores a new key and returns it in full; it cannot be recovered
+// later.
+func (a *APIKeys) Create(ctx context.Context, input APIKeyInput) (string, APIKey, error) {
+	if strings.TrimSpace(input.Name) == "" {
+		return "", APIKey{}, fmt.Errorf("%w: name is required", ErrInvalidAPIKeyRequest)
+	}
+	if len(input.Scopes) == 0 {
+		return "", APIKey{}, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyRequest)
+	}
+	for _, scope := range input.Scopes {
+		if !slices.Contains(KnownScopes, scope) {
+			return "", APIKey{}, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKeyRequest, scope)
+		}
+	}
+	if input.TTL < 0 || input.RateLimit < 0 {
+		return "", APIKey{}, fmt.Errorf("%w: ttl and rate limit must not be negative", ErrInvalidAPIKeyRequest)
+	}
+	if input.RateLimit > MaxAPIKeyRateLimit {
+		return "", APIKey{}, fmt.Errorf("%w: rate limit must not exceed %d requests per minute", ErrInvalidAPIKeyRequest, MaxAPIKeyRateLimit)
+	}
+
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", APIKey{}, fmt.Errorf("auth: failed to generate api key: %w", err)
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", APIKey{}, fmt.Errorf("auth: failed to generate api key: %w", err)
+	}
+	prefix := APIKeyPrefix + hex.EncodeToString(prefixBytes)
+	raw := prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	now := a.now().UTC()
+	key := APIKey{
+		ID:        uuid.New(),
+		OwnerID:   input.OwnerID,
+		Name:      strings.TrimSpace(input.Name),
+		Prefix:    prefix,
+		Hash:      hashToken(raw),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(input.Scopes))),
+		RateLimit: input.RateLimit,
+		CreatedAt: now,
+	}
+	if key.RateLimit == 0 {
+		key.RateLimit = DefaultAPIKeyRateLimit
+	}
+	if input.TTL > 0 {
+		expiresAt := now.Add(input.TTL)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := a.store.CreateAPIKey(ctx, key); err != nil {
+		return "", APIKey{}, err
+	}
+	logging.FromContext(ctx).Info("auth: created api key", "prefix", key.Prefix, "name", key.Name, "owner_id", key.OwnerID, "scopes", key.Scopes)
+	return raw, key, nil
+}
+
+func (a *APIKeys) List(ctx context.Context, ownerID uuid.UUID) ([]APIKey, error) {
+	return a.store.ListAPIKeys(ctx, ownerID)
+}
+
+func (a *APIKeys) Revoke(ctx context.Context, ownerID, id uuid.UUID) error {
+	if err := a.store.RevokeAPIKey(ctx, ownerID, id, a.now().UTC()); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("auth: revoked api key", "key_id", id, "owner_id", ownerID)
+	return nil
+}
+
+// Authenticate checks a raw key, applies its rate limit and records its use.
+func (a *APIKeys) Authenticate(ctx context.Context, raw string) (APIKey, error) {
+	prefix, ok := apiKeyPrefix(raw)
+	if !ok {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	key, err := a.store.APIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return APIKey{}, err
+	}
+	now := a.now().UTC()
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashToken(raw))) != 1 || !key.usable(now) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	if a.limiter != nil {
+		if err := a.limiter.Allow(ctx, "apikey:"+key.ID.String(), key.RateLimit, apiKeyRateWindow); err != nil {
+			return APIKey{}, err
+		}
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedGranularity {
+		if err := a.store.TouchAPIKey(ctx, key.ID, now); err != nil {
+			logging.FromContext(ctx).Warn("auth: record use of api key failed", "prefix", key.Prefix, "error", err)
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// IsAPIKey reports whether a bearer token looks like an API key.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// apiKeyPrefix splits "yafds_<prefix>_<secret>" and returns "yafds_<prefix>".
+func apiKeyPrefix(raw string) (string, bool) {
+	rest, ok := strings.CutPrefix(raw, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return APIKeyPrefix + id, true
+}
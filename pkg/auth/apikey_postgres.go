@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostgresAPIKeyStore keeps keys in RESTAURANT_API_KEYS. Only the SHA-256
+// of a key is stored; the prefix is kept in clear to find it again.
+type PostgresAPIKeyStore struct {
+	db *sql.DB
+}
+
+func NewPostgresAPIKeyStore(db *sql.DB) *PostgresAPIKeyStore {
+	return &PostgresAPIKeyStore{db: db}
+}
+
+const apiKeyColumns = `emp_id, restaurant_id, name, prefix, key_hash, scopes, rate_limit, created_at, expires_at, last_used_at, revoked_at`
+
+func (s *PostgresAPIKeyStore) CreateAPIKey(ctx context.Context, key APIKey) error {
+	if s.db == nil {
+		return errors.New("auth: api key store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO RESTAURANT_API_KEYS (`+apiKeyColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULL, NULL)`,
+		key.ID, key.OwnerID, key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, ","), key.RateLimit, key.CreatedAt, key.ExpiresAt)
+	return err
+}
+
+func (s *PostgresAPIKeyStore) APIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error) {
+	if s.db == nil {
+		return APIKey{}, errors.New("auth: api key store not initialized")
+	}
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM RESTAURANT_API_KEYS WHERE prefix = $1`, prefix))
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (s *PostgresAPIKeyStore) ListAPIKeys(ctx context.Context, ownerID uuid.UUID) ([]APIKey, error) {
+	if s.db == nil {
+		return nil, errors.New("auth: api key store not initialized")
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM RESTAURANT_API_KEYS WHERE restaurant_id = $1 ORDER BY created_at`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *PostgresAPIKeyStore) RevokeAPIKey(ctx context.Context, ownerID, id uuid.UUID, at time.Time) error {
+	if s.db == nil {
+		return errors.New("auth: api key store not initialized")
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE RESTAURANT_API_KEYS SET revoked_at = $3 WHERE emp_id = $1 AND restaurant_id = $2 AND revoked_at IS NULL`, id, ownerID, at)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *PostgresAPIKeyStore) TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if s.db == nil {
+		return errors.New("auth: api key store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE RESTAURANT_API_KEYS SET last_used_at = $2 WHERE emp_id = $1`, id, at)
+	return err
+}
+
+type apiKeyScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row apiKeyScanner) (APIKey, error) {
+	var key APIKey
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.OwnerID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.RateLimit,
+		&key.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+		return APIKey{}, err
+	}
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, ",")
+	}
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+	return key, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryAPIKeys struct {
+	keys    map[string]APIKey
+	touches int
+}
+
+func (m *memoryAPIKeys) CreateAPIKey(ctx context.Context, key APIKey) error {
+	m.keys[key.Prefix] = key
+	return nil
+}
+
+func (m *memoryAPIKeys) APIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error) {
+	key, ok := m.keys[prefix]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (m *memoryAPIKeys) ListAPIKeys(ctx context.Context, ownerID uuid.UUID) ([]APIKey, error) {
+	var keys []APIKey
+	for _, key := range m.keys {
+		if key.OwnerID == ownerID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryAPIKeys) RevokeAPIKey(ctx context.Context, ownerID, id uuid.UUID, at time.Time) error {
+	for prefix, key := range m.keys {
+		if key.ID == id && key.OwnerID == ownerID && key.RevokedAt == nil {
+			key.RevokedAt = &at
+			m.keys[prefix] = key
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+func (m *memoryAPIKeys) TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	m.touches++
+	for prefix, key := range m.keys {
+		if key.ID == id {
+			key.LastUsedAt = &at
+			m.keys[prefix] = key
+		}
+	}
+	return nil
+}
+
+// countingLimiter is a fixed window that never resets.
+type countingLimiter struct {
+	counts map[string]int
+}
+
+func (l *countingLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) error {
+	l.counts[key]++
+	if l.counts[key] > limit {
+		return &ThrottleError{RetryAfter: window}
+	}
+	return nil
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryAPIKeys{keys: map[string]APIKey{}}
+	keys := NewAPIKeys(store, &countingLimiter{counts: map[string]int{}})
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	keys.now = func() time.Time { return now }
+	owner := uuid.New()
+
+	if _, _, err := keys.Create(ctx, APIKeyInput{OwnerID: owner, Name: "pos", Scopes: []string{"menu:delete"}}); !errors.Is(err, ErrInvalidAPIKeyRequest) {
+		t.Errorf("Create() with unknown scope error = %v", err)
+	}
+	if _, _, err := keys.Create(ctx, APIKeyInput{OwnerID: owner, Name: "pos", Scopes: []string{ScopeOrdersRead}, RateLimit: MaxAPIKeyRateLimit + 1}); !errors.Is(err, ErrInvalidAPIKeyRequest) {
+		t.Errorf("Create() over the rate limit cap error = %v", err)
+	}
+	raw, key, err := keys.Create(ctx, APIKeyInput{
+		OwnerID:   owner,
+		Name:      "POS terminal",
+		Scopes:    []string{ScopeOrdersRead, ScopeMenuWrite, ScopeOrdersRead},
+		TTL:       24 * time.Hour,
+		RateLimit: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, key.Prefix+"_") || strings.Contains(key.Hash, raw) {
+		t.Fatalf("key %q does not start with prefix %q or is stored in clear", raw, key.Prefix)
+	}
+	if len(key.Scopes) != 2 || !key.HasScope(ScopeMenuWrite) || key.HasScope(ScopeWebhooksWrite) {
+		t.Errorf("scopes = %v", key.Scopes)
+	}
+
+	got, err := keys.Authenticate(ctx, raw)
+	if err != nil || got.ID != key.ID || got.LastUsedAt == nil {
+		t.Fatalf("Authenticate() = %+v, %v", got, err)
+	}
+	// Uses within a minute are not written again.
+	if _, err := keys.Authenticate(ctx, raw); err != nil || store.touches != 1 {
+		t.Errorf("second Authenticate() err = %v, touches = %d", err, store.touches)
+	}
+	if _, err := keys.Authenticate(ctx, raw); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Authenticate() over rate limit error = %v", err)
+	}
+
+	for _, bad := range []string{"", "yafds_", key.Prefix + "_wrong", "yafds_00000000_" + strings.Repeat("a", 43)} {
+		if _, err := keys.Authenticate(ctx, bad); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("Authenticate(%q) error = %v", bad, err)
+		}
+	}
+
+	expiring, _, _ := keys.Create(ctx, APIKeyInput{OwnerID: owner, Name: "aggregator", Scopes: []string{ScopeMenuRead}, TTL: time.Hour})
+	now = now.Add(2 * time.Hour)
+	if _, err := keys.Authenticate(ctx, expiring); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expired key error = %v", err)
+	}
+
+	if err := keys.Revoke(ctx, uuid.New(), key.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Revoke() by another owner error = %v", err)
+	}
+	if err := keys.Revoke(ctx, owner, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Authenticate(ctx, raw); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("revoked key error = %v", err)
+	}
+}
+
+func TestAuthenticatorMiddleware(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	keys := NewAPIKeys(&memoryAPIKeys{keys: map[string]APIKey{}}, nil)
+	menuKey, _, _ := keys.Create(ctx, APIKeyInput{OwnerID: owner, Name: "pos", Scopes: []string{ScopeMenuWrite}})
+	authn := NewAuthenticator(&mockSessions{}, keys)
+
+	var seen Principal
+	next := func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = PrincipalFrom(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}
+	serve := func(h http.HandlerFunc, method string, header, value string) int {
+		req := httptest.NewRequest(method, "/menu/upload", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		return rec.Code
+	}
+
+	menu := authn.Require(ScopeMenuWrite, next)
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		method        string
+		header, value string
+		want          int
+	}{
+		{"no credentials", menu, http.MethodPost, "", "", http.StatusUnauthorized},
+		{"preflight", menu, http.MethodOptions, "", "", http.StatusNoContent},
+		{"session", menu, http.MethodPost, "Authorization", "Bearer token-" + owner.String(), http.StatusNoContent},
+		{"api key bearer", menu, http.MethodPost, "Authorization", "Bearer " + menuKey, http.StatusNoContent},
+		{"api key header", menu, http.MethodPost, "X-API-Key", menuKey, http.StatusNoContent},
+		{"bad api key", menu, http.MethodPost, "X-API-Key", "yafds_00000000_x", http.StatusUnauthorized},
+		{"missing scope", authn.Require(ScopeOrdersRead, next), http.MethodGet, "X-API-Key", menuKey, http.StatusForbidden},
+		{"public read", authn.RequireForWrites(ScopeScheduleWrite, next), http.MethodGet, "", "", http.StatusNoContent},
+		{"guarded write", authn.RequireForWrites(ScopeScheduleWrite, next), http.MethodPost, "", "", http.StatusUnauthorized},
+		{"session only", authn.RequireSession(next), http.MethodPost, "X-API-Key", menuKey, http.StatusForbidden},
+		{"service token", RequireServiceToken("s3cret", next), http.MethodPost, ServiceTokenHeader, "s3cret", http.StatusNoContent},
+		{"wrong service token", RequireServiceToken("s3cret", next), http.MethodPost, ServiceTokenHeader, "guess", http.StatusUnauthorized},
+		{"session instead of service token", RequireServiceToken("s3cret", next), http.MethodPost, "Authorization", "Bearer token-" + owner.String(), http.StatusUnauthorized},
+		{"service token not configured", RequireServiceToken("", next), http.MethodPost, ServiceTokenHeader, "", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(tt.handler, tt.method, tt.header, tt.value); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	serve(menu, http.MethodPost, "X-API-Key", menuKey)
+	if seen.UserID != owner || seen.APIKey == nil || !ActsFor(WithPrincipal(ctx, seen), owner) {
+		t.Errorf("principal = %+v", seen)
+	}
+}
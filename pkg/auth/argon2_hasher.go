@@ -5,6 +5,8 @@ import (
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/Kabanya/YAFDS/pkg/utils"
 
@@ -18,13 +20,38 @@ type ArgonParams struct {
 	KeyLen  uint32
 }
 
+// ArgonThreads is the Argon2 parallelism. The hash depends on it, so it is
+// fixed rather than taken from the host's CPU count: otherwise a host with
+// fewer cores would see every PHC hash as outdated.
+const ArgonThreads uint8 = 6
+
 var DefaultArgonParams = ArgonParams{
 	Memory:  utils.Memory64KB,
 	Time:    1,
-	Threads: utils.NumThreads(6),
+	Threads: ArgonThreads,
+	KeyLen:  32,
+}
+
+// LegacyArgonParams are the parameters behind the bare base64 hashes stored
+// before the PHC format. They must not change with DefaultArgonParams.
+// Threads is only the upper bound: the old hasher used utils.NumThreads(6),
+// min(6, GOMAXPROCS) of the host that made the hash, and the hash does not
+// record it, so Verify tries every parallelism from Threads down to 1.
+var LegacyArgonParams = ArgonParams{
+	Memory:  utils.Memory64KB,
+	Time:    1,
+	Threads: ArgonThreads,
 	KeyLen:  32,
 }
 
+const argon2idPrefix = "$argon2id$"
+
+// Rehasher is implemented by hashers that can tell when a stored hash was
+// made with outdated parameters.
+type Rehasher interface {
+	NeedsRehash(encoded string) bool
+}
+
 type Argon2Hasher struct {
 	params ArgonParams
 }
@@ -40,6 +67,9 @@ func (h *Argon2Hasher) WithLogger() *Argon2Hasher {
 	return &Argon2Hasher{params: h.params}
 }
 
+// Hash returns a PHC string ($argon2id$v=19$m=..,t=..,p=..$salt$key). The
+// salt is returned as well for the legacy password_salt column, but the PHC
+// string alone is enough to verify.
 func (h *Argon2Hasher) Hash(password string) (string, []byte, error) {
 	if password == "" {
 		return "", nil, errors.New("auth: empty password")
@@ -51,18 +81,100 @@ func (h *Argon2Hasher) Hash(password string) (string, []byte, error) {
 	if _, err := rand.Read(salt); err != nil {
 		return "", nil, err
 	}
-	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Threads, h.params.KeyLen)
-	return base64.RawStdEncoding.EncodeToString(key), salt, nil
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return EncodeArgon2PHC(p, salt, key), salt, nil
 }
 
-func (h *Argon2Hasher) Verify(password string, salt []byte, expectedB64 string) bool {
-	if password == "" || len(salt) == 0 || expectedB64 == "" {
+// Verify accepts PHC strings, which carry their own parameters and salt,
+// and legacy base64 keys, which use the salt column and LegacyArgonParams.
+// A wrong password costs up to LegacyArgonParams.Threads hashes against a
+// legacy key; the login throttle bounds how often that can be forced, and
+// the first successful login replaces the key with a PHC string.
+func (h *Argon2Hasher) Verify(password string, salt []byte, expected string) bool {
+	if password == "" || expected == "" {
 		return false
 	}
-	expected, err := base64.RawStdEncoding.DecodeString(expectedB64)
+	if strings.HasPrefix(expected, argon2idPrefix) {
+		parsed, err := ParseArgon2PHC(expected)
+		if err != nil {
+			return false
+		}
+		return verifyArgon2(password, parsed.Salt, parsed.Key, parsed.Params)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(expected)
 	if err != nil {
 		return false
 	}
-	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Threads, uint32(len(expected)))
-	return subtle.ConstantTimeCompare(key, expected) == 1
+	params := LegacyArgonParams
+	for threads := LegacyArgonParams.Threads; threads >= 1; threads-- {
+		params.Threads = threads
+		if verifyArgon2(password, salt, key, params) {
+			return true
+		}
+	}
+	return false
+}
+
+func verifyArgon2(password string, salt, key []byte, params ArgonParams) bool {
+	if len(salt) == 0 || len(key) == 0 {
+		return false
+	}
+	computed := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(computed, key) == 1
+}
+
+// NeedsRehash reports whether encoded is a legacy hash or was made with
+// parameters other than the hasher's.
+func (h *Argon2Hasher) NeedsRehash(encoded string) bool {
+	parsed, err := ParseArgon2PHC(encoded)
+	if err != nil {
+		return true
+	}
+	return parsed.Params != h.params
+}
+
+// Argon2PHC is a parsed $argon2id$ PHC string.
+type Argon2PHC struct {
+	Params ArgonParams
+	Salt   []byte
+	Key    []byte
+}
+
+func EncodeArgon2PHC(params ArgonParams, salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		params.Memory, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func ParseArgon2PHC(encoded string) (Argon2PHC, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return Argon2PHC{}, errors.New("auth: not an argon2id PHC string")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2PHC{}, fmt.Errorf("auth: unsupported argon2 version %q", parts[2])
+	}
+	var (
+		phc     Argon2PHC
+		threads uint32
+	)
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &phc.Params.Memory, &phc.Params.Time, &threads); err != nil {
+		return Argon2PHC{}, fmt.Errorf("auth: bad argon2 parameters %q: %w", parts[3], err)
+	}
+	if phc.Params.Memory == 0 || phc.Params.Time == 0 || threads == 0 || threads > 255 {
+		return Argon2PHC{}, fmt.Errorf("auth: bad argon2 parameters %q", parts[3])
+	}
+	phc.Params.Threads = uint8(threads)
+	var err error
+	if phc.Salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return Argon2PHC{}, fmt.Errorf("auth: bad argon2 salt: %w", err)
+	}
+	if phc.Key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return Argon2PHC{}, fmt.Errorf("auth: bad argon2 hash: %w", err)
+	}
+	phc.Params.KeyLen = uint32(len(phc.Key))
+	return phc, nil
 }
@@ -1,9 +1,28 @@
 package auth
 
 import (
+	"encoding/base64"
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/argon2"
 )
 
+// legacyHash builds a hash the way the pre-PHC hasher stored it on a host
+// with at least six cores.
+func legacyHash(password string) (string, []byte) {
+	return legacyHashWithThreads(password, LegacyArgonParams.Threads)
+}
+
+// legacyHashWithThreads builds a legacy hash made on a host whose
+// GOMAXPROCS capped the parallelism at threads.
+func legacyHashWithThreads(password string, threads uint8) (string, []byte) {
+	salt := []byte("0123456789abcdef")
+	p := LegacyArgonParams
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, threads, p.KeyLen)
+	return base64.RawStdEncoding.EncodeToString(key), salt
+}
+
 func TestArgon2Hasher(t *testing.T) {
 	hasher := NewArgon2Hasher(DefaultArgonParams)
 
@@ -52,8 +71,12 @@ func TestArgon2Hasher(t *testing.T) {
 		if hasher.Verify("", salt, hash) {
 			t.Error("Verify() should fail for empty password")
 		}
-		if hasher.Verify(password, nil, hash) {
-			t.Error("Verify() should fail for nil salt")
+		legacyHash, legacySalt := legacyHash(password)
+		if hasher.Verify(password, nil, legacyHash) {
+			t.Error("Verify() should fail for legacy hash without salt")
+		}
+		if !hasher.Verify(password, legacySalt, legacyHash) {
+			t.Error("Verify() failed for legacy hash with salt")
 		}
 		if hasher.Verify(password, salt, "") {
 			t.Error("Verify() should fail for empty hash")
@@ -63,3 +86,69 @@ func TestArgon2Hasher(t *testing.T) {
 		}
 	})
 }
+
+func TestVerifyLegacyHashFromSmallerHost(t *testing.T) {
+	hasher := NewArgon2Hasher(DefaultArgonParams)
+	for _, threads := range []uint8{1, 2, 4} {
+		hash, salt := legacyHashWithThreads("my-secure-password", threads)
+		if !hasher.Verify("my-secure-password", salt, hash) {
+			t.Errorf("Verify() failed for legacy hash with p=%d", threads)
+		}
+		if hasher.Verify("wrong-password", salt, hash) {
+			t.Errorf("Verify() accepted wrong password for legacy hash with p=%d", threads)
+		}
+	}
+}
+
+func TestArgon2PHC(t *testing.T) {
+	params := ArgonParams{Memory: 32 * 1024, Time: 2, Threads: 1, KeyLen: 32}
+	hasher := NewArgon2Hasher(params)
+	hash, _, err := hasher.Hash("my-secure-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=32768,t=2,p=1$") {
+		t.Fatalf("Hash() = %q, want PHC string", hash)
+	}
+	parsed, err := ParseArgon2PHC(hash)
+	if err != nil || parsed.Params != params || len(parsed.Salt) != 16 {
+		t.Fatalf("ParseArgon2PHC() = %+v, %v", parsed, err)
+	}
+	if EncodeArgon2PHC(parsed.Params, parsed.Salt, parsed.Key) != hash {
+		t.Error("encode(parse(hash)) != hash")
+	}
+
+	// A hasher with other defaults still verifies: parameters come from the string.
+	if !NewArgon2Hasher(DefaultArgonParams).Verify("my-secure-password", nil, hash) {
+		t.Error("Verify() with different hasher params failed")
+	}
+
+	for _, bad := range []string{
+		"$argon2i$v=19$m=32768,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=32768,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=0,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=32768,t=2,p=1$c2FsdA",
+		"$argon2id$v=19$m=32768,t=2,p=1$!!$a2V5",
+	} {
+		if _, err := ParseArgon2PHC(bad); err == nil {
+			t.Errorf("ParseArgon2PHC(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current := NewArgon2Hasher(DefaultArgonParams)
+	hash, _, _ := current.Hash("my-secure-password")
+	if current.NeedsRehash(hash) {
+		t.Error("fresh hash needs rehash")
+	}
+	legacy, _ := legacyHash("my-secure-password")
+	if !current.NeedsRehash(legacy) {
+		t.Error("legacy hash does not need rehash")
+	}
+	tuned := DefaultArgonParams
+	tuned.Time++
+	if !NewArgon2Hasher(tuned).NeedsRehash(hash) {
+		t.Error("hash with old time cost does not need rehash")
+	}
+}
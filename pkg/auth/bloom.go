@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// BloomFilter is a fixed-size set membership filter: Test never misses an
+// added key and reports absent keys as present with roughly the false
+// positive rate it was sized for.
+type BloomFilter struct {
+	bits   []uint64
+	m      uint64
+	k      uint64
+	length int
+}
+
+// NewBloomFilter sizes a filter for n keys at falsePositiveRate.
+func NewBloomFilter(n int, falsePositiveRate float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.001
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+func (f *BloomFilter) Add(key []byte) {
+	h1, h2 := bloomHashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.length++
+}
+
+func (f *BloomFilter) Test(key []byte) bool {
+	if f == nil {
+		return false
+	}
+	h1, h2 := bloomHashes(key)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Len is the number of keys added.
+func (f *BloomFilter) Len() int {
+	if f == nil {
+		return 0
+	}
+	return f.length
+}
+
+// bloomHashes derives the two hashes for double hashing (Kirsch–Mitzenmacher).
+func bloomHashes(key []byte) (uint64, uint64) {
+	sum := sha256.Sum256(key)
+	return binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16]) | 1
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// breachedFalsePositiveRate keeps roughly one in ten thousand good
+// passwords from being rejected by mistake.
+const breachedFalsePositiveRate = 0.0001
+
+// BreachedPasswords answers "has this password leaked?" from a Bloom filter
+// of SHA-1 digests, so a list of millions of entries fits in a few MB.
+type BreachedPasswords struct {
+	filter *BloomFilter
+}
+
+// LoadBreachedPasswords reads one entry per line: either a plain password
+// or a 40-digit SHA-1 hex digest, optionally followed by ":count" as in the
+// Have I Been Pwned downloads. Blank lines and lines starting with # are
+// skipped.
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	// Two passes: count first to size the filter, then fill it.
+	n := 0
+	if err := scanBreached(file, func([]byte) { n++ }); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("auth: rewind breached password list: %w", err)
+	}
+	list := &BreachedPasswords{filter: NewBloomFilter(n, breachedFalsePositiveRate)}
+	if err := scanBreached(file, list.filter.Add); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// NewBreachedPasswords builds a list from plain passwords.
+func NewBreachedPasswords(passwords ...string) *BreachedPasswords {
+	list := &BreachedPasswords{filter: NewBloomFilter(len(passwords), breachedFalsePositiveRate)}
+	for _, password := range passwords {
+		digest := sha1.Sum([]byte(password))
+		list.filter.Add(digest[:])
+	}
+	return list
+}
+
+func (b *BreachedPasswords) Contains(password string) bool {
+	if b == nil {
+		return false
+	}
+	digest := sha1.Sum([]byte(password))
+	return b.filter.Test(digest[:])
+}
+
+func (b *BreachedPasswords) Len() int {
+	if b == nil {
+		return 0
+	}
+	return b.filter.Len()
+}
+
+func scanBreached(r io.Reader, add func(digest []byte)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if digest, ok := parseSHA1Line(line); ok {
+			add(digest)
+			continue
+		}
+		digest := sha1.Sum([]byte(line))
+		add(digest[:])
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("auth: read breached password list: %w", err)
+	}
+	return nil
+}
+
+func parseSHA1Line(line string) ([]byte, bool) {
+	hash, _, _ := strings.Cut(line, ":")
+	if len(hash) != 2*sha1.Size {
+		return nil, false
+	}
+	digest, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, false
+	}
+	return digest, true
+}
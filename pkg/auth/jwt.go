@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessClaims are carried by access tokens.
+type AccessClaims struct {
+	Issuer    string    `json:"iss,omitempty"`
+	Subject   uuid.UUID `json:"sub"`
+	Role      string    `json:"role,omitempty"`
+	IssuedAt  int64     `json:"iat"`
+	ExpiresAt int64     `json:"exp"`
+	ID        string    `json:"jti"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// tokenLeeway tolerates small clock differences between services.
+const tokenLeeway = 30 * time.Second
+
+// SignAccessToken returns an EdDSA-signed JWT.
+func SignAccessToken(keys *KeySet, claims AccessClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	kid, key, err := keys.signingKey()
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(jwtHeader{Alg: "EdDSA", Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(key, []byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ParseAccessToken verifies signature, expiry and, when issuer is not
+// empty, the issuer of a token.
+func ParseAccessToken(keys *KeySet, token, issuer string, now time.Time) (AccessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return AccessClaims{}, fmt.Errorf("%w: malformed token", ErrInvalidSession)
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return AccessClaims{}, fmt.Errorf("%w: bad header", ErrInvalidSession)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "EdDSA" {
+		return AccessClaims{}, fmt.Errorf("%w: unsupported algorithm", ErrInvalidSession)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return AccessClaims{}, fmt.Errorf("%w: bad signature encoding", ErrInvalidSession)
+	}
+	if err := keys.Verify(header.Kid, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return AccessClaims{}, fmt.Errorf("%w: %v", ErrInvalidSession, err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return AccessClaims{}, fmt.Errorf("%w: bad payload", ErrInvalidSession)
+	}
+	var claims AccessClaims
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&claims); err != nil {
+		return AccessClaims{}, fmt.Errorf("%w: bad claims", ErrInvalidSession)
+	}
+	if claims.Subject == uuid.Nil {
+		return AccessClaims{}, fmt.Errorf("%w: missing subject", ErrInvalidSession)
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(tokenLeeway)) {
+		return AccessClaims{}, fmt.Errorf("%w: token expired", ErrInvalidSession)
+	}
+	if issuer != "" && claims.Issuer != issuer {
+		return AccessClaims{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidSession)
+	}
+	return claims, nil
+}
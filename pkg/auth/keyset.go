@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("auth: unknown signing key")
+
+// KeySet holds Ed25519 keys by key ID. The signing key is the private key
+// with the greatest ID, so naming keys by date ("2026-10-19") makes the
+// newest one sign while older public keys keep verifying until removed.
+type KeySet struct {
+	mu         sync.RWMutex
+	public     map[string]ed25519.PublicKey
+	signingKID string
+	signing    ed25519.PrivateKey
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{public: make(map[string]ed25519.PublicKey)}
+}
+
+// AddPrivate adds a signing key; it becomes the signing key if its ID sorts last.
+func (k *KeySet) AddPrivate(kid string, key ed25519.PrivateKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.public[kid] = key.Public().(ed25519.PublicKey)
+	if k.signing == nil || kid > k.signingKID {
+		k.signingKID, k.signing = kid, key
+	}
+}
+
+// AddPublic adds a verify-only key, e.g. a retired one.
+func (k *KeySet) AddPublic(kid string, key ed25519.PublicKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.public[kid] = key
+}
+
+// signingKey returns the current signing key with its ID.
+func (k *KeySet) signingKey() (string, ed25519.PrivateKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.signing == nil {
+		return "", nil, errors.New("auth: key set has no signing key")
+	}
+	return k.signingKID, k.signing, nil
+}
+
+func (k *KeySet) Verify(kid string, message, signature []byte) error {
+	k.mu.RLock()
+	key, ok := k.public[kid]
+	k.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+	if !ed25519.Verify(key, message, signature) {
+		return errors.New("auth: bad token signature")
+	}
+	return nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid"`
+	X   string `json:"x"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// JWKS returns the public keys as an RFC 8037 OKP key set.
+func (k *KeySet) JWKS() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	kids := make([]string, 0, len(k.public))
+	for kid := range k.public {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	set := jwkSet{Keys: make([]jwk, 0, len(kids))}
+	for _, kid := range kids {
+		set.Keys = append(set.Keys, jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			Kid: kid,
+			X:   base64.RawURLEncoding.EncodeToString(k.public[kid]),
+			Use: "sig",
+			Alg: "EdDSA",
+		})
+	}
+	return json.Marshal(set)
+}
+
+// LoadKeySet reads <kid>.key (PKCS#8 PEM private keys) and <kid>.pub
+// (PKIX PEM public keys, verify only) from dir.
+func LoadKeySet(dir string) (*KeySet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read key dir: %w", err)
+	}
+	keys := NewKeySet()
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if entry.IsDir() || (ext != ".key" && ext != ".pub") {
+			continue
+		}
+		kid := strings.TrimSuffix(name, ext)
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("auth: read key %s: %w", name, err)
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("auth: key %s is not PEM", name)
+		}
+		if ext == ".key" {
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			private, ok := parsed.(ed25519.PrivateKey)
+			if err != nil || !ok {
+				return nil, fmt.Errorf("auth: key %s is not an Ed25519 private key", name)
+			}
+			keys.AddPrivate(kid, private)
+			continue
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		public, ok := parsed.(ed25519.PublicKey)
+		if err != nil || !ok {
+			return nil, fmt.Errorf("auth: key %s is not an Ed25519 public key", name)
+		}
+		keys.AddPublic(kid, public)
+	}
+	return keys, nil
+}
+
+// GenerateKeyFile writes a new <kid>.key to dir and returns its private key.
+func GenerateKeyFile(dir, kid string) (ed25519.PrivateKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".key"), data, 0o600); err != nil {
+		return nil, err
+	}
+	return private, nil
+}
+
+// LoadOrCreateKeySet loads dir and, when it holds no keys yet, creates a
+// first signing key named after today's date.
+func LoadOrCreateKeySet(dir string) (*KeySet, error) {
+	keys, err := LoadKeySet(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if _, _, signErr := keys.signingKey(); signErr == nil {
+			return keys, nil
+		}
+	}
+	kid := time.Now().UTC().Format("2006-01-02")
+	private, err := GenerateKeyFile(dir, kid)
+	if err != nil {
+		return nil, fmt.Errorf("auth: create signing key: %w", err)
+	}
+	slog.Info("auth: created signing key", "kid", kid, "dir", dir)
+	if keys == nil {
+		keys = NewKeySet()
+	}
+	keys.AddPrivate(kid, private)
+	return keys, nil
+}
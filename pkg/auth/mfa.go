@@ -0,0 +1,400 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrMFANotEnrolled    = errors.New("auth: two-factor authentication is not set up")
+	ErrMFAAlreadyEnabled = errors.New("auth: two-factor authentication is already enabled")
+	ErrInvalidMFACode    = errors.New("auth: invalid two-factor code")
+	ErrInvalidMFAToken   = errors.New("auth: invalid or expired two-factor challenge")
+)
+
+const (
+	DefaultMFAChallengeTTL = 5 * time.Minute
+	// DefaultMFAMaxAttempts caps wrong codes per challenge; with a 6-digit
+	// code that keeps a guessing attacker well below 1 in 10^5 per login.
+	DefaultMFAMaxAttempts = 5
+	backupCodeCount       = 10
+)
+
+// MFAState is a user's TOTP enrollment. BackupCodes holds SHA-256 hashes of
+// the unused backup codes; LastStep is the last TOTP step accepted, so a
+// code cannot be used twice.
+type MFAState struct {
+	UserID      uuid.UUID
+	Secret      []byte
+	Enabled     bool
+	BackupCodes []string
+	LastStep    int64
+}
+
+type MFAStore interface {
+	// LoadMFA returns ErrMFANotEnrolled when the user has no enrollment.
+	LoadMFA(ctx context.Context, userID uuid.UUID) (MFAState, error)
+	SaveMFA(ctx context.Context, state MFAState) error
+	DeleteMFA(ctx context.Context, userID uuid.UUID) error
+}
+
+// MFAChallenge is who passed the password step, and on which service.
+type MFAChallenge struct {
+	Role          string
+	WalletAddress string
+}
+
+// MFAChallengeStore keeps the short-lived tokens that stand between a
+// correct password and a session, by their SHA-256 hash.
+type MFAChallengeStore interface {
+	Save(ctx context.Context, tokenHash string, challenge MFAChallenge, ttl time.Duration) error
+	Lookup(ctx context.Context, tokenHash string) (MFAChallenge, error)
+	// Fail counts a wrong code and returns the failures so far.
+	Fail(ctx context.Context, tokenHash string) (int, error)
+	Consume(ctx context.Context, tokenHash string) (bool, error)
+}
+
+// MFAConfig enables two-factor login when Store and Challenges are set.
+type MFAConfig struct {
+	Store      MFAStore
+	Challenges MFAChallengeStore
+	// Issuer is the account name shown in authenticator apps.
+	Issuer string
+	// Role names the service, e.g. "restaurant". The services share the
+	// challenge store, so VerifyMFA rejects challenges of another role.
+	Role         string
+	ChallengeTTL time.Duration
+	MaxAttempts  int
+}
+
+func (c MFAConfig) enabled() bool {
+	return c.Store != nil && c.Challenges != nil
+}
+
+func (c MFAConfig) withDefaults() MFAConfig {
+	if c.Issuer == "" {
+		c.Issuer = "YAFDS"
+	}
+	if c.ChallengeTTL <= 0 {
+		c.ChallengeTTL = DefaultMFAChallengeTTL
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMFAMaxAttempts
+	}
+	return c
+}
+
+// MFAEnrollment is shown once so the user can add the account to an
+// authenticator app.
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// EnrollMFA starts (or restarts) TOTP enrollment for the session's user.
+// The secret only takes effect once ConfirmMFA sees a code made with it.
+func (s *Service) EnrollMFA(ctx context.Context, sessionToken, walletAddress string) (MFAEnrollment, error) {
+	logging.FromContext(ctx).Info("auth: two-factor enrollment", "wallet_address", walletAddress)
+	user, err := s.mfaUser(ctx, sessionToken, walletAddress)
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+	state, err := s.mfa.Store.LoadMFA(ctx, user.ID)
+	if err != nil && !errors.Is(err, ErrMFANotEnrolled) {
+		return MFAEnrollment{}, err
+	}
+	if err == nil && state.Enabled {
+		return MFAEnrollment{}, ErrMFAAlreadyEnabled
+	}
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+	if err := s.mfa.Store.SaveMFA(ctx, MFAState{UserID: user.ID, Secret: secret}); err != nil {
+		return MFAEnrollment{}, err
+	}
+	return MFAEnrollment{
+		Secret: totpEncoding.EncodeToString(secret),
+		URI:    TOTPURI(s.mfa.Issuer, user.WalletAddress, secret),
+	}, nil
+}
+
+// ConfirmMFA turns on two-factor login once the user proves the
+// authenticator works, and returns backup codes that are never shown again.
+func (s *Service) ConfirmMFA(ctx context.Context, sessionToken, walletAddress, code string) ([]string, error) {
+	user, err := s.mfaUser(ctx, sessionToken, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	state, err := s.mfa.Store.LoadMFA(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	step, ok := verifyTOTP(state.Secret, code, time.Now(), state.LastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	codes, hashes, err := newBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+	state.Enabled, state.LastStep, state.BackupCodes = true, step, hashes
+	if err := s.mfa.Store.SaveMFA(ctx, state); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("auth: two-factor authentication enabled", "wallet_address", walletAddress)
+	return codes, nil
+}
+
+// DisableMFA removes two-factor login; it takes a current code or a backup
+// code so a stolen session alone cannot turn it off.
+func (s *Service) DisableMFA(ctx context.Context, sessionToken, walletAddress, code string) error {
+	user, err := s.mfaUser(ctx, sessionToken, walletAddress)
+	if err != nil {
+		return err
+	}
+	state, err := s.mfa.Store.LoadMFA(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if state.Enabled && !checkMFACode(&state, code) {
+		return ErrInvalidMFACode
+	}
+	logging.FromContext(ctx).Info("auth: two-factor authentication disabled", "wallet_address", walletAddress)
+	return s.mfa.Store.DeleteMFA(ctx, user.ID)
+}
+
+// VerifyMFA finishes a login that Login answered with an MFA token.
+func (s *Service) VerifyMFA(ctx context.Context, mfaToken, code string) (LoginResult, error) {
+	if s == nil {
+		return LoginResult{}, errors.New("auth: service is nil")
+	}
+	if !s.mfa.enabled() {
+		return LoginResult{}, errors.New("auth: two-factor authentication is not configured")
+	}
+	if mfaToken == "" {
+		return LoginResult{}, ErrInvalidMFAToken
+	}
+	tokenHash := hashToken(mfaToken)
+	challenge, err := s.mfa.Challenges.Lookup(ctx, tokenHash)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	// A wallet has the same user ID on every service, so a challenge from
+	// another service's password step must not finish a login here.
+	if challenge.Role != s.mfa.Role {
+		logging.FromContext(ctx).Warn("auth: two-factor challenge of another service", "role", challenge.Role)
+		return LoginResult{}, ErrInvalidMFAToken
+	}
+	walletAddress := challenge.WalletAddress
+	// Wrong codes count as failed logins, so fresh challenges from the
+	// password step do not give unlimited guesses.
+	clientIP := ClientIP(ctx)
+	if s.throttle != nil {
+		if err := s.throttle.Allow(ctx, walletAddress, clientIP); err != nil {
+			logging.FromContext(ctx).Warn("auth: two-factor login throttled", "wallet_address", walletAddress, "client_ip", clientIP, "error", err)
+			return LoginResult{}, err
+		}
+	}
+	user, err := s.store.LoadByWalletAddress(ctx, walletAddress)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	state, err := s.mfa.Store.LoadMFA(ctx, user.ID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if !state.Enabled || !checkMFACode(&state, code) {
+		s.recordFailure(ctx, walletAddress, clientIP)
+		failures, err := s.mfa.Challenges.Fail(ctx, tokenHash)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		if failures >= s.mfa.MaxAttempts {
+			logging.FromContext(ctx).Warn("auth: two-factor challenge dropped after wrong codes", "wallet_address", walletAddress, "failures", failures)
+			if _, err := s.mfa.Challenges.Consume(ctx, tokenHash); err != nil {
+				logging.FromContext(ctx).Error("auth: drop two-factor challenge failed", "error", err)
+			}
+		}
+		return LoginResult{}, ErrInvalidMFACode
+	}
+	// Consume before saving so two requests racing with one code cannot
+	// both get a session.
+	consumed, err := s.mfa.Challenges.Consume(ctx, tokenHash)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if !consumed {
+		return LoginResult{}, ErrInvalidMFAToken
+	}
+	if err := s.mfa.Store.SaveMFA(ctx, state); err != nil {
+		return LoginResult{}, err
+	}
+	s.recordSuccess(ctx, walletAddress, clientIP)
+	return s.issueSession(ctx, user)
+}
+
+// mfaChallenge is what Login returns instead of a session for users with
+// two-factor authentication.
+func (s *Service) mfaChallenge(ctx context.Context, user StoredUser) (LoginResult, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return LoginResult{}, fmt.Errorf("auth: failed to generate two-factor token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	if err := s.mfa.Challenges.Save(ctx, hashToken(token), MFAChallenge{Role: s.mfa.Role, WalletAddress: user.WalletAddress}, s.mfa.ChallengeTTL); err != nil {
+		return LoginResult{}, fmt.Errorf("auth: failed to store two-factor challenge: %w", err)
+	}
+	logging.FromContext(ctx).Info("auth: password accepted, waiting for two-factor code", "wallet_address", user.WalletAddress)
+	return LoginResult{User: user, MFAToken: token, MFAExpiration: time.Now().Add(s.mfa.ChallengeTTL)}, nil
+}
+
+// mfaUser resolves the user behind a session and checks it owns the wallet.
+func (s *Service) mfaUser(ctx context.Context, sessionToken, walletAddress string) (StoredUser, error) {
+	if s == nil {
+		return StoredUser{}, errors.New("auth: service is nil")
+	}
+	if !s.mfa.enabled() {
+		return StoredUser{}, errors.New("auth: two-factor authentication is not configured")
+	}
+	userID, err := s.sessions.Validate(ctx, sessionToken)
+	if err != nil {
+		return StoredUser{}, err
+	}
+	user, err := s.store.LoadByWalletAddress(ctx, walletAddress)
+	if err != nil {
+		return StoredUser{}, err
+	}
+	if user.ID != userID {
+		return StoredUser{}, ErrInvalidSession
+	}
+	return user, nil
+}
+
+// checkMFACode accepts a TOTP code or an unused backup code and updates the
+// state so neither can be used again.
+func checkMFACode(state *MFAState, code string) bool {
+	if step, ok := verifyTOTP(state.Secret, code, time.Now(), state.LastStep); ok {
+		state.LastStep = step
+		return true
+	}
+	codeHash := hashToken(normalizeBackupCode(code))
+	for i, stored := range state.BackupCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(codeHash)) == 1 {
+			state.BackupCodes = append(state.BackupCodes[:i:i], state.BackupCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// newBackupCodes returns codes like "k3m9-x2qa" and their hashes. Each has
+// 40 random bits, so a fast hash is enough, as for reset tokens.
+func newBackupCodes() ([]string, []string, error) {
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("auth: failed to generate backup code: %w", err)
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(raw))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeBackupCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// failScript counts a wrong code only while the challenge exists, so a late
+// attempt does not leave a key without expiry behind.
+var failScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return -1
+end
+return redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+`)
+
+type RedisMFAChallengeStore struct {
+	client *redis.Client
+}
+
+func NewRedisMFAChallengeStore(client *redis.Client) *RedisMFAChallengeStore {
+	return &RedisMFAChallengeStore{client: client}
+}
+
+func (s *RedisMFAChallengeStore) Save(ctx context.Context, tokenHash string, challenge MFAChallenge, ttl time.Duration) error {
+	if s == nil || s.client == nil {
+		return errors.New("auth: redis client is not initialized")
+	}
+	key := mfaChallengeKey(tokenHash)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "role", challenge.Role, "wallet", challenge.WalletAddress, "attempts", 0)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisMFAChallengeStore) Lookup(ctx context.Context, tokenHash string) (MFAChallenge, error) {
+	if s == nil || s.client == nil {
+		return MFAChallenge{}, errors.New("auth: redis client is not initialized")
+	}
+	fields, err := s.client.HMGet(ctx, mfaChallengeKey(tokenHash), "role", "wallet").Result()
+	if err != nil {
+		return MFAChallenge{}, err
+	}
+	role, _ := fields[0].(string)
+	wallet, ok := fields[1].(string)
+	if !ok {
+		return MFAChallenge{}, ErrInvalidMFAToken
+	}
+	return MFAChallenge{Role: role, WalletAddress: wallet}, nil
+}
+
+func (s *RedisMFAChallengeStore) Fail(ctx context.Context, tokenHash string) (int, error) {
+	if s == nil || s.client == nil {
+		return 0, errors.New("auth: redis client is not initialized")
+	}
+	failures, err := failScript.Run(ctx, s.client, []string{mfaChallengeKey(tokenHash)}).Int()
+	if err != nil {
+		return 0, err
+	}
+	if failures < 0 {
+		return 0, ErrInvalidMFAToken
+	}
+	return failures, nil
+}
+
+func (s *RedisMFAChallengeStore) Consume(ctx context.Context, tokenHash string) (bool, error) {
+	if s == nil || s.client == nil {
+		return false, errors.New("auth: redis client is not initialized")
+	}
+	deleted, err := s.client.Del(ctx, mfaChallengeKey(tokenHash)).Result()
+	if err != nil {
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+func mfaChallengeKey(tokenHash string) string {
+	return "mfa_challenge:" + tokenHash
+}
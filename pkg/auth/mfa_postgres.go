@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// PostgresMFAStore keeps enrollments in a per-service table (RESTAURANT_MFA,
+// COURIER_MFA), since each service has its own user IDs. The TOTP secret
+// is stored as-is because every login has to compute codes from it.
+type PostgresMFAStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPostgresMFAStore uses table, which comes from code, never from input.
+func NewPostgresMFAStore(db *sql.DB, table string) *PostgresMFAStore {
+	return &PostgresMFAStore{db: db, table: table}
+}
+
+func (s *PostgresMFAStore) LoadMFA(ctx context.Context, userID uuid.UUID) (MFAState, error) {
+	if s.db == nil {
+		return MFAState{}, errors.New("auth: mfa store not initialized")
+	}
+	state := MFAState{UserID: userID}
+	var backupCodes string
+	err := s.db.QueryRowContext(ctx, `SELECT secret, enabled, backup_codes, last_step FROM `+s.table+` WHERE user_id = $1`, userID).
+		Scan(&state.Secret, &state.Enabled, &backupCodes, &state.LastStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return MFAState{}, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return MFAState{}, err
+	}
+	if backupCodes != "" {
+		state.BackupCodes = strings.Split(backupCodes, ",")
+	}
+	return state, nil
+}
+
+func (s *PostgresMFAStore) SaveMFA(ctx context.Context, state MFAState) error {
+	if s.db == nil {
+		return errors.New("auth: mfa store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO `+s.table+` (user_id, secret, enabled, backup_codes, last_step, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, enabled = EXCLUDED.enabled, backup_codes = EXCLUDED.backup_codes,
+		    last_step = EXCLUDED.last_step, updated_at = NOW()`,
+		state.UserID, state.Secret, state.Enabled, strings.Join(state.BackupCodes, ","), state.LastStep)
+	return err
+}
+
+func (s *PostgresMFAStore) DeleteMFA(ctx context.Context, userID uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("auth: mfa store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM `+s.table+` WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryMFAStore struct {
+	states map[uuid.UUID]MFAState
+}
+
+func (m *memoryMFAStore) LoadMFA(ctx context.Context, userID uuid.UUID) (MFAState, error) {
+	state, ok := m.states[userID]
+	if !ok {
+		return MFAState{}, ErrMFANotEnrolled
+	}
+	state.BackupCodes = append([]string(nil), state.BackupCodes...)
+	return state, nil
+}
+
+func (m *memoryMFAStore) SaveMFA(ctx context.Context, state MFAState) error {
+	m.states[state.UserID] = state
+	return nil
+}
+
+func (m *memoryMFAStore) DeleteMFA(ctx context.Context, userID uuid.UUID) error {
+	delete(m.states, userID)
+	return nil
+}
+
+type mfaChallenge struct {
+	MFAChallenge
+	failures int
+}
+
+type memoryMFAChallenges struct {
+	challenges map[string]*mfaChallenge
+}
+
+func (m *memoryMFAChallenges) Save(ctx context.Context, tokenHash string, challenge MFAChallenge, ttl time.Duration) error {
+	m.challenges[tokenHash] = &mfaChallenge{MFAChallenge: challenge}
+	return nil
+}
+
+func (m *memoryMFAChallenges) Lookup(ctx context.Context, tokenHash string) (MFAChallenge, error) {
+	c, ok := m.challenges[tokenHash]
+	if !ok {
+		return MFAChallenge{}, ErrInvalidMFAToken
+	}
+	return c.MFAChallenge, nil
+}
+
+func (m *memoryMFAChallenges) Fail(ctx context.Context, tokenHash string) (int, error) {
+	c, ok := m.challenges[tokenHash]
+	if !ok {
+		return 0, ErrInvalidMFAToken
+	}
+	c.failures++
+	return c.failures, nil
+}
+
+func (m *memoryMFAChallenges) Consume(ctx context.Context, tokenHash string) (bool, error) {
+	_, ok := m.challenges[tokenHash]
+	delete(m.challenges, tokenHash)
+	return ok, nil
+}
+
+func TestMFALogin(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	store := &mockStore{users: map[string]StoredUser{
+		"0xabc": {ID: userID, WalletAddress: "0xabc", PasswordHash: "hashed-Secret-pass-1"},
+	}}
+	mfaStore := &memoryMFAStore{states: map[uuid.UUID]MFAState{}}
+	challenges := &memoryMFAChallenges{challenges: map[string]*mfaChallenge{}}
+	service, err := NewService(ServiceConfig{
+		Store:    store,
+		Hasher:   &mockHasher{},
+		Sessions: &mockSessions{},
+		MFA: MFAConfig{
+			Store:      mfaStore,
+			Challenges: challenges,
+			Issuer:     "YAFDS Restaurant",
+			Role:       "restaurant",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := "token-" + userID.String()
+
+	// Without enrollment Login goes straight to a session.
+	res, err := service.Login(ctx, "0xabc", "Secret-pass-1")
+	if err != nil || res.Token == "" || res.MFAToken != "" {
+		t.Fatalf("Login() before enrollment = %+v, %v", res, err)
+	}
+
+	if _, err := service.EnrollMFA(ctx, "token-"+uuid.NewString(), "0xabc"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("EnrollMFA() with another user's session error = %v", err)
+	}
+	if _, err := service.EnrollMFA(ctx, session, "0xabc"); err != nil {
+		t.Fatal(err)
+	}
+	// Enrollment is pending until confirmed.
+	if res, _ := service.Login(ctx, "0xabc", "Secret-pass-1"); res.MFAToken != "" {
+		t.Error("Login() asked for a code before enrollment was confirmed")
+	}
+	secret := mfaStore.states[userID].Secret
+	if _, err := service.ConfirmMFA(ctx, session, "0xabc", "000000"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("ConfirmMFA() with a bad code error = %v", err)
+	}
+	// Confirm with the previous step's code so the current one stays usable.
+	now := totpStep(time.Now())
+	backupCodes, err := service.ConfirmMFA(ctx, session, "0xabc", totpCode(secret, now-1))
+	if err != nil || len(backupCodes) != backupCodeCount {
+		t.Fatalf("ConfirmMFA() = %v, %v", backupCodes, err)
+	}
+
+	res, err = service.Login(ctx, "0xabc", "Secret-pass-1")
+	if err != nil || res.MFAToken == "" || res.Token != "" {
+		t.Fatalf("Login() with MFA = %+v, %v", res, err)
+	}
+	// Another service sharing the challenge store, where the wallet has
+	// the same user ID, cannot finish the restaurant's login.
+	courier, _ := NewService(ServiceConfig{
+		Store:    store,
+		Hasher:   &mockHasher{},
+		Sessions: &mockSessions{},
+		MFA:      MFAConfig{Store: mfaStore, Challenges: challenges, Role: "courier"},
+	})
+	if _, err := courier.VerifyMFA(ctx, res.MFAToken, totpCode(secret, now)); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Errorf("VerifyMFA() on another service error = %v, want ErrInvalidMFAToken", err)
+	}
+	if _, err := service.VerifyMFA(ctx, res.MFAToken, totpCode(secret, now-1)); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("VerifyMFA() with a used code error = %v", err)
+	}
+	final, err := service.VerifyMFA(ctx, res.MFAToken, totpCode(secret, now))
+	if err != nil || final.Token == "" {
+		t.Fatalf("VerifyMFA() = %+v, %v", final, err)
+	}
+	if _, err := service.VerifyMFA(ctx, res.MFAToken, totpCode(secret, now+1)); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Errorf("reused MFA token error = %v", err)
+	}
+
+	// A backup code works once.
+	res, _ = service.Login(ctx, "0xabc", "Secret-pass-1")
+	if _, err := service.VerifyMFA(ctx, res.MFAToken, backupCodes[0]); err != nil {
+		t.Fatalf("VerifyMFA() with backup code error = %v", err)
+	}
+	res, _ = service.Login(ctx, "0xabc", "Secret-pass-1")
+	if _, err := service.VerifyMFA(ctx, res.MFAToken, backupCodes[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("reused backup code error = %v", err)
+	}
+
+	// Too many wrong codes drop the challenge.
+	for i := 1; i < DefaultMFAMaxAttempts; i++ {
+		service.VerifyMFA(ctx, res.MFAToken, "bad")
+	}
+	if _, err := service.VerifyMFA(ctx, res.MFAToken, backupCodes[1]); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Errorf("VerifyMFA() after too many failures error = %v", err)
+	}
+
+	if err := service.DisableMFA(ctx, session, "0xabc", "bad"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("DisableMFA() with a bad code error = %v", err)
+	}
+	if err := service.DisableMFA(ctx, session, "0xabc", backupCodes[2]); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := service.Login(ctx, "0xabc", "Secret-pass-1"); res.Token == "" {
+		t.Error("Login() still asks for a code after DisableMFA")
+	}
+}
+
+func TestMFAThrottle(t *testing.T) {
+	ctx := WithClientIP(context.Background(), "203.0.113.7")
+	userID := uuid.New()
+	store := &mockStore{users: map[string]StoredUser{
+		"0xabc": {ID: userID, WalletAddress: "0xabc", PasswordHash: "hashed-Secret-pass-1"},
+	}}
+	mfaStore := &memoryMFAStore{states: map[uuid.UUID]MFAState{}}
+	throttle := &fakeThrottle{lockAt: 3, failures: map[string]int{}}
+	service, err := NewService(ServiceConfig{
+		Store:    store,
+		Hasher:   &mockHasher{},
+		Sessions: &mockSessions{},
+		Throttle: throttle,
+		MFA: MFAConfig{
+			Store:      mfaStore,
+			Challenges: &memoryMFAChallenges{challenges: map[string]*mfaChallenge{}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := "token-" + userID.String()
+	if _, err := service.EnrollMFA(ctx, session, "0xabc"); err != nil {
+		t.Fatal(err)
+	}
+	secret := mfaStore.states[userID].Secret
+	now := totpStep(time.Now())
+	if _, err := service.ConfirmMFA(ctx, session, "0xabc", totpCode(secret, now-1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The password step does not clear the failures of wrong codes.
+	for i := 0; i < 2; i++ {
+		res, err := service.Login(ctx, "0xabc", "Secret-pass-1")
+		if err != nil || res.MFAToken == "" {
+			t.Fatalf("Login() = %+v, %v", res, err)
+		}
+		if _, err := service.VerifyMFA(ctx, res.MFAToken, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("VerifyMFA() with a bad code error = %v", err)
+		}
+	}
+	if throttle.successes != 0 || throttle.failures["0xabc"] != 2 {
+		t.Fatalf("throttle successes=%d failures=%d, want 0 and 2", throttle.successes, throttle.failures["0xabc"])
+	}
+
+	// A good code clears them.
+	res, _ := service.Login(ctx, "0xabc", "Secret-pass-1")
+	if _, err := service.VerifyMFA(ctx, res.MFAToken, totpCode(secret, now)); err != nil {
+		t.Fatalf("VerifyMFA() error = %v", err)
+	}
+	if throttle.successes != 1 || throttle.failures["0xabc"] != 0 {
+		t.Fatalf("throttle successes=%d failures=%d after a good code", throttle.successes, throttle.failures["0xabc"])
+	}
+
+	// Once locked, even a right code is refused.
+	res, _ = service.Login(ctx, "0xabc", "Secret-pass-1")
+	throttle.failures["0xabc"] = throttle.lockAt
+	if _, err := service.VerifyMFA(ctx, res.MFAToken, totpCode(secret, now+1)); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("VerifyMFA() while locked error = %v, want ErrTooManyAttempts", err)
+	}
+}
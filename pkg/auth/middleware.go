@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// Principal is who made an authenticated request: a user through a
+// session, or a user's integration through one of their API keys.
+type Principal struct {
+	UserID uuid.UUID
+	// APIKey is nil for sessions.
+	APIKey *APIKey
+}
+
+// HasScope reports whether the principal may use scope. Sessions act as
+// the user and have every scope.
+func (p Principal) HasScope(scope string) bool {
+	return p.APIKey == nil || p.APIKey.HasScope(scope)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// ActsFor reports whether the request's principal is ownerID, so handlers
+// can check the restaurant in the body or query against the credentials.
+func ActsFor(ctx context.Context, ownerID uuid.UUID) bool {
+	p, ok := PrincipalFrom(ctx)
+	return ok && p.UserID == ownerID
+}
+
+// Authenticator accepts session tokens and API keys as bearer tokens;
+// API keys may also come in X-API-Key.
+type Authenticator struct {
+	sessions SessionManager
+	keys     *APIKeys
+}
+
+// NewAuthenticator accepts only sessions when keys is nil.
+func NewAuthenticator(sessions SessionManager, keys *APIKeys) *Authenticator {
+	return &Authenticator{sessions: sessions, keys: keys}
+}
+
+// Authenticate resolves the principal of a request.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	token := BearerToken(r)
+	if token == "" {
+		token = strings.TrimSpace(r.Header.Get("X-API-Key"))
+	}
+	if token == "" {
+		return Principal{}, ErrInvalidSession
+	}
+	if IsAPIKey(token) {
+		if a.keys == nil {
+			return Principal{}, ErrInvalidAPIKey
+		}
+		key, err := a.keys.Authenticate(r.Context(), token)
+		if err != nil {
+			return Principal{}, err
+		}
+		return Principal{UserID: key.OwnerID, APIKey: &key}, nil
+	}
+	userID, err := a.sessions.Validate(r.Context(), token)
+	if err != nil {
+		return Principal{}, err
+	}
+	return Principal{UserID: userID}, nil
+}
+
+// Require lets a request through only with a session or an API key that
+// has scope. Preflight requests pass so the handler can answer CORS.
+func (a *Authenticator) Require(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return a.require(scope, false, next)
+}
+
+// RequireForWrites is Require for every method except GET and HEAD, for
+// endpoints whose reads are public.
+func (a *Authenticator) RequireForWrites(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+		a.require(scope, false, next)(w, r)
+	}
+}
+
+// RequireSession lets only sessions through, for actions an integration
+// must not take on its own, such as minting more API keys.
+func (a *Authenticator) RequireSession(next http.HandlerFunc) http.HandlerFunc {
+	return a.require("", true, next)
+}
+
+func (a *Authenticator) require(scope string, sessionOnly bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		principal, err := a.Authenticate(r)
+		if err != nil {
+			writeAuthError(w, err)
+			return
+		}
+		if sessionOnly && principal.APIKey != nil {
+			utils.WriteError(w, "this endpoint needs a session, not an api key", http.StatusForbidden)
+			return
+		}
+		if scope != "" && !principal.HasScope(scope) {
+			utils.WriteError(w, "api key lacks scope "+scope, http.StatusForbidden)
+			return
+		}
+		next(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	}
+}
+
+// ServiceTokenHeader carries the shared secret of calls between services.
+const ServiceTokenHeader = "X-Service-Token"
+
+// RequireServiceToken lets a request through only with token in
+// ServiceTokenHeader. An empty token turns the endpoint off, so a service
+// started without SERVICE_TOKEN never leaves it open.
+func RequireServiceToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			utils.WriteError(w, "service calls are not configured", http.StatusServiceUnavailable)
+			return
+		}
+		got := r.Header.Get(ServiceTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			utils.WriteError(w, "missing or invalid service token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeAuthError(w http.ResponseWriter, err error) {
+	if retryAfter, ok := RetryAfter(err); ok {
+		w.Header().Set("Retry-After", RetryAfterSeconds(retryAfter))
+		utils.WriteError(w, "rate limit exceeded, try again later", http.StatusTooManyRequests)
+		return
+	}
+	switch {
+	case errors.Is(err, ErrInvalidAPIKey):
+		utils.WriteError(w, "invalid, expired or revoked api key", http.StatusUnauthorized)
+	case errors.Is(err, ErrInvalidSession):
+		utils.WriteError(w, "missing, invalid or expired session", http.StatusUnauthorized)
+	default:
+		slog.Error("auth: authenticate request failed", "error", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrPasswordPolicy = errors.New("auth: password does not meet policy")
+
+// Field-level codes the front-end maps to its own messages.
+const (
+	CodePasswordTooShort       = "PASSWORD_TOO_SHORT"
+	CodePasswordTooLong        = "PASSWORD_TOO_LONG"
+	CodePasswordMissingUpper   = "PASSWORD_MISSING_UPPERCASE"
+	CodePasswordMissingLower   = "PASSWORD_MISSING_LOWERCASE"
+	CodePasswordMissingDigit   = "PASSWORD_MISSING_DIGIT"
+	CodePasswordMissingSymbol  = "PASSWORD_MISSING_SYMBOL"
+	CodePasswordContainsWallet = "PASSWORD_CONTAINS_WALLET_ADDRESS"
+	CodePasswordContainsName   = "PASSWORD_CONTAINS_NAME"
+	CodePasswordBreached       = "PASSWORD_BREACHED"
+)
+
+type FieldError struct {
+	Field   string         `json:"field"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+// ValidationError lists every rule the input broke, not just the first. It
+// encodes like models.ErrorResponce plus a fields array and matches
+// ErrPasswordPolicy with errors.Is.
+type ValidationError struct {
+	ErrorMessage string       `json:"error_message"`
+	Fields       []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	codes := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		codes = append(codes, f.Code)
+	}
+	return fmt.Sprintf("%s: %s", ErrPasswordPolicy, strings.Join(codes, ", "))
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrPasswordPolicy }
+
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MinNameToken is the shortest part of the user's name that counts as
+	// "contained"; shorter parts like initials are ignored.
+	MinNameToken int
+	Breached     *BreachedPasswords
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    10,
+	MaxLength:    128,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	MinNameToken: 3,
+}
+
+// NewPolicyValidator returns a Validator enforcing policy on registration.
+func NewPolicyValidator(policy PasswordPolicy) Validator {
+	return func(ctx context.Context, data RegisterInput) error {
+		return policy.Check(data.Password, data.WalletAddress, data.Name)
+	}
+}
+
+// Check returns a *ValidationError listing each broken rule, or nil.
+func (p PasswordPolicy) Check(password, walletAddress, name string) error {
+	var fields []FieldError
+	fail := func(code, message string, params map[string]any) {
+		fields = append(fields, FieldError{Field: "password", Code: code, Message: message, Params: params})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		fail(CodePasswordTooShort, fmt.Sprintf("must be at least %d characters", p.MinLength), map[string]any{"min": p.MinLength})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		fail(CodePasswordTooLong, fmt.Sprintf("must be at most %d characters", p.MaxLength), map[string]any{"max": p.MaxLength})
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		fail(CodePasswordMissingUpper, "must contain an uppercase letter", nil)
+	}
+	if p.RequireLower && !lower {
+		fail(CodePasswordMissingLower, "must contain a lowercase letter", nil)
+	}
+	if p.RequireDigit && !digit {
+		fail(CodePasswordMissingDigit, "must contain a digit", nil)
+	}
+	if p.RequireSymbol && !symbol {
+		fail(CodePasswordMissingSymbol, "must contain a symbol", nil)
+	}
+
+	folded := strings.ToLower(password)
+	if wallet := strings.ToLower(strings.TrimSpace(walletAddress)); wallet != "" {
+		if strings.Contains(folded, wallet) || strings.Contains(folded, strings.TrimPrefix(wallet, "0x")) {
+			fail(CodePasswordContainsWallet, "must not contain your wallet address", nil)
+		}
+	}
+	for _, token := range strings.Fields(strings.ToLower(name)) {
+		if utf8.RuneCountInString(token) >= p.MinNameToken && strings.Contains(folded, token) {
+			fail(CodePasswordContainsName, "must not contain your name", nil)
+			break
+		}
+	}
+
+	if p.Breached.Contains(password) {
+		fail(CodePasswordBreached, "appears in a list of leaked passwords", nil)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{ErrorMessage: "password does not meet policy", Fields: fields}
+}
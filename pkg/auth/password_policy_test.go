@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func codes(err error) []string {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	out := make([]string, 0, len(verr.Fields))
+	for _, f := range verr.Fields {
+		out = append(out, f.Code)
+	}
+	return out
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := DefaultPasswordPolicy
+	policy.Breached = NewBreachedPasswords("Password123")
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "valid", password: "Correct-Horse7"},
+		{name: "short and no digit", password: "Abcdef", want: []string{CodePasswordTooShort, CodePasswordMissingDigit}},
+		{name: "lowercase only", password: "abcdefghijkl1", want: []string{CodePasswordMissingUpper}},
+		{name: "contains wallet", password: "My0xABC123wallet", want: []string{CodePasswordContainsWallet}},
+		{name: "contains wallet without prefix", password: "Myabc123wallet", want: []string{CodePasswordContainsWallet}},
+		{name: "contains name", password: "IamGrace2026", want: []string{CodePasswordContainsName}},
+		{name: "breached", password: "Password123", want: []string{CodePasswordBreached}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password, "0xabc123", "Grace Li")
+			got := codes(err)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("Check() codes = %v, want %v (err %v)", got, tt.want, err)
+			}
+			if len(tt.want) > 0 && !errors.Is(err, ErrPasswordPolicy) {
+				t.Errorf("error %v does not match ErrPasswordPolicy", err)
+			}
+		})
+	}
+}
+
+func TestPolicyValidatorOnRegister(t *testing.T) {
+	validate := NewPolicyValidator(DefaultPasswordPolicy)
+	err := validate(context.Background(), RegisterInput{WalletAddress: "0x1", Name: "Bo", Password: "short"})
+	if !errors.Is(err, ErrPasswordPolicy) {
+		t.Fatalf("validator error = %v, want ErrPasswordPolicy", err)
+	}
+}
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// "qwerty" as a plain line, "letmein" as an HIBP-style SHA-1 line.
+	content := "# leaked\nqwerty\n\nB7A875FC1EA228B9061041B7CEC4BD3C52AB3CE3:4012\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadBreachedPasswords(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedPasswords() error = %v", err)
+	}
+	if list.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", list.Len())
+	}
+	for _, password := range []string{"qwerty", "letmein"} {
+		if !list.Contains(password) {
+			t.Errorf("Contains(%q) = false", password)
+		}
+	}
+	if list.Contains("Correct-Horse7") {
+		t.Error("Contains() reported an unlisted password")
+	}
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	const n = 10000
+	filter := NewBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		filter.Add([]byte(fmt.Sprintf("in-%d", i)))
+	}
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if !filter.Test([]byte(fmt.Sprintf("in-%d", i))) {
+			t.Fatalf("added key in-%d missing", i)
+		}
+		if filter.Test([]byte(fmt.Sprintf("out-%d", i))) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / n; rate > 0.02 {
+		t.Errorf("false positive rate = %.4f, want about 0.01", rate)
+	}
+}
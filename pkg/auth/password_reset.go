@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const DefaultResetTTL = 30 * time.Minute
+
+// ResetLimits caps password reset requests per wallet address and per
+// client IP within Window, so the endpoint cannot flood a user's inbox or
+// be sprayed across many wallets.
+type ResetLimits struct {
+	Window    time.Duration
+	PerWallet int
+	PerIP     int
+}
+
+var DefaultResetLimits = ResetLimits{Window: time.Hour, PerWallet: 3, PerIP: 20}
+
+func (l ResetLimits) withDefaults() ResetLimits {
+	if l.Window <= 0 {
+		l.Window = DefaultResetLimits.Window
+	}
+	if l.PerWallet <= 0 {
+		l.PerWallet = DefaultResetLimits.PerWallet
+	}
+	if l.PerIP <= 0 {
+		l.PerIP = DefaultResetLimits.PerIP
+	}
+	return l
+}
+
+// ResetTokenStore keeps reset tokens by their SHA-256 hash, so a leaked
+// store does not leak usable tokens.
+type ResetTokenStore interface {
+	Save(ctx context.Context, tokenHash string, walletAddress string, ttl time.Duration) error
+	// Lookup returns the wallet address of a live token without using it up.
+	Lookup(ctx context.Context, tokenHash string) (string, error)
+	// Consume deletes the token and reports whether it was still live; only
+	// one caller can consume a given token.
+	Consume(ctx context.Context, tokenHash string) (bool, error)
+}
+
+// ResetMessage is what a ResetSender delivers to the user's recovery email.
+type ResetMessage struct {
+	User      StoredUser
+	To        string
+	Token     string
+	ExpiresAt time.Time
+}
+
+type ResetSender interface {
+	SendReset(ctx context.Context, msg ResetMessage) error
+}
+
+// ResetSenderFunc adapts a function to ResetSender.
+type ResetSenderFunc func(ctx context.Context, msg ResetMessage) error
+
+func (f ResetSenderFunc) SendReset(ctx context.Context, msg ResetMessage) error { return f(ctx, msg) }
+
+// RedisResetTokenStore keeps the tokens of one service under its own key
+// prefix, since the services share Redis but not their users: a token
+// issued by the customer service must not reset a restaurant's password.
+type RedisResetTokenStore struct {
+	client  *redis.Client
+	service string
+}
+
+// NewRedisResetTokenStore scopes tokens to service, e.g. "customer".
+func NewRedisResetTokenStore(client *redis.Client, service string) *RedisResetTokenStore {
+	return &RedisResetTokenStore{client: client, service: service}
+}
+
+func (s *RedisResetTokenStore) Save(ctx context.Context, tokenHash string, walletAddress string, ttl time.Duration) error {
+	if s == nil || s.client == nil {
+		return errors.New("auth: redis client is not initialized")
+	}
+	return s.client.Set(ctx, resetKey(s.service, tokenHash), walletAddress, ttl).Err()
+}
+
+func (s *RedisResetTokenStore) Lookup(ctx context.Context, tokenHash string) (string, error) {
+	if s == nil || s.client == nil {
+		return "", errors.New("auth: redis client is not initialized")
+	}
+	wallet, err := s.client.Get(ctx, resetKey(s.service, tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrInvalidResetToken
+	}
+	return wallet, err
+}
+
+func (s *RedisResetTokenStore) Consume(ctx context.Context, tokenHash string) (bool, error) {
+	if s == nil || s.client == nil {
+		return false, errors.New("auth: redis client is not initialized")
+	}
+	deleted, err := s.client.Del(ctx, resetKey(s.service, tokenHash)).Result()
+	if err != nil {
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+func resetKey(service, tokenHash string) string {
+	return "password_reset:" + service + ":" + tokenHash
+}
+
+// hashToken is how reset and refresh tokens are stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChangePassword replaces the password of the session's user after checking
+// the old one, then ends all of the user's sessions.
+func (s *Service) ChangePassword(ctx context.Context, sessionToken, walletAddress, oldPassword, newPassword string) error {
+	logging.FromContext(ctx).Info("auth: password change", "wallet_address", walletAddress)
+	if s == nil {
+		return errors.New("auth: service is nil")
+	}
+	userID, err := s.sessions.Validate(ctx, sessionToken)
+	if err != nil {
+		return err
+	}
+	clientIP := ClientIP(ctx)
+	if s.throttle != nil {
+		if err := s.throttle.Allow(ctx, walletAddress, clientIP); err != nil {
+			return err
+		}
+	}
+	user, err := s.store.LoadByWalletAddress(ctx, walletAddress)
+	if err != nil {
+		return err
+	}
+	if user.ID != userID {
+		return ErrInvalidSession
+	}
+	if !s.hasher.Verify(oldPassword, user.PasswordSalt, user.PasswordHash) {
+		s.recordFailure(ctx, walletAddress, clientIP)
+		return ErrInvalidCredentials
+	}
+	if err := s.checkNewPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// RequestPasswordReset issues a reset token for the wallet and hands it to
+// the sender for the user's confirmed recovery email. Unknown wallets and
+// users without a recovery email succeed silently so the endpoint cannot
+// be used to find registered addresses; all count against the rate limits.
+func (s *Service) RequestPasswordReset(ctx context.Context, walletAddress string) error {
+	logging.FromContext(ctx).Info("auth: password reset requested", "wallet_address", walletAddress)
+	if s == nil {
+		return errors.New("auth: service is nil")
+	}
+	if s.resetTokens == nil || s.resetSender == nil {
+		return ErrPasswordResetDisabled
+	}
+	if strings.TrimSpace(walletAddress) == "" {
+		return errors.New("auth: wallet address is required")
+	}
+	if err := s.allowReset(ctx, walletAddress); err != nil {
+		return err
+	}
+	user, err := s.store.LoadByWalletAddress(ctx, walletAddress)
+	if err != nil {
+		logging.FromContext(ctx).Info("auth: password reset for unknown wallet address", "wallet_address", walletAddress, "error", err)
+		return nil
+	}
+	if user.RecoveryEmail == "" {
+		logging.FromContext(ctx).Warn("auth: password reset for user without a recovery email", "wallet_address", walletAddress)
+		return nil
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return fmt.Errorf("auth: failed to generate reset token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	expiresAt := time.Now().Add(s.resetTTL)
+	if err := s.resetTokens.Save(ctx, hashToken(token), user.WalletAddress, s.resetTTL); err != nil {
+		return fmt.Errorf("auth: failed to store reset token: %w", err)
+	}
+	return s.resetSender.SendReset(ctx, ResetMessage{User: user, To: user.RecoveryEmail, Token: token, ExpiresAt: expiresAt})
+}
+
+// ResetPassword sets a new password using a reset token. The token is only
+// used up once the new password passes validation, so a rejected password
+// can be retried with the same token.
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if s == nil {
+		return errors.New("auth: service is nil")
+	}
+	if s.resetTokens == nil {
+		return ErrPasswordResetDisabled
+	}
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	tokenHash := hashToken(token)
+	walletAddress, err := s.resetTokens.Lookup(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	user, err := s.store.LoadByWalletAddress(ctx, walletAddress)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNewPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	consumed, err := s.resetTokens.Consume(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+	logging.FromContext(ctx).Info("auth: password reset", "wallet_address", walletAddress)
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// allowReset applies the per-wallet and per-IP reset limits. Requests
+// without a client IP are limited by wallet only.
+func (s *Service) allowReset(ctx context.Context, walletAddress string) error {
+	if s.resetLimiter == nil {
+		return nil
+	}
+	limits := s.resetLimits
+	wallet := strings.ToLower(strings.TrimSpace(walletAddress))
+	if err := s.resetLimiter.Allow(ctx, "reset:wallet:"+wallet, limits.PerWallet, limits.Window); err != nil {
+		return err
+	}
+	if clientIP := ClientIP(ctx); clientIP != "" {
+		return s.resetLimiter.Allow(ctx, "reset:ip:"+clientIP, limits.PerIP, limits.Window)
+	}
+	return nil
+}
+
+func (s *Service) checkNewPassword(ctx context.Context, user StoredUser, newPassword string) error {
+	if strings.TrimSpace(newPassword) == "" {
+		return errors.New("auth: password is required")
+	}
+	return s.validator(ctx, RegisterInput{
+		ID:            user.ID,
+		Name:          user.Name,
+		WalletAddress: user.WalletAddress,
+		Password:      newPassword,
+		Fields:        user.Fields,
+	})
+}
+
+// setPassword stores an already validated password and ends all sessions.
+func (s *Service) setPassword(ctx context.Context, user StoredUser, newPassword string) error {
+	passwordHash, passwordSalt, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdatePassword(ctx, user.ID, passwordHash, passwordSalt); err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeAll(ctx, user.ID); err != nil {
+		// The password did change; report the revocation failure so the
+		// caller knows old sessions may still work.
+		return fmt.Errorf("auth: password updated but sessions not revoked: %w", err)
+	}
+	if s.throttle != nil {
+		_ = s.throttle.Success(ctx, user.WalletAddress, ClientIP(ctx))
+	}
+	logging.FromContext(ctx).Info("auth: password updated and sessions revoked", "wallet_address", user.WalletAddress)
+	return nil
+}
+
+// BearerToken reads the session token from "Authorization: Bearer <token>".
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryResetTokens struct {
+	tokens map[string]string
+}
+
+func (m *memoryResetTokens) Save(ctx context.Context, tokenHash string, walletAddress string, ttl time.Duration) error {
+	m.tokens[tokenHash] = walletAddress
+	return nil
+}
+
+func (m *memoryResetTokens) Lookup(ctx context.Context, tokenHash string) (string, error) {
+	wallet, ok := m.tokens[tokenHash]
+	if !ok {
+		return "", ErrInvalidResetToken
+	}
+	return wallet, nil
+}
+
+func (m *memoryResetTokens) Consume(ctx context.Context, tokenHash string) (bool, error) {
+	_, ok := m.tokens[tokenHash]
+	delete(m.tokens, tokenHash)
+	return ok, nil
+}
+
+func newPasswordTestService(t *testing.T) (*Service, *mockStore, *mockSessions, *memoryResetTokens, *[]ResetMessage) {
+	t.Helper()
+	store := &mockStore{users: map[string]StoredUser{
+		"0xabc":    {ID: uuid.New(), Name: "Grace", WalletAddress: "0xabc", PasswordHash: "hashed-Old-pass-123", RecoveryEmail: "grace@example.com"},
+		"0xnomail": {ID: uuid.New(), Name: "Ada", WalletAddress: "0xnomail", PasswordHash: "hashed-Old-pass-123"},
+	}}
+	sessions := &mockSessions{}
+	tokens := &memoryResetTokens{tokens: map[string]string{}}
+	var sent []ResetMessage
+	service, err := NewService(ServiceConfig{
+		Store:       store,
+		Hasher:      &mockHasher{},
+		Sessions:    sessions,
+		Validator:   NewPolicyValidator(DefaultPasswordPolicy),
+		ResetTokens: tokens,
+		ResetSender: ResetSenderFunc(func(ctx context.Context, msg ResetMessage) error {
+			sent = append(sent, msg)
+			return nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service, store, sessions, tokens, &sent
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	service, store, sessions, _, _ := newPasswordTestService(t)
+	user := store.users["0xabc"]
+	token := "token-" + user.ID.String()
+
+	if err := service.ChangePassword(ctx, "token-"+uuid.NewString(), "0xabc", "Old-pass-123", "New-pass-456"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("foreign session error = %v, want ErrInvalidSession", err)
+	}
+	if err := service.ChangePassword(ctx, token, "0xabc", "wrong", "New-pass-456"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong old password error = %v, want ErrInvalidCredentials", err)
+	}
+	if err := service.ChangePassword(ctx, token, "0xabc", "Old-pass-123", "weak"); !errors.Is(err, ErrPasswordPolicy) {
+		t.Errorf("weak new password error = %v, want ErrPasswordPolicy", err)
+	}
+	if err := service.ChangePassword(ctx, token, "0xabc", "Old-pass-123", "New-pass-456"); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	if store.users["0xabc"].PasswordHash != "hashed-New-pass-456" {
+		t.Error("password not updated")
+	}
+	if !sessions.revoked[user.ID] {
+		t.Error("sessions not revoked")
+	}
+	if _, err := sessions.Validate(ctx, token); !errors.Is(err, ErrInvalidSession) {
+		t.Error("old session still valid")
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	service, store, sessions, tokens, sent := newPasswordTestService(t)
+
+	if err := service.RequestPasswordReset(ctx, "0xnobody"); err != nil || len(*sent) != 0 {
+		t.Fatalf("unknown wallet: err %v, sent %d", err, len(*sent))
+	}
+	// Without a confirmed recovery email there is nowhere to send a token.
+	if err := service.RequestPasswordReset(ctx, "0xnomail"); err != nil || len(*sent) != 0 || len(tokens.tokens) != 0 {
+		t.Fatalf("user without recovery email: err %v, sent %d, stored %d", err, len(*sent), len(tokens.tokens))
+	}
+	if err := service.RequestPasswordReset(ctx, "0xabc"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	if len(*sent) != 1 || (*sent)[0].To != "grace@example.com" {
+		t.Fatalf("sent %+v, want one message to the recovery email", *sent)
+	}
+	token := (*sent)[0].Token
+	if _, stored := tokens.tokens[token]; stored {
+		t.Error("reset token stored in plain text")
+	}
+
+	// A rejected password leaves the token usable.
+	if err := service.ResetPassword(ctx, token, "weak"); !errors.Is(err, ErrPasswordPolicy) {
+		t.Fatalf("weak password error = %v", err)
+	}
+	if err := service.ResetPassword(ctx, token, "Reset-pass-789"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if store.users["0xabc"].PasswordHash != "hashed-Reset-pass-789" || !sessions.revoked[store.users["0xabc"].ID] {
+		t.Error("reset did not update the password and revoke sessions")
+	}
+	if err := service.ResetPassword(ctx, token, "Another-pass-1"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("reused token error = %v, want ErrInvalidResetToken", err)
+	}
+}
+
+func TestPasswordResetDisabled(t *testing.T) {
+	ctx := context.Background()
+	service, err := NewService(ServiceConfig{
+		Store:       &mockStore{users: map[string]StoredUser{}},
+		Sessions:    &mockSessions{},
+		ResetTokens: &memoryResetTokens{tokens: map[string]string{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RequestPasswordReset(ctx, "0xabc"); !errors.Is(err, ErrPasswordResetDisabled) {
+		t.Errorf("RequestPasswordReset() without a sender error = %v, want ErrPasswordResetDisabled", err)
+	}
+}
+
+func TestPasswordResetRateLimit(t *testing.T) {
+	service, _, _, _, sent := newPasswordTestService(t)
+	service.resetLimiter = &countingLimiter{counts: map[string]int{}}
+	service.resetLimits = ResetLimits{Window: time.Hour, PerWallet: 2, PerIP: 3}
+	ctx := WithClientIP(context.Background(), "203.0.113.7")
+
+	for i := 0; i < 2; i++ {
+		if err := service.RequestPasswordReset(ctx, "0xabc"); err != nil {
+			t.Fatalf("request %d error = %v", i, err)
+		}
+	}
+	// Case does not get around the wallet limit.
+	if _, ok := RetryAfter(service.RequestPasswordReset(ctx, "0xABC")); !ok {
+		t.Fatal("third request for the wallet was not limited")
+	}
+	if len(*sent) != 2 {
+		t.Errorf("sent %d reset messages, want 2", len(*sent))
+	}
+
+	// Unknown wallets count against the IP as well.
+	if err := service.RequestPasswordReset(ctx, "0xnobody"); err != nil {
+		t.Fatalf("request for unknown wallet error = %v", err)
+	}
+	if _, ok := RetryAfter(service.RequestPasswordReset(ctx, "0xother")); !ok {
+		t.Error("request over the IP limit was not limited")
+	}
+	other := WithClientIP(context.Background(), "198.51.100.1")
+	if err := service.RequestPasswordReset(other, "0xother"); err != nil {
+		t.Errorf("request from another IP error = %v", err)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, "/password/change", nil)
+	r.Header.Set("Authorization", "bearer abc.def")
+	if got := BearerToken(r); got != "abc.def" {
+		t.Errorf("BearerToken() = %q", got)
+	}
+	r.Header.Set("Authorization", "Basic Zm9v")
+	if got := BearerToken(r); got != "" {
+		t.Errorf("BearerToken() with Basic = %q", got)
+	}
+}
+
+func TestResetKeyPerService(t *testing.T) {
+	hash := hashToken("token")
+	if resetKey("customer", hash) == resetKey("restaurant", hash) {
+		t.Error("services share reset token keys")
+	}
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRateLimiter applies the login throttle's sliding window to any key,
+// stored under ratelimit:<key>.
+type RedisRateLimiter struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+func NewRedisRateLimiter(client *redis.Client) *RedisRateLimiter {
+	return &RedisRateLimiter{client: client, now: time.Now}
+}
+
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) error {
+	if l == nil || l.client == nil {
+		return errors.New("auth: redis client is not initialized")
+	}
+	if limit <= 0 {
+		return nil
+	}
+	wait, err := slidingWindowScript.Run(ctx, l.client, []string{"ratelimit:" + key},
+		l.now().UnixMilli(), window.Milliseconds(), limit, uuid.NewString()).Int64()
+	if err != nil {
+		return fmt.Errorf("auth: rate limit check failed: %w", err)
+	}
+	if wait > 0 {
+		return &ThrottleError{RetryAfter: time.Duration(wait) * time.Millisecond}
+	}
+	return nil
+}
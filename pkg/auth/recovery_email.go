@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrInvalidEmail      = errors.New("auth: invalid email address")
+	ErrInvalidEmailToken = errors.New("auth: invalid or expired email confirmation token")
+	// ErrRecoveryEmailDisabled is returned when no way to deliver
+	// confirmation tokens is configured.
+	ErrRecoveryEmailDisabled = errors.New("auth: recovery email is disabled")
+)
+
+const DefaultEmailTokenTTL = 24 * time.Hour
+
+// PendingEmail is an address waiting for its owner to confirm it.
+type PendingEmail struct {
+	UserID uuid.UUID
+	Email  string
+}
+
+// EmailTokenStore keeps confirmation tokens by their SHA-256 hash, like
+// ResetTokenStore.
+type EmailTokenStore interface {
+	Save(ctx context.Context, tokenHash string, pending PendingEmail, ttl time.Duration) error
+	// Consume deletes the token and returns what it confirms, or
+	// ErrInvalidEmailToken; only one caller can consume a given token.
+	Consume(ctx context.Context, tokenHash string) (PendingEmail, error)
+}
+
+// EmailConfirmation is what an EmailSender delivers to the new address.
+type EmailConfirmation struct {
+	User      StoredUser
+	To        string
+	Token     string
+	ExpiresAt time.Time
+}
+
+type EmailSender interface {
+	SendEmailConfirmation(ctx context.Context, msg EmailConfirmation) error
+}
+
+// RequestRecoveryEmail sends a confirmation token to email for the
+// session's user. The address becomes the user's recovery email, the only
+// place reset tokens go, once ConfirmRecoveryEmail sees the token.
+func (s *Service) RequestRecoveryEmail(ctx context.Context, sessionToken, walletAddress, email string) error {
+	logging.FromContext(ctx).Info("auth: recovery email requested", "wallet_address", walletAddress)
+	user, err := s.recoveryEmailUser(ctx, sessionToken, walletAddress)
+	if err != nil {
+		return err
+	}
+	email, err = normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	// Confirmations go to addresses the user picks, so they share the
+	// per-wallet reset limit rather than mail any inbox without bound.
+	if err := s.allowReset(ctx, user.WalletAddress); err != nil {
+		return err
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return fmt.Errorf("auth: failed to generate email token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+	expiresAt := time.Now().Add(s.emailTokenTTL)
+	if err := s.emailTokens.Save(ctx, hashToken(token), PendingEmail{UserID: user.ID, Email: email}, s.emailTokenTTL); err != nil {
+		return fmt.Errorf("auth: failed to store email token: %w", err)
+	}
+	return s.emailSender.SendEmailConfirmation(ctx, EmailConfirmation{User: user, To: email, Token: token, ExpiresAt: expiresAt})
+}
+
+// ConfirmRecoveryEmail stores the address the token was sent to as the
+// recovery email of the session's user.
+func (s *Service) ConfirmRecoveryEmail(ctx context.Context, sessionToken, walletAddress, token string) error {
+	user, err := s.recoveryEmailUser(ctx, sessionToken, walletAddress)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return ErrInvalidEmailToken
+	}
+	pending, err := s.emailTokens.Consume(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if pending.UserID != user.ID {
+		return ErrInvalidEmailToken
+	}
+	if err := s.store.UpdateRecoveryEmail(ctx, user.ID, pending.Email); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("auth: recovery email confirmed", "wallet_address", user.WalletAddress)
+	return nil
+}
+
+// recoveryEmailUser resolves the user behind a session and checks it owns
+// the wallet.
+func (s *Service) recoveryEmailUser(ctx context.Context, sessionToken, walletAddress string) (StoredUser, error) {
+	if s == nil {
+		return StoredUser{}, errors.New("auth: service is nil")
+	}
+	if s.emailTokens == nil || s.emailSender == nil {
+		return StoredUser{}, ErrRecoveryEmailDisabled
+	}
+	userID, err := s.sessions.Validate(ctx, sessionToken)
+	if err != nil {
+		return StoredUser{}, err
+	}
+	user, err := s.store.LoadByWalletAddress(ctx, walletAddress)
+	if err != nil {
+		return StoredUser{}, err
+	}
+	if user.ID != userID {
+		return StoredUser{}, ErrInvalidSession
+	}
+	return user, nil
+}
+
+// normalizeEmail accepts a bare address such as "grace@example.com", without
+// a display name.
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// RedisEmailTokenStore keeps the tokens of one service under its own key
+// prefix, like RedisResetTokenStore.
+type RedisEmailTokenStore struct {
+	client  *redis.Client
+	service string
+}
+
+// NewRedisEmailTokenStore scopes tokens to service, e.g. "customer".
+func NewRedisEmailTokenStore(client *redis.Client, service string) *RedisEmailTokenStore {
+	return &RedisEmailTokenStore{client: client, service: service}
+}
+
+func (s *RedisEmailTokenStore) Save(ctx context.Context, tokenHash string, pending PendingEmail, ttl time.Duration) error {
+	if s == nil || s.client == nil {
+		return errors.New("auth: redis client is not initialized")
+	}
+	key := emailTokenKey(s.service, tokenHash)
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", pending.UserID.String(), "email", pending.Email)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisEmailTokenStore) Consume(ctx context.Context, tokenHash string) (PendingEmail, error) {
+	if s == nil || s.client == nil {
+		return PendingEmail{}, errors.New("auth: redis client is not initialized")
+	}
+	key := emailTokenKey(s.service, tokenHash)
+	pipe := s.client.TxPipeline()
+	fields := pipe.HGetAll(ctx, key)
+	deleted := pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return PendingEmail{}, err
+	}
+	if deleted.Val() != 1 {
+		return PendingEmail{}, ErrInvalidEmailToken
+	}
+	userID, err := uuid.Parse(fields.Val()["user_id"])
+	if err != nil {
+		return PendingEmail{}, ErrInvalidEmailToken
+	}
+	return PendingEmail{UserID: userID, Email: fields.Val()["email"]}, nil
+}
+
+func emailTokenKey(service, tokenHash string) string {
+	return "recovery_email:" + service + ":" + tokenHash
+}
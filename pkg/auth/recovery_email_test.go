@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type memoryEmailTokens struct {
+	tokens map[string]PendingEmail
+}
+
+func (m *memoryEmailTokens) Save(ctx context.Context, tokenHash string, pending PendingEmail, ttl time.Duration) error {
+	m.tokens[tokenHash] = pending
+	return nil
+}
+
+func (m *memoryEmailTokens) Consume(ctx context.Context, tokenHash string) (PendingEmail, error) {
+	pending, ok := m.tokens[tokenHash]
+	if !ok {
+		return PendingEmail{}, ErrInvalidEmailToken
+	}
+	delete(m.tokens, tokenHash)
+	return pending, nil
+}
+
+type emailSenderFunc func(ctx context.Context, msg EmailConfirmation) error
+
+func (f emailSenderFunc) SendEmailConfirmation(ctx context.Context, msg EmailConfirmation) error {
+	return f(ctx, msg)
+}
+
+func TestRecoveryEmail(t *testing.T) {
+	ctx := context.Background()
+	service, store, _, _, resets := newPasswordTestService(t)
+	var sent []EmailConfirmation
+	service.emailTokens = &memoryEmailTokens{tokens: map[string]PendingEmail{}}
+	service.emailSender = emailSenderFunc(func(ctx context.Context, msg EmailConfirmation) error {
+		sent = append(sent, msg)
+		return nil
+	})
+	ada := store.users["0xnomail"]
+	session := "token-" + ada.ID.String()
+
+	if err := service.RequestRecoveryEmail(ctx, session, "0xabc", "ada@example.com"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("request for another wallet error = %v, want ErrInvalidSession", err)
+	}
+	if err := service.RequestRecoveryEmail(ctx, session, "0xnomail", "Ada <ada@example.com>"); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("display name error = %v, want ErrInvalidEmail", err)
+	}
+	if err := service.RequestRecoveryEmail(ctx, session, "0xnomail", "ada@example.com"); err != nil {
+		t.Fatalf("RequestRecoveryEmail() error = %v", err)
+	}
+	if len(sent) != 1 || sent[0].To != "ada@example.com" {
+		t.Fatalf("sent %+v, want one confirmation to the new address", sent)
+	}
+	if store.users["0xnomail"].RecoveryEmail != "" {
+		t.Fatal("recovery email set before confirmation")
+	}
+
+	// Another user's session cannot confirm Ada's token.
+	grace := store.users["0xabc"]
+	if err := service.ConfirmRecoveryEmail(ctx, "token-"+grace.ID.String(), "0xabc", sent[0].Token); !errors.Is(err, ErrInvalidEmailToken) {
+		t.Errorf("confirm by another user error = %v, want ErrInvalidEmailToken", err)
+	}
+	if err := service.RequestRecoveryEmail(ctx, session, "0xnomail", "ada@example.com"); err != nil {
+		t.Fatalf("second RequestRecoveryEmail() error = %v", err)
+	}
+	if err := service.ConfirmRecoveryEmail(ctx, session, "0xnomail", sent[1].Token); err != nil {
+		t.Fatalf("ConfirmRecoveryEmail() error = %v", err)
+	}
+	if store.users["0xnomail"].RecoveryEmail != "ada@example.com" {
+		t.Errorf("recovery email = %q", store.users["0xnomail"].RecoveryEmail)
+	}
+	if err := service.ConfirmRecoveryEmail(ctx, session, "0xnomail", sent[1].Token); !errors.Is(err, ErrInvalidEmailToken) {
+		t.Errorf("reused token error = %v, want ErrInvalidEmailToken", err)
+	}
+
+	if err := service.RequestPasswordReset(ctx, "0xnomail"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	if len(*resets) != 1 || (*resets)[0].To != "ada@example.com" {
+		t.Errorf("resets = %+v, want one to the confirmed address", *resets)
+	}
+}
+
+func TestRecoveryEmailDisabled(t *testing.T) {
+	service, store, _, _, _ := newPasswordTestService(t)
+	user := store.users["0xabc"]
+	err := service.RequestRecoveryEmail(context.Background(), "token-"+user.ID.String(), "0xabc", "grace@example.com")
+	if !errors.Is(err, ErrRecoveryEmailDisabled) {
+		t.Errorf("RequestRecoveryEmail() without a sender error = %v, want ErrRecoveryEmailDisabled", err)
+	}
+}
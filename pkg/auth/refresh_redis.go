@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// rotateScript returns {1, user} after swapping the hash, {-1, user} after
+// revoking a family whose current hash did not match, or {0, ""} for an
+// unknown family or one started by another role, which it leaves alone.
+var rotateScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'current')
+if not current then
+  return {0, ''}
+end
+if redis.call('HGET', KEYS[1], 'role') ~= ARGV[4] then
+  return {0, ''}
+end
+local user = redis.call('HGET', KEYS[1], 'user_id')
+if current ~= ARGV[1] then
+  redis.call('DEL', KEYS[1])
+  return {-1, user}
+end
+redis.call('HSET', KEYS[1], 'current', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {1, user}
+`)
+
+// RedisRefreshStore keeps refresh_family:<id> hashes (role, user_id,
+// current) and user_refresh_families:<role>:<user> sets for revocation.
+// The services share Redis, and a wallet has the same user ID in each of
+// them, so families are kept apart by role.
+type RedisRefreshStore struct {
+	client *redis.Client
+}
+
+func NewRedisRefreshStore(client *redis.Client) *RedisRefreshStore {
+	return &RedisRefreshStore{client: client}
+}
+
+func (s *RedisRefreshStore) Create(ctx context.Context, familyID, role string, userID uuid.UUID, tokenHash string, ttl time.Duration) error {
+	if s == nil || s.client == nil {
+		return errors.New("auth: redis client is not initialized")
+	}
+	familyKey := refreshFamilyKey(familyID)
+	userKey := userRefreshFamiliesKey(role, userID)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, familyKey, "role", role, "user_id", userID.String(), "current", tokenHash)
+		pipe.Expire(ctx, familyKey, ttl)
+		pipe.SAdd(ctx, userKey, familyID)
+		pipe.Expire(ctx, userKey, ttl)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisRefreshStore) Rotate(ctx context.Context, familyID, role, oldHash, newHash string, ttl time.Duration) (uuid.UUID, error) {
+	if s == nil || s.client == nil {
+		return uuid.Nil, errors.New("auth: redis client is not initialized")
+	}
+	result, err := rotateScript.Run(ctx, s.client, []string{refreshFamilyKey(familyID)}, oldHash, newHash, ttl.Milliseconds(), role).Slice()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("auth: rotate refresh token: %w", err)
+	}
+	status, _ := result[0].(int64)
+	userStr, _ := result[1].(string)
+	userID, _ := uuid.Parse(userStr)
+	switch status {
+	case 1:
+		return userID, nil
+	case -1:
+		return userID, ErrRefreshTokenReused
+	default:
+		return uuid.Nil, ErrInvalidSession
+	}
+}
+
+func (s *RedisRefreshStore) RevokeUser(ctx context.Context, role string, userID uuid.UUID) error {
+	if s == nil || s.client == nil {
+		return errors.New("auth: redis client is not initialized")
+	}
+	userKey := userRefreshFamiliesKey(role, userID)
+	families, err := s.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(families)+1)
+	for _, familyID := range families {
+		keys = append(keys, refreshFamilyKey(familyID))
+	}
+	keys = append(keys, userKey)
+	return s.client.Del(ctx, keys...).Err()
+}
+
+func refreshFamilyKey(familyID string) string {
+	return "refresh_family:" + familyID
+}
+
+func userRefreshFamiliesKey(role string, userID uuid.UUID) string {
+	return fmt.Sprintf("user_refresh_families:%s:%s", role, userID)
+}
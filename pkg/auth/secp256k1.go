@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Minimal secp256k1 arithmetic for recovering Ethereum addresses from
+// personal_sign signatures. Only public data is processed here, so the
+// math/big implementation does not need to be constant time.
+
+var (
+	secpP, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	secpN, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	secpGx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	secpGy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	secpB     = big.NewInt(7)
+)
+
+var ErrInvalidSignature = errors.New("auth: invalid wallet signature")
+
+// ecPoint is an affine point; nil x means the point at infinity.
+type ecPoint struct {
+	x, y *big.Int
+}
+
+func (p ecPoint) infinity() bool { return p.x == nil }
+
+func ecAdd(a, b ecPoint) ecPoint {
+	if a.infinity() {
+		return b
+	}
+	if b.infinity() {
+		return a
+	}
+	var lambda *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if new(big.Int).Add(a.y, b.y).Mod(new(big.Int).Add(a.y, b.y), secpP).Sign() == 0 {
+			return ecPoint{}
+		}
+		// Doubling: λ = 3x² / 2y.
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		lambda = num.Mul(num, den.ModInverse(den, secpP))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, secpP)
+		lambda = num.Mul(num, den.ModInverse(den, secpP))
+	}
+	lambda.Mod(lambda, secpP)
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, secpP)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, lambda).Sub(y, a.y).Mod(y, secpP)
+	return ecPoint{x: x, y: y}
+}
+
+func ecMul(p ecPoint, k *big.Int) ecPoint {
+	result := ecPoint{}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = ecAdd(result, result)
+		if k.Bit(i) == 1 {
+			result = ecAdd(result, p)
+		}
+	}
+	return result
+}
+
+func secpGenerator() ecPoint { return ecPoint{x: secpGx, y: secpGy} }
+
+// Keccak256 is Ethereum's pre-standard SHA-3.
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// PersonalMessageHash is the EIP-191 hash wallets sign for personal_sign.
+func PersonalMessageHash(message string) []byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message))
+	return Keccak256([]byte(prefix), []byte(message))
+}
+
+// RecoverAddress returns the lowercase 0x address whose key made the
+// 65-byte r||s||v signature over hash. v may be 0/1 or 27/28.
+func RecoverAddress(hash []byte, signature []byte) (string, error) {
+	if len(hash) != 32 || len(signature) != 65 {
+		return "", ErrInvalidSignature
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:64])
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 || r.Sign() == 0 || s.Sign() == 0 || r.Cmp(secpN) >= 0 || s.Cmp(secpN) >= 0 {
+		return "", ErrInvalidSignature
+	}
+
+	// R = (r, y) with y² = r³ + 7 and the parity given by v.
+	y2 := new(big.Int).Exp(r, big.NewInt(3), secpP)
+	y2.Add(y2, secpB).Mod(y2, secpP)
+	exp := new(big.Int).Add(secpP, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(y2, exp, secpP)
+	if new(big.Int).Exp(y, big.NewInt(2), secpP).Cmp(y2) != 0 {
+		return "", ErrInvalidSignature
+	}
+	if y.Bit(0) != uint(v) {
+		y.Sub(secpP, y)
+	}
+	R := ecPoint{x: r, y: y}
+
+	// Q = r⁻¹(sR − eG)
+	e := new(big.Int).SetBytes(hash)
+	e.Mod(e, secpN)
+	rInv := new(big.Int).ModInverse(r, secpN)
+	u1 := new(big.Int).Neg(e)
+	u1.Mul(u1, rInv).Mod(u1, secpN)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, secpN)
+	Q := ecAdd(ecMul(secpGenerator(), u1), ecMul(R, u2))
+	if Q.infinity() {
+		return "", ErrInvalidSignature
+	}
+	return publicKeyAddress(Q), nil
+}
+
+func publicKeyAddress(q ecPoint) string {
+	pub := make([]byte, 64)
+	q.x.FillBytes(pub[:32])
+	q.y.FillBytes(pub[32:])
+	return "0x" + hex.EncodeToString(Keccak256(pub)[12:])
+}
+
+// DecodeSignature accepts a 0x-prefixed or bare hex signature.
+func DecodeSignature(signature string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "0x"))
+	if err != nil || len(raw) != 65 {
+		return nil, fmt.Errorf("%w: want 65 hex-encoded bytes", ErrInvalidSignature)
+	}
+	return raw, nil
+}
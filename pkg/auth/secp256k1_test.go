@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// signPersonal signs like a wallet's personal_sign: ECDSA over the EIP-191
+// hash with the recovery id in v (27/28).
+func signPersonal(t *testing.T, d *big.Int, message string) []byte {
+	t.Helper()
+	hash := PersonalMessageHash(message)
+	e := new(big.Int).SetBytes(hash)
+	for {
+		k, err := rand.Int(rand.Reader, secpN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.Sign() == 0 {
+			continue
+		}
+		R := ecMul(secpGenerator(), k)
+		r := new(big.Int).Mod(R.x, secpN)
+		if r.Sign() == 0 || R.x.Cmp(secpN) >= 0 {
+			continue
+		}
+		s := new(big.Int).Mul(r, d)
+		s.Add(s, e).Mul(s, new(big.Int).ModInverse(k, secpN)).Mod(s, secpN)
+		if s.Sign() == 0 {
+			continue
+		}
+		v := byte(R.y.Bit(0))
+		// Wallets emit low-s signatures; flipping s flips the recovery id.
+		if s.Cmp(new(big.Int).Rsh(secpN, 1)) > 0 {
+			s.Sub(secpN, s)
+			v ^= 1
+		}
+		sig := make([]byte, 65)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:64])
+		sig[64] = 27 + v
+		return sig
+	}
+}
+
+func TestPublicKeyAddressKnownKeys(t *testing.T) {
+	tests := []struct {
+		key  int64
+		want string
+	}{
+		{key: 1, want: "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf"},
+		{key: 2, want: "0x2b5ad5c4795c026514f8317c7a215e218dccd6cf"},
+	}
+	for _, tt := range tests {
+		if got := publicKeyAddress(ecMul(secpGenerator(), big.NewInt(tt.key))); got != tt.want {
+			t.Errorf("address of key %d = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestRecoverAddress(t *testing.T) {
+	d, _ := new(big.Int).SetString("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318", 16)
+	want := publicKeyAddress(ecMul(secpGenerator(), d))
+	message := "localhost wants you to sign in with your Ethereum account"
+
+	sig := signPersonal(t, d, message)
+	got, err := RecoverAddress(PersonalMessageHash(message), sig)
+	if err != nil || got != want {
+		t.Fatalf("RecoverAddress() = %s, %v; want %s", got, err, want)
+	}
+
+	// v as 0/1 works too.
+	sig[64] -= 27
+	if got, _ := RecoverAddress(PersonalMessageHash(message), sig); got != want {
+		t.Errorf("RecoverAddress() with v=0/1 = %s", got)
+	}
+
+	// Another message recovers another (random) address.
+	if got, _ := RecoverAddress(PersonalMessageHash(message+"!"), sig); got == want {
+		t.Error("signature verified for a different message")
+	}
+
+	bad := make([]byte, 65)
+	if _, err := RecoverAddress(PersonalMessageHash(message), bad); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("zero signature error = %v", err)
+	}
+}
+
+func TestKeccak256(t *testing.T) {
+	// keccak256("") is a well-known constant distinct from SHA3-256("").
+	if got := big.NewInt(0).SetBytes(Keccak256(nil)).Text(16); got != "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470" {
+		t.Errorf("Keccak256(\"\") = %s", got)
+	}
+}
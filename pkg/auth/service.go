@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
+	"github.com/Kabanya/YAFDS/pkg/logging"
 	"github.com/Kabanya/YAFDS/pkg/utils"
 )
 
@@ -17,17 +19,23 @@ type Service struct {
 	sessions   SessionManager
 	validator  Validator
 	sessionTTL time.Duration
-}
+	throttle   LoginThrottle
 
-func logPrintf(format string, v ...any) {
-	logger, err := utils.Logger()
-	if err == nil {
-		logger.Printf(format, v...)
-	}
+	resetTokens  ResetTokenStore
+	resetSender  ResetSender
+	resetTTL     time.Duration
+	resetLimiter RateLimiter
+	resetLimits  ResetLimits
+
+	emailTokens   EmailTokenStore
+	emailSender   EmailSender
+	emailTokenTTL time.Duration
+
+	wallet WalletLoginConfig
+	mfa    MFAConfig
 }
 
 func NewService(cfg ServiceConfig) (*Service, error) {
-	logPrintf("auth: initializing service with session TTL %s", cfg.SessionTTL)
 	if cfg.Store == nil {
 		return nil, fmt.Errorf("auth: store is required")
 	}
@@ -46,18 +54,40 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 	if sessTTL <= 0 {
 		sessTTL = utils.DefaultSessionTTL
 	}
-	logPrintf("auth: using session TTL %s", sessTTL)
+	slog.Debug("auth: service initialized", "session_ttl", sessTTL)
+	resetTTL := cfg.ResetTTL
+	if resetTTL <= 0 {
+		resetTTL = DefaultResetTTL
+	}
+	emailTokenTTL := cfg.EmailTokenTTL
+	if emailTokenTTL <= 0 {
+		emailTokenTTL = DefaultEmailTokenTTL
+	}
 	return &Service{
 		store:      cfg.Store,
 		hasher:     hasher,
 		sessions:   cfg.Sessions,
 		validator:  validator,
 		sessionTTL: sessTTL,
+		throttle:   cfg.Throttle,
+
+		resetTokens:  cfg.ResetTokens,
+		resetSender:  cfg.ResetSender,
+		resetTTL:     resetTTL,
+		resetLimiter: cfg.ResetLimiter,
+		resetLimits:  cfg.ResetLimits.withDefaults(),
+
+		emailTokens:   cfg.EmailTokens,
+		emailSender:   cfg.EmailSender,
+		emailTokenTTL: emailTokenTTL,
+
+		wallet: cfg.WalletLogin.withDefaults(),
+		mfa:    cfg.MFA.withDefaults(),
 	}, nil
 }
 
 func (s *Service) Register(ctx context.Context, input RegisterInput) error {
-	logPrintf("auth: registering user with wallet address %s", input.WalletAddress)
+	logging.FromContext(ctx).Debug("auth: registering user", "wallet_address", input.WalletAddress)
 	if s == nil {
 		return errors.New("auth: service is nil")
 	}
@@ -69,45 +99,154 @@ func (s *Service) Register(ctx context.Context, input RegisterInput) error {
 	}
 	passwordHash, passwordSalt, err := s.hasher.Hash(input.Password)
 	if err != nil {
-		logPrintf("auth: hash failed for %s: %v", input.WalletAddress, err)
+		logging.FromContext(ctx).Error("auth: hash failed", "wallet_address", input.WalletAddress, "error", err)
 		return err
 	}
-	logPrintf("auth: registration successful for wallet address %s", input.WalletAddress)
+	logging.FromContext(ctx).Info("auth: registration successful", "wallet_address", input.WalletAddress)
 	return s.store.SaveWithPassword(ctx, input, passwordHash, passwordSalt)
 }
 
 func (s *Service) Login(ctx context.Context, walletAddress string, password string) (LoginResult, error) {
-	logPrintf("auth: login attempt for wallet address %s", walletAddress)
+	logging.FromContext(ctx).Debug("auth: login attempt", "wallet_address", walletAddress)
 	if s == nil {
 		return LoginResult{}, errors.New("auth: service is nil")
 	}
 	if strings.TrimSpace(walletAddress) == "" {
 		return LoginResult{}, errors.New("auth: wallet address is required")
 	}
+	// The throttle runs before the store lookup and the Argon2 check, so a
+	// throttled caller costs neither a query nor a hash.
+	clientIP := ClientIP(ctx)
+	if s.throttle != nil {
+		if err := s.throttle.Allow(ctx, walletAddress, clientIP); err != nil {
+			logging.FromContext(ctx).Warn("auth: login throttled", "wallet_address", walletAddress, "client_ip", clientIP, "error", err)
+			return LoginResult{}, err
+		}
+	}
 	user, err := s.store.LoadByWalletAddress(ctx, walletAddress)
 	if err != nil {
+		s.recordFailure(ctx, walletAddress, clientIP)
 		return LoginResult{}, err
 	}
 	if !s.hasher.Verify(password, user.PasswordSalt, user.PasswordHash) {
+		s.recordFailure(ctx, walletAddress, clientIP)
 		return LoginResult{}, ErrInvalidCredentials
 	}
+	s.upgradeHash(ctx, user, password)
+	result, err := s.finishLogin(ctx, user)
+	// With two-factor authentication the failures are only cleared once the
+	// code checks out, so wrong codes keep counting against the wallet.
+	if err == nil && result.MFAToken == "" {
+		s.recordSuccess(ctx, walletAddress, clientIP)
+	}
+	return result, err
+}
+
+// finishLogin issues a session for a user whose first factor checked out,
+// or an MFA token when the user has two-factor authentication enabled.
+func (s *Service) finishLogin(ctx context.Context, user StoredUser) (LoginResult, error) {
+	if s.mfa.enabled() {
+		state, err := s.mfa.Store.LoadMFA(ctx, user.ID)
+		if err != nil && !errors.Is(err, ErrMFANotEnrolled) {
+			return LoginResult{}, err
+		}
+		if err == nil && state.Enabled {
+			return s.mfaChallenge(ctx, user)
+		}
+	}
+	return s.issueSession(ctx, user)
+}
+
+// issueSession starts a session for an authenticated user, as a token pair
+// when the session manager supports refresh tokens.
+func (s *Service) issueSession(ctx context.Context, user StoredUser) (LoginResult, error) {
+	if issuer, ok := s.sessions.(TokenPairIssuer); ok {
+		pair, err := issuer.CreatePair(ctx, user.ID, s.sessionTTL)
+		if err != nil {
+			logging.FromContext(ctx).Error("auth: token pair create failed", "wallet_address", user.WalletAddress, "error", err)
+			return LoginResult{}, err
+		}
+		logging.FromContext(ctx).Info("auth: login successful", "wallet_address", user.WalletAddress)
+		return LoginResult{
+			User:              user,
+			Token:             pair.AccessToken,
+			Expiration:        pair.AccessExpiresAt,
+			RefreshToken:      pair.RefreshToken,
+			RefreshExpiration: pair.RefreshExpiresAt,
+		}, nil
+	}
 	token, exp, err := s.sessions.Create(ctx, user.ID, s.sessionTTL)
 	if err != nil {
-		logPrintf("auth: session create failed for %s: %v", walletAddress, err)
+		logging.FromContext(ctx).Error("auth: session create failed", "wallet_address", user.WalletAddress, "error", err)
 		return LoginResult{}, err
 	}
-	logPrintf("auth: login successful for wallet address %s", walletAddress)
+	logging.FromContext(ctx).Info("auth: login successful", "wallet_address", user.WalletAddress)
 	return LoginResult{User: user, Token: token, Expiration: exp}, nil
 }
 
+// Refresh exchanges a refresh token for a new token pair.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	if s == nil {
+		return TokenPair{}, errors.New("auth: service is nil")
+	}
+	issuer, ok := s.sessions.(TokenPairIssuer)
+	if !ok {
+		return TokenPair{}, errors.New("auth: session manager does not issue refresh tokens")
+	}
+	pair, err := issuer.Refresh(ctx, refreshToken)
+	if err != nil {
+		logging.FromContext(ctx).Info("auth: refresh failed", "error", err)
+		return TokenPair{}, err
+	}
+	return pair, nil
+}
+
+// upgradeHash re-hashes a verified password whose stored hash is legacy or
+// uses outdated parameters. Failures only cost the upgrade, not the login.
+func (s *Service) upgradeHash(ctx context.Context, user StoredUser, password string) {
+	rehasher, ok := s.hasher.(Rehasher)
+	if !ok || !rehasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+	passwordHash, passwordSalt, err := s.hasher.Hash(password)
+	if err != nil {
+		logging.FromContext(ctx).Error("auth: rehash failed", "wallet_address", user.WalletAddress, "error", err)
+		return
+	}
+	if err := s.store.UpdatePassword(ctx, user.ID, passwordHash, passwordSalt); err != nil {
+		logging.FromContext(ctx).Error("auth: store upgraded hash failed", "wallet_address", user.WalletAddress, "error", err)
+		return
+	}
+	logging.FromContext(ctx).Info("auth: upgraded password hash", "wallet_address", user.WalletAddress)
+}
+
+// recordFailure counts a failed login; unknown wallets count too so the
+// throttle does not reveal which addresses exist.
+func (s *Service) recordFailure(ctx context.Context, walletAddress, clientIP string) {
+	if s.throttle == nil {
+		return
+	}
+	if err := s.throttle.Failure(ctx, walletAddress, clientIP); err != nil {
+		logging.FromContext(ctx).Warn("auth: record login failure failed", "wallet_address", walletAddress, "error", err)
+	}
+}
+
+// recordSuccess clears the failures counted against a wallet.
+func (s *Service) recordSuccess(ctx context.Context, walletAddress, clientIP string) {
+	if s.throttle == nil {
+		return
+	}
+	if err := s.throttle.Success(ctx, walletAddress, clientIP); err != nil {
+		logging.FromContext(ctx).Warn("auth: reset login failures failed", "wallet_address", walletAddress, "error", err)
+	}
+}
+
 func (s *Service) ensureInput(input RegisterInput) error {
-	logPrintf("auth: validating registration input for wallet address %s", input.WalletAddress)
 	if strings.TrimSpace(input.WalletAddress) == "" {
 		return errors.New("auth: wallet address is required")
 	}
 	if strings.TrimSpace(input.Password) == "" {
 		return errors.New("auth: password is required")
 	}
-	logPrintf("auth: registration input validated for wallet address %s", input.WalletAddress)
 	return nil
 }
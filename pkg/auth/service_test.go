@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,7 +19,7 @@ func (m *mockStore) SaveWithPassword(ctx context.Context, data RegisterInput, ha
 		ID:            data.ID,
 		Name:          data.Name,
 		WalletAddress: data.WalletAddress,
-		Address:       data.Address,
+		Fields:        data.Fields,
 		PasswordHash:  hash,
 		PasswordSalt:  salt,
 	}
@@ -33,6 +34,28 @@ func (m *mockStore) LoadByWalletAddress(ctx context.Context, walletAddress strin
 	return u, nil
 }
 
+func (m *mockStore) UpdatePassword(ctx context.Context, userID uuid.UUID, hash string, salt []byte) error {
+	for wallet, u := range m.users {
+		if u.ID == userID {
+			u.PasswordHash, u.PasswordSalt = hash, salt
+			m.users[wallet] = u
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func (m *mockStore) UpdateRecoveryEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	for wallet, u := range m.users {
+		if u.ID == userID {
+			u.RecoveryEmail = email
+			m.users[wallet] = u
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
 type mockHasher struct{}
 
 func (m *mockHasher) Hash(password string) (string, []byte, error) {
@@ -42,12 +65,30 @@ func (m *mockHasher) Verify(password string, salt []byte, expected string) bool
 	return expected == "hashed-"+password
 }
 
-type mockSessions struct{}
+type mockSessions struct {
+	revoked map[uuid.UUID]bool
+}
 
 func (m *mockSessions) Create(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
 	return "token-" + userID.String(), time.Now().Add(ttl), nil
 }
 
+func (m *mockSessions) Validate(ctx context.Context, token string) (uuid.UUID, error) {
+	userID, err := uuid.Parse(strings.TrimPrefix(token, "token-"))
+	if err != nil || m.revoked[userID] {
+		return uuid.Nil, ErrInvalidSession
+	}
+	return userID, nil
+}
+
+func (m *mockSessions) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	if m.revoked == nil {
+		m.revoked = make(map[uuid.UUID]bool)
+	}
+	m.revoked[userID] = true
+	return nil
+}
+
 func TestAuthService(t *testing.T) {
 	store := &mockStore{users: make(map[string]StoredUser)}
 	hasher := &mockHasher{}
@@ -65,7 +106,7 @@ func TestAuthService(t *testing.T) {
 		ID:            uuid.New(),
 		Name:          "Test User",
 		WalletAddress: "0x123",
-		Address:       "Main St",
+		Fields:        map[string]any{"address": "Main St"},
 		Password:      "password123",
 	}
 
@@ -103,3 +144,28 @@ func TestAuthService(t *testing.T) {
 		}
 	})
 }
+
+func TestLoginUpgradesLegacyHash(t *testing.T) {
+	ctx := context.Background()
+	// Made on a two-core host, where the old hasher used p=2.
+	legacy, salt := legacyHashWithThreads("password123", 2)
+	store := &mockStore{users: map[string]StoredUser{
+		"0xold": {ID: uuid.New(), WalletAddress: "0xold", PasswordHash: legacy, PasswordSalt: salt},
+	}}
+	hasher := NewArgon2Hasher(DefaultArgonParams)
+	service, _ := NewService(ServiceConfig{Store: store, Hasher: hasher, Sessions: &mockSessions{}})
+
+	if _, err := service.Login(ctx, "0xold", "password123"); err != nil {
+		t.Fatalf("Login() with legacy hash error = %v", err)
+	}
+	upgraded := store.users["0xold"].PasswordHash
+	if upgraded == legacy || hasher.NeedsRehash(upgraded) {
+		t.Fatalf("hash not upgraded: %q", upgraded)
+	}
+	if _, err := service.Login(ctx, "0xold", "password123"); err != nil {
+		t.Fatalf("Login() after upgrade error = %v", err)
+	}
+	if store.users["0xold"].PasswordHash != upgraded {
+		t.Error("current hash rehashed again")
+	}
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrRefreshTokenReused = errors.New("auth: refresh token reuse detected")
+
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+
+	AuditRefreshReuse AuditEventType = "REFRESH_TOKEN_REUSE"
+)
+
+// TokenPair is a short-lived access token with the refresh token that
+// replaces it.
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// TokenPairIssuer is implemented by session managers that hand out refresh
+// tokens next to access tokens.
+type TokenPairIssuer interface {
+	CreatePair(ctx context.Context, userID uuid.UUID, ttl time.Duration) (TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (TokenPair, error)
+}
+
+// RefreshStore keeps refresh token families server side. A family starts
+// at login and belongs to the role that issued it; every refresh replaces
+// its current token hash. Presenting a hash that is no longer current means
+// a token was copied, so the whole family is revoked.
+type RefreshStore interface {
+	Create(ctx context.Context, familyID, role string, userID uuid.UUID, tokenHash string, ttl time.Duration) error
+	// Rotate swaps oldHash for newHash and returns the family's user. It
+	// fails with ErrInvalidSession for unknown families and families of
+	// another role, and with ErrRefreshTokenReused, after revoking the
+	// family, for stale hashes.
+	Rotate(ctx context.Context, familyID, role, oldHash, newHash string, ttl time.Duration) (uuid.UUID, error)
+	// RevokeUser ends the user's families of role only.
+	RevokeUser(ctx context.Context, role string, userID uuid.UUID) error
+}
+
+type JWTConfig struct {
+	Keys    *KeySet
+	Refresh RefreshStore
+	// Issuer and Role go into every access token; Validate rejects tokens
+	// of another role so a courier token cannot act on a customer service.
+	Issuer     string
+	Role       string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	Audit      AuditFunc
+}
+
+// JWTSessionManager issues EdDSA-signed JWT access tokens that any service
+// holding the public keys verifies without a lookup, plus rotating refresh
+// tokens. RevokeAll ends refresh families; access tokens already issued stay
+// valid until they expire, which is why AccessTTL is short.
+type JWTSessionManager struct {
+	cfg JWTConfig
+	now func() time.Time
+}
+
+func NewJWTSessionManager(cfg JWTConfig) (*JWTSessionManager, error) {
+	if cfg.Keys == nil {
+		return nil, errors.New("auth: key set is required")
+	}
+	if _, _, err := cfg.Keys.signingKey(); err != nil {
+		return nil, err
+	}
+	if cfg.Refresh == nil {
+		return nil, errors.New("auth: refresh store is required")
+	}
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = DefaultAccessTTL
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = DefaultRefreshTTL
+	}
+	if cfg.Audit == nil {
+		cfg.Audit = LogAudit
+	}
+	return &JWTSessionManager{cfg: cfg, now: time.Now}, nil
+}
+
+// Create issues an access token only. ttl is capped at AccessTTL so a long
+// SESSION_TTL cannot turn access tokens into long-lived ones.
+func (m *JWTSessionManager) Create(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 || ttl > m.cfg.AccessTTL {
+		ttl = m.cfg.AccessTTL
+	}
+	now := m.now()
+	expiresAt := now.Add(ttl)
+	token, err := SignAccessToken(m.cfg.Keys, AccessClaims{
+		Issuer:    m.cfg.Issuer,
+		Subject:   userID,
+		Role:      m.cfg.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		ID:        uuid.NewString(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("auth: sign access token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+func (m *JWTSessionManager) Validate(ctx context.Context, token string) (uuid.UUID, error) {
+	claims, err := m.Claims(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.Subject, nil
+}
+
+// Claims verifies an access token and returns its claims.
+func (m *JWTSessionManager) Claims(token string) (AccessClaims, error) {
+	claims, err := ParseAccessToken(m.cfg.Keys, token, "", m.now())
+	if err != nil {
+		return AccessClaims{}, err
+	}
+	if m.cfg.Role != "" && claims.Role != m.cfg.Role {
+		return AccessClaims{}, fmt.Errorf("%w: token for role %q", ErrInvalidSession, claims.Role)
+	}
+	return claims, nil
+}
+
+func (m *JWTSessionManager) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	return m.cfg.Refresh.RevokeUser(ctx, m.cfg.Role, userID)
+}
+
+// CreatePair starts a new refresh token family.
+func (m *JWTSessionManager) CreatePair(ctx context.Context, userID uuid.UUID, ttl time.Duration) (TokenPair, error) {
+	familyID := uuid.NewString()
+	refreshToken, refreshHash, err := newRefreshToken(familyID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if err := m.cfg.Refresh.Create(ctx, familyID, m.cfg.Role, userID, refreshHash, m.cfg.RefreshTTL); err != nil {
+		return TokenPair{}, fmt.Errorf("auth: store refresh token: %w", err)
+	}
+	return m.pair(ctx, userID, ttl, refreshToken)
+}
+
+// Refresh trades a refresh token for a new pair; the old refresh token
+// stops working. Refresh tokens of another role are rejected, so one
+// service cannot mint access tokens from another service's login.
+func (m *JWTSessionManager) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	familyID, _, ok := strings.Cut(refreshToken, ".")
+	if !ok || familyID == "" {
+		return TokenPair{}, ErrInvalidSession
+	}
+	next, nextHash, err := newRefreshToken(familyID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	userID, err := m.cfg.Refresh.Rotate(ctx, familyID, m.cfg.Role, hashToken(refreshToken), nextHash, m.cfg.RefreshTTL)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		now := m.now()
+		m.cfg.Audit(ctx, AuditEvent{Type: AuditRefreshReuse, UserID: userID, ClientIP: ClientIP(ctx), At: now})
+		return TokenPair{}, err
+	}
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return m.pair(ctx, userID, 0, next)
+}
+
+func (m *JWTSessionManager) pair(ctx context.Context, userID uuid.UUID, ttl time.Duration, refreshToken string) (TokenPair, error) {
+	access, accessExp, err := m.Create(ctx, userID, ttl)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: m.now().Add(m.cfg.RefreshTTL),
+	}, nil
+}
+
+// newRefreshToken returns "<family>.<secret>" and the hash stored for it.
+func newRefreshToken(familyID string) (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("auth: generate refresh token: %w", err)
+	}
+	token := familyID + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return token, hashToken(token), nil
+}
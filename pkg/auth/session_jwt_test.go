@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type refreshFamily struct {
+	role    string
+	user    uuid.UUID
+	current string
+}
+
+type memoryRefreshStore struct {
+	families map[string]refreshFamily
+}
+
+func newMemoryRefreshStore() *memoryRefreshStore {
+	return &memoryRefreshStore{families: map[string]refreshFamily{}}
+}
+
+func (m *memoryRefreshStore) Create(ctx context.Context, familyID, role string, userID uuid.UUID, tokenHash string, ttl time.Duration) error {
+	m.families[familyID] = refreshFamily{role: role, user: userID, current: tokenHash}
+	return nil
+}
+
+func (m *memoryRefreshStore) Rotate(ctx context.Context, familyID, role, oldHash, newHash string, ttl time.Duration) (uuid.UUID, error) {
+	family, ok := m.families[familyID]
+	if !ok || family.role != role {
+		return uuid.Nil, ErrInvalidSession
+	}
+	if family.current != oldHash {
+		delete(m.families, familyID)
+		return family.user, ErrRefreshTokenReused
+	}
+	family.current = newHash
+	m.families[familyID] = family
+	return family.user, nil
+}
+
+func (m *memoryRefreshStore) RevokeUser(ctx context.Context, role string, userID uuid.UUID) error {
+	for id, family := range m.families {
+		if family.role == role && family.user == userID {
+			delete(m.families, id)
+		}
+	}
+	return nil
+}
+
+func testKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAccessTokenVerifiesAcrossKeyRotation(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	keys := NewKeySet()
+	keys.AddPrivate("2026-01-01", testKey(t))
+	userID := uuid.New()
+
+	oldToken, err := SignAccessToken(keys, AccessClaims{Subject: userID, Role: "customer", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix(), ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.AddPrivate("2026-10-19", testKey(t))
+	newToken, _ := SignAccessToken(keys, AccessClaims{Subject: userID, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix(), ID: "2"})
+	if !strings.Contains(newToken, ".") || oldToken == newToken {
+		t.Fatal("unexpected tokens")
+	}
+
+	// The published key set alone verifies tokens of both keys.
+	jwks, err := keys.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set jwkSet
+	if err := json.Unmarshal(jwks, &set); err != nil || len(set.Keys) != 2 {
+		t.Fatalf("JWKS() = %s, %v", jwks, err)
+	}
+	verifier := NewKeySet()
+	for _, key := range set.Keys {
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil || key.Kty != "OKP" || key.Crv != "Ed25519" {
+			t.Fatalf("published key = %+v, %v", key, err)
+		}
+		verifier.AddPublic(key.Kid, ed25519.PublicKey(x))
+	}
+	for _, token := range []string{oldToken, newToken} {
+		claims, err := ParseAccessToken(verifier, token, "", now)
+		if err != nil || claims.Subject != userID {
+			t.Fatalf("ParseAccessToken() = %+v, %v", claims, err)
+		}
+	}
+
+	if _, err := ParseAccessToken(verifier, oldToken, "", now.Add(time.Hour)); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("expired token error = %v", err)
+	}
+	parts := strings.Split(oldToken, ".")
+	tampered := parts[0] + "." + strings.TrimSuffix(parts[1], "A") + "B." + parts[2]
+	if _, err := ParseAccessToken(verifier, tampered, "", now); err == nil {
+		t.Error("tampered token accepted")
+	}
+	if _, err := ParseAccessToken(NewKeySet(), oldToken, "", now); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("unknown key error = %v", err)
+	}
+}
+
+func TestJWTSessionManagerRefreshRotation(t *testing.T) {
+	ctx := context.Background()
+	keys := NewKeySet()
+	keys.AddPrivate("k1", testKey(t))
+	var audited []AuditEvent
+	manager, err := NewJWTSessionManager(JWTConfig{
+		Keys:    keys,
+		Refresh: newMemoryRefreshStore(),
+		Role:    "customer",
+		Audit:   func(ctx context.Context, event AuditEvent) { audited = append(audited, event) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := uuid.New()
+
+	pair, err := manager.CreatePair(ctx, userID, time.Hour)
+	if err != nil {
+		t.Fatalf("CreatePair() error = %v", err)
+	}
+	if got, err := manager.Validate(ctx, pair.AccessToken); err != nil || got != userID {
+		t.Fatalf("Validate() = %v, %v", got, err)
+	}
+	if pair.AccessExpiresAt.Sub(time.Now()) > DefaultAccessTTL {
+		t.Error("access token outlives AccessTTL")
+	}
+
+	next, err := manager.Refresh(ctx, pair.RefreshToken)
+	if err != nil || next.RefreshToken == pair.RefreshToken {
+		t.Fatalf("Refresh() = %+v, %v", next, err)
+	}
+
+	// Replaying the first refresh token revokes the family, including the new token.
+	if _, err := manager.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused Refresh() error = %v", err)
+	}
+	if len(audited) != 1 || audited[0].Type != AuditRefreshReuse || audited[0].UserID != userID {
+		t.Errorf("audit = %+v", audited)
+	}
+	if _, err := manager.Refresh(ctx, next.RefreshToken); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Refresh() after reuse error = %v", err)
+	}
+
+	other, _ := NewJWTSessionManager(JWTConfig{Keys: keys, Refresh: newMemoryRefreshStore(), Role: "courier"})
+	if _, err := other.Validate(ctx, pair.AccessToken); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("token of another role accepted: %v", err)
+	}
+
+	fresh, _ := manager.CreatePair(ctx, userID, 0)
+	// Another service sharing the store can neither refresh nor revoke
+	// the customer's login, even for the same user ID.
+	courier, _ := NewJWTSessionManager(JWTConfig{Keys: keys, Refresh: manager.cfg.Refresh, Role: "courier"})
+	if _, err := courier.Refresh(ctx, fresh.RefreshToken); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Refresh() by another role error = %v, want ErrInvalidSession", err)
+	}
+	_ = courier.RevokeAll(ctx, userID)
+	fresh, err = manager.Refresh(ctx, fresh.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() after foreign attempts error = %v", err)
+	}
+	_ = manager.RevokeAll(ctx, userID)
+	if _, err := manager.Refresh(ctx, fresh.RefreshToken); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Refresh() after RevokeAll error = %v", err)
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := GenerateKeyFile(dir, "2026-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := GenerateKeyFile(dir, "2026-10-19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	kid, key, err := keys.signingKey()
+	if err != nil || kid != "2026-10-19" || !key.Equal(latest) {
+		t.Errorf("signing key = %s, %v", kid, err)
+	}
+}
+
+func TestServiceLoginReturnsRefreshToken(t *testing.T) {
+	keys := NewKeySet()
+	keys.AddPrivate("k1", testKey(t))
+	sessions, _ := NewJWTSessionManager(JWTConfig{Keys: keys, Refresh: newMemoryRefreshStore()})
+	store := &mockStore{users: map[string]StoredUser{
+		"0xabc": {ID: uuid.New(), WalletAddress: "0xabc", PasswordHash: "hashed-secret1"},
+	}}
+	service, _ := NewService(ServiceConfig{Store: store, Hasher: &mockHasher{}, Sessions: sessions})
+	ctx := context.Background()
+
+	res, err := service.Login(ctx, "0xabc", "secret1")
+	if err != nil || res.RefreshToken == "" {
+		t.Fatalf("Login() = %+v, %v", res, err)
+	}
+	pair, err := service.Refresh(ctx, res.RefreshToken)
+	if err != nil || pair.AccessToken == "" {
+		t.Fatalf("Refresh() = %+v, %v", pair, err)
+	}
+}
@@ -6,18 +6,27 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
+// RedisSessionManager stores session:<role>:<token> -> "<role>:<user ID>"
+// and keeps the tokens of each user in user_sessions:<role>:<user ID> so
+// they can be revoked together. The services share Redis and a wallet has
+// the same user ID on each of them, so sessions are kept per role and
+// Validate rejects sessions another role issued.
 type RedisSessionManager struct {
 	client *redis.Client
+	role   string
 }
 
-func NewRedisSessionManager(client *redis.Client) *RedisSessionManager {
-	return &RedisSessionManager{client: client}
+// NewRedisSessionManager issues and accepts sessions of role, e.g.
+// "customer".
+func NewRedisSessionManager(client *redis.Client, role string) *RedisSessionManager {
+	return &RedisSessionManager{client: client, role: role}
 }
 
 func (m *RedisSessionManager) Create(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
@@ -32,10 +41,78 @@ func (m *RedisSessionManager) Create(ctx context.Context, userID uuid.UUID, ttl
 		return "", time.Time{}, fmt.Errorf("auth: failed to generate session token: %w", err)
 	}
 	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
-	key := fmt.Sprintf("session:%s", token)
+	key := sessionKey(m.role, token)
 	expiresAt := time.Now().Add(ttl)
-	if err := m.client.Set(ctx, key, userID.String(), ttl).Err(); err != nil {
+	indexKey := userSessionsKey(m.role, userID)
+	_, err := m.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, m.role+":"+userID.String(), ttl)
+		pipe.SAdd(ctx, indexKey, token)
+		// The index lives as long as the newest session; stale members are
+		// harmless because their session keys have expired.
+		pipe.Expire(ctx, indexKey, ttl)
+		return nil
+	})
+	if err != nil {
 		return "", time.Time{}, fmt.Errorf("auth: failed to store session token: %w", err)
 	}
 	return token, expiresAt, nil
 }
+
+func (m *RedisSessionManager) Validate(ctx context.Context, token string) (uuid.UUID, error) {
+	if m == nil || m.client == nil {
+		return uuid.Nil, errors.New("auth: redis client is not initialized")
+	}
+	if token == "" {
+		return uuid.Nil, ErrInvalidSession
+	}
+	value, err := m.client.Get(ctx, sessionKey(m.role, token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return uuid.Nil, ErrInvalidSession
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("auth: failed to load session: %w", err)
+	}
+	return parseSessionValue(m.role, value)
+}
+
+func (m *RedisSessionManager) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	if m == nil || m.client == nil {
+		return errors.New("auth: redis client is not initialized")
+	}
+	indexKey := userSessionsKey(m.role, userID)
+	tokens, err := m.client.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return fmt.Errorf("auth: failed to list sessions: %w", err)
+	}
+	keys := make([]string, 0, len(tokens)+1)
+	for _, token := range tokens {
+		keys = append(keys, sessionKey(m.role, token))
+	}
+	keys = append(keys, indexKey)
+	if err := m.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("auth: failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// parseSessionValue returns the user of a stored session, or
+// ErrInvalidSession when another role issued it.
+func parseSessionValue(role, value string) (uuid.UUID, error) {
+	sessionRole, user, ok := strings.Cut(value, ":")
+	if !ok || sessionRole != role {
+		return uuid.Nil, ErrInvalidSession
+	}
+	userID, err := uuid.Parse(user)
+	if err != nil {
+		return uuid.Nil, ErrInvalidSession
+	}
+	return userID, nil
+}
+
+func sessionKey(role, token string) string {
+	return fmt.Sprintf("session:%s:%s", role, token)
+}
+
+func userSessionsKey(role string, userID uuid.UUID) string {
+	return fmt.Sprintf("user_sessions:%s:%s", role, userID)
+}
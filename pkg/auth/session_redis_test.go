@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRedisSessionsPerRole(t *testing.T) {
+	userID := uuid.New()
+	if sessionKey("customer", "t") == sessionKey("restaurant", "t") {
+		t.Error("roles share session keys")
+	}
+	if userSessionsKey("customer", userID) == userSessionsKey("restaurant", userID) {
+		t.Error("roles share session indexes")
+	}
+
+	if got, err := parseSessionValue("restaurant", "restaurant:"+userID.String()); err != nil || got != userID {
+		t.Errorf("own session = %v, %v", got, err)
+	}
+	for _, value := range []string{
+		"customer:" + userID.String(),
+		// Sessions stored before the role was recorded.
+		userID.String(),
+		"restaurant:not-a-uuid",
+	} {
+		if _, err := parseSessionValue("restaurant", value); !errors.Is(err, ErrInvalidSession) {
+			t.Errorf("parseSessionValue(%q) error = %v, want ErrInvalidSession", value, err)
+		}
+	}
+}
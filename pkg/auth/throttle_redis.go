@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript drops entries older than the window and admits the
+// attempt if fewer than limit remain. It returns 0 when admitted, otherwise
+// the milliseconds until the oldest entry leaves the window.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+if redis.call('ZCARD', key) >= limit then
+  local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+  return math.max(1, tonumber(oldest[2]) + window - now)
+end
+redis.call('ZADD', key, now, ARGV[4])
+redis.call('PEXPIRE', key, window)
+return 0
+`)
+
+// failureScript records a failure and returns the failures in the window.
+var failureScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+redis.call('ZADD', key, now, ARGV[3])
+redis.call('PEXPIRE', key, window)
+return redis.call('ZCARD', key)
+`)
+
+// RedisLoginThrottle keeps sliding windows as sorted sets of attempt times:
+//
+//	login:attempts:wallet:<wallet>, login:attempts:ip:<ip>  admitted attempts
+//	login:failures:<wallet>                                 failed attempts
+//	login:delay:<wallet>, login:lock:<wallet>               TTL-only blocks
+type RedisLoginThrottle struct {
+	client *redis.Client
+	cfg    ThrottleConfig
+	audit  AuditFunc
+	now    func() time.Time
+}
+
+func NewRedisLoginThrottle(client *redis.Client, cfg ThrottleConfig, audit AuditFunc) *RedisLoginThrottle {
+	if cfg == (ThrottleConfig{}) {
+		cfg = DefaultThrottleConfig
+	}
+	if audit == nil {
+		audit = LogAudit
+	}
+	return &RedisLoginThrottle{client: client, cfg: cfg, audit: audit, now: time.Now}
+}
+
+func (t *RedisLoginThrottle) Allow(ctx context.Context, walletAddress, clientIP string) error {
+	if t == nil || t.client == nil {
+		return errors.New("auth: login throttle is not initialized")
+	}
+	wallet := normalizeWallet(walletAddress)
+	for _, block := range []struct {
+		key    string
+		locked bool
+	}{
+		{key: "login:lock:" + wallet, locked: true},
+		{key: "login:delay:" + wallet},
+	} {
+		ttl, err := t.client.PTTL(ctx, block.key).Result()
+		if err != nil {
+			return fmt.Errorf("auth: throttle lookup failed: %w", err)
+		}
+		if ttl > 0 {
+			return &ThrottleError{RetryAfter: ttl, Locked: block.locked}
+		}
+	}
+
+	if err := t.admit(ctx, "login:attempts:wallet:"+wallet, t.cfg.WalletAttempts); err != nil {
+		return err
+	}
+	if clientIP != "" {
+		if err := t.admit(ctx, "login:attempts:ip:"+clientIP, t.cfg.IPAttempts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *RedisLoginThrottle) Failure(ctx context.Context, walletAddress, clientIP string) error {
+	if t == nil || t.client == nil {
+		return errors.New("auth: login throttle is not initialized")
+	}
+	wallet := normalizeWallet(walletAddress)
+	now := t.now()
+	failures, err := failureScript.Run(ctx, t.client, []string{"login:failures:" + wallet},
+		now.UnixMilli(), t.cfg.Window.Milliseconds(), uuid.NewString()).Int()
+	if err != nil {
+		return fmt.Errorf("auth: record login failure: %w", err)
+	}
+
+	if t.cfg.LockoutFailures > 0 && failures >= t.cfg.LockoutFailures {
+		if err := t.client.Set(ctx, "login:lock:"+wallet, strconv.Itoa(failures), t.cfg.LockoutDuration).Err(); err != nil {
+			return fmt.Errorf("auth: lock account: %w", err)
+		}
+		// Start over after the lockout instead of locking again on the next miss.
+		t.client.Del(ctx, "login:failures:"+wallet, "login:delay:"+wallet)
+		t.audit(ctx, AuditEvent{
+			Type:          AuditLoginLockout,
+			WalletAddress: walletAddress,
+			ClientIP:      clientIP,
+			Failures:      failures,
+			Until:         now.Add(t.cfg.LockoutDuration),
+			At:            now,
+		})
+		return nil
+	}
+	if delay := t.cfg.failureDelay(failures); delay > 0 {
+		if err := t.client.Set(ctx, "login:delay:"+wallet, strconv.Itoa(failures), delay).Err(); err != nil {
+			return fmt.Errorf("auth: set login delay: %w", err)
+		}
+	}
+	return nil
+}
+
+func (t *RedisLoginThrottle) Success(ctx context.Context, walletAddress, clientIP string) error {
+	if t == nil || t.client == nil {
+		return errors.New("auth: login throttle is not initialized")
+	}
+	wallet := normalizeWallet(walletAddress)
+	return t.client.Del(ctx, "login:failures:"+wallet, "login:delay:"+wallet).Err()
+}
+
+func (t *RedisLoginThrottle) admit(ctx context.Context, key string, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+	wait, err := slidingWindowScript.Run(ctx, t.client, []string{key},
+		t.now().UnixMilli(), t.cfg.Window.Milliseconds(), limit, uuid.NewString()).Int64()
+	if err != nil {
+		return fmt.Errorf("auth: throttle check failed: %w", err)
+	}
+	if wait > 0 {
+		return &ThrottleError{RetryAfter: time.Duration(wait) * time.Millisecond}
+	}
+	return nil
+}
+
+func normalizeWallet(walletAddress string) string {
+	return strings.ToLower(strings.TrimSpace(walletAddress))
+}
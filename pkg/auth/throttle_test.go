@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFailureDelay(t *testing.T) {
+	cfg := DefaultThrottleConfig
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 3, want: 0},
+		{failures: 4, want: time.Second},
+		{failures: 5, want: 2 * time.Second},
+		{failures: 7, want: 8 * time.Second},
+		{failures: 20, want: cfg.MaxDelay},
+	}
+	for _, tt := range tests {
+		if got := cfg.failureDelay(tt.failures); got != tt.want {
+			t.Errorf("failureDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := error(&ThrottleError{RetryAfter: 1500 * time.Millisecond, Locked: true})
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatal("ThrottleError does not match ErrTooManyAttempts")
+	}
+	d, ok := RetryAfter(err)
+	if !ok || RetryAfterSeconds(d) != "2" {
+		t.Errorf("RetryAfter() = %v, %v", d, ok)
+	}
+	if _, ok := RetryAfter(ErrInvalidCredentials); ok {
+		t.Error("RetryAfter() matched a non-throttle error")
+	}
+}
+
+func TestClientIPFromRequest(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, "/login", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := ClientIPFromRequest(r); got != "203.0.113.7" {
+		t.Errorf("ClientIPFromRequest() = %q", got)
+	}
+}
+
+// fakeThrottle locks after lockAt failures, like RedisLoginThrottle without Redis.
+type fakeThrottle struct {
+	lockAt    int
+	failures  map[string]int
+	successes int
+	ips       []string
+}
+
+func (f *fakeThrottle) Allow(ctx context.Context, walletAddress, clientIP string) error {
+	f.ips = append(f.ips, clientIP)
+	if f.failures[walletAddress] >= f.lockAt {
+		return &ThrottleError{RetryAfter: time.Minute, Locked: true}
+	}
+	return nil
+}
+
+func (f *fakeThrottle) Failure(ctx context.Context, walletAddress, clientIP string) error {
+	f.failures[walletAddress]++
+	return nil
+}
+
+func (f *fakeThrottle) Success(ctx context.Context, walletAddress, clientIP string) error {
+	f.successes++
+	f.failures[walletAddress] = 0
+	return nil
+}
+
+type countingHasher struct {
+	mockHasher
+	verifies int
+}
+
+func (h *countingHasher) Verify(password string, salt []byte, expected string) bool {
+	h.verifies++
+	return h.mockHasher.Verify(password, salt, expected)
+}
+
+func TestLoginThrottled(t *testing.T) {
+	store := &mockStore{users: map[string]StoredUser{
+		"0xabc": {ID: uuid.New(), WalletAddress: "0xabc", PasswordHash: "hashed-secret1"},
+	}}
+	hasher := &countingHasher{}
+	throttle := &fakeThrottle{lockAt: 3, failures: map[string]int{}}
+	service, err := NewService(ServiceConfig{Store: store, Hasher: hasher, Sessions: &mockSessions{}, Throttle: throttle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithClientIP(context.Background(), "203.0.113.7")
+
+	if _, err := service.Login(ctx, "0xabc", "secret1"); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if throttle.successes != 1 || throttle.ips[0] != "203.0.113.7" {
+		t.Fatalf("throttle saw successes=%d ips=%v", throttle.successes, throttle.ips)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, _ = service.Login(ctx, "0xabc", "wrong")
+	}
+	verifies := hasher.verifies
+	_, err = service.Login(ctx, "0xabc", "secret1")
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("Login() after failures error = %v, want ErrTooManyAttempts", err)
+	}
+	if hasher.verifies != verifies {
+		t.Error("throttled login still verified the password")
+	}
+
+	// Unknown wallets are counted as failures as well.
+	_, _ = service.Login(ctx, "0xnobody", "x")
+	if throttle.failures["0xnobody"] != 1 {
+		t.Errorf("unknown wallet failures = %d, want 1", throttle.failures["0xnobody"])
+	}
+}
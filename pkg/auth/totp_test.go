@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1; the RFC lists 8 digits, we use the last 6.
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(secret, totpStep(time.Unix(tt.unix, 0))); got != tt.want {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1234567890, 0)
+	step := totpStep(now)
+
+	if got, ok := verifyTOTP(secret, totpCode(secret, step-1), now, 0); !ok || got != step-1 {
+		t.Errorf("previous step code rejected: %d, %v", got, ok)
+	}
+	if _, ok := verifyTOTP(secret, totpCode(secret, step-2), now, 0); ok {
+		t.Error("code from two steps ago accepted")
+	}
+	if _, ok := verifyTOTP(secret, totpCode(secret, step), now, step); ok {
+		t.Error("already used step accepted")
+	}
+	if _, ok := verifyTOTP(secret, "12345", now, 0); ok {
+		t.Error("short code accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("YAFDS Restaurant", "0xabc", []byte("12345678901234567890"))
+	for _, part := range []string{"otpauth://totp/YAFDS%20Restaurant:0xabc?", "secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "issuer=YAFDS+Restaurant", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("TOTPURI() = %s, missing %s", uri, part)
+		}
+	}
+}
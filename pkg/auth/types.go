@@ -8,7 +8,15 @@ import (
 	"github.com/google/uuid"
 )
 
-var ErrInvalidCredentials = errors.New("invalid credentials")
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidSession     = errors.New("auth: invalid or expired session")
+	ErrInvalidResetToken  = errors.New("auth: invalid or expired reset token")
+	ErrInvalidChallenge   = errors.New("auth: invalid or expired wallet challenge")
+	// ErrPasswordResetDisabled is returned when no way to deliver reset
+	// tokens is configured.
+	ErrPasswordResetDisabled = errors.New("auth: password reset is disabled")
+)
 
 type Hasher interface {
 	Hash(password string) (hash string, salt []byte, err error)
@@ -17,6 +25,10 @@ type Hasher interface {
 
 type SessionManager interface {
 	Create(ctx context.Context, userID uuid.UUID, ttl time.Duration) (token string, expiration time.Time, err error)
+	// Validate returns the user of a live session or ErrInvalidSession.
+	Validate(ctx context.Context, token string) (uuid.UUID, error)
+	// RevokeAll ends every session of the user.
+	RevokeAll(ctx context.Context, userID uuid.UUID) error
 }
 
 type Validator func(ctx context.Context, data RegisterInput) error
@@ -24,33 +36,44 @@ type Validator func(ctx context.Context, data RegisterInput) error
 type Store interface {
 	SaveWithPassword(ctx context.Context, data RegisterInput, passwordHash string, passwordSalt []byte) error
 	LoadByWalletAddress(ctx context.Context, walletAddress string) (StoredUser, error)
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, passwordSalt []byte) error
+	// UpdateRecoveryEmail stores an address the user has confirmed.
+	UpdateRecoveryEmail(ctx context.Context, userID uuid.UUID, email string) error
 }
 
 type RegisterInput struct {
 	ID            uuid.UUID
 	Name          string
 	WalletAddress string
-	Address       string
 	Password      string
-	TransportType string
-	IsActive      bool
+	// Fields holds the columns a service adds to its users, such as a
+	// restaurant's address or a courier's transport_type.
+	Fields map[string]any
 }
 
 type StoredUser struct {
 	ID            uuid.UUID
 	Name          string
 	WalletAddress string
-	Address       string
 	PasswordHash  string
 	PasswordSalt  []byte
-	TransportType string
-	IsActive      bool
+	Fields        map[string]any
+	// RecoveryEmail is the confirmed address reset tokens go to; empty
+	// until the user confirms one.
+	RecoveryEmail string
 }
 
 type LoginResult struct {
 	User       StoredUser
 	Token      string
 	Expiration time.Time
+	// RefreshToken is only set when the session manager issues token pairs.
+	RefreshToken      string
+	RefreshExpiration time.Time
+	// MFAToken is set instead of a session when the user has two-factor
+	// authentication; VerifyMFA trades it and a code for the session.
+	MFAToken      string
+	MFAExpiration time.Time
 }
 
 type ServiceConfig struct {
@@ -59,6 +82,26 @@ type ServiceConfig struct {
 	Sessions   SessionManager
 	Validator  Validator
 	SessionTTL time.Duration
+	// Throttle is optional; without it Login is not rate limited.
+	Throttle LoginThrottle
+	// ResetTokens and ResetSender enable the password reset flow.
+	ResetTokens ResetTokenStore
+	ResetSender ResetSender
+	ResetTTL    time.Duration
+	// ResetLimiter caps reset requests with ResetLimits; without it they
+	// are not rate limited.
+	ResetLimiter RateLimiter
+	ResetLimits  ResetLimits
+	// EmailTokens and EmailSender let users confirm a recovery email;
+	// without them no user has one and reset tokens reach nobody.
+	EmailTokens   EmailTokenStore
+	EmailSender   EmailSender
+	EmailTokenTTL time.Duration
+	// WalletLogin enables sign-in with a wallet signature when its
+	// Challenges store is set.
+	WalletLogin WalletLoginConfig
+	// MFA enables optional TOTP two-factor login.
+	MFA MFAConfig
 }
 
 var NoopValidator Validator = func(context.Context, RegisterInput) error { return nil }
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const DefaultChallengeTTL = 5 * time.Minute
+
+var ErrInvalidWalletAddress = errors.New("auth: wallet address must be 0x followed by 40 hex digits")
+
+var walletAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// ChallengeStore keeps issued wallet challenges by nonce until they are
+// used or expire.
+type ChallengeStore interface {
+	Save(ctx context.Context, nonce string, message string, ttl time.Duration) error
+	// Consume deletes the challenge and returns its message, or
+	// ErrInvalidChallenge; only one caller can consume a given nonce.
+	Consume(ctx context.Context, nonce string) (string, error)
+}
+
+// WalletLoginConfig describes the challenges a service issues. The fields
+// end up in the signed message, so a signature made for another site or
+// chain is rejected.
+type WalletLoginConfig struct {
+	Challenges ChallengeStore
+	// Domain is the host the user signs in to, e.g. "app.example.com".
+	Domain    string
+	URI       string
+	ChainID   int
+	Statement string
+	TTL       time.Duration
+}
+
+func (c WalletLoginConfig) withDefaults() WalletLoginConfig {
+	if c.Domain == "" {
+		c.Domain = "localhost"
+	}
+	if c.URI == "" {
+		c.URI = "http://" + c.Domain
+	}
+	if c.ChainID <= 0 {
+		c.ChainID = 1
+	}
+	if c.Statement == "" {
+		c.Statement = "Sign in to YAFDS."
+	}
+	if c.TTL <= 0 {
+		c.TTL = DefaultChallengeTTL
+	}
+	return c
+}
+
+// WalletChallenge is the message a wallet has to sign to log in.
+type WalletChallenge struct {
+	Message   string
+	Nonce     string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// walletMessage holds the fields of an EIP-4361 (Sign-In with Ethereum)
+// message that the server checks.
+type walletMessage struct {
+	Domain    string
+	Address   string
+	URI       string
+	ChainID   int
+	Nonce     string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+func formatWalletMessage(cfg WalletLoginConfig, address, nonce string, issuedAt, expiresAt time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s wants you to sign in with your Ethereum account:\n%s\n\n", cfg.Domain, address)
+	fmt.Fprintf(&b, "%s\n\n", cfg.Statement)
+	fmt.Fprintf(&b, "URI: %s\nVersion: 1\nChain ID: %d\nNonce: %s\n", cfg.URI, cfg.ChainID, nonce)
+	fmt.Fprintf(&b, "Issued At: %s\nExpiration Time: %s", issuedAt.UTC().Format(time.RFC3339), expiresAt.UTC().Format(time.RFC3339))
+	return b.String()
+}
+
+func parseWalletMessage(message string) (walletMessage, error) {
+	lines := strings.Split(message, "\n")
+	if len(lines) < 2 {
+		return walletMessage{}, ErrInvalidChallenge
+	}
+	domain, ok := strings.CutSuffix(lines[0], " wants you to sign in with your Ethereum account:")
+	if !ok {
+		return walletMessage{}, ErrInvalidChallenge
+	}
+	msg := walletMessage{Domain: domain, Address: lines[1]}
+	for _, line := range lines[2:] {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Chain ID":
+			msg.ChainID, err = strconv.Atoi(value)
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			msg.ExpiresAt, err = time.Parse(time.RFC3339, value)
+		}
+		if err != nil {
+			return walletMessage{}, fmt.Errorf("%w: bad %s", ErrInvalidChallenge, key)
+		}
+	}
+	if msg.Nonce == "" || msg.ExpiresAt.IsZero() {
+		return walletMessage{}, ErrInvalidChallenge
+	}
+	return msg, nil
+}
+
+// WalletChallenge issues a single-use message for the wallet to sign.
+// Challenges are issued for any well-formed address, registered or not, so
+// the endpoint does not reveal which wallets have accounts.
+func (s *Service) WalletChallenge(ctx context.Context, walletAddress string) (WalletChallenge, error) {
+	logging.FromContext(ctx).Info("auth: wallet challenge requested", "wallet_address", walletAddress)
+	if s == nil {
+		return WalletChallenge{}, errors.New("auth: service is nil")
+	}
+	if s.wallet.Challenges == nil {
+		return WalletChallenge{}, errors.New("auth: wallet login is not configured")
+	}
+	if !walletAddressPattern.MatchString(walletAddress) {
+		return WalletChallenge{}, ErrInvalidWalletAddress
+	}
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return WalletChallenge{}, fmt.Errorf("auth: failed to generate nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	issuedAt := time.Now().UTC().Truncate(time.Second)
+	expiresAt := issuedAt.Add(s.wallet.TTL)
+	message := formatWalletMessage(s.wallet, walletAddress, nonce, issuedAt, expiresAt)
+	if err := s.wallet.Challenges.Save(ctx, nonce, message, s.wallet.TTL); err != nil {
+		return WalletChallenge{}, fmt.Errorf("auth: failed to store wallet challenge: %w", err)
+	}
+	return WalletChallenge{Message: message, Nonce: nonce, IssuedAt: issuedAt, ExpiresAt: expiresAt}, nil
+}
+
+// LoginWithWallet logs in the owner of the wallet that signed an issued
+// challenge. The nonce is used up by the first attempt, valid or not, so a
+// captured message and signature cannot be replayed.
+func (s *Service) LoginWithWallet(ctx context.Context, message string, signature string) (LoginResult, error) {
+	if s == nil {
+		return LoginResult{}, errors.New("auth: service is nil")
+	}
+	if s.wallet.Challenges == nil {
+		return LoginResult{}, errors.New("auth: wallet login is not configured")
+	}
+	msg, err := parseWalletMessage(message)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	issued, err := s.wallet.Challenges.Consume(ctx, msg.Nonce)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if issued != message {
+		logging.FromContext(ctx).Warn("auth: wallet login with altered challenge", "nonce", msg.Nonce)
+		return LoginResult{}, ErrInvalidChallenge
+	}
+	if msg.Domain != s.wallet.Domain || msg.ChainID != s.wallet.ChainID || time.Now().After(msg.ExpiresAt) {
+		return LoginResult{}, ErrInvalidChallenge
+	}
+
+	sig, err := DecodeSignature(signature)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	recovered, err := RecoverAddress(PersonalMessageHash(message), sig)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if !strings.EqualFold(recovered, msg.Address) {
+		logging.FromContext(ctx).Warn("auth: wallet signature made by another address", "wallet_address", msg.Address, "recovered_address", recovered)
+		return LoginResult{}, ErrInvalidSignature
+	}
+
+	user, err := s.store.LoadByWalletAddress(ctx, msg.Address)
+	if err != nil {
+		logging.FromContext(ctx).Info("auth: wallet login for unknown wallet address", "wallet_address", msg.Address, "error", err)
+		return LoginResult{}, ErrInvalidCredentials
+	}
+	return s.finishLogin(ctx, user)
+}
+
+type RedisChallengeStore struct {
+	client *redis.Client
+}
+
+func NewRedisChallengeStore(client *redis.Client) *RedisChallengeStore {
+	return &RedisChallengeStore{client: client}
+}
+
+func (s *RedisChallengeStore) Save(ctx context.Context, nonce string, message string, ttl time.Duration) error {
+	if s == nil || s.client == nil {
+		return errors.New("auth: redis client is not initialized")
+	}
+	return s.client.Set(ctx, challengeKey(nonce), message, ttl).Err()
+}
+
+func (s *RedisChallengeStore) Consume(ctx context.Context, nonce string) (string, error) {
+	if s == nil || s.client == nil {
+		return "", errors.New("auth: redis client is not initialized")
+	}
+	message, err := s.client.GetDel(ctx, challengeKey(nonce)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrInvalidChallenge
+	}
+	return message, err
+}
+
+func challengeKey(nonce string) string {
+	return "wallet_challenge:" + nonce
+}
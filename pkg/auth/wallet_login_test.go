@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryChallenges struct {
+	messages map[string]string
+}
+
+func (m *memoryChallenges) Save(ctx context.Context, nonce string, message string, ttl time.Duration) error {
+	m.messages[nonce] = message
+	return nil
+}
+
+func (m *memoryChallenges) Consume(ctx context.Context, nonce string) (string, error) {
+	message, ok := m.messages[nonce]
+	if !ok {
+		return "", ErrInvalidChallenge
+	}
+	delete(m.messages, nonce)
+	return message, nil
+}
+
+func TestLoginWithWallet(t *testing.T) {
+	ctx := context.Background()
+	key := big.NewInt(0xC0FFEE)
+	wallet := publicKeyAddress(ecMul(secpGenerator(), key))
+	// Registered addresses keep whatever case the user typed.
+	registered := "0x" + strings.ToUpper(wallet[2:])
+	userID := uuid.New()
+	store := &mockStore{users: map[string]StoredUser{
+		registered: {ID: userID, WalletAddress: registered},
+	}}
+	service, err := NewService(ServiceConfig{
+		Store:    store,
+		Hasher:   &mockHasher{},
+		Sessions: &mockSessions{},
+		WalletLogin: WalletLoginConfig{
+			Challenges: &memoryChallenges{messages: map[string]string{}},
+			Domain:     "shop.example",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := service.WalletChallenge(ctx, registered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(challenge.Message, "shop.example wants you to sign in") || !strings.Contains(challenge.Message, "Nonce: "+challenge.Nonce) {
+		t.Fatalf("unexpected challenge message:\n%s", challenge.Message)
+	}
+	signature := "0x" + hex.EncodeToString(signPersonal(t, key, challenge.Message))
+
+	result, err := service.LoginWithWallet(ctx, challenge.Message, signature)
+	if err != nil {
+		t.Fatalf("LoginWithWallet() error = %v", err)
+	}
+	if result.User.ID != userID || result.Token == "" {
+		t.Errorf("LoginWithWallet() = %+v", result)
+	}
+
+	// The nonce is single use.
+	if _, err := service.LoginWithWallet(ctx, challenge.Message, signature); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("replayed login error = %v, want ErrInvalidChallenge", err)
+	}
+
+	// Another key cannot sign for the wallet.
+	challenge, _ = service.WalletChallenge(ctx, registered)
+	forged := "0x" + hex.EncodeToString(signPersonal(t, big.NewInt(42), challenge.Message))
+	if _, err := service.LoginWithWallet(ctx, challenge.Message, forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("forged signature error = %v, want ErrInvalidSignature", err)
+	}
+
+	// The message must be the one issued, not a lookalike with a later expiry.
+	challenge, _ = service.WalletChallenge(ctx, registered)
+	altered := challenge.Message[:strings.LastIndex(challenge.Message, "Expiration Time: ")] + "Expiration Time: 2999-01-01T00:00:00Z"
+	signature = "0x" + hex.EncodeToString(signPersonal(t, key, altered))
+	if _, err := service.LoginWithWallet(ctx, altered, signature); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("altered message error = %v, want ErrInvalidChallenge", err)
+	}
+
+	if _, err := service.WalletChallenge(ctx, "0x123"); !errors.Is(err, ErrInvalidWalletAddress) {
+		t.Error("WalletChallenge() accepted a malformed address")
+	}
+}
+
+func TestParseWalletMessage(t *testing.T) {
+	cfg := WalletLoginConfig{Domain: "shop.example"}.withDefaults()
+	issued := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	message := formatWalletMessage(cfg, "0xabc", "n0nce", issued, issued.Add(time.Minute))
+
+	msg, err := parseWalletMessage(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := walletMessage{Domain: "shop.example", Address: "0xabc", URI: "http://shop.example", ChainID: 1,
+		Nonce: "n0nce", IssuedAt: issued, ExpiresAt: issued.Add(time.Minute)}
+	if msg != want {
+		t.Errorf("parseWalletMessage() = %+v, want %+v", msg, want)
+	}
+	if _, err := parseWalletMessage("hello"); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("parseWalletMessage(garbage) error = %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+// Package config loads service settings into typed structs.
+//
+// Fields are tagged with the variable they come from and how to check it:
+//
+//	Port     int           `env:"COURIER_PORT" default:"8090" min:"1" max:"65535"`
+//	Password string        `env:"DB_PASSWORD" secret:"true"`
+//	TTL      time.Duration `env:"SESSION_TTL" default:"30m" min:"1s"`
+//	User     string        `env:"DB_USER" required:"true"`
+//	Format   string        `env:"LOG_FORMAT" default:"text" oneof:"text json"`
+//
+// Values are taken from the process environment, then the .env file, then
+// the YAML file, then the default. Untagged struct fields are walked, so
+// services can embed the shared Postgres, Redis, Auth, HTTP and Log sections.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEnvFile is where each service keeps its .env, relative to
+	// its working directory.
+	DefaultEnvFile = "config/.env"
+	// DefaultYAMLFile is read when present; CONFIG_FILE points elsewhere.
+	DefaultYAMLFile = "config/config.yaml"
+	// PrintFlag makes a service print its configuration and exit.
+	PrintFlag = "--print-config"
+)
+
+// Options says where Load looks. Empty fields use the defaults; a file
+// that does not exist is skipped unless CONFIG_FILE names it.
+type Options struct {
+	EnvFile  string
+	YAMLFile string
+	// Lookup replaces os.LookupEnv, e.g. in tests.
+	Lookup func(key string) (string, bool)
+}
+
+// FieldError is one setting that could not be used.
+type FieldError struct {
+	Key    string
+	Reason string
+}
+
+func (e FieldError) Error() string {
+	return e.Key + " " + e.Reason
+}
+
+// ValidationError lists every invalid setting, so one run shows them all.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		reasons[i] = fe.Error()
+	}
+	return "config: " + strings.Join(reasons, "; ")
+}
+
+// Load fills dst, a pointer to a struct, and validates it. Unreadable or
+// malformed files are returned as is; bad values as a *ValidationError.
+func Load(dst any, opts Options) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Pointer || target.Elem().Kind() != reflect.Struct {
+		return errors.New("config: destination must be a pointer to a struct")
+	}
+	lookup := opts.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	envFile := opts.EnvFile
+	if envFile == "" {
+		envFile = DefaultEnvFile
+	}
+	dotenv, err := readDotenv(envFile, lookup)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	yamlFile, explicit := lookup("CONFIG_FILE")
+	if !explicit || yamlFile == "" {
+		yamlFile, explicit = opts.YAMLFile, opts.YAMLFile != ""
+		if !explicit {
+			yamlFile = DefaultYAMLFile
+		}
+	}
+	yaml, err := readYAML(yamlFile)
+	if err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+		return err
+	}
+
+	value := func(key string) (string, bool) {
+		if v, ok := lookup(key); ok && v != "" {
+			return v, true
+		}
+		if v, ok := dotenv[key]; ok && v != "" {
+			return v, true
+		}
+		v, ok := yaml[key]
+		return v, ok && v != ""
+	}
+
+	var errs []FieldError
+	walk(target.Elem(), func(field reflect.StructField, v reflect.Value) {
+		key := field.Tag.Get("env")
+		raw, ok := value(key)
+		if !ok {
+			raw, ok = field.Tag.Lookup("default")
+		}
+		if !ok || raw == "" {
+			if field.Tag.Get("required") == "true" {
+				errs = append(errs, FieldError{Key: key, Reason: "is required"})
+			}
+			return
+		}
+		if err := set(v, raw); err != nil {
+			errs = append(errs, FieldError{Key: key, Reason: err.Error()})
+			return
+		}
+		if reason := checkRange(field, v); reason != "" {
+			errs = append(errs, FieldError{Key: key, Reason: reason})
+		}
+		if reason := checkOneOf(field, raw); reason != "" {
+			errs = append(errs, FieldError{Key: key, Reason: reason})
+		}
+	})
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// PrintRequested reports whether args, usually os.Args[1:], ask for
+// PrintFlag.
+func PrintRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == PrintFlag || arg == "-print-config" {
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls fn for every env-tagged field, descending into untagged
+// structs.
+func walk(v reflect.Value, fn func(reflect.StructField, reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("env"); ok {
+			fn(field, v.Field(i))
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), fn)
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func set(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := parseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", raw)
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("has unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// parseDuration accepts Go durations ("30m") and, as the .env files have
+// always used, plain seconds ("1200").
+func parseDuration(raw string) (time.Duration, error) {
+	if d, err := time.ParseDuration(raw); err == nil {
+		return d, nil
+	}
+	if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Duration(sec) * time.Second, nil
+	}
+	return 0, fmt.Errorf("must be a duration such as 30s or 5m, got %q", raw)
+}
+
+// checkRange applies the min and max tags to numbers and durations.
+func checkRange(field reflect.StructField, v reflect.Value) string {
+	for _, bound := range []string{"min", "max"} {
+		limit, ok := field.Tag.Lookup(bound)
+		if !ok {
+			continue
+		}
+		var value, l float64
+		switch {
+		case v.Type() == durationType:
+			d, err := parseDuration(limit)
+			if err != nil {
+				return "has a bad " + bound + " tag"
+			}
+			value, l = float64(v.Int()), float64(d)
+		case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+			n, err := strconv.ParseFloat(limit, 64)
+			if err != nil {
+				return "has a bad " + bound + " tag"
+			}
+			value, l = float64(v.Int()), n
+		case v.Kind() == reflect.Float64:
+			n, err := strconv.ParseFloat(limit, 64)
+			if err != nil {
+				return "has a bad " + bound + " tag"
+			}
+			value, l = v.Float(), n
+		default:
+			continue
+		}
+		if bound == "min" && value < l {
+			return "must be at least " + limit
+		}
+		if bound == "max" && value > l {
+			return "must be at most " + limit
+		}
+	}
+	return ""
+}
+
+// checkOneOf applies the space-separated oneof tag to the raw value.
+func checkOneOf(field reflect.StructField, raw string) string {
+	allowed, ok := field.Tag.Lookup("oneof")
+	if !ok {
+		return ""
+	}
+	options := strings.Fields(allowed)
+	for _, option := range options {
+		if strings.EqualFold(raw, option) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("must be one of %s, got %q", strings.Join(options, ", "), raw)
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Port     int           `env:"TEST_PORT" default:"8090" min:"1" max:"65535"`
+	Rate     float64       `env:"TEST_RATE" default:"0.5" min:"0" max:"1"`
+	TTL      time.Duration `env:"SESSION_TTL" default:"30m" min:"1s"`
+	Debug    bool          `env:"TEST_DEBUG"`
+	URL      string        `env:"TEST_URL"`
+	Format   string        `env:"TEST_FORMAT" default:"text" oneof:"text json"`
+	Postgres Postgres
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func lookupMap(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoadSources(t *testing.T) {
+	envFile := writeFile(t, ".env", strings.Join([]string{
+		"# comment",
+		"DB_USER      := postgres",
+		"DB_PASSWORD  := secret",
+		"DB_PORT      := 5644",
+		"DB_DSN_BASE  := user=$(DB_USER) port=$(DB_PORT)",
+		"SESSION_TTL  := 1200",
+		"TEST_URL     := http://localhost:8092 #TODO make configurable",
+		"TEST_RATE=0.25",
+	}, "\n"))
+	yamlFile := writeFile(t, "config.yaml", strings.Join([]string{
+		"test:",
+		"  port: 9000 # from yaml",
+		"  debug: \"true\"",
+		"db:",
+		"  host: postgres",
+		"  port: 1",
+	}, "\n"))
+
+	var cfg testConfig
+	err := Load(&cfg, Options{EnvFile: envFile, YAMLFile: yamlFile, Lookup: lookupMap(map[string]string{"DB_PORT": "6000"})})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := testConfig{
+		Port:     9000,
+		Rate:     0.25,
+		TTL:      20 * time.Minute,
+		Debug:    true,
+		URL:      "http://localhost:8092",
+		Format:   "text",
+		Postgres: Postgres{Host: "postgres", Port: 6000, User: "postgres", Password: "secret"},
+	}
+	if cfg != want {
+		t.Errorf("Load() = %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	var cfg testConfig
+	err := Load(&cfg, Options{
+		EnvFile:  filepath.Join(t.TempDir(), "missing.env"),
+		YAMLFile: "",
+		Lookup:   lookupMap(map[string]string{"TEST_PORT": "70000", "TEST_RATE": "abc", "SESSION_TTL": "0s", "TEST_FORMAT": "xml"}),
+	})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Load() error = %v, want *ValidationError", err)
+	}
+	got := verr.Error()
+	for _, want := range []string{"TEST_PORT must be at most 65535", "TEST_RATE must be a number", "SESSION_TTL must be at least 1s", "DB_USER is required", `TEST_FORMAT must be one of text, json, got "xml"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("error %q lacks %q", got, want)
+		}
+	}
+}
+
+func TestLoadExplicitYAMLMustExist(t *testing.T) {
+	var cfg testConfig
+	err := Load(&cfg, Options{
+		EnvFile: filepath.Join(t.TempDir(), "missing.env"),
+		Lookup:  lookupMap(map[string]string{"CONFIG_FILE": filepath.Join(t.TempDir(), "nope.yaml"), "DB_USER": "u"}),
+	})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() with missing CONFIG_FILE = %v", err)
+	}
+}
+
+func TestFprintRedactsSecrets(t *testing.T) {
+	cfg := testConfig{Port: 1, TTL: time.Minute, Postgres: Postgres{User: "postgres", Password: "hunter2"}}
+	var b strings.Builder
+	if err := Fprint(&b, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if strings.Contains(out, "hunter2") || !strings.Contains(out, "DB_PASSWORD="+redacted) {
+		t.Errorf("secret not redacted:\n%s", out)
+	}
+	if !strings.Contains(out, "SESSION_TTL=1m0s\n") || !strings.Contains(out, "TEST_PORT=1\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestPrintRequested(t *testing.T) {
+	if !PrintRequested([]string{"-v", "--print-config"}) || PrintRequested([]string{"--print"}) {
+		t.Error("PrintRequested mismatch")
+	}
+}
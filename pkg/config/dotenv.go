@@ -0,0 +1,67 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readDotenv parses a .env file. The files double as Makefile includes, so
+// both KEY := value and KEY=value work, $(VAR) expands to an earlier key or
+// an environment variable, and " #" starts a comment.
+func readDotenv(filename string, lookup func(string) (string, bool)) (map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	expand := func(name string) string {
+		if v, ok := lookup(name); ok && v != "" {
+			return v
+		}
+		return values[name]
+	}
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":=")
+		if !ok {
+			key, value, ok = strings.Cut(line, "=")
+		}
+		if !ok {
+			return nil, fmt.Errorf("config: %s:%d: expected KEY := value", filename, n)
+		}
+		key = strings.TrimSpace(key)
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		values[key] = expandMake(strings.TrimSpace(value), expand)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("config: read %s: %w", filename, err)
+	}
+	return values, nil
+}
+
+// expandMake replaces Makefile-style $(VAR) references.
+func expandMake(value string, expand func(string) string) string {
+	for {
+		start := strings.Index(value, "$(")
+		if start == -1 {
+			return value
+		}
+		end := strings.Index(value[start:], ")")
+		if end == -1 {
+			return value
+		}
+		end += start
+		value = value[:start] + expand(value[start+2:end]) + value[end+1:]
+	}
+}
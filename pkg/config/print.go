@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+const redacted = "******"
+
+// Fprint writes cfg, a struct or pointer to one, as KEY=value lines in
+// field order. Fields tagged secret:"true" are shown only as set or not.
+func Fprint(w io.Writer, cfg any) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("config: cannot print %T", cfg)
+	}
+	var err error
+	walk(v, func(field reflect.StructField, value reflect.Value) {
+		if err != nil {
+			return
+		}
+		_, err = fmt.Fprintf(w, "%s=%s\n", field.Tag.Get("env"), display(field, value))
+	})
+	return err
+}
+
+func display(field reflect.StructField, v reflect.Value) string {
+	if field.Tag.Get("secret") == "true" {
+		if v.IsZero() {
+			return ""
+		}
+		return redacted
+	}
+	if v.Type() == durationType {
+		return v.Interface().(fmt.Stringer).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Postgres is the server all three services connect to; each names its
+// own databases.
+type Postgres struct {
+	Host     string `env:"DB_HOST" default:"localhost"`
+	Port     int    `env:"DB_PORT" default:"5432" min:"1" max:"65535"`
+	User     string `env:"DB_USER" required:"true"`
+	Password string `env:"DB_PASSWORD" secret:"true"`
+}
+
+// DSN is the lib/pq connection string for dbName.
+func (p Postgres) DSN(dbName string) string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		p.Host, p.Port, p.User, p.Password, dbName)
+}
+
+type Redis struct {
+	Address  string `env:"REDIS_ADDRESS" required:"true"`
+	Password string `env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `env:"REDIS_DB" default:"0" min:"0" max:"15"`
+}
+
+// Auth holds the session and password settings shared by the user module.
+type Auth struct {
+	SessionTTL        time.Duration `env:"SESSION_TTL" default:"30m" min:"1s"`
+	PasswordMinLength int           `env:"PASSWORD_MIN_LENGTH" default:"10" min:"1" max:"128"`
+	// BreachedPasswordsFile lists passwords to reject, one per line.
+	BreachedPasswordsFile string `env:"BREACHED_PASSWORDS_FILE"`
+	// KeysDir switches sessions to signed access tokens with the keys in it.
+	KeysDir string `env:"AUTH_KEYS_DIR"`
+	// ServiceToken is shared by the services for calls between each other;
+	// empty turns those endpoints off.
+	ServiceToken string `env:"SERVICE_TOKEN" secret:"true"`
+}
+
+// SMTP is the mail server for notifications and password reset tokens;
+// without Addr password reset is turned off.
+type SMTP struct {
+	Addr     string `env:"SMTP_ADDR"`
+	From     string `env:"SMTP_FROM"`
+	Username string `env:"SMTP_USERNAME"`
+	Password string `env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// HTTP bounds how long a client may take and how long shutdown waits for
+// in-flight requests and background workers, and which browser origins
+// may call the API.
+type HTTP struct {
+	ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"5s" min:"1ms"`
+	ReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" default:"15s" min:"1ms"`
+	WriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"30s" min:"1ms"`
+	IdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"60s" min:"1ms"`
+	ShutdownTimeout   time.Duration `env:"SHUTDOWN_TIMEOUT" default:"20s" min:"1s"`
+	// ShutdownDelay keeps serving after /readyz turns DRAINING, so load
+	// balancers notice before the listener closes.
+	ShutdownDelay time.Duration `env:"SHUTDOWN_DELAY" default:"5s" min:"0s"`
+	// CORSOrigins is a comma-separated list such as
+	// "http://localhost:5173,https://app.example.com"; "*" allows any.
+	CORSOrigins  string `env:"CORS_ALLOWED_ORIGINS" default:"*"`
+	MaxBodyBytes int64  `env:"HTTP_MAX_BODY_BYTES" default:"1048576" min:"1"`
+}
+
+// AllowedOrigins splits CORSOrigins, dropping empty entries.
+func (h HTTP) AllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(h.CORSOrigins, ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// Log selects how much the services log and where to.
+type Log struct {
+	Level  string `env:"LOG_LEVEL" default:"info" oneof:"debug info warn error"`
+	Format string `env:"LOG_FORMAT" default:"text" oneof:"text json"`
+	// File appends logs to a file instead of writing them to stdout.
+	File string `env:"LOG_FILE"`
+}
+
+// Trace selects where spans go: to the OTLP/HTTP collector at OTLPEndpoint
+// when set, otherwise to File as JSON lines ("-" for stdout). With neither,
+// trace context is still propagated but no spans are exported.
+type Trace struct {
+	// OTLPEndpoint is the collector base URL, e.g. http://localhost:4318.
+	OTLPEndpoint string  `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	File         string  `env:"TRACE_FILE"`
+	SampleRatio  float64 `env:"TRACE_SAMPLE_RATIO" default:"1" min:"0" max:"1"`
+}
+
+// OpenAPI selects what the services check against their OpenAPI document.
+// Response checks buffer every response and are meant for tests.
+type OpenAPI struct {
+	ValidateRequests  bool `env:"OPENAPI_VALIDATE_REQUESTS" default:"true"`
+	ValidateResponses bool `env:"OPENAPI_VALIDATE_RESPONSES" default:"false"`
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readYAML reads the subset of YAML a settings file needs: nested maps of
+// scalars, comments and quoted strings. Nested keys join with "_" and are
+// upper-cased, so
+//
+//	db:
+//	  host: postgres
+//
+// sets DB_HOST, the same key as the environment variable.
+func readYAML(filename string) (map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	type level struct {
+		indent int
+		prefix string
+	}
+	values := make(map[string]string)
+	var stack []level
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(stripYAMLComment(text))
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.Contains(text, "\t") {
+			return nil, fmt.Errorf("config: %s:%d: indent with spaces, not tabs", filename, n)
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			return nil, fmt.Errorf("config: %s:%d: lists are not supported", filename, n)
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("config: %s:%d: expected key: value", filename, n)
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		key = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(key), "-", "_"))
+		if len(stack) > 0 {
+			key = stack[len(stack)-1].prefix + "_" + key
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			stack = append(stack, level{indent: indent, prefix: key})
+			continue
+		}
+		values[key] = unquoteYAML(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("config: read %s: %w", filename, err)
+	}
+	return values, nil
+}
+
+// stripYAMLComment drops a " #" comment that is not inside quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquoteYAML(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
@@ -0,0 +1,64 @@
+// Package logging sets up the services' log/slog logger, redacts
+// sensitive values before they are written, and carries a request-scoped
+// logger through the context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/config"
+)
+
+// Setup builds the logger cfg describes and makes it the slog default,
+// which also routes the standard log package through it. The returned
+// function closes the log file, if there is one.
+func Setup(cfg config.Log) (*slog.Logger, func() error, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, nil, fmt.Errorf("logging: level %q: %w", cfg.Level, err)
+	}
+	var w io.Writer = os.Stdout
+	closeFn := func() error { return nil }
+	if cfg.File != "" {
+		file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("logging: open %s: %w", cfg.File, err)
+		}
+		w, closeFn = file, file.Close
+	}
+	logger := slog.New(NewHandler(w, cfg.Format, level))
+	slog.SetDefault(logger)
+	return logger, closeFn, nil
+}
+
+// NewHandler returns a JSON or text handler that redacts as Redact does.
+func NewHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: Redact}
+	if strings.EqualFold(format, "json") {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+type loggerKey struct{}
+
+// WithContext returns ctx carrying logger.
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger Middleware put in ctx, or the default
+// logger outside a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
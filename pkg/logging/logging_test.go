@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, "json", slog.LevelDebug))
+	logger.Info("login for 0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+		"password", "hunter2",
+		"refresh_token", "abc",
+		"X-API-Key", "yk_live_123",
+		"code", "123456",
+		"wallet_address", "0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+		"error", errors.New("no user 0x1f9840a85d5af5bf1d1762f925bdaddc4201f984"),
+		"order_id", "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+	)
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"msg":            "login for 0x742d…f44e",
+		"password":       Redacted,
+		"refresh_token":  Redacted,
+		"X-API-Key":      Redacted,
+		"code":           Redacted,
+		"wallet_address": "0x742d…f44e",
+		"error":          "no user 0x1f98…f984",
+		"order_id":       "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+	if strings.Contains(buf.String(), "hunter2") || strings.Contains(buf.String(), "4438f44e\"") {
+		t.Errorf("sensitive value leaked: %s", buf.String())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(NewHandler(&buf, "json", slog.LevelInfo)))
+
+	var seen string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		FromContext(r.Context()).Info("inside")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if seen != "abc-123" || rec.Header().Get(RequestIDHeader) != "abc-123" {
+		t.Errorf("request id = %q, header %q", seen, rec.Header().Get(RequestIDHeader))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines:\n%s", len(lines), buf.String())
+	}
+	var inside, access map[string]any
+	json.Unmarshal([]byte(lines[0]), &inside)
+	json.Unmarshal([]byte(lines[1]), &access)
+	if inside["request_id"] != "abc-123" || access["request_id"] != "abc-123" {
+		t.Errorf("request_id missing: %s", buf.String())
+	}
+	if access["status"] != float64(http.StatusTeapot) || access["path"] != "/orders" {
+		t.Errorf("access log = %v", access)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == "" || seen == "bad id\n" {
+		t.Errorf("invalid incoming request id kept: %q", seen)
+	}
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the value of a sensitive attribute.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are matched against attribute keys lower-cased and with
+// "_" and "-" removed, so "new_password" and "X-API-Key" both match.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "apikey", "signature", "cookie"}
+
+// sensitiveExact are keys that would match too much as substrings.
+var sensitiveExact = map[string]bool{"code": true, "codes": true, "backupcodes": true, "otp": true}
+
+var walletPattern = regexp.MustCompile(`0x[0-9a-fA-F]{8,}`)
+
+// Redact is a slog ReplaceAttr function. Sensitive attributes lose their
+// value, and wallet addresses anywhere in a string, error or the message
+// are shortened to their first and last characters.
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); walletPattern.MatchString(s) {
+			return slog.String(a.Key, MaskWallets(s))
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, MaskWallets(err.Error()))
+		}
+	}
+	return a
+}
+
+func sensitiveKey(key string) bool {
+	k := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	if sensitiveExact[k] {
+		return true
+	}
+	for _, s := range sensitiveKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskWallets shortens every wallet address in s to 0x1234…abcd, enough
+// to tell users apart in logs without recording the address.
+func MaskWallets(s string) string {
+	return walletPattern.ReplaceAllStringFunc(s, func(addr string) string {
+		return addr[:6] + "…" + addr[len(addr)-4:]
+	})
+}
@@ -0,0 +1,14 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// InstrumentDB reports the database/sql pool statistics of db, read at
+// scrape time, under the db_name label name, e.g. "order_db", the same name
+// the readiness check uses.
+func InstrumentDB(name string, db *sql.DB) {
+	Default.reg.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/router"
+)
+
+var (
+	httpRequests = NewCounterVec("http_requests_total",
+		"HTTP requests served, by method, route and status.", "method", "route", "status")
+	httpDuration = NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency, by method, route and status.", DefBuckets, "method", "route", "status")
+	httpInFlight = NewGauge("http_requests_in_flight", "HTTP requests being served.")
+)
+
+// unmatchedRoute labels requests no handler was registered for, so
+// scanners probing random paths add one series rather than one per path.
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels methods outside the standard set for the same reason:
+// the method is whatever the client sent.
+const otherMethod = "OTHER"
+
+var standardMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true,
+	http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+	http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// Middleware records every request under the route that served it. It has
+// to wrap the router directly: the router stores the pattern on the
+// request it is handed, not on copies made further out.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpInFlight.Inc()
+		defer httpInFlight.Dec()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		route := router.Route(r)
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := r.Method
+		if !standardMethods[method] {
+			method = otherMethod
+		}
+		status := strconv.Itoa(rec.status)
+		httpRequests.With(method, route, status).Inc()
+		httpDuration.With(method, route, status).ObserveSince(start)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,147 @@
+// Package metrics defines the services' Prometheus metrics and serves them
+// at /metrics. It wraps prometheus/client_golang behind a small API so call
+// sites only name the metric and its labels.
+//
+// Metrics are created once, usually as package variables, and register
+// themselves on Default:
+//
+//	var ordersCreated = metrics.NewCounterVec("orders_created_total", "Orders created.", "source")
+//
+//	ordersCreated.With("cart").Inc()
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefBuckets are histogram buckets in seconds for request latencies.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry is a set of metrics served together. Registering a name twice
+// panics: it is a programming error, caught the first time the process
+// starts.
+type Registry struct {
+	reg *prometheus.Registry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{reg: prometheus.NewRegistry()}
+}
+
+// Default is the registry the New* functions register on and Handler serves.
+// It also reports the Go runtime and process metrics dashboards expect.
+var Default = NewRegistry()
+
+func init() {
+	Default.reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the registry for Prometheus to scrape.
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.reg, promhttp.HandlerOpts{})
+}
+
+// Handler serves Default.
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// Operation documents GET /metrics.
+var Operation = openapi.Op{
+	Summary:   "Prometheus metrics",
+	Responses: map[int]any{http.StatusOK: openapi.Raw("text/plain; version=0.0.4")},
+}
+
+// Counter only goes up; Add panics on a negative delta.
+type Counter = prometheus.Counter
+
+// Gauge goes up and down.
+type Gauge = prometheus.Gauge
+
+// Histogram counts observations into buckets.
+type Histogram struct {
+	prometheus.Observer
+}
+
+// ObserveSince records the seconds elapsed since start.
+func (h Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+type CounterVec struct {
+	vec *prometheus.CounterVec
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	r.reg.MustRegister(vec)
+	return &CounterVec{vec: vec}
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounter is a counter without labels.
+func NewCounter(name, help string) Counter {
+	return NewCounterVec(name, help).With()
+}
+
+// With returns the counter for the label values, in the order the labels
+// were declared.
+func (c *CounterVec) With(values ...string) Counter {
+	return c.vec.WithLabelValues(values...)
+}
+
+type GaugeVec struct {
+	vec *prometheus.GaugeVec
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+	r.reg.MustRegister(vec)
+	return &GaugeVec{vec: vec}
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// NewGauge is a gauge without labels.
+func NewGauge(name, help string) Gauge {
+	return NewGaugeVec(name, help).With()
+}
+
+func (g *GaugeVec) With(values ...string) Gauge {
+	return g.vec.WithLabelValues(values...)
+}
+
+type HistogramVec struct {
+	vec *prometheus.HistogramVec
+}
+
+// NewHistogramVec takes the bucket upper bounds in increasing order; the
+// +Inf bucket is implied.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+	r.reg.MustRegister(vec)
+	return &HistogramVec{vec: vec}
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+func (h *HistogramVec) With(values ...string) Histogram {
+	return Histogram{h.vec.WithLabelValues(values...)}
+}
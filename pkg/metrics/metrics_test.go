@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	payments := r.NewCounterVec("payment_failures_total", "Failed payments.", "reason")
+	payments.With("insufficient_funds").Inc()
+	payments.With("insufficient_funds").Add(2)
+	payments.With(`wallet "down"`).Inc()
+	latency := r.NewHistogramVec("menu_fetch_seconds", "Menu fetch latency.", []float64{.1, 1})
+	latency.With().Observe(.05)
+	latency.With().Observe(.5)
+	latency.With().Observe(3)
+	r.NewGaugeVec("queue_depth", "Queued jobs.\nMultiline.").With().Set(7)
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	want := `# HELP menu_fetch_seconds Menu fetch latency.
+# TYPE menu_fetch_seconds histogram
+menu_fetch_seconds_bucket{le="0.1"} 1
+menu_fetch_seconds_bucket{le="1"} 2
+menu_fetch_seconds_bucket{le="+Inf"} 3
+menu_fetch_seconds_sum 3.55
+menu_fetch_seconds_count 3
+# HELP payment_failures_total Failed payments.
+# TYPE payment_failures_total counter
+payment_failures_total{reason="insufficient_funds"} 3
+payment_failures_total{reason="wallet \"down\""} 1
+# HELP queue_depth Queued jobs.\nMultiline.
+# TYPE queue_depth gauge
+queue_depth 7
+`
+	if rec.Body.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", rec.Body.String(), want)
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("orders_total", "Orders.")
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate registration did not panic")
+		}
+	}()
+	r.NewGaugeVec("orders_total", "Orders again.")
+}
+
+func TestMiddlewareUsesRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	handler := Middleware(mux)
+	for _, path := range []string{"/orders/1/pay", "/orders/2/pay", "/wp-login.php"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("X-SCAN-1", "/orders/3", nil))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`http_requests_total{method="POST",route="/orders/",status="202"} 2`,
+		`http_requests_total{method="POST",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="POST",route="/orders/",status="202"} 2`,
+		`http_requests_total{method="OTHER",route="/orders/",status="202"} 1`,
+		"go_goroutines ",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "/orders/1/pay") || strings.Contains(body, "X-SCAN-1") {
+		t.Error("raw path used as a label")
+	}
+}
@@ -11,6 +11,8 @@ type OrderStatus string
 
 const (
 	OrderStatusCustomerCreated    OrderStatus = "CUSTOMER_CREATED"
+	OrderStatusCustomerPaying     OrderStatus = "CUSTOMER_PAYING" // claimed by one pay request, wallet being debited
+	OrderStatusCustomerScheduled  OrderStatus = "CUSTOMER_SCHEDULED"
 	OrderStatusCustomerPaid       OrderStatus = "CUSTOMER_PAID"
 	OrderStatusCustomerCancelled  OrderStatus = "CUSTOMER_CANCELLED"
 	OrderStatusKitchenAccepted    OrderStatus = "KITCHEN_ACCEPTED"
@@ -25,24 +27,84 @@ const (
 	OrderStatusOrderCompleted     OrderStatus = "ORDER_COMPLETED"
 )
 
+type CancellationReason string
+
+const (
+	CancellationReasonChangedMind      CancellationReason = "CHANGED_MIND"
+	CancellationReasonOrderedByMistake CancellationReason = "ORDERED_BY_MISTAKE"
+	CancellationReasonTooSlow          CancellationReason = "TOO_SLOW"
+	CancellationReasonWrongAddress     CancellationReason = "WRONG_ADDRESS"
+	CancellationReasonOther            CancellationReason = "OTHER"
+)
+
+func (r CancellationReason) Valid() bool {
+	switch r {
+	case CancellationReasonChangedMind, CancellationReasonOrderedByMistake, CancellationReasonTooSlow,
+		CancellationReasonWrongAddress, CancellationReasonOther:
+		return true
+	}
+	return false
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusNone     RefundStatus = "NONE"
+	RefundStatusPending  RefundStatus = "PENDING"
+	RefundStatusRefunded RefundStatus = "REFUNDED"
+	RefundStatusFailed   RefundStatus = "FAILED"
+)
+
+// ErrorResponce is the body of every error response. Code is the status
+// in snake case ("not_found"); RequestID matches the X-Request-ID header.
 type ErrorResponce struct {
 	ErrorMessage string `json:"error_message"`
+	Code         string `json:"code,omitempty"`
+	RequestID    string `json:"request_id,omitempty"`
 }
 
 type Order struct {
-	ID         uuid.UUID `json:"id"`
-	CustomerID uuid.UUID `json:"customer_id"`
-	CourierID  uuid.UUID `json:"courier_id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Status     string    `json:"status"`
+	ID           uuid.UUID  `json:"id"`
+	CustomerID   uuid.UUID  `json:"customer_id"`
+	CourierID    uuid.UUID  `json:"courier_id"`
+	RestaurantID uuid.UUID  `json:"restaurant_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Status       string     `json:"status"`
+	DeliverAt    *time.Time `json:"deliver_at,omitempty"`
+	ReleaseAt    *time.Time `json:"release_at,omitempty"`
+}
+
+// OpeningHours is one weekday window in the restaurant's time zone, times
+// formatted as HH:MM.
+type OpeningHours struct {
+	Weekday time.Weekday `json:"weekday"`
+	Opens   string       `json:"opens"`
+	Closes  string       `json:"closes"`
+}
+
+// RestaurantSchedule describes when a restaurant takes scheduled orders.
+// TZ is the IANA time zone of Hours, e.g. Europe/Berlin; empty is UTC.
+type RestaurantSchedule struct {
+	RestaurantID    uuid.UUID      `json:"restaurant_id" required:"true"`
+	SlotMinutes     int            `json:"slot_minutes"`
+	SlotCapacity    int            `json:"slot_capacity"`
+	PrepLeadMinutes int            `json:"prep_lead_minutes"`
+	TZ              string         `json:"tz,omitempty"`
+	Hours           []OpeningHours `json:"hours"`
+}
+
+type OrderItem struct {
+	RestaurantItemID uuid.UUID `json:"restaurant_item_id"`
+	Price            float64   `json:"price"`
+	Quantity         int       `json:"quantity"`
 }
 
 type MenuItem struct {
 	OrderItemID  uuid.UUID `json:"order_item_id" db:"order_item_id"`
-	RestaurantID uuid.UUID `json:"restaurant_id" db:"restaurant_id"`
-	Name         string    `json:"name" db:"name"`
-	Price        float64   `json:"price" db:"price"`
+	RestaurantID uuid.UUID `json:"restaurant_id" db:"restaurant_id" required:"true"`
+	Name         string    `json:"name" db:"name" required:"true"`
+	Price        float64   `json:"price" db:"price" required:"true"`
 	Quantity     int       `json:"quantity" db:"quantity"`
 	Description  string    `json:"description" db:"description"`
 }
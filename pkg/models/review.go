@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// RatingSubject is what an aggregated rating belongs to.
+type RatingSubject string
+
+const (
+	RatingSubjectRestaurant RatingSubject = "RESTAURANT"
+	RatingSubjectCourier    RatingSubject = "COURIER"
+	RatingSubjectDish       RatingSubject = "DISH"
+)
+
+type Review struct {
+	OrderID        uuid.UUID    `json:"order_id"`
+	CustomerID     uuid.UUID    `json:"customer_id"`
+	RestaurantID   uuid.UUID    `json:"restaurant_id"`
+	CourierID      uuid.UUID    `json:"courier_id"`
+	FoodRating     int          `json:"food_rating"`
+	DeliveryRating int          `json:"delivery_rating"`
+	Dishes         []DishRating `json:"dishes,omitempty"`
+	Comment        string       `json:"comment,omitempty"`
+	Reply          string       `json:"reply,omitempty"`
+	RepliedAt      *time.Time   `json:"replied_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+type DishRating struct {
+	RestaurantItemID uuid.UUID `json:"restaurant_item_id"`
+	Rating           int       `json:"rating"`
+}
+
+// RatingSummary is kept as a running sum so new reviews update it in O(1).
+type RatingSummary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TipStage string
+
+const (
+	// TipStageCheckout covers tips given while the order is paid and in progress.
+	TipStageCheckout TipStage = "CHECKOUT"
+	// TipStageAfterDelivery covers tips given after ORDER_COMPLETED.
+	TipStageAfterDelivery TipStage = "AFTER_DELIVERY"
+)
+
+type TipStatus string
+
+const (
+	TipStatusPending  TipStatus = "PENDING"  // recorded, customer not debited yet
+	TipStatusDebited  TipStatus = "DEBITED"  // customer debited, courier payout outstanding
+	TipStatusPaidOut  TipStatus = "PAID_OUT" // whole amount credited to the courier
+	TipStatusFailed   TipStatus = "FAILED"   // customer debit failed, nothing moved
+	TipStatusRefunded TipStatus = "REFUNDED" // order cancelled, tip back with the customer
+)
+
+// Tip is kept apart from the order total: it never goes to the restaurant.
+type Tip struct {
+	ID         uuid.UUID  `json:"id"`
+	OrderID    uuid.UUID  `json:"order_id"`
+	CustomerID uuid.UUID  `json:"customer_id"`
+	CourierID  uuid.UUID  `json:"courier_id"`
+	Amount     float64    `json:"amount"`
+	Stage      TipStage   `json:"stage"`
+	Status     TipStatus  `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	PaidOutAt  *time.Time `json:"paid_out_at,omitempty"`
+}
+
+type CourierEarnings struct {
+	CourierID       uuid.UUID `json:"courier_id"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	CompletedOrders int       `json:"completed_orders"`
+	TipsCount       int       `json:"tips_count"`
+	TipsPaidOut     float64   `json:"tips_paid_out"`
+	TipsPending     float64   `json:"tips_pending"`
+	Tips            []Tip     `json:"tips"`
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+)
+
+// LogChannel writes messages to the service log instead of delivering them.
+type LogChannel struct {
+	kind ChannelKind
+}
+
+func NewLogChannel(kind ChannelKind) *LogChannel {
+	return &LogChannel{kind: kind}
+}
+
+func (c *LogChannel) Kind() ChannelKind { return c.kind }
+
+func (c *LogChannel) Send(ctx context.Context, msg Message) error {
+	logging.FromContext(ctx).Info("notify: message", "channel", c.kind, "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// FileChannel appends every message as a JSON line to a file. It stands in
+// for SMS and push providers when running offline.
+type FileChannel struct {
+	kind ChannelKind
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileChannel(kind ChannelKind, path string) *FileChannel {
+	return &FileChannel{kind: kind, path: path}
+}
+
+func (c *FileChannel) Kind() ChannelKind { return c.kind }
+
+func (c *FileChannel) Send(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("notify: create sink dir: %w", err)
+	}
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("notify: open sink: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+
+	"github.com/google/uuid"
+)
+
+// AccountMailer delivers password reset tokens and recovery email
+// confirmations. Unlike Notify it sends right away instead of queueing, so
+// tokens are never written to the outbox, and it sends only to the address
+// auth hands it: the user's confirmed recovery email, or the address being
+// confirmed. Preferences only pick the language.
+type AccountMailer struct {
+	prefs     PreferenceStore
+	templates *Templates
+	email     Channel
+	now       func() time.Time
+}
+
+// NewAccountMailer sends on the email channel. A nil prefs writes in
+// DefaultLocale; a nil templates uses DefaultTemplates.
+func NewAccountMailer(prefs PreferenceStore, templates *Templates, email Channel) *AccountMailer {
+	if templates == nil {
+		templates = DefaultTemplates()
+	}
+	return &AccountMailer{
+		prefs:     prefs,
+		templates: templates,
+		email:     email,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SendReset mails the token to the user's recovery email; the token itself
+// is never logged.
+func (m *AccountMailer) SendReset(ctx context.Context, msg auth.ResetMessage) error {
+	return m.send(ctx, msg.User.ID, msg.To, EventPasswordReset, map[string]any{
+		"token":       msg.Token,
+		"valid_until": msg.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// SendEmailConfirmation mails the token to the address being confirmed.
+func (m *AccountMailer) SendEmailConfirmation(ctx context.Context, msg auth.EmailConfirmation) error {
+	return m.send(ctx, msg.User.ID, msg.To, EventEmailConfirmation, map[string]any{
+		"token":       msg.Token,
+		"valid_until": msg.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (m *AccountMailer) send(ctx context.Context, userID uuid.UUID, to string, event EventType, data map[string]any) error {
+	if m.email == nil {
+		return errors.New("notify: no email channel")
+	}
+	if to == "" {
+		return fmt.Errorf("notify: %s without an address", event)
+	}
+	subject, body, err := m.templates.Render(event, m.locale(ctx, userID), data)
+	if err != nil {
+		return err
+	}
+	err = m.email.Send(ctx, Message{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Event:     event,
+		Channel:   ChannelEmail,
+		To:        to,
+		Subject:   subject,
+		Body:      body,
+		Status:    MessageSent,
+		CreatedAt: m.now(),
+	})
+	if err != nil {
+		return fmt.Errorf("notify: send %s by email: %w", event, err)
+	}
+	return nil
+}
+
+func (m *AccountMailer) locale(ctx context.Context, userID uuid.UUID) string {
+	if m.prefs == nil {
+		return DefaultLocale
+	}
+	prefs, err := m.prefs.Get(ctx, userID)
+	if err != nil || prefs.Locale == "" {
+		return DefaultLocale
+	}
+	return prefs.Locale
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+
+	"github.com/google/uuid"
+)
+
+func TestAccountMailerSendsToGivenAddress(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	prefs := NewMemoryPreferences()
+	_ = prefs.Save(ctx, Preferences{
+		UserID: userID,
+		Locale: "ru",
+		// Whatever the preferences say, tokens only go to the address auth
+		// passes in.
+		Email: "attacker@example.com",
+		// Muting notifications does not mute password resets.
+		Channels: map[EventType][]ChannelKind{EventPasswordReset: {}},
+	})
+	email := &flakyChannel{kind: ChannelEmail}
+	mailer := NewAccountMailer(prefs, nil, email)
+
+	msg := auth.ResetMessage{
+		User:      auth.StoredUser{ID: userID},
+		To:        "grace@example.com",
+		Token:     "secret-token",
+		ExpiresAt: time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC),
+	}
+	if err := mailer.SendReset(ctx, msg); err != nil {
+		t.Fatalf("SendReset() error = %v", err)
+	}
+	if len(email.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(email.sent))
+	}
+	got := email.sent[0]
+	if got.To != "grace@example.com" || !strings.Contains(got.Body, "secret-token") || !strings.Contains(got.Body, "2026-10-19T12:30:00Z") {
+		t.Errorf("message = %+v", got)
+	}
+	if got.Subject != "Сброс пароля" {
+		t.Errorf("subject = %q, want the user's locale", got.Subject)
+	}
+
+	confirmation := auth.EmailConfirmation{User: auth.StoredUser{ID: uuid.New()}, To: "new@example.com", Token: "confirm-token"}
+	if err := mailer.SendEmailConfirmation(ctx, confirmation); err != nil {
+		t.Fatalf("SendEmailConfirmation() error = %v", err)
+	}
+	if got := email.sent[1]; got.To != "new@example.com" || got.Event != EventEmailConfirmation || !strings.Contains(got.Body, "confirm-token") {
+		t.Errorf("confirmation = %+v", got)
+	}
+}
+
+func TestAccountMailerReportsFailure(t *testing.T) {
+	ctx := context.Background()
+	mailer := NewAccountMailer(nil, nil, &flakyChannel{kind: ChannelEmail, failures: 1})
+
+	if err := mailer.SendReset(ctx, auth.ResetMessage{User: auth.StoredUser{ID: uuid.New()}, To: "grace@example.com", Token: "t"}); err == nil {
+		t.Error("SendReset() with a failing channel succeeded")
+	}
+	if err := mailer.SendReset(ctx, auth.ResetMessage{User: auth.StoredUser{ID: uuid.New()}, Token: "t"}); err == nil {
+		t.Error("SendReset() without an address succeeded")
+	}
+}
@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultBaseBackoff = 30 * time.Second
+	DefaultMaxBackoff  = time.Hour
+	DefaultInterval    = 5 * time.Second
+	DefaultBatchSize   = 50
+	// DefaultLease must outlast one send; an unacknowledged message is retried after it.
+	DefaultLease = 2 * time.Minute
+)
+
+// Notifier renders notifications into per-channel messages, queues them and
+// delivers the queue with retries.
+type Notifier struct {
+	prefs       PreferenceStore
+	templates   *Templates
+	queue       Queue
+	channels    map[ChannelKind]Channel
+	now         func() time.Time
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Lease       time.Duration
+	BatchSize   int
+}
+
+func New(prefs PreferenceStore, templates *Templates, queue Queue) *Notifier {
+	if templates == nil {
+		templates = DefaultTemplates()
+	}
+	return &Notifier{
+		prefs:       prefs,
+		templates:   templates,
+		queue:       queue,
+		channels:    make(map[ChannelKind]Channel),
+		now:         func() time.Time { return time.Now().UTC() },
+		MaxAttempts: DefaultMaxAttempts,
+		BaseBackoff: DefaultBaseBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+		Lease:       DefaultLease,
+		BatchSize:   DefaultBatchSize,
+	}
+}
+
+// Register adds or replaces the channel for ch.Kind().
+func (n *Notifier) Register(ch Channel) *Notifier {
+	n.channels[ch.Kind()] = ch
+	return n
+}
+
+// Notify queues the notification on every channel the user wants and has
+// an address for. Users without preferences are skipped silently.
+func (n *Notifier) Notify(ctx context.Context, notification Notification) error {
+	prefs, err := n.prefs.Get(ctx, notification.UserID)
+	if errors.Is(err, ErrNoPreferences) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := n.now()
+	msgs := make([]Message, 0)
+	for _, kind := range prefs.ChannelsFor(notification.Event) {
+		if _, ok := n.channels[kind]; !ok {
+			continue
+		}
+		subject, body, err := n.templates.Render(notification.Event, prefs.Locale, notification.Data)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, Message{
+			ID:            uuid.New(),
+			UserID:        notification.UserID,
+			Event:         notification.Event,
+			Channel:       kind,
+			To:            prefs.Address(kind),
+			Subject:       subject,
+			Body:          body,
+			Status:        MessagePending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return n.queue.Enqueue(ctx, msgs...)
+}
+
+// Process sends one batch of due messages and returns how many went out.
+func (n *Notifier) Process(ctx context.Context) (int, error) {
+	now := n.now()
+	msgs, err := n.queue.Claim(ctx, now, now.Add(n.Lease), n.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, msg := range msgs {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		msg.Attempts++
+		sendErr := n.send(ctx, msg)
+		if sendErr == nil {
+			sentAt := n.now()
+			msg.Status = MessageSent
+			msg.SentAt = &sentAt
+			msg.LastError = ""
+			sent++
+		} else {
+			msg.LastError = sendErr.Error()
+			if msg.Attempts >= n.MaxAttempts {
+				msg.Status = MessageDead
+				logging.FromContext(ctx).Error("notify: giving up on message", "channel", msg.Channel, "message_id", msg.ID, "attempts", msg.Attempts, "error", sendErr)
+			} else {
+				msg.NextAttemptAt = n.now().Add(n.backoff(msg.Attempts))
+			}
+		}
+		if err := n.queue.Update(ctx, msg); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// Run drains the queue every interval until ctx is cancelled.
+func (n *Notifier) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := n.Process(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logging.FromContext(ctx).Error("notify: process queue failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, msg Message) error {
+	ch, ok := n.channels[msg.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, msg.Channel)
+	}
+	return ch.Send(ctx, msg)
+}
+
+// backoff doubles from BaseBackoff per failed attempt, capped at MaxBackoff.
+func (n *Notifier) backoff(attempts int) time.Duration {
+	delay := n.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= n.MaxBackoff {
+			return n.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type flakyChannel struct {
+	kind     ChannelKind
+	failures int
+	sent     []Message
+}
+
+func (c *flakyChannel) Kind() ChannelKind { return c.kind }
+
+func (c *flakyChannel) Send(ctx context.Context, msg Message) error {
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("provider down")
+	}
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func TestNotifierDeliversWithRetries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+
+	prefs := NewMemoryPreferences()
+	_ = prefs.Save(ctx, Preferences{
+		UserID: userID,
+		Locale: "ru-RU",
+		Email:  "kitchen@example.com",
+		Phone:  "+70000000000",
+		Channels: map[EventType][]ChannelKind{
+			EventOrderCancelled: {},
+		},
+	})
+
+	queuePath := filepath.Join(t.TempDir(), "queue.json")
+	queue, err := NewFileQueue(queuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := &flakyChannel{kind: ChannelEmail, failures: 2}
+	sms := &flakyChannel{kind: ChannelSMS}
+	n := New(prefs, nil, queue).Register(email).Register(sms)
+	n.now = func() time.Time { return now }
+
+	if err := n.Notify(ctx, Notification{Event: EventOrderPaid, UserID: userID, Data: map[string]any{"order_id": "42"}}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	// Muted event and unknown user queue nothing.
+	_ = n.Notify(ctx, Notification{Event: EventOrderCancelled, UserID: userID})
+	_ = n.Notify(ctx, Notification{Event: EventOrderPaid, UserID: uuid.New()})
+	if got := len(queue.Messages()); got != 2 {
+		t.Fatalf("queued %d messages, want 2", got)
+	}
+
+	sent, err := n.Process(ctx)
+	if err != nil || sent != 1 {
+		t.Fatalf("Process() = %d, %v; want 1 sent", sent, err)
+	}
+	if sms.sent[0].Subject != "Заказ 42 оплачен" {
+		t.Errorf("subject = %q, want ru template", sms.sent[0].Subject)
+	}
+
+	// Not due yet: the failed email waits for its backoff.
+	if sent, _ := n.Process(ctx); sent != 0 {
+		t.Fatalf("Process() before backoff sent %d", sent)
+	}
+	now = now.Add(DefaultBaseBackoff)
+	_, _ = n.Process(ctx)
+	now = now.Add(2 * DefaultBaseBackoff)
+	if sent, _ := n.Process(ctx); sent != 1 {
+		t.Fatalf("third attempt sent %d, want 1", sent)
+	}
+
+	// The queue survives a restart.
+	reopened, err := NewFileQueue(queuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range reopened.Messages() {
+		if msg.Status != MessageSent {
+			t.Errorf("message %s status = %s, want SENT", msg.Channel, msg.Status)
+		}
+		if msg.Channel == ChannelEmail && msg.Attempts != 3 {
+			t.Errorf("email attempts = %d, want 3", msg.Attempts)
+		}
+	}
+}
+
+func TestNotifierGivesUp(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	prefs := NewMemoryPreferences()
+	_ = prefs.Save(ctx, Preferences{UserID: userID, PushToken: "token"})
+
+	queue, _ := NewFileQueue(filepath.Join(t.TempDir(), "queue.json"))
+	n := New(prefs, nil, queue).Register(&flakyChannel{kind: ChannelPush, failures: 100})
+	n.now = func() time.Time { return now }
+	n.MaxAttempts = 2
+
+	_ = n.Notify(ctx, Notification{Event: EventOrderStatusChanged, UserID: userID, Data: map[string]any{"order_id": "1", "status": "PAID"}})
+	for i := 0; i < 3; i++ {
+		_, _ = n.Process(ctx)
+		now = now.Add(DefaultMaxBackoff)
+	}
+	msgs := queue.Messages()
+	if len(msgs) != 1 || msgs[0].Status != MessageDead || msgs[0].Attempts != 2 {
+		t.Fatalf("messages = %+v, want one DEAD after 2 attempts", msgs)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	n := New(NewMemoryPreferences(), nil, nil)
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, w := range want {
+		if got := n.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := n.backoff(20); got != DefaultMaxBackoff {
+		t.Errorf("backoff(20) = %v, want cap %v", got, DefaultMaxBackoff)
+	}
+}
+
+func TestTemplatesFallback(t *testing.T) {
+	templates := DefaultTemplates()
+	subject, _, err := templates.Render(EventOrderPaid, "de", map[string]any{"order_id": "7"})
+	if err != nil || subject != "Order 7 is paid" {
+		t.Errorf("Render(de) = %q, %v; want English fallback", subject, err)
+	}
+	if _, _, err := templates.Render("UNKNOWN", "en", nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("Render(unknown) error = %v, want ErrUnknownTemplate", err)
+	}
+}
@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostgresPreferences stores preferences in NOTIFICATION_CONTACTS and
+// NOTIFICATION_PREFERENCES.
+type PostgresPreferences struct {
+	db *sql.DB
+}
+
+func NewPostgresPreferences(db *sql.DB) *PostgresPreferences {
+	return &PostgresPreferences{db: db}
+}
+
+func (s *PostgresPreferences) Get(ctx context.Context, userID uuid.UUID) (Preferences, error) {
+	if s.db == nil {
+		return Preferences{}, errors.New("notify: preferences store not initialized")
+	}
+	prefs := Preferences{UserID: userID}
+	err := s.db.QueryRowContext(ctx, "SELECT locale, email, phone, push_token FROM NOTIFICATION_CONTACTS WHERE user_id = $1", userID).
+		Scan(&prefs.Locale, &prefs.Email, &prefs.Phone, &prefs.PushToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Preferences{}, ErrNoPreferences
+	}
+	if err != nil {
+		return Preferences{}, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT event_type, channels FROM NOTIFICATION_PREFERENCES WHERE user_id = $1", userID)
+	if err != nil {
+		return Preferences{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event, channels string
+		if err := rows.Scan(&event, &channels); err != nil {
+			return Preferences{}, err
+		}
+		if prefs.Channels == nil {
+			prefs.Channels = make(map[EventType][]ChannelKind)
+		}
+		kinds := make([]ChannelKind, 0)
+		for _, kind := range strings.Split(channels, ",") {
+			if kind != "" {
+				kinds = append(kinds, ChannelKind(kind))
+			}
+		}
+		prefs.Channels[EventType(event)] = kinds
+	}
+	return prefs, rows.Err()
+}
+
+func (s *PostgresPreferences) Save(ctx context.Context, prefs Preferences) (err error) {
+	if s.db == nil {
+		return errors.New("notify: preferences store not initialized")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	const upsertContact = `
+		INSERT INTO NOTIFICATION_CONTACTS (user_id, locale, email, phone, push_token, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET locale = EXCLUDED.locale, email = EXCLUDED.email,
+			phone = EXCLUDED.phone, push_token = EXCLUDED.push_token, updated_at = EXCLUDED.updated_at
+	`
+	if _, err = tx.ExecContext(ctx, upsertContact, prefs.UserID, prefs.Locale, prefs.Email, prefs.Phone, prefs.PushToken, time.Now().UTC()); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM NOTIFICATION_PREFERENCES WHERE user_id = $1", prefs.UserID); err != nil {
+		return err
+	}
+	for event, kinds := range prefs.Channels {
+		names := make([]string, 0, len(kinds))
+		for _, kind := range kinds {
+			names = append(names, string(kind))
+		}
+		if _, err = tx.ExecContext(ctx, "INSERT INTO NOTIFICATION_PREFERENCES (user_id, event_type, channels) VALUES ($1, $2, $3)",
+			prefs.UserID, string(event), strings.Join(names, ",")); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PostgresQueue keeps messages in NOTIFICATIONS_OUTBOX. Claim locks rows with
+// SKIP LOCKED, so several workers can drain the same table.
+type PostgresQueue struct {
+	db *sql.DB
+}
+
+func NewPostgresQueue(db *sql.DB) *PostgresQueue {
+	return &PostgresQueue{db: db}
+}
+
+const messageColumns = `emp_id, user_id, event_type, channel, recipient, subject, body, status, attempts, next_attempt_at, last_error, created_at, sent_at`
+
+func (q *PostgresQueue) Enqueue(ctx context.Context, msgs ...Message) error {
+	if q.db == nil {
+		return errors.New("notify: queue not initialized")
+	}
+	const query = `INSERT INTO NOTIFICATIONS_OUTBOX (` + messageColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	for _, msg := range msgs {
+		if _, err := q.db.ExecContext(ctx, query, msg.ID, msg.UserID, string(msg.Event), string(msg.Channel), msg.To, msg.Subject, msg.Body,
+			string(msg.Status), msg.Attempts, msg.NextAttemptAt, msg.LastError, msg.CreatedAt, msg.SentAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *PostgresQueue) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Message, error) {
+	if q.db == nil {
+		return nil, errors.New("notify: queue not initialized")
+	}
+	const query = `
+		UPDATE NOTIFICATIONS_OUTBOX SET next_attempt_at = $1
+		WHERE emp_id IN (
+			SELECT emp_id FROM NOTIFICATIONS_OUTBOX
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + messageColumns
+	rows, err := q.db.QueryContext(ctx, query, leaseUntil, string(MessagePending), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs := make([]Message, 0)
+	for rows.Next() {
+		var (
+			msg                    Message
+			event, channel, status string
+			sentAt                 sql.NullTime
+		)
+		if err := rows.Scan(&msg.ID, &msg.UserID, &event, &channel, &msg.To, &msg.Subject, &msg.Body, &status,
+			&msg.Attempts, &msg.NextAttemptAt, &msg.LastError, &msg.CreatedAt, &sentAt); err != nil {
+			return nil, err
+		}
+		msg.Event = EventType(event)
+		msg.Channel = ChannelKind(channel)
+		msg.Status = MessageStatus(status)
+		if sentAt.Valid {
+			t := sentAt.Time
+			msg.SentAt = &t
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
+
+func (q *PostgresQueue) Update(ctx context.Context, msg Message) error {
+	if q.db == nil {
+		return errors.New("notify: queue not initialized")
+	}
+	_, err := q.db.ExecContext(ctx, `UPDATE NOTIFICATIONS_OUTBOX SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, sent_at = $5 WHERE emp_id = $6`,
+		string(msg.Status), msg.Attempts, msg.NextAttemptAt, msg.LastError, msg.SentAt, msg.ID)
+	return err
+}
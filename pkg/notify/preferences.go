@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Preferences holds where a user can be reached and which channels they
+// want per event. Events missing from Channels go to every channel the user
+// has an address for; an empty list mutes the event.
+type Preferences struct {
+	UserID    uuid.UUID                   `json:"user_id" required:"true"`
+	Locale    string                      `json:"locale"`
+	Email     string                      `json:"email,omitempty"`
+	Phone     string                      `json:"phone,omitempty"`
+	PushToken string                      `json:"push_token,omitempty"`
+	Channels  map[EventType][]ChannelKind `json:"channels,omitempty"`
+}
+
+// Address returns where to send on the given channel, or "" if unknown.
+func (p Preferences) Address(kind ChannelKind) string {
+	switch kind {
+	case ChannelEmail:
+		return p.Email
+	case ChannelSMS:
+		return p.Phone
+	case ChannelPush:
+		return p.PushToken
+	}
+	return ""
+}
+
+// ChannelsFor resolves the channels an event should go out on.
+func (p Preferences) ChannelsFor(event EventType) []ChannelKind {
+	wanted, explicit := p.Channels[event]
+	if !explicit {
+		wanted = AllChannels
+	}
+	result := make([]ChannelKind, 0, len(wanted))
+	for _, kind := range wanted {
+		if p.Address(kind) != "" {
+			result = append(result, kind)
+		}
+	}
+	return result
+}
+
+// PreferenceStore loads and saves Preferences. Get returns ErrNoPreferences
+// for users that never registered contact details.
+type PreferenceStore interface {
+	Get(ctx context.Context, userID uuid.UUID) (Preferences, error)
+	Save(ctx context.Context, prefs Preferences) error
+}
+
+// MemoryPreferences is a PreferenceStore for tests and local runs.
+type MemoryPreferences struct {
+	mu    sync.RWMutex
+	prefs map[uuid.UUID]Preferences
+}
+
+func NewMemoryPreferences() *MemoryPreferences {
+	return &MemoryPreferences{prefs: make(map[uuid.UUID]Preferences)}
+}
+
+func (m *MemoryPreferences) Get(ctx context.Context, userID uuid.UUID) (Preferences, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	prefs, ok := m.prefs[userID]
+	if !ok {
+		return Preferences{}, ErrNoPreferences
+	}
+	return prefs, nil
+}
+
+func (m *MemoryPreferences) Save(ctx context.Context, prefs Preferences) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prefs[prefs.UserID] = prefs
+	return nil
+}
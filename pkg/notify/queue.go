@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Queue persists messages between enqueueing and delivery.
+//
+// Claim hands out due PENDING messages and pushes their next attempt to
+// leaseUntil, so a crashed worker's messages are picked up again later and
+// two workers do not send the same message at once.
+type Queue interface {
+	Enqueue(ctx context.Context, msgs ...Message) error
+	Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Message, error)
+	Update(ctx context.Context, msg Message) error
+}
+
+// FileQueue keeps the queue in a single JSON file, rewritten atomically on
+// every change. Good for one process and offline runs, not for high volume.
+type FileQueue struct {
+	mu       sync.Mutex
+	path     string
+	messages map[uuid.UUID]Message
+}
+
+func NewFileQueue(path string) (*FileQueue, error) {
+	q := &FileQueue{path: path, messages: make(map[uuid.UUID]Message)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("notify: read queue: %w", err)
+	}
+	var stored []Message
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return nil, fmt.Errorf("notify: decode queue: %w", err)
+		}
+	}
+	for _, msg := range stored {
+		q.messages[msg.ID] = msg
+	}
+	return q, nil
+}
+
+func (q *FileQueue) Enqueue(ctx context.Context, msgs ...Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, msg := range msgs {
+		q.messages[msg.ID] = msg
+	}
+	return q.flush()
+}
+
+func (q *FileQueue) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	due := make([]Message, 0)
+	for _, msg := range q.messages {
+		if msg.Status == MessagePending && !msg.NextAttemptAt.After(now) {
+			due = append(due, msg)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	for _, msg := range due {
+		msg.NextAttemptAt = leaseUntil
+		q.messages[msg.ID] = msg
+	}
+	if len(due) > 0 {
+		if err := q.flush(); err != nil {
+			return nil, err
+		}
+	}
+	return due, nil
+}
+
+func (q *FileQueue) Update(ctx context.Context, msg Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.messages[msg.ID]; !ok {
+		return fmt.Errorf("notify: message %s not queued", msg.ID)
+	}
+	q.messages[msg.ID] = msg
+	return q.flush()
+}
+
+// Messages returns a snapshot of every stored message, oldest first.
+func (q *FileQueue) Messages() []Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.sorted()
+}
+
+func (q *FileQueue) sorted() []Message {
+	all := make([]Message, 0, len(q.messages))
+	for _, msg := range q.messages {
+		all = append(all, msg)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+	return all
+}
+
+func (q *FileQueue) flush() error {
+	data, err := json.MarshalIndent(q.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return fmt.Errorf("notify: create queue dir: %w", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("notify: write queue: %w", err)
+	}
+	return os.Rename(tmp, q.path)
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig points the email channel at a mail server. Without Username the
+// client sends unauthenticated, which is what local test servers expect.
+type SMTPConfig struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// SMTPChannel sends email with net/smtp.
+type SMTPChannel struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPChannel(cfg SMTPConfig) *SMTPChannel {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &SMTPChannel{cfg: cfg}
+}
+
+func (c *SMTPChannel) Kind() ChannelKind { return ChannelEmail }
+
+func (c *SMTPChannel) Send(ctx context.Context, msg Message) error {
+	if c.cfg.Addr == "" || c.cfg.From == "" {
+		return fmt.Errorf("notify: smtp channel not configured")
+	}
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("notify: invalid recipient %q", msg.To)
+	}
+
+	dialer := net.Dialer{Timeout: c.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("notify: smtp dial: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(c.cfg.Timeout))
+
+	host, _, _ := net.SplitHostPort(c.cfg.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("notify: smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if c.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, host)); err != nil {
+			return fmt.Errorf("notify: smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(c.cfg.From); err != nil {
+		return fmt.Errorf("notify: smtp MAIL: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("notify: smtp RCPT: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("notify: smtp DATA: %w", err)
+	}
+	if _, err := w.Write(buildEmail(c.cfg.From, msg)); err != nil {
+		return fmt.Errorf("notify: smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("notify: smtp DATA end: %w", err)
+	}
+	return client.Quit()
+}
+
+func buildEmail(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Message-ID: <" + msg.ID.String() + "@yafds>\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeSMTPServer accepts one session and returns the DATA payload.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	data := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				reply("250 ok")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var body strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					body.WriteString(l)
+				}
+				data <- body.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), data
+}
+
+func TestSMTPChannel(t *testing.T) {
+	addr, data := fakeSMTPServer(t)
+	ch := NewSMTPChannel(SMTPConfig{Addr: addr, From: "noreply@yafds.local"})
+
+	err := ch.Send(context.Background(), Message{ID: uuid.New(), To: "kitchen@example.com", Subject: "Order 1 is paid", Body: "line one\nline two"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	got := <-data
+	for _, want := range []string{"To: kitchen@example.com\r\n", "Subject: Order 1 is paid\r\n", "line one\r\nline two"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("email missing %q:\n%s", want, got)
+		}
+	}
+}
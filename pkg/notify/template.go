@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultLocale is used when a user's locale has no template.
+const DefaultLocale = "en"
+
+// Template is the source of one event/locale pair. Subject doubles as the
+// push title; SMS sends only the body.
+type Template struct {
+	Subject string
+	Body    string
+}
+
+type compiledTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// Templates renders events per locale with text/template syntax.
+type Templates struct {
+	mu        sync.RWMutex
+	templates map[string]compiledTemplate
+}
+
+func NewTemplates() *Templates {
+	return &Templates{templates: make(map[string]compiledTemplate)}
+}
+
+// DefaultTemplates returns the built-in English and Russian order templates.
+func DefaultTemplates() *Templates {
+	t := NewTemplates()
+	for event, byLocale := range defaultTemplateSources {
+		for locale, src := range byLocale {
+			if err := t.Register(event, locale, src); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return t
+}
+
+func templateKey(event EventType, locale string) string {
+	return string(event) + "/" + strings.ToLower(locale)
+}
+
+// Register compiles and stores a template, replacing an existing one.
+func (t *Templates) Register(event EventType, locale string, src Template) error {
+	subject, err := template.New("subject").Option("missingkey=zero").Parse(src.Subject)
+	if err != nil {
+		return fmt.Errorf("notify: parse %s/%s subject: %w", event, locale, err)
+	}
+	body, err := template.New("body").Option("missingkey=zero").Parse(src.Body)
+	if err != nil {
+		return fmt.Errorf("notify: parse %s/%s body: %w", event, locale, err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.templates[templateKey(event, locale)] = compiledTemplate{subject: subject, body: body}
+	return nil
+}
+
+// Render picks the template for locale, then its base language ("ru" for
+// "ru-RU"), then DefaultLocale.
+func (t *Templates) Render(event EventType, locale string, data map[string]any) (string, string, error) {
+	t.mu.RLock()
+	compiled, ok := t.lookup(event, locale)
+	t.mu.RUnlock()
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", ErrUnknownTemplate, event)
+	}
+
+	var subject, body bytes.Buffer
+	if err := compiled.subject.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("notify: render %s subject: %w", event, err)
+	}
+	if err := compiled.body.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("notify: render %s body: %w", event, err)
+	}
+	return subject.String(), body.String(), nil
+}
+
+func (t *Templates) lookup(event EventType, locale string) (compiledTemplate, bool) {
+	candidates := []string{locale}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, base)
+	}
+	candidates = append(candidates, DefaultLocale)
+	for _, candidate := range candidates {
+		if compiled, ok := t.templates[templateKey(event, candidate)]; ok {
+			return compiled, true
+		}
+	}
+	return compiledTemplate{}, false
+}
+
+var defaultTemplateSources = map[EventType]map[string]Template{
+	EventOrderPaid: {
+		"en": {Subject: "Order {{.order_id}} is paid", Body: "Order {{.order_id}} was paid and is waiting for the kitchen."},
+		"ru": {Subject: "Заказ {{.order_id}} оплачен", Body: "Заказ {{.order_id}} оплачен и ждёт кухню."},
+	},
+	EventOrderReleased: {
+		"en": {Subject: "Scheduled order {{.order_id}} is due", Body: "Scheduled order {{.order_id}} should be delivered at {{.deliver_at}}. Start preparing it."},
+		"ru": {Subject: "Пора готовить заказ {{.order_id}}", Body: "Запланированный заказ {{.order_id}} нужно доставить к {{.deliver_at}}. Начинайте готовить."},
+	},
+	EventOrderCancelled: {
+		"en": {Subject: "Order {{.order_id}} was cancelled", Body: "Order {{.order_id}} was cancelled by the customer ({{.reason_code}})."},
+		"ru": {Subject: "Заказ {{.order_id}} отменён", Body: "Покупатель отменил заказ {{.order_id}} ({{.reason_code}})."},
+	},
+	EventOrderStatusChanged: {
+		"en": {Subject: "Order {{.order_id}}: {{.status}}", Body: "Your order {{.order_id}} is now {{.status}}."},
+		"ru": {Subject: "Заказ {{.order_id}}: {{.status}}", Body: "Статус вашего заказа {{.order_id}}: {{.status}}."},
+	},
+	EventPasswordReset: {
+		"en": {Subject: "Password reset", Body: "Your password reset code is {{.token}}. It is valid until {{.valid_until}}. If you did not ask for it, ignore this message."},
+		"ru": {Subject: "Сброс пароля", Body: "Код для сброса пароля: {{.token}}. Он действует до {{.valid_until}}. Если вы его не запрашивали, проигнорируйте это сообщение."},
+	},
+	EventEmailConfirmation: {
+		"en": {Subject: "Confirm your recovery email", Body: "Your confirmation code is {{.token}}. It is valid until {{.valid_until}}. Once confirmed, password reset codes are sent to this address. If you did not ask for it, ignore this message."},
+		"ru": {Subject: "Подтвердите почту для восстановления", Body: "Код подтверждения: {{.token}}. Он действует до {{.valid_until}}. После подтверждения коды для сброса пароля будут приходить на этот адрес. Если вы его не запрашивали, проигнорируйте это сообщение."},
+	},
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType names what happened; templates are looked up by it.
+type EventType string
+
+const (
+	EventOrderPaid          EventType = "ORDER_PAID"
+	EventOrderReleased      EventType = "ORDER_RELEASED"
+	EventOrderCancelled     EventType = "ORDER_CANCELLED"
+	EventOrderStatusChanged EventType = "ORDER_STATUS_CHANGED"
+	EventPasswordReset      EventType = "PASSWORD_RESET"
+	EventEmailConfirmation  EventType = "EMAIL_CONFIRMATION"
+)
+
+// ChannelKind is a delivery medium a user can opt into.
+type ChannelKind string
+
+const (
+	ChannelEmail ChannelKind = "EMAIL"
+	ChannelSMS   ChannelKind = "SMS"
+	ChannelPush  ChannelKind = "PUSH"
+)
+
+// AllChannels lists the channels in the order they are tried.
+var AllChannels = []ChannelKind{ChannelEmail, ChannelSMS, ChannelPush}
+
+func (k ChannelKind) Valid() bool {
+	for _, known := range AllChannels {
+		if k == known {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrNoPreferences   = errors.New("notify: user has no contact details")
+	ErrUnknownTemplate = errors.New("notify: no template for event")
+	ErrUnknownChannel  = errors.New("notify: unknown channel")
+)
+
+// Channel delivers one rendered message. Implementations must be safe for
+// concurrent use.
+type Channel interface {
+	Kind() ChannelKind
+	Send(ctx context.Context, msg Message) error
+}
+
+// Notification is what callers hand to the Notifier. RestaurantID is the
+// restaurant of the order; webhooks are published to it while UserID is
+// who gets the message.
+type Notification struct {
+	Event        EventType
+	UserID       uuid.UUID
+	RestaurantID uuid.UUID
+	Data         map[string]any
+}
+
+type MessageStatus string
+
+const (
+	MessagePending MessageStatus = "PENDING"
+	MessageSent    MessageStatus = "SENT"
+	MessageDead    MessageStatus = "DEAD"
+)
+
+// Message is a rendered notification for one channel, as stored in the queue.
+type Message struct {
+	ID            uuid.UUID     `json:"id"`
+	UserID        uuid.UUID     `json:"user_id"`
+	Event         EventType     `json:"event"`
+	Channel       ChannelKind   `json:"channel"`
+	To            string        `json:"to"`
+	Subject       string        `json:"subject"`
+	Body          string        `json:"body"`
+	Status        MessageStatus `json:"status"`
+	Attempts      int           `json:"attempts"`
+	NextAttemptAt time.Time     `json:"next_attempt_at"`
+	LastError     string        `json:"last_error,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	SentAt        *time.Time    `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,347 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/config"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// PrintFlag makes a service print its document and exit.
+const PrintFlag = "--print-openapi"
+
+// PrintRequested reports whether args, usually os.Args[1:], ask for
+// PrintFlag.
+func PrintRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == PrintFlag || arg == "-print-openapi" {
+			return true
+		}
+	}
+	return false
+}
+
+// Fprint writes the document as indented JSON.
+func Fprint(w io.Writer, d *Document) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Operations documents GET /openapi.json, GET /docs and its assets.
+var Operations = map[string]Op{
+	"GET /openapi.json": {
+		Summary:   "This OpenAPI document",
+		Responses: map[int]any{http.StatusOK: &Schema{Type: "object"}},
+	},
+	"GET /docs": {
+		Summary:   "API documentation page",
+		Responses: map[int]any{http.StatusOK: Raw("text/html")},
+	},
+	"GET /docs/{file}": {
+		Summary:   "Script or stylesheet of the API documentation page",
+		Responses: map[int]any{http.StatusOK: Raw("*/*")},
+	},
+}
+
+// Handler serves the document, for GET /openapi.json.
+func (d *Document) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteJSON(w, d, http.StatusOK)
+	})
+}
+
+// The page loads Swagger UI from the binary rather than a CDN, so a
+// compromised CDN cannot run script next to a user's tokens, and docsCSP
+// keeps it to those files. Swagger UI sets inline styles, hence
+// 'unsafe-inline' for styles only.
+const docsCSP = "default-src 'none'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="/docs/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui" data-url="{{.SpecURL}}"></div>
+<script src="/docs/swagger-ui-bundle.js"></script>
+<script src="/docs/init.js"></script>
+</body>
+</html>
+`))
+
+// docsInit starts Swagger UI; it is a file rather than an inline script
+// so docsCSP need not allow inline scripts. The validator badge would
+// send the document to validator.swagger.io, so it is off.
+const docsInit = `window.ui = SwaggerUIBundle({
+  url: document.getElementById("swagger-ui").dataset.url,
+  dom_id: "#swagger-ui",
+  validatorUrl: null
+});
+`
+
+// docsAssets are the files of swagger-ui-dist the page loads.
+var docsAssets = map[string]bool{"swagger-ui.css": true, "swagger-ui-bundle.js": true}
+
+// DocsHandler serves a Swagger UI page for the document at specURL, for
+// GET /docs.
+func (d *Document) DocsHandler(specURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", docsCSP)
+		_ = docsPage.Execute(w, struct{ Title, SpecURL string }{d.Info.Title, specURL})
+	})
+}
+
+// DocsAssetsHandler serves the scripts and stylesheet the page loads, for
+// GET /docs/{file}.
+func DocsAssetsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file := r.PathValue("file")
+		if file != "init.js" && !docsAssets[file] {
+			utils.WriteError(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		if file == "init.js" {
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			_, _ = io.WriteString(w, docsInit)
+			return
+		}
+		http.ServeFileFS(w, r, swaggerFiles.FS, file)
+	})
+}
+
+// Validate checks requests against the document before they reach the
+// router and answers mismatches with a 400 listing every offending value.
+// With cfg.ValidateResponses it also buffers responses and replaces those
+// that break the document with a 500, so tests catch handlers drifting
+// from the contract. Requests no operation matches pass through for the
+// router to answer.
+//
+// It passes r itself on, so it may sit between tracing and the router.
+func Validate(d *Document, cfg config.OpenAPI) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.ValidateRequests && !cfg.ValidateResponses {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, params := d.match(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if cfg.ValidateRequests {
+				errs, err := d.checkRequest(op, params, r)
+				var tooLarge *http.MaxBytesError
+				switch {
+				case errors.As(err, &tooLarge):
+					utils.WriteError(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+					return
+				case err != nil:
+					utils.WriteError(w, "failed to read request body", http.StatusBadRequest)
+					return
+				case len(errs) > 0:
+					utils.WriteJSON(w, ValidationError{
+						ErrorResponce: utils.ErrorBody(w, "request does not match the API contract", http.StatusBadRequest),
+						Fields:        errs,
+					}, http.StatusBadRequest)
+					return
+				}
+			}
+			if !cfg.ValidateResponses {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &responseBuffer{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if errs := d.checkResponse(op, status, w.Header(), rec.body.Bytes()); len(errs) > 0 {
+				logging.FromContext(r.Context()).Error("openapi: response does not match the API contract",
+					"method", r.Method, "path", r.URL.Path, "status", status, "fields", errs)
+				w.Header().Del("Content-Length")
+				utils.WriteJSON(w, ValidationError{
+					ErrorResponce: utils.ErrorBody(w, "response does not match the API contract", http.StatusInternalServerError),
+					Fields:        errs,
+				}, http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// match finds the operation for method and path and the path parameters
+// it names. Literal segments win over parameters, like in http.ServeMux.
+func (d *Document) match(method, path string) (*Operation, map[string]string) {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	var best *route
+	bestLiterals := -1
+	for i := range d.routes {
+		rt := &d.routes[i]
+		if rt.method != method || len(rt.segments) != len(segments) {
+			continue
+		}
+		literals, ok := 0, true
+		for j, segment := range rt.segments {
+			if _, isParam := pathParam(segment); isParam {
+				ok = ok && segments[j] != ""
+			} else if segment == segments[j] {
+				literals++
+			} else {
+				ok = false
+			}
+		}
+		if ok && literals > bestLiterals {
+			best, bestLiterals = rt, literals
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	params := map[string]string{}
+	for j, segment := range best.segments {
+		if name, ok := pathParam(segment); ok {
+			params[name] = segments[j]
+		}
+	}
+	return best.op, params
+}
+
+// checkRequest validates the parameters and body of r. It reads the body
+// and puts it back for the handler.
+func (d *Document) checkRequest(op *Operation, params map[string]string, r *http.Request) ([]FieldError, error) {
+	var errs []FieldError
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		c := &checker{doc: d, in: p.In}
+		var raw string
+		if p.In == "path" {
+			raw = params[p.Name]
+		} else {
+			raw = query.Get(p.Name)
+		}
+		switch {
+		case raw != "":
+			c.param(p.Schema, raw, p.Name)
+		case p.Required:
+			c.fail(p.Name, "required", "is required")
+		}
+		errs = append(errs, c.errs...)
+	}
+
+	if op.RequestBody == nil || r.Body == nil {
+		return errs, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	c := &checker{doc: d, in: "body"}
+	v, err := decode(body)
+	switch {
+	case len(bytes.TrimSpace(body)) == 0:
+		if op.RequestBody.Required {
+			c.fail("", "required", "request body is required")
+		}
+	case err != nil:
+		c.fail("", "syntax", "must be valid JSON: %v", err)
+	default:
+		c.value(op.RequestBody.Content["application/json"].Schema, v, "")
+	}
+	return append(errs, c.errs...), nil
+}
+
+// checkResponse validates a JSON response against the schema for its
+// status; statuses below 400 must be documented.
+func (d *Document) checkResponse(op *Operation, status int, header http.Header, body []byte) []FieldError {
+	c := &checker{doc: d, in: "response"}
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if status < http.StatusBadRequest {
+			c.fail("", "status", "status %d is not documented", status)
+			return c.errs
+		}
+		response = op.Responses["default"]
+	}
+	if len(response.Content) == 0 {
+		if len(body) > 0 {
+			c.fail("", "content", "status %d has no body", status)
+		}
+		return c.errs
+	}
+	media, ok := response.Content["application/json"]
+	if !ok {
+		return nil
+	}
+	if contentType, _, _ := mime.ParseMediaType(header.Get("Content-Type")); contentType != "application/json" {
+		c.fail("", "content", "must be application/json, not %q", header.Get("Content-Type"))
+		return c.errs
+	}
+	v, err := decode(body)
+	if err != nil {
+		c.fail("", "syntax", "must be valid JSON: %v", err)
+		return c.errs
+	}
+	c.value(media.Schema, v, "")
+	return c.errs
+}
+
+func decode(body []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// responseBuffer holds a response back until it has been validated;
+// headers go straight to the real writer.
+type responseBuffer struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
@@ -0,0 +1,335 @@
+// Package openapi builds the OpenAPI 3 document of a service from the Go
+// types its handlers decode and encode, serves it with a docs page, and
+// checks requests (and, when testing, responses) against it.
+//
+// Request structs carry the rules their handlers enforce in tags next to
+// the json names, in the same style as config sections:
+//
+//	CustomerID string `json:"customer_id" required:"true" format:"uuid"`
+//	Quantity   int    `json:"quantity" min:"1"`
+//	ReasonCode string `json:"reason_code" oneof:"CHANGED_MIND OTHER"`
+//
+// A required string must also be non-empty, and formats are not checked
+// on empty strings: handlers treat "" as absent.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+)
+
+// Version is the OpenAPI version the documents follow.
+const Version = "3.0.3"
+
+// Security schemes an Op may list.
+const (
+	Bearer       = "bearer"
+	APIKey       = "apiKey"
+	ServiceToken = "serviceToken"
+)
+
+var securitySchemes = map[string]SecurityScheme{
+	Bearer:       {Type: "http", Scheme: "bearer", Description: "Session token or signed access token from /login"},
+	APIKey:       {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "Restaurant API key from /api-keys"},
+	ServiceToken: {Type: "apiKey", In: "header", Name: "X-Service-Token", Description: "SERVICE_TOKEN shared by the services"},
+}
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	// names maps the Go types turned into component schemas to their names.
+	names  map[reflect.Type]string
+	routes []route
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema is the subset of JSON Schema the documents use.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// Op describes one route for Document.Add.
+type Op struct {
+	Summary     string
+	Description string
+	// Security names the schemes that authenticate the call; any one
+	// of them is enough.
+	Security []string
+	Query    []Param
+	// Body is a value of the type the handler decodes, or a *Schema.
+	Body any
+	// Responses maps statuses to a value of the type written, a *Schema,
+	// a Raw content type for non-JSON bodies, or nil for no body. A 400
+	// for malformed requests and a default error are added when missing.
+	Responses map[int]any
+}
+
+// Param is a query parameter. Path parameters come from the pattern.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// Raw is the content type of a response that is not JSON, e.g. "text/html".
+type Raw string
+
+// ValidationError is the 400 body of a request that does not match the
+// document: the error envelope plus one entry per offending value.
+type ValidationError struct {
+	models.ErrorResponce
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError names a value that breaks the document. In is "path",
+// "query", "body" or "response"; Field is a path such as
+// "items[0].quantity", empty for the whole body.
+type FieldError struct {
+	In      string `json:"in"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// route is an operation with its pattern split for matching.
+type route struct {
+	method   string
+	segments []string
+	op       *Operation
+}
+
+func New(title, version string) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: title, Version: version},
+		Paths:      map[string]PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+// Add documents the route of pattern, e.g. "POST /orders/{order_id}/pay".
+// Path parameters ending in _id are UUIDs. Adding a pattern twice panics,
+// like registering it twice on a router.
+func (d *Document) Add(pattern string, op Op) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok || method == "" || !strings.HasPrefix(path, "/") {
+		panic(fmt.Sprintf("openapi: pattern %q has no method", pattern))
+	}
+	item := d.Paths[path]
+	if item == nil {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+	key := strings.ToLower(method)
+	if item[key] != nil {
+		panic(fmt.Sprintf("openapi: %s documented twice", pattern))
+	}
+
+	operation := &Operation{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Responses:   map[string]Response{},
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if tag := strings.TrimPrefix(segments[0], "."); tag != "" {
+		operation.Tags = []string{tag}
+	}
+	for _, segment := range segments {
+		if name, ok := pathParam(segment); ok {
+			schema := &Schema{Type: "string"}
+			if strings.HasSuffix(name, "_id") {
+				schema.Format = "uuid"
+			}
+			operation.Parameters = append(operation.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		}
+	}
+	for _, p := range op.Query {
+		schema := p.Schema
+		if schema == nil {
+			schema = String()
+		}
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name: p.Name, In: "query", Description: p.Description, Required: p.Required, Schema: schema,
+		})
+	}
+	if op.Body != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: d.schemaOf(op.Body)}},
+		}
+	}
+	for _, name := range op.Security {
+		scheme, ok := securitySchemes[name]
+		if !ok {
+			panic(fmt.Sprintf("openapi: %s: unknown security scheme %q", pattern, name))
+		}
+		if d.Components.SecuritySchemes == nil {
+			d.Components.SecuritySchemes = map[string]SecurityScheme{}
+		}
+		d.Components.SecuritySchemes[name] = scheme
+		operation.Security = append(operation.Security, map[string][]string{name: {}})
+	}
+
+	for status, body := range op.Responses {
+		operation.Responses[strconv.Itoa(status)] = d.response(status, body)
+	}
+	if _, ok := op.Responses[http.StatusBadRequest]; !ok && (len(operation.Parameters) > 0 || op.Body != nil) {
+		operation.Responses["400"] = d.response(http.StatusBadRequest, ValidationError{})
+	}
+	operation.Responses["default"] = Response{
+		Description: "Error",
+		Content:     map[string]MediaType{"application/json": {Schema: d.schemaOf(models.ErrorResponce{})}},
+	}
+
+	item[key] = operation
+	d.routes = append(d.routes, route{method: method, segments: segments, op: operation})
+}
+
+// AddAll adds ops; with patterns, only those, which must all be in ops.
+// Patterns are added in sorted order so schema names do not depend on map
+// iteration.
+func (d *Document) AddAll(ops map[string]Op, patterns ...string) {
+	if len(patterns) == 0 {
+		for pattern := range ops {
+			patterns = append(patterns, pattern)
+		}
+	}
+	patterns = slices.Clone(patterns)
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		op, ok := ops[pattern]
+		if !ok {
+			panic(fmt.Sprintf("openapi: no operation for %s", pattern))
+		}
+		d.Add(pattern, op)
+	}
+}
+
+// Missing returns the patterns, as registered on a router, that the
+// document does not describe.
+func (d *Document) Missing(patterns []string) []string {
+	var missing []string
+	for _, pattern := range patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		if d.Paths[path][strings.ToLower(method)] == nil {
+			missing = append(missing, pattern)
+		}
+	}
+	return missing
+}
+
+func (d *Document) response(status int, body any) Response {
+	response := Response{Description: http.StatusText(status)}
+	switch body := body.(type) {
+	case nil:
+	case Raw:
+		response.Content = map[string]MediaType{string(body): {Schema: String()}}
+	default:
+		response.Content = map[string]MediaType{"application/json": {Schema: d.schemaOf(body)}}
+	}
+	return response
+}
+
+func pathParam(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return strings.TrimSuffix(segment[1:len(segment)-1], "..."), true
+	}
+	return "", false
+}
+
+// String, UUID, DateTime, Integer and Boolean are schemas for query
+// parameters and hand-built bodies.
+func String() *Schema { return &Schema{Type: "string"} }
+
+func UUID() *Schema { return &Schema{Type: "string", Format: "uuid"} }
+
+func DateTime() *Schema { return &Schema{Type: "string", Format: "date-time"} }
+
+func Boolean() *Schema { return &Schema{Type: "boolean"} }
+
+// Integer is an integer of at least minimum.
+func Integer(minimum float64) *Schema { return &Schema{Type: "integer", Minimum: &minimum} }
+
+// Enum is a string that must be one of values.
+func Enum(values ...string) *Schema { return &Schema{Type: "string", Enum: values} }
@@ -0,0 +1,265 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/config"
+	"github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+type lineRequest struct {
+	RestaurantItemID string `json:"restaurant_item_id" required:"true" format:"uuid"`
+	Quantity         int    `json:"quantity" min:"1"`
+}
+
+type orderRequest struct {
+	CustomerID string        `json:"customer_id" required:"true" format:"uuid"`
+	DeliverAt  string        `json:"deliver_at" format:"date-time"`
+	Reason     string        `json:"reason" oneof:"CHANGED_MIND OTHER"`
+	Items      []lineRequest `json:"items" required:"true" min:"1"`
+}
+
+type orderResponse struct {
+	models.ErrorResponce
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeliverAt *time.Time `json:"deliver_at,omitempty"`
+	Total     float64    `json:"total"`
+	Internal  string     `json:"-"`
+}
+
+func testDocument() *Document {
+	doc := New("test", "1")
+	doc.Add("POST /orders/{order_id}/items", Op{
+		Query:     []Param{{Name: "limit", Schema: Integer(1)}},
+		Body:      orderRequest{},
+		Responses: map[int]any{http.StatusCreated: orderResponse{}, http.StatusNoContent: nil},
+	})
+	doc.Add("POST /orders/special/items", Op{Responses: map[int]any{http.StatusOK: nil}})
+	return doc
+}
+
+func TestSchemaReflection(t *testing.T) {
+	doc := testDocument()
+	req := doc.Components.Schemas["OrderRequest"]
+	if req == nil {
+		t.Fatalf("schemas: %v", reflect.ValueOf(doc.Components.Schemas).MapKeys())
+	}
+	if !reflect.DeepEqual(req.Required, []string{"customer_id", "items"}) {
+		t.Errorf("required = %v", req.Required)
+	}
+	if s := req.Properties["customer_id"]; s.Format != "uuid" || s.MinLength == nil || *s.MinLength != 1 {
+		t.Errorf("customer_id = %+v", s)
+	}
+	if s := req.Properties["items"]; s.Nullable || s.MinItems == nil || *s.MinItems != 1 || s.Items.Ref != "#/components/schemas/LineRequest" {
+		t.Errorf("items = %+v", s)
+	}
+	if s := req.Properties["reason"]; !reflect.DeepEqual(s.Enum, []string{"CHANGED_MIND", "OTHER"}) {
+		t.Errorf("reason = %+v", s)
+	}
+
+	resp := doc.Components.Schemas["OrderResponse"]
+	for _, name := range []string{"error_message", "code", "id", "created_at", "deliver_at", "total"} {
+		if resp.Properties[name] == nil {
+			t.Errorf("response lacks %s", name)
+		}
+	}
+	if resp.Properties["Internal"] != nil || resp.Properties["-"] != nil {
+		t.Error(`json:"-" field documented`)
+	}
+	if s := resp.Properties["deliver_at"]; !s.Nullable || s.Format != "date-time" {
+		t.Errorf("deliver_at = %+v", s)
+	}
+
+	op := doc.Paths["/orders/{order_id}/items"]["post"]
+	if p := op.Parameters[0]; p.In != "path" || p.Name != "order_id" || !p.Required || p.Schema.Format != "uuid" {
+		t.Errorf("path parameter = %+v", p)
+	}
+	for _, status := range []string{"201", "204", "400", "default"} {
+		if _, ok := op.Responses[status]; !ok {
+			t.Errorf("response %s missing", status)
+		}
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	got := testDocument().Missing([]string{"POST /orders/{order_id}/items", "GET /orders/{order_id}/items"})
+	if !reflect.DeepEqual(got, []string{"GET /orders/{order_id}/items"}) {
+		t.Errorf("Missing = %v", got)
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	reached := false
+	h := Validate(testDocument(), config.OpenAPI{ValidateRequests: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		var req orderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("handler cannot read body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	orderPath := "/orders/" + uuid.NewString() + "/items"
+	valid := `{"customer_id":"` + uuid.NewString() + `","items":[{"restaurant_item_id":"` + uuid.NewString() + `","quantity":2}]}`
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		fields []string
+	}{
+		{"valid", orderPath, valid, http.StatusCreated, nil},
+		{"unmatched route", "/menu", valid, http.StatusCreated, nil},
+		{"bad path param", "/orders/42/items", valid, http.StatusBadRequest, []string{"path order_id format"}},
+		{"bad query", orderPath + "?limit=0", valid, http.StatusBadRequest, []string{"query limit minimum"}},
+		{"empty body", orderPath, "", http.StatusBadRequest, []string{"body  required"}},
+		{"syntax", orderPath, "{", http.StatusBadRequest, []string{"body  syntax"}},
+		{
+			"fields", orderPath,
+			`{"customer_id":"","deliver_at":"tomorrow","reason":"BORED","items":[{"restaurant_item_id":"x","quantity":0},{"quantity":"1"}]}`,
+			http.StatusBadRequest,
+			[]string{
+				"body customer_id min_length",
+				"body deliver_at format",
+				"body items[0].quantity minimum",
+				"body items[0].restaurant_item_id format",
+				"body items[1].restaurant_item_id required",
+				"body items[1].quantity type",
+				"body reason enum",
+			},
+		},
+		{"missing required", orderPath, `{"items":[]}`, http.StatusBadRequest, []string{"body customer_id required", "body items min_items"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached = false
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if reached != (tt.status != http.StatusBadRequest) {
+				t.Errorf("handler reached = %v", reached)
+			}
+			if tt.fields == nil {
+				return
+			}
+			var body ValidationError
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range body.Fields {
+				got = append(got, f.In+" "+f.Field+" "+f.Code)
+			}
+			if !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("fields = %q, want %q", got, tt.fields)
+			}
+			if body.Code != "bad_request" {
+				t.Errorf("code = %q", body.Code)
+			}
+		})
+	}
+}
+
+func TestLiteralRouteWins(t *testing.T) {
+	op, params := testDocument().match(http.MethodPost, "/orders/special/items")
+	if op == nil || len(params) != 0 || op.Responses["200"].Description == "" {
+		t.Errorf("matched %+v %v", op, params)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	doc := testDocument()
+	respond := func(status int, body string) *httptest.ResponseRecorder {
+		h := Validate(doc, config.OpenAPI{ValidateResponses: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if body == "" {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/"+uuid.NewString()+"/items", strings.NewReader("{}")))
+		return rec
+	}
+
+	good := `{"id":"` + uuid.NewString() + `","created_at":"2026-01-02T03:04:05.123Z","deliver_at":null,"total":12.5}`
+	if rec := respond(http.StatusCreated, good); rec.Code != http.StatusCreated || rec.Body.String() != good {
+		t.Errorf("valid response changed: %d %s", rec.Code, rec.Body)
+	}
+	if rec := respond(http.StatusNoContent, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("no content: %d", rec.Code)
+	}
+	if rec := respond(http.StatusNotFound, `{"error_message":"not found","code":"not_found"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("default error: %d %s", rec.Code, rec.Body)
+	}
+
+	for name, tc := range map[string]struct {
+		status int
+		body   string
+		field  string
+	}{
+		"wrong type":       {http.StatusCreated, `{"id":"` + uuid.NewString() + `","total":"12.5"}`, "total"},
+		"bad format":       {http.StatusCreated, `{"id":"42"}`, "id"},
+		"undocumented":     {http.StatusAccepted, `{}`, ""},
+		"unexpected body":  {http.StatusNoContent, `{}`, ""},
+		"bad error object": {http.StatusConflict, `{"error_message":1}`, "error_message"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := respond(tc.status, tc.body)
+			var body ValidationError
+			_ = json.Unmarshal(rec.Body.Bytes(), &body)
+			if rec.Code != http.StatusInternalServerError || len(body.Fields) != 1 || body.Fields[0].In != "response" || body.Fields[0].Field != tc.field {
+				t.Errorf("got %d %+v", rec.Code, body)
+			}
+		})
+	}
+}
+
+func TestDocsServeOwnAssets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /docs", testDocument().DocsHandler("/openapi.json"))
+	mux.Handle("GET /docs/{file}", DocsAssetsHandler())
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	page := get("/docs")
+	if csp := page.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self';") {
+		t.Errorf("Content-Security-Policy = %q", csp)
+	}
+	if body := page.Body.String(); strings.Contains(body, "https://") || !strings.Contains(body, `data-url="/openapi.json"`) {
+		t.Errorf("page loads remote assets or lacks the spec URL:\n%s", body)
+	}
+
+	for file, contentType := range map[string]string{
+		"swagger-ui.css":       "text/css",
+		"swagger-ui-bundle.js": "text/javascript",
+		"init.js":              "text/javascript",
+	} {
+		rec := get("/docs/" + file)
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), contentType) || rec.Body.Len() == 0 {
+			t.Errorf("%s: %d %q, %d bytes", file, rec.Code, rec.Header().Get("Content-Type"), rec.Body.Len())
+		}
+	}
+	if rec := get("/docs/index.html"); rec.Code != http.StatusNotFound {
+		t.Errorf("index.html: %d", rec.Code)
+	}
+}
@@ -0,0 +1,212 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+var (
+	timeType        = reflect.TypeFor[time.Time]()
+	uuidType        = reflect.TypeFor[uuid.UUID]()
+	rawMessageType  = reflect.TypeFor[json.RawMessage]()
+	textMarshalType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// schemaOf describes v's type, or returns v itself when it is a *Schema.
+func (d *Document) schemaOf(v any) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return d.typeSchema(reflect.TypeOf(v))
+}
+
+// typeSchema describes t the way encoding/json writes it. Named structs
+// become component schemas and are referenced.
+func (d *Document) typeSchema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return DateTime()
+	case uuidType:
+		return UUID()
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem := d.typeSchema(t.Elem())
+		if elem.Ref != "" {
+			// $ref takes no siblings in OpenAPI 3.0.
+			return &Schema{AllOf: []*Schema{elem}, Nullable: true}
+		}
+		elem.Nullable = true
+		return elem
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.typeSchema(t.Elem()), Nullable: true}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: d.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.typeSchema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Implements(textMarshalType) {
+			return &Schema{Type: "string"}
+		}
+		if t.Name() == "" {
+			return d.object(t)
+		}
+		return d.component(t)
+	}
+	return &Schema{}
+}
+
+// component registers t under a schema name and references it. The name
+// is the type's, capitalized, prefixed with its package when another type
+// already took it.
+func (d *Document) component(t reflect.Type) *Schema {
+	name, ok := d.names[t]
+	if !ok {
+		name = exported(t.Name())
+		if _, taken := d.Components.Schemas[name]; taken {
+			pkg := t.PkgPath()
+			name = exported(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+		}
+		d.names[t] = name
+		// Reserve the name first so recursive types end in a reference.
+		d.Components.Schemas[name] = &Schema{}
+		*d.Components.Schemas[name] = *d.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (d *Document) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	d.fields(s, t)
+	return s
+}
+
+// fields adds t's fields to s, flattening embedded structs like
+// encoding/json does.
+func (d *Document) fields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				d.fields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := d.typeSchema(field.Type)
+		required := field.Tag.Get("required") == "true"
+		if property.Ref == "" {
+			constrain(property, field.Tag, required)
+		}
+		s.Properties[name] = property
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// constrain applies the format, oneof, min and max tags of a field. min
+// and max bound numbers, and the length of strings and arrays.
+func constrain(s *Schema, tag reflect.StructTag, required bool) {
+	if format := tag.Get("format"); format != "" {
+		s.Format = format
+	}
+	if oneof := tag.Get("oneof"); oneof != "" {
+		s.Enum = strings.Fields(oneof)
+	}
+	minimum, hasMin := tagNumber(tag, "min")
+	maximum, hasMax := tagNumber(tag, "max")
+	switch s.Type {
+	case "integer", "number":
+		if hasMin {
+			s.Minimum = &minimum
+		}
+		if hasMax {
+			s.Maximum = &maximum
+		}
+	case "string":
+		if required && !hasMin {
+			minimum, hasMin = 1, true
+		}
+		if hasMin {
+			s.MinLength = intPtr(minimum)
+		}
+		if hasMax {
+			s.MaxLength = intPtr(maximum)
+		}
+	case "array":
+		if hasMin {
+			s.MinItems = intPtr(minimum)
+		}
+		if hasMax {
+			s.MaxItems = intPtr(maximum)
+		}
+	}
+	if required {
+		s.Nullable = false
+	}
+}
+
+func tagNumber(tag reflect.StructTag, key string) (float64, bool) {
+	raw, ok := tag.Lookup(key)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		panic("openapi: bad " + key + " tag " + strconv.Quote(raw))
+	}
+	return n, true
+}
+
+func intPtr(f float64) *int {
+	n := int(f)
+	return &n
+}
+
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
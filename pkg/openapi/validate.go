@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// checker collects the values at one location ("path", "query", "body"
+// or "response") that break their schemas.
+type checker struct {
+	doc  *Document
+	in   string
+	errs []FieldError
+}
+
+func (c *checker) fail(field, code, format string, args ...any) {
+	c.errs = append(c.errs, FieldError{In: c.in, Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// resolve follows a $ref to its component schema.
+func (c *checker) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		s = c.doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// value checks v, decoded by encoding/json with UseNumber, against s.
+func (c *checker) value(s *Schema, v any, field string) {
+	s = c.resolve(s)
+	if v == nil {
+		if !s.Nullable && (s.Type != "" || len(s.AllOf) > 0) {
+			c.fail(field, "type", "must not be null")
+		}
+		return
+	}
+	for _, sub := range s.AllOf {
+		c.value(sub, v, field)
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			c.fail(field, "type", "must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				c.fail(join(field, name), "required", "is required")
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := s.Properties[name]; ok {
+				c.value(property, obj[name], join(field, name))
+			} else if s.AdditionalProperties != nil {
+				c.value(s.AdditionalProperties, obj[name], join(field, name))
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			c.fail(field, "type", "must be an array")
+			return
+		}
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			c.fail(field, "min_items", "must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			c.fail(field, "max_items", "must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				c.value(s.Items, item, field+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			c.fail(field, "type", "must be a string")
+			return
+		}
+		c.text(s, str, field)
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			c.fail(field, "type", "must be an integer")
+			return
+		}
+		if _, err := n.Int64(); err != nil {
+			c.fail(field, "type", "must be an integer")
+			return
+		}
+		c.bounds(s, n, field)
+	case "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			c.fail(field, "type", "must be a number")
+			return
+		}
+		c.bounds(s, n, field)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			c.fail(field, "type", "must be a boolean")
+		}
+	}
+}
+
+func (c *checker) text(s *Schema, str, field string) {
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		if *s.MinLength == 1 {
+			c.fail(field, "min_length", "must not be empty")
+		} else {
+			c.fail(field, "min_length", "must be at least %d characters", *s.MinLength)
+		}
+		return
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		c.fail(field, "max_length", "must be at most %d characters", *s.MaxLength)
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			found = found || str == allowed
+		}
+		if !found {
+			c.fail(field, "enum", "must be one of %s", strings.Join(s.Enum, ", "))
+		}
+	}
+	if str == "" {
+		return
+	}
+	switch s.Format {
+	case "uuid":
+		if _, err := uuid.Parse(str); err != nil {
+			c.fail(field, "format", "must be a UUID")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			c.fail(field, "format", "must be an RFC 3339 timestamp")
+		}
+	}
+}
+
+func (c *checker) bounds(s *Schema, n json.Number, field string) {
+	f, err := n.Float64()
+	if err != nil {
+		c.fail(field, "type", "must be a number")
+		return
+	}
+	if s.Minimum != nil && f < *s.Minimum {
+		c.fail(field, "minimum", "must be at least %s", formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		c.fail(field, "maximum", "must be at most %s", formatNumber(*s.Maximum))
+	}
+}
+
+// param checks a path or query value, converting it to the schema's type
+// first.
+func (c *checker) param(s *Schema, raw, field string) {
+	switch c.resolve(s).Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			c.value(s, raw, field)
+			return
+		}
+		c.value(s, json.Number(raw), field)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.value(s, raw, field)
+			return
+		}
+		c.value(s, b, field)
+	default:
+		c.value(s, raw, field)
+	}
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
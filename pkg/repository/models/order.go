@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"time"
 
 	"github.com/Kabanya/YAFDS/pkg/models"
 
@@ -12,14 +13,28 @@ type Order interface { //dto - data transfer object. dto похож на model 
 	//                   по возможности не плодим dto
 	Create(ctx context.Context, order models.Order) (models.Order, error)
 	CreateWithItems(ctx context.Context, order models.Order, items []OrderItemInput) (models.Order, error)
+	CreateScheduled(ctx context.Context, order models.Order, items []OrderItemInput, slot Slot) (models.Order, error)
 	List(ctx context.Context, filter Filter) ([]models.Order, error)
 	Get(ctx context.Context, orderID uuid.UUID) (models.Order, error)
 	GetOrderStatus(ctx context.Context, orderID uuid.UUID) (models.OrderStatus, error)
 	UpdateStatus(ctx context.Context, orderID uuid.UUID, status models.OrderStatus) error
+	// ClaimPayment moves a CUSTOMER_CREATED order to CUSTOMER_PAYING. It
+	// reports false when the order has another status, e.g. because a
+	// concurrent request claimed it, so only one request debits the wallet.
+	// A scheduled order takes its place in the slot here.
+	ClaimPayment(ctx context.Context, orderID uuid.UUID) (bool, error)
 	GetOrderTotal(ctx context.Context, orderID uuid.UUID) (float64, error)
 	GetCustomerWalletAddress(ctx context.Context, customerID uuid.UUID) (string, error)
 	Accept(ctx context.Context, input AcceptInput) (AcceptResult, error)
 	AddItem(ctx context.Context, orderID uuid.UUID, item OrderItemInput) error
+	ListItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error)
+	UpdateItemQuantity(ctx context.Context, orderID uuid.UUID, item OrderItemInput) error
+	RemoveItem(ctx context.Context, orderID uuid.UUID, restaurantItemID uuid.UUID) error
+	ReplaceItems(ctx context.Context, orderID uuid.UUID, items []OrderItemInput) error
+	Cancel(ctx context.Context, input CancelInput) error
+	SetRefundStatus(ctx context.Context, orderID uuid.UUID, status models.RefundStatus) error
+	ListDueScheduled(ctx context.Context, now time.Time, limit int) ([]models.Order, error)
+	ReleaseScheduled(ctx context.Context, orderID uuid.UUID) error
 }
 
 type OrderItemInput struct {
@@ -28,10 +43,38 @@ type OrderItemInput struct {
 	Quantity         int
 }
 
+// MergeItems collapses lines with the same restaurant item into one,
+// summing quantities and keeping the latest price. Order of first appearance is preserved.
+func MergeItems(items []OrderItemInput) []OrderItemInput {
+	merged := make([]OrderItemInput, 0, len(items))
+	index := make(map[uuid.UUID]int, len(items))
+	for _, item := range items {
+		if i, ok := index[item.RestaurantItemID]; ok {
+			merged[i].Quantity += item.Quantity
+			merged[i].Price = item.Price
+			continue
+		}
+		index[item.RestaurantItemID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
 type Filter struct {
 	CustomerID *uuid.UUID
 	CourierID  *uuid.UUID
 	Status     string
+	Scheduled  bool
+}
+
+// Slot is the delivery window a scheduled order books; at most Capacity live
+// orders of one restaurant may share it.
+type Slot struct {
+	Start     time.Time
+	End       time.Time
+	Capacity  int
+	DeliverAt time.Time
+	ReleaseAt time.Time
 }
 
 type AcceptInput struct {
@@ -42,7 +85,20 @@ type AcceptInput struct {
 	Status     models.OrderStatus
 }
 
+// CancelInput moves an order to CUSTOMER_CANCELLED only if it is still in
+// ExpectedStatus, and records why for analytics.
+type CancelInput struct {
+	OrderID        uuid.UUID
+	ExpectedStatus models.OrderStatus
+	ReasonCode     models.CancellationReason
+	Comment        string
+	RefundAmount   float64
+}
+
 type AcceptResult struct {
 	OrderID uuid.UUID `json:"order_id"`
 	Status  string    `json:"status"`
+	// Replayed is set when the kitchen had already decided on the order
+	// and this call changed nothing.
+	Replayed bool `json:"-"`
 }
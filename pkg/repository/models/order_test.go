@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMergeItems(t *testing.T) {
+	first := uuid.New()
+	second := uuid.New()
+
+	merged := MergeItems([]OrderItemInput{
+		{RestaurantItemID: first, Price: 10, Quantity: 1},
+		{RestaurantItemID: second, Price: 5, Quantity: 2},
+		{RestaurantItemID: first, Price: 12, Quantity: 3},
+	})
+
+	if len(merged) != 2 {
+		t.Fatalf("MergeItems() returned %d lines, want 2", len(merged))
+	}
+	if merged[0].RestaurantItemID != first || merged[0].Quantity != 4 || merged[0].Price != 12 {
+		t.Errorf("first line = %+v, want quantity 4 and price 12", merged[0])
+	}
+	if merged[1].RestaurantItemID != second || merged[1].Quantity != 2 {
+		t.Errorf("second line = %+v, want quantity 2", merged[1])
+	}
+	if len(MergeItems(nil)) != 0 {
+		t.Error("MergeItems(nil) should be empty")
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"context"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+type Review interface {
+	// Create stores the review and folds its ratings into the aggregates in one transaction.
+	Create(ctx context.Context, review models.Review) (models.Review, error)
+	Get(ctx context.Context, orderID uuid.UUID) (models.Review, error)
+	ListByRestaurant(ctx context.Context, restaurantID uuid.UUID, limit, offset int) ([]models.Review, error)
+	Reply(ctx context.Context, orderID uuid.UUID, restaurantID uuid.UUID, reply string) (models.Review, error)
+	Summaries(ctx context.Context, subject models.RatingSubject, ids []uuid.UUID) (map[uuid.UUID]models.RatingSummary, error)
+}
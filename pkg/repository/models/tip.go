@@ -0,0 +1,26 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+type Tip interface {
+	// Create records a PENDING tip; an order holds at most one tip that has not FAILED.
+	Create(ctx context.Context, tip models.Tip) (models.Tip, error)
+	// SetStatus moves the tip from one status to another and fails when it
+	// is no longer in from, so only one caller gets to move the money.
+	SetStatus(ctx context.Context, tipID uuid.UUID, from, to models.TipStatus) error
+	// ListByOrder returns the tips of an order that have not FAILED.
+	ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Tip, error)
+	// ListUnsettled returns DEBITED tips and paid out checkout tips of
+	// cancelled orders, oldest first.
+	ListUnsettled(ctx context.Context, limit int) ([]models.Tip, error)
+	GetCourierWalletAddress(ctx context.Context, courierID uuid.UUID) (string, error)
+	ListByCourier(ctx context.Context, courierID uuid.UUID, from, to time.Time) ([]models.Tip, error)
+	CountCompletedOrders(ctx context.Context, courierID uuid.UUID, from, to time.Time) (int, error)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"hash/fnv"
 	"strconv"
 	"strings"
 	"time"
@@ -25,9 +26,13 @@ func NewPostgresRepository(ordersDB, customersDB, couriersDB *sql.DB) repository
 }
 
 var (
-	ErrCustomerNotFound = errors.New("customer not found")
-	ErrCourierNotFound  = errors.New("courier not found")
-	ErrOrderNotFound    = errors.New("order not found")
+	ErrCustomerNotFound  = errors.New("customer not found")
+	ErrCourierNotFound   = errors.New("courier not found")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderNotEditable  = errors.New("order can only be edited while CUSTOMER_CREATED")
+	ErrOrderItemNotFound = errors.New("order item not found")
+	ErrStatusChanged     = errors.New("order status changed concurrently")
+	ErrSlotFull          = errors.New("delivery slot is fully booked")
 )
 
 func (r *postgresRepository) Create(ctx context.Context, order models.Order) (models.Order, error) {
@@ -42,7 +47,7 @@ func (r *postgresRepository) Create(ctx context.Context, order models.Order) (mo
 	order.CreatedAt = now
 	order.UpdatedAt = now
 	if strings.TrimSpace(order.Status) == "" {
-		order.Status = "created"
+		order.Status = string(models.OrderStatusCustomerCreated)
 	}
 
 	if _, err := r.ensureExists(ctx, r.customersDB, "SELECT 1 FROM customers WHERE emp_id = $1", order.CustomerID); err != nil {
@@ -53,11 +58,11 @@ func (r *postgresRepository) Create(ctx context.Context, order models.Order) (mo
 	}
 
 	const insertQuery = `
-        INSERT INTO ORDERS (emp_id, customer_id, courier_id, created_at, updated_at, status)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO ORDERS (emp_id, customer_id, courier_id, restaurant_id, created_at, updated_at, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
     `
 
-	_, err := r.ordersDB.ExecContext(ctx, insertQuery, order.ID, order.CustomerID, order.CourierID, order.CreatedAt, order.UpdatedAt, order.Status)
+	_, err := r.ordersDB.ExecContext(ctx, insertQuery, order.ID, order.CustomerID, order.CourierID, nullableUUID(order.RestaurantID), order.CreatedAt, order.UpdatedAt, order.Status)
 	if err != nil {
 		return models.Order{}, err
 	}
@@ -65,6 +70,20 @@ func (r *postgresRepository) Create(ctx context.Context, order models.Order) (mo
 }
 
 func (r *postgresRepository) CreateWithItems(ctx context.Context, order models.Order, items []repositoryModels.OrderItemInput) (models.Order, error) {
+	return r.createWithItems(ctx, order, items, nil)
+}
+
+func (r *postgresRepository) CreateScheduled(ctx context.Context, order models.Order, items []repositoryModels.OrderItemInput, slot repositoryModels.Slot) (models.Order, error) {
+	if order.RestaurantID == uuid.Nil {
+		return models.Order{}, errors.New("restaurant_id must be a valid UUID")
+	}
+	deliverAt, releaseAt := slot.DeliverAt.UTC(), slot.ReleaseAt.UTC()
+	order.DeliverAt = &deliverAt
+	order.ReleaseAt = &releaseAt
+	return r.createWithItems(ctx, order, items, &slot)
+}
+
+func (r *postgresRepository) createWithItems(ctx context.Context, order models.Order, items []repositoryModels.OrderItemInput, slot *repositoryModels.Slot) (models.Order, error) {
 	if r.ordersDB == nil || r.customersDB == nil || r.couriersDB == nil {
 		return models.Order{}, errors.New("orders repository not fully initialized")
 	}
@@ -79,7 +98,7 @@ func (r *postgresRepository) CreateWithItems(ctx context.Context, order models.O
 	order.CreatedAt = now
 	order.UpdatedAt = now
 	if strings.TrimSpace(order.Status) == "" {
-		order.Status = "created"
+		order.Status = string(models.OrderStatusCustomerCreated)
 	}
 
 	if _, err := r.ensureExists(ctx, r.customersDB, "SELECT 1 FROM customers WHERE emp_id = $1", order.CustomerID); err != nil {
@@ -99,11 +118,27 @@ func (r *postgresRepository) CreateWithItems(ctx context.Context, order models.O
 		}
 	}()
 
+	if slot != nil {
+		if err = reserveSlot(ctx, tx, order.RestaurantID, *slot); err != nil {
+			return models.Order{}, err
+		}
+	}
+
+	// The slot is kept with the order so payment can check it again.
+	var slotStart, slotEnd sql.NullTime
+	var slotCapacity sql.NullInt64
+	if slot != nil {
+		slotStart = sql.NullTime{Time: slot.Start.UTC(), Valid: true}
+		slotEnd = sql.NullTime{Time: slot.End.UTC(), Valid: true}
+		slotCapacity = sql.NullInt64{Int64: int64(slot.Capacity), Valid: true}
+	}
 	const insertOrderQuery = `
-		INSERT INTO ORDERS (emp_id, customer_id, courier_id, created_at, updated_at, status)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO ORDERS (emp_id, customer_id, courier_id, restaurant_id, created_at, updated_at, status, deliver_at, release_at,
+			slot_start, slot_end, slot_capacity)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
-	if _, err = tx.ExecContext(ctx, insertOrderQuery, order.ID, order.CustomerID, order.CourierID, order.CreatedAt, order.UpdatedAt, order.Status); err != nil {
+	if _, err = tx.ExecContext(ctx, insertOrderQuery, order.ID, order.CustomerID, order.CourierID, nullableUUID(order.RestaurantID),
+		order.CreatedAt, order.UpdatedAt, order.Status, order.DeliverAt, order.ReleaseAt, slotStart, slotEnd, slotCapacity); err != nil {
 		return models.Order{}, err
 	}
 
@@ -123,8 +158,53 @@ func (r *postgresRepository) CreateWithItems(ctx context.Context, order models.O
 	return order, nil
 }
 
+// slotStatuses are the orders that hold a place in their slot: being paid,
+// scheduled, paid or on their way. Unpaid, cancelled, denied and refunded
+// orders do not, which is why payment checks the slot again.
+var slotStatuses = []models.OrderStatus{
+	models.OrderStatusCustomerPaying,
+	models.OrderStatusCustomerScheduled,
+	models.OrderStatusCustomerPaid,
+	models.OrderStatusKitchenAccepted,
+	models.OrderStatusKitchenPreparing,
+	models.OrderStatusDeliveryPending,
+	models.OrderStatusDeliveryPicking,
+	models.OrderStatusDeliveryDelivering,
+	models.OrderStatusOrderCompleted,
+}
+
+// reserveSlot serializes bookings of one restaurant slot with an advisory lock
+// and fails when the slot already holds Capacity live orders.
+func reserveSlot(ctx context.Context, tx *sql.Tx, restaurantID uuid.UUID, slot repositoryModels.Slot) error {
+	lockKey := fnv.New64a()
+	_, _ = lockKey.Write(restaurantID[:])
+	_, _ = lockKey.Write([]byte(slot.Start.UTC().Format(time.RFC3339)))
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", int64(lockKey.Sum64())); err != nil {
+		return err
+	}
+
+	args := []any{restaurantID, slot.Start.UTC(), slot.End.UTC()}
+	statuses := make([]string, 0, len(slotStatuses))
+	for _, status := range slotStatuses {
+		args = append(args, string(status))
+		statuses = append(statuses, "$"+strconv.Itoa(len(args)))
+	}
+	countQuery := `
+		SELECT COUNT(1) FROM ORDERS
+		WHERE restaurant_id = $1 AND deliver_at >= $2 AND deliver_at < $3 AND status IN (` + strings.Join(statuses, ", ") + `)
+	`
+	var booked int
+	if err := tx.QueryRowContext(ctx, countQuery, args...).Scan(&booked); err != nil {
+		return err
+	}
+	if booked >= slot.Capacity {
+		return ErrSlotFull
+	}
+	return nil
+}
+
 func (r *postgresRepository) List(ctx context.Context, filter repositoryModels.Filter) ([]models.Order, error) {
-	query := `SELECT emp_id, customer_id, courier_id, created_at, updated_at, status FROM ORDERS`
+	query := `SELECT ` + orderColumns + ` FROM ORDERS`
 	var args []any
 	var where []string
 
@@ -133,7 +213,7 @@ func (r *postgresRepository) List(ctx context.Context, filter repositoryModels.F
 		args = append(args, *filter.CustomerID)
 	}
 	if filter.CourierID != nil {
-		where = append(where, "courrier_id = $"+strconv.Itoa(len(args)+1))
+		where = append(where, "courier_id = $"+strconv.Itoa(len(args)+1))
 		args = append(args, *filter.CourierID)
 	}
 	if filter.Status != "" {
@@ -141,10 +221,18 @@ func (r *postgresRepository) List(ctx context.Context, filter repositoryModels.F
 		args = append(args, filter.Status)
 	}
 
+	if filter.Scheduled {
+		where = append(where, "deliver_at IS NOT NULL")
+	}
+
 	if len(where) > 0 {
 		query += " WHERE " + strings.Join(where, " AND ")
 	}
-	query += " ORDER BY created_at DESC "
+	if filter.Scheduled {
+		query += " ORDER BY deliver_at ASC "
+	} else {
+		query += " ORDER BY created_at DESC "
+	}
 
 	rows, err := r.ordersDB.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -154,8 +242,8 @@ func (r *postgresRepository) List(ctx context.Context, filter repositoryModels.F
 
 	var result []models.Order
 	for rows.Next() {
-		var order models.Order
-		if err := rows.Scan(&order.ID, &order.CustomerID, &order.CourierID, &order.CreatedAt, &order.UpdatedAt, &order.Status); err != nil {
+		order, err := scanOrder(rows)
+		if err != nil {
 			return nil, err
 		}
 		result = append(result, order)
@@ -175,9 +263,8 @@ func (r *postgresRepository) Get(ctx context.Context, orderID uuid.UUID) (models
 		return models.Order{}, errors.New("order_id must be a valid UUID")
 	}
 
-	var order models.Order
-	query := `SELECT emp_id, customer_id, courier_id, created_at, updated_at, status FROM ORDERS WHERE emp_id = $1`
-	err := r.ordersDB.QueryRowContext(ctx, query, orderID).Scan(&order.ID, &order.CustomerID, &order.CourierID, &order.CreatedAt, &order.UpdatedAt, &order.Status)
+	query := `SELECT ` + orderColumns + ` FROM ORDERS WHERE emp_id = $1`
+	order, err := scanOrder(r.ordersDB.QueryRowContext(ctx, query, orderID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.Order{}, ErrOrderNotFound
@@ -225,6 +312,53 @@ func (r *postgresRepository) UpdateStatus(ctx context.Context, orderID uuid.UUID
 	return err
 }
 
+// ClaimPayment also books the slot of a scheduled order: unpaid orders do
+// not hold a place, so it checks the capacity again under the same lock as
+// creation and fails with ErrSlotFull when the slot filled up meanwhile.
+func (r *postgresRepository) ClaimPayment(ctx context.Context, orderID uuid.UUID) (claimed bool, err error) {
+	if r.ordersDB == nil {
+		return false, errors.New("orders repository not fully initialized")
+	}
+	tx, err := r.ordersDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil || !claimed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var status string
+	var restaurantID uuid.NullUUID
+	var slotStart, slotEnd sql.NullTime
+	var slotCapacity sql.NullInt64
+	const selectQuery = "SELECT status, restaurant_id, slot_start, slot_end, slot_capacity FROM ORDERS WHERE emp_id = $1 FOR UPDATE"
+	if err = tx.QueryRowContext(ctx, selectQuery, orderID).Scan(&status, &restaurantID, &slotStart, &slotEnd, &slotCapacity); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrOrderNotFound
+		}
+		return false, err
+	}
+	if !strings.EqualFold(status, string(models.OrderStatusCustomerCreated)) {
+		return false, nil
+	}
+	if restaurantID.Valid && slotCapacity.Valid {
+		slot := repositoryModels.Slot{Start: slotStart.Time, End: slotEnd.Time, Capacity: int(slotCapacity.Int64)}
+		if err = reserveSlot(ctx, tx, restaurantID.UUID, slot); err != nil {
+			return false, err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, "UPDATE ORDERS SET status = $1, updated_at = $2 WHERE emp_id = $3",
+		string(models.OrderStatusCustomerPaying), time.Now().UTC(), orderID); err != nil {
+		return false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (r *postgresRepository) GetOrderTotal(ctx context.Context, orderID uuid.UUID) (float64, error) {
 	if r.ordersDB == nil {
 		return 0, errors.New("orders repository not fully initialized")
@@ -304,7 +438,7 @@ func (r *postgresRepository) Accept(ctx context.Context, input repositoryModels.
 		if err = tx.Commit(); err != nil {
 			return repositoryModels.AcceptResult{}, err
 		}
-		return repositoryModels.AcceptResult{OrderID: input.OrderID, Status: existingStatus}, nil
+		return repositoryModels.AcceptResult{OrderID: input.OrderID, Status: existingStatus, Replayed: true}, nil
 	}
 	if scanErr != nil && !errors.Is(scanErr, sql.ErrNoRows) {
 		err = scanErr
@@ -369,33 +503,367 @@ func (r *postgresRepository) AddItem(ctx context.Context, orderID uuid.UUID, ite
 		}
 	}()
 
-	var exists int
-	if err = tx.QueryRowContext(ctx, "SELECT 1 FROM ORDERS WHERE emp_id = $1", orderID).Scan(&exists); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrOrderNotFound
+	if err = lockEditableOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	var existing int
+	existingQuery := "SELECT COALESCE(SUM(quantity), 0) FROM ORDERS_ITEMS WHERE order_id = $1 AND restaurant_item_id = $2"
+	if err = tx.QueryRowContext(ctx, existingQuery, orderID, item.RestaurantItemID).Scan(&existing); err != nil {
+		return err
+	}
+	item.Quantity += existing
+
+	if err = replaceItemLine(ctx, tx, orderID, item); err != nil {
+		return err
+	}
+	if err = touchOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *postgresRepository) ListItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error) {
+	if r.ordersDB == nil {
+		return nil, errors.New("orders repository not fully initialized")
+	}
+	if orderID == uuid.Nil {
+		return nil, errors.New("order_id must be a valid UUID")
+	}
+
+	// Orders written before lines were merged can hold several lines of one
+	// item at different prices; the weighted price keeps price * quantity
+	// equal to what those lines cost.
+	const query = `
+		SELECT restaurant_item_id, SUM(price * quantity) / SUM(quantity), SUM(quantity)
+		FROM ORDERS_ITEMS
+		WHERE order_id = $1
+		GROUP BY restaurant_item_id
+		ORDER BY restaurant_item_id
+	`
+	rows, err := r.ordersDB.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.OrderItem{}
+	for rows.Next() {
+		var item models.OrderItem
+		if err := rows.Scan(&item.RestaurantItemID, &item.Price, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *postgresRepository) UpdateItemQuantity(ctx context.Context, orderID uuid.UUID, item repositoryModels.OrderItemInput) error {
+	if r.ordersDB == nil {
+		return errors.New("orders repository not fully initialized")
+	}
+	if orderID == uuid.Nil {
+		return errors.New("order_id must be a valid UUID")
+	}
+	if item.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+
+	tx, err := r.ordersDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockEditableOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	var lines int
+	countQuery := "SELECT COUNT(1) FROM ORDERS_ITEMS WHERE order_id = $1 AND restaurant_item_id = $2"
+	if err = tx.QueryRowContext(ctx, countQuery, orderID, item.RestaurantItemID).Scan(&lines); err != nil {
+		return err
+	}
+	if lines == 0 {
+		err = ErrOrderItemNotFound
+		return err
+	}
+
+	if err = replaceItemLine(ctx, tx, orderID, item); err != nil {
+		return err
+	}
+	if err = touchOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresRepository) RemoveItem(ctx context.Context, orderID uuid.UUID, restaurantItemID uuid.UUID) error {
+	if r.ordersDB == nil {
+		return errors.New("orders repository not fully initialized")
+	}
+	if orderID == uuid.Nil {
+		return errors.New("order_id must be a valid UUID")
+	}
+
+	tx, err := r.ordersDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockEditableOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM ORDERS_ITEMS WHERE order_id = $1 AND restaurant_item_id = $2", orderID, restaurantItemID)
+	if err != nil {
+		return err
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		err = ErrOrderItemNotFound
+		return err
+	}
+	if err = touchOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresRepository) ReplaceItems(ctx context.Context, orderID uuid.UUID, items []repositoryModels.OrderItemInput) error {
+	if r.ordersDB == nil {
+		return errors.New("orders repository not fully initialized")
+	}
+	if orderID == uuid.Nil {
+		return errors.New("order_id must be a valid UUID")
+	}
+	if len(items) == 0 {
+		return errors.New("items must not be empty")
+	}
+
+	tx, err := r.ordersDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
 		}
+	}()
+
+	if err = lockEditableOrder(ctx, tx, orderID); err != nil {
 		return err
 	}
 
+	if _, err = tx.ExecContext(ctx, "DELETE FROM ORDERS_ITEMS WHERE order_id = $1", orderID); err != nil {
+		return err
+	}
 	const insertItemQuery = `
 		INSERT INTO ORDERS_ITEMS (emp_id, order_id, restaurant_item_id, price, quantity)
 		VALUES ($1, $2, $3, $4, $5)
 	`
-	if _, err = tx.ExecContext(ctx, insertItemQuery, uuid.New(), orderID, item.RestaurantItemID, item.Price, item.Quantity); err != nil {
+	for _, item := range repositoryModels.MergeItems(items) {
+		if _, err = tx.ExecContext(ctx, insertItemQuery, uuid.New(), orderID, item.RestaurantItemID, item.Price, item.Quantity); err != nil {
+			return err
+		}
+	}
+	if err = touchOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresRepository) Cancel(ctx context.Context, input repositoryModels.CancelInput) error {
+	if r.ordersDB == nil {
+		return errors.New("orders repository not fully initialized")
+	}
+	if input.OrderID == uuid.Nil {
+		return errors.New("order_id must be a valid UUID")
+	}
+
+	tx, err := r.ordersDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, "UPDATE ORDERS SET status = $1, updated_at = $2 WHERE emp_id = $3 AND status = $4",
+		string(models.OrderStatusCustomerCancelled), now, input.OrderID, string(input.ExpectedStatus))
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		err = ErrStatusChanged
 		return err
 	}
 
-	if _, err = tx.ExecContext(ctx, "UPDATE ORDERS SET updated_at = $1 WHERE emp_id = $2", time.Now().UTC(), orderID); err != nil {
+	refundStatus := models.RefundStatusNone
+	if input.RefundAmount > 0 {
+		refundStatus = models.RefundStatusPending
+	}
+	const insertQuery = `
+		INSERT INTO ORDERS_CANCELLATIONS (order_id, previous_status, reason_code, comment, refund_amount, refund_status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err = tx.ExecContext(ctx, insertQuery, input.OrderID, string(input.ExpectedStatus), string(input.ReasonCode),
+		input.Comment, input.RefundAmount, string(refundStatus), now); err != nil {
 		return err
 	}
 
-	if err = tx.Commit(); err != nil {
+	return tx.Commit()
+}
+
+func (r *postgresRepository) SetRefundStatus(ctx context.Context, orderID uuid.UUID, status models.RefundStatus) error {
+	if r.ordersDB == nil {
+		return errors.New("orders repository not fully initialized")
+	}
+	res, err := r.ordersDB.ExecContext(ctx, "UPDATE ORDERS_CANCELLATIONS SET refund_status = $1 WHERE order_id = $2", string(status), orderID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err == nil && rows == 0 {
+		return ErrOrderNotFound
+	}
+	return err
+}
+
+func (r *postgresRepository) ListDueScheduled(ctx context.Context, now time.Time, limit int) ([]models.Order, error) {
+	if r.ordersDB == nil {
+		return nil, errors.New("orders repository not fully initialized")
+	}
+	query := `SELECT ` + orderColumns + ` FROM ORDERS WHERE status = $1 AND release_at <= $2 ORDER BY release_at ASC LIMIT $3`
+	rows, err := r.ordersDB.QueryContext(ctx, query, string(models.OrderStatusCustomerScheduled), now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *postgresRepository) ReleaseScheduled(ctx context.Context, orderID uuid.UUID) error {
+	if r.ordersDB == nil {
+		return errors.New("orders repository not fully initialized")
+	}
+	res, err := r.ordersDB.ExecContext(ctx, "UPDATE ORDERS SET status = $1, updated_at = $2 WHERE emp_id = $3 AND status = $4",
+		string(models.OrderStatusCustomerPaid), time.Now().UTC(), orderID, string(models.OrderStatusCustomerScheduled))
+	if err != nil {
 		return err
 	}
+	rows, err := res.RowsAffected()
+	if err == nil && rows == 0 {
+		return ErrStatusChanged
+	}
+	return err
+}
 
+// lockEditableOrder locks the order row for the rest of the transaction and
+// rejects edits once the order has left CUSTOMER_CREATED.
+func lockEditableOrder(ctx context.Context, tx *sql.Tx, orderID uuid.UUID) error {
+	var status string
+	err := tx.QueryRowContext(ctx, "SELECT status FROM ORDERS WHERE emp_id = $1 FOR UPDATE", orderID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrderNotFound
+		}
+		return err
+	}
+	if !strings.EqualFold(status, string(models.OrderStatusCustomerCreated)) {
+		return ErrOrderNotEditable
+	}
 	return nil
 }
 
+// replaceItemLine drops every line of the item and writes a single merged one.
+func replaceItemLine(ctx context.Context, tx *sql.Tx, orderID uuid.UUID, item repositoryModels.OrderItemInput) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ORDERS_ITEMS WHERE order_id = $1 AND restaurant_item_id = $2", orderID, item.RestaurantItemID); err != nil {
+		return err
+	}
+	const insertItemQuery = `
+		INSERT INTO ORDERS_ITEMS (emp_id, order_id, restaurant_item_id, price, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := tx.ExecContext(ctx, insertItemQuery, uuid.New(), orderID, item.RestaurantItemID, item.Price, item.Quantity)
+	return err
+}
+
+func touchOrder(ctx context.Context, tx *sql.Tx, orderID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, "UPDATE ORDERS SET updated_at = $1 WHERE emp_id = $2", time.Now().UTC(), orderID)
+	return err
+}
+
+const orderColumns = "emp_id, customer_id, courier_id, restaurant_id, created_at, updated_at, status, deliver_at, release_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row rowScanner) (models.Order, error) {
+	var order models.Order
+	var restaurantID uuid.NullUUID
+	var deliverAt, releaseAt sql.NullTime
+	if err := row.Scan(&order.ID, &order.CustomerID, &order.CourierID, &restaurantID, &order.CreatedAt, &order.UpdatedAt,
+		&order.Status, &deliverAt, &releaseAt); err != nil {
+		return models.Order{}, err
+	}
+	order.RestaurantID = restaurantID.UUID
+	if deliverAt.Valid {
+		order.DeliverAt = &deliverAt.Time
+	}
+	if releaseAt.Valid {
+		order.ReleaseAt = &releaseAt.Time
+	}
+	return order, nil
+}
+
+func nullableUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
 func (r *postgresRepository) ensureExists(ctx context.Context, db *sql.DB, query string, id uuid.UUID) (bool, error) {
 	var dummy int
 	err := db.QueryRowContext(ctx, query, id).Scan(&dummy)
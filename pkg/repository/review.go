@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrReviewExists   = errors.New("order already has a review")
+	ErrReviewNotFound = errors.New("review not found")
+)
+
+type reviewRepository struct {
+	ordersDB *sql.DB
+}
+
+func NewReviewRepository(ordersDB *sql.DB) repositoryModels.Review {
+	return &reviewRepository{ordersDB: ordersDB}
+}
+
+const reviewColumns = `order_id, customer_id, restaurant_id, courier_id, food_rating, delivery_rating, comment, reply, replied_at, created_at`
+
+func (r *reviewRepository) Create(ctx context.Context, review models.Review) (models.Review, error) {
+	if r.ordersDB == nil {
+		return models.Review{}, errors.New("reviews repository not fully initialized")
+	}
+
+	tx, err := r.ordersDB.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Review{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	review.CreatedAt = time.Now().UTC()
+	const insertQuery = `
+		INSERT INTO REVIEWS (order_id, customer_id, restaurant_id, courier_id, food_rating, delivery_rating, comment, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (order_id) DO NOTHING
+	`
+	res, err := tx.ExecContext(ctx, insertQuery, review.OrderID, review.CustomerID, review.RestaurantID, review.CourierID,
+		review.FoodRating, review.DeliveryRating, review.Comment, review.CreatedAt)
+	if err != nil {
+		return models.Review{}, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return models.Review{}, err
+	}
+	if inserted == 0 {
+		err = ErrReviewExists
+		return models.Review{}, err
+	}
+
+	for _, dish := range review.Dishes {
+		if _, err = tx.ExecContext(ctx, "INSERT INTO REVIEWS_DISHES (order_id, restaurant_item_id, rating) VALUES ($1, $2, $3)",
+			review.OrderID, dish.RestaurantItemID, dish.Rating); err != nil {
+			return models.Review{}, err
+		}
+		if err = addRating(ctx, tx, models.RatingSubjectDish, dish.RestaurantItemID, dish.Rating); err != nil {
+			return models.Review{}, err
+		}
+	}
+	if err = addRating(ctx, tx, models.RatingSubjectRestaurant, review.RestaurantID, review.FoodRating); err != nil {
+		return models.Review{}, err
+	}
+	if err = addRating(ctx, tx, models.RatingSubjectCourier, review.CourierID, review.DeliveryRating); err != nil {
+		return models.Review{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Review{}, err
+	}
+	return review, nil
+}
+
+func (r *reviewRepository) Get(ctx context.Context, orderID uuid.UUID) (models.Review, error) {
+	if r.ordersDB == nil {
+		return models.Review{}, errors.New("reviews repository not fully initialized")
+	}
+	review, err := scanReview(r.ordersDB.QueryRowContext(ctx, `SELECT `+reviewColumns+` FROM REVIEWS WHERE order_id = $1`, orderID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Review{}, ErrReviewNotFound
+	}
+	if err != nil {
+		return models.Review{}, err
+	}
+	dishes, err := r.dishRatings(ctx, []uuid.UUID{orderID})
+	if err != nil {
+		return models.Review{}, err
+	}
+	review.Dishes = dishes[orderID]
+	return review, nil
+}
+
+func (r *reviewRepository) ListByRestaurant(ctx context.Context, restaurantID uuid.UUID, limit, offset int) ([]models.Review, error) {
+	if r.ordersDB == nil {
+		return nil, errors.New("reviews repository not fully initialized")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := r.ordersDB.QueryContext(ctx, `SELECT `+reviewColumns+` FROM REVIEWS WHERE restaurant_id = $1
+		ORDER BY created_at DESC LIMIT $2 OFFSET $3`, restaurantID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := make([]models.Review, 0)
+	orderIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+		orderIDs = append(orderIDs, review.OrderID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	dishes, err := r.dishRatings(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reviews {
+		reviews[i].Dishes = dishes[reviews[i].OrderID]
+	}
+	return reviews, nil
+}
+
+func (r *reviewRepository) Reply(ctx context.Context, orderID uuid.UUID, restaurantID uuid.UUID, reply string) (models.Review, error) {
+	if r.ordersDB == nil {
+		return models.Review{}, errors.New("reviews repository not fully initialized")
+	}
+	res, err := r.ordersDB.ExecContext(ctx, "UPDATE REVIEWS SET reply = $1, replied_at = $2 WHERE order_id = $3 AND restaurant_id = $4",
+		reply, time.Now().UTC(), orderID, restaurantID)
+	if err != nil {
+		return models.Review{}, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return models.Review{}, err
+	}
+	if rows == 0 {
+		return models.Review{}, ErrReviewNotFound
+	}
+	return r.Get(ctx, orderID)
+}
+
+func (r *reviewRepository) Summaries(ctx context.Context, subject models.RatingSubject, ids []uuid.UUID) (map[uuid.UUID]models.RatingSummary, error) {
+	if r.ordersDB == nil {
+		return nil, errors.New("reviews repository not fully initialized")
+	}
+	result := make(map[uuid.UUID]models.RatingSummary, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, string(subject))
+	query := `SELECT subject_id, ratings_count, ratings_sum FROM RATING_AGGREGATES WHERE subject_type = $1 AND subject_id IN (` +
+		placeholders(2, ids, &args) + `)`
+	rows, err := r.ordersDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    uuid.UUID
+			count int
+			sum   int64
+		)
+		if err := rows.Scan(&id, &count, &sum); err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			result[id] = models.RatingSummary{Average: float64(sum) / float64(count), Count: count}
+		}
+	}
+	return result, rows.Err()
+}
+
+func (r *reviewRepository) dishRatings(ctx context.Context, orderIDs []uuid.UUID) (map[uuid.UUID][]models.DishRating, error) {
+	result := make(map[uuid.UUID][]models.DishRating, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return result, nil
+	}
+	args := make([]any, 0, len(orderIDs))
+	query := `SELECT order_id, restaurant_item_id, rating FROM REVIEWS_DISHES WHERE order_id IN (` +
+		placeholders(1, orderIDs, &args) + `)`
+	rows, err := r.ordersDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orderID uuid.UUID
+			dish    models.DishRating
+		)
+		if err := rows.Scan(&orderID, &dish.RestaurantItemID, &dish.Rating); err != nil {
+			return nil, err
+		}
+		result[orderID] = append(result[orderID], dish)
+	}
+	return result, rows.Err()
+}
+
+// addRating bumps the running count/sum; averages are derived on read.
+func addRating(ctx context.Context, tx *sql.Tx, subject models.RatingSubject, subjectID uuid.UUID, rating int) error {
+	const query = `
+		INSERT INTO RATING_AGGREGATES (subject_type, subject_id, ratings_count, ratings_sum)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (subject_type, subject_id)
+		DO UPDATE SET ratings_count = RATING_AGGREGATES.ratings_count + 1,
+		              ratings_sum = RATING_AGGREGATES.ratings_sum + EXCLUDED.ratings_sum
+	`
+	_, err := tx.ExecContext(ctx, query, string(subject), subjectID, rating)
+	return err
+}
+
+// placeholders renders "$start, $start+1, ..." and appends ids to args.
+func placeholders(start int, ids []uuid.UUID, args *[]any) string {
+	parts := make([]string, 0, len(ids))
+	for i, id := range ids {
+		parts = append(parts, "$"+strconv.Itoa(start+i))
+		*args = append(*args, id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func scanReview(row rowScanner) (models.Review, error) {
+	var (
+		review    models.Review
+		reply     sql.NullString
+		repliedAt sql.NullTime
+	)
+	if err := row.Scan(&review.OrderID, &review.CustomerID, &review.RestaurantID, &review.CourierID,
+		&review.FoodRating, &review.DeliveryRating, &review.Comment, &reply, &repliedAt, &review.CreatedAt); err != nil {
+		return models.Review{}, err
+	}
+	review.Reply = reply.String
+	if repliedAt.Valid {
+		t := repliedAt.Time
+		review.RepliedAt = &t
+	}
+	return review, nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTipExists        = errors.New("order already has a tip")
+	ErrTipStatusChanged = errors.New("tip status changed concurrently")
+)
+
+type tipRepository struct {
+	ordersDB   *sql.DB
+	couriersDB *sql.DB
+}
+
+func NewTipRepository(ordersDB, couriersDB *sql.DB) repositoryModels.Tip {
+	return &tipRepository{ordersDB: ordersDB, couriersDB: couriersDB}
+}
+
+func (r *tipRepository) Create(ctx context.Context, tip models.Tip) (models.Tip, error) {
+	if r.ordersDB == nil {
+		return models.Tip{}, errors.New("tips repository not fully initialized")
+	}
+	if tip.ID == uuid.Nil {
+		tip.ID = uuid.New()
+	}
+	tip.Status = models.TipStatusPending
+	tip.CreatedAt = time.Now().UTC()
+
+	const query = `
+		INSERT INTO ORDERS_TIPS (emp_id, order_id, customer_id, courier_id, amount, stage, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (order_id) WHERE status <> 'FAILED' DO NOTHING
+	`
+	res, err := r.ordersDB.ExecContext(ctx, query, tip.ID, tip.OrderID, tip.CustomerID, tip.CourierID,
+		tip.Amount, string(tip.Stage), string(tip.Status), tip.CreatedAt)
+	if err != nil {
+		return models.Tip{}, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return models.Tip{}, err
+	}
+	if inserted == 0 {
+		return models.Tip{}, ErrTipExists
+	}
+	return tip, nil
+}
+
+func (r *tipRepository) SetStatus(ctx context.Context, tipID uuid.UUID, from, to models.TipStatus) error {
+	if r.ordersDB == nil {
+		return errors.New("tips repository not fully initialized")
+	}
+	var paidOutAt sql.NullTime
+	if to == models.TipStatusPaidOut {
+		paidOutAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
+	res, err := r.ordersDB.ExecContext(ctx, "UPDATE ORDERS_TIPS SET status = $1, paid_out_at = COALESCE($2, paid_out_at) WHERE emp_id = $3 AND status = $4",
+		string(to), paidOutAt, tipID, string(from))
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err == nil && rows == 0 {
+		return ErrTipStatusChanged
+	}
+	return err
+}
+
+const tipColumns = `t.emp_id, t.order_id, t.customer_id, t.courier_id, t.amount, t.stage, t.status, t.created_at, t.paid_out_at`
+
+func (r *tipRepository) ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Tip, error) {
+	if r.ordersDB == nil {
+		return nil, errors.New("tips repository not fully initialized")
+	}
+	query := `SELECT ` + tipColumns + ` FROM ORDERS_TIPS t WHERE t.order_id = $1 AND t.status <> $2`
+	rows, err := r.ordersDB.QueryContext(ctx, query, orderID, string(models.TipStatusFailed))
+	if err != nil {
+		return nil, err
+	}
+	return scanTips(rows)
+}
+
+func (r *tipRepository) ListUnsettled(ctx context.Context, limit int) ([]models.Tip, error) {
+	if r.ordersDB == nil {
+		return nil, errors.New("tips repository not fully initialized")
+	}
+	query := `
+		SELECT ` + tipColumns + `
+		FROM ORDERS_TIPS t JOIN ORDERS o ON o.emp_id = t.order_id
+		WHERE t.status = $1 OR (t.stage = $2 AND t.status = $3 AND o.status = $4)
+		ORDER BY t.created_at
+		LIMIT $5
+	`
+	rows, err := r.ordersDB.QueryContext(ctx, query, string(models.TipStatusDebited), string(models.TipStageCheckout),
+		string(models.TipStatusPaidOut), string(models.OrderStatusCustomerCancelled), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanTips(rows)
+}
+
+func (r *tipRepository) GetCourierWalletAddress(ctx context.Context, courierID uuid.UUID) (string, error) {
+	if r.couriersDB == nil {
+		return "", errors.New("couriers repository not fully initialized")
+	}
+	var wallet string
+	query := "SELECT wallet_address FROM COURIERS WHERE emp_id = $1"
+	if err := r.couriersDB.QueryRowContext(ctx, query, courierID).Scan(&wallet); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrCourierNotFound
+		}
+		return "", err
+	}
+	if strings.TrimSpace(wallet) == "" {
+		return "", errors.New("wallet_address is empty")
+	}
+	return wallet, nil
+}
+
+func (r *tipRepository) ListByCourier(ctx context.Context, courierID uuid.UUID, from, to time.Time) ([]models.Tip, error) {
+	if r.ordersDB == nil {
+		return nil, errors.New("tips repository not fully initialized")
+	}
+	query := `
+		SELECT ` + tipColumns + `
+		FROM ORDERS_TIPS t
+		WHERE t.courier_id = $1 AND t.created_at >= $2 AND t.created_at < $3 AND t.status NOT IN ($4, $5)
+		ORDER BY t.created_at DESC
+	`
+	rows, err := r.ordersDB.QueryContext(ctx, query, courierID, from.UTC(), to.UTC(),
+		string(models.TipStatusFailed), string(models.TipStatusRefunded))
+	if err != nil {
+		return nil, err
+	}
+	return scanTips(rows)
+}
+
+func scanTips(rows *sql.Rows) ([]models.Tip, error) {
+	defer rows.Close()
+
+	tips := make([]models.Tip, 0)
+	for rows.Next() {
+		var (
+			tip       models.Tip
+			stage     string
+			status    string
+			paidOutAt sql.NullTime
+		)
+		if err := rows.Scan(&tip.ID, &tip.OrderID, &tip.CustomerID, &tip.CourierID, &tip.Amount,
+			&stage, &status, &tip.CreatedAt, &paidOutAt); err != nil {
+			return nil, err
+		}
+		tip.Stage = models.TipStage(stage)
+		tip.Status = models.TipStatus(status)
+		if paidOutAt.Valid {
+			t := paidOutAt.Time
+			tip.PaidOutAt = &t
+		}
+		tips = append(tips, tip)
+	}
+	return tips, rows.Err()
+}
+
+// CountCompletedOrders uses updated_at as the completion time: ORDER_COMPLETED
+// is terminal, so the row is not touched afterwards.
+func (r *tipRepository) CountCompletedOrders(ctx context.Context, courierID uuid.UUID, from, to time.Time) (int, error) {
+	if r.ordersDB == nil {
+		return 0, errors.New("tips repository not fully initialized")
+	}
+	var count int
+	query := "SELECT COUNT(*) FROM ORDERS WHERE courier_id = $1 AND status = $2 AND updated_at >= $3 AND updated_at < $4"
+	err := r.ordersDB.QueryRowContext(ctx, query, courierID, string(models.OrderStatusOrderCompleted), from.UTC(), to.UTC()).Scan(&count)
+	return count, err
+}
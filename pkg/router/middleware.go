@@ -0,0 +1,135 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+)
+
+// Chain wraps h in middleware, the first one outermost.
+func Chain(h http.Handler, middleware ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+var (
+	corsMethods = strings.Join(methods, ", ")
+	corsHeaders = strings.Join([]string{
+		"Content-Type",
+		"Authorization",
+		"X-API-Key",
+		logging.RequestIDHeader,
+		// W3C trace context; tracing imports this package.
+		"traceparent",
+		"tracestate",
+	}, ", ")
+)
+
+// corsMaxAge lets browsers reuse a preflight answer for ten minutes.
+const corsMaxAge = "600"
+
+// CORS lets browsers on origins call the API; "*" allows any origin.
+// Preflight requests are answered here and never reach the router. A
+// request from another origin is still served, the browser just keeps
+// the response from the page.
+func CORS(origins []string) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(origins, "*")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			switch {
+			case anyOrigin:
+				h.Set("Access-Control-Allow-Origin", "*")
+			case slices.Contains(origins, origin):
+				h.Set("Access-Control-Allow-Origin", origin)
+			case preflight:
+				utils.WriteError(w, "origin "+origin+" is not allowed", http.StatusForbidden)
+				return
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.Set("Access-Control-Expose-Headers", logging.RequestIDHeader)
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.Set("Access-Control-Allow-Methods", corsMethods)
+			h.Set("Access-Control-Allow-Headers", corsHeaders)
+			h.Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// LimitBody rejects bodies over limit bytes: at once when Content-Length
+// says so, otherwise with an error from the first Read past the limit.
+func LimitBody(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				utils.WriteError(w, fmt.Sprintf("request body exceeds %d bytes", limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Recover turns a panicking handler into a logged 500 so one bad request
+// cannot take the process down. It goes directly around the router, where
+// metrics and tracing still see the 500 it writes.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &headerRecorder{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			logging.FromContext(r.Context()).Error("http: handler panicked",
+				"panic", v, "stack", string(debug.Stack()))
+			if !rec.wroteHeader {
+				utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+type headerRecorder struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (r *headerRecorder) WriteHeader(status int) {
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *headerRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *headerRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// UUIDParam parses the path parameter name, e.g. {order_id}. When it is
+// not a UUID it writes a 400 naming the parameter and returns false.
+func UUIDParam(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		utils.WriteError(w, name+" must be UUID", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
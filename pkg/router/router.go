@@ -0,0 +1,102 @@
+// Package router maps "METHOD /path/{param}" patterns to handlers and
+// holds the middleware every service puts in front of them: CORS, panic
+// recovery and request size limits.
+//
+// It is a thin layer over http.ServeMux, so path parameters are read with
+// r.PathValue and r.Pattern names the matched route for metrics and
+// tracing. What it adds is that every route must name its method and that
+// unknown paths and wrong methods get the JSON error envelope instead of
+// the mux's plain-text replies.
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/utils"
+)
+
+// Mux is what Mount functions register on; both *Router and
+// *http.ServeMux satisfy it.
+type Mux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// methods are probed, in this order, to build the Allow header of a 405.
+var methods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+type Router struct {
+	mux      *http.ServeMux
+	patterns []string
+}
+
+func New() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+// Handle registers h for pattern, e.g. "POST /orders/{order_id}/pay". A
+// pattern without a method panics: a route open to every method is a
+// programming error, caught the first time the process starts.
+func (rt *Router) Handle(pattern string, h http.Handler) {
+	method, _, ok := strings.Cut(pattern, " ")
+	if !ok || method == "" || strings.HasPrefix(method, "/") {
+		panic(fmt.Sprintf("router: pattern %q has no method", pattern))
+	}
+	rt.mux.Handle(pattern, h)
+	rt.patterns = append(rt.patterns, pattern)
+}
+
+func (rt *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	rt.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// Patterns lists the registered patterns in registration order.
+func (rt *Router) Patterns() []string {
+	return slices.Clone(rt.patterns)
+}
+
+// ServeHTTP passes r itself to the mux, so middleware outside the router
+// sees the matched route in r.Pattern once the handler returns.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern != "" {
+		rt.mux.ServeHTTP(w, r)
+		return
+	}
+	if allowed := rt.allowed(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	utils.WriteError(w, "not found", http.StatusNotFound)
+}
+
+// allowed lists the methods some route accepts for r's path.
+func (rt *Router) allowed(r *http.Request) []string {
+	var allowed []string
+	probe := *r
+	for _, method := range methods {
+		probe.Method = method
+		if _, pattern := rt.mux.Handler(&probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// Route returns the matched route without its method, e.g.
+// "/orders/{order_id}/pay", or "" before the router has run.
+func Route(r *http.Request) string {
+	if _, path, ok := strings.Cut(r.Pattern, " "); ok {
+		return path
+	}
+	return r.Pattern
+}
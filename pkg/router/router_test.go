@@ -0,0 +1,135 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+)
+
+func serve(h http.Handler, req *http.Request) (*httptest.ResponseRecorder, models.ErrorResponce) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var body models.ErrorResponce
+	_ = json.NewDecoder(rec.Body).Decode(&body)
+	return rec, body
+}
+
+func TestRouter(t *testing.T) {
+	rt := New()
+	var route string
+	rt.HandleFunc("POST /orders/{order_id}/pay", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := UUIDParam(w, r, "order_id"); !ok {
+			return
+		}
+		route = Route(r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	rt.HandleFunc("DELETE /orders/{order_id}/pay", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+		allow  string
+	}{
+		{"match", http.MethodPost, "/orders/5b0c1b2e-8f5d-4c1a-9d8e-1f2a3b4c5d6e/pay", http.StatusNoContent, "", ""},
+		{"bad param", http.MethodPost, "/orders/42/pay", http.StatusBadRequest, "bad_request", ""},
+		{"wrong method", http.MethodGet, "/orders/42/pay", http.StatusMethodNotAllowed, "method_not_allowed", "POST, DELETE"},
+		{"unknown path", http.MethodPost, "/orders/42/refund", http.StatusNotFound, "not_found", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, body := serve(rt, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status || body.Code != tt.code {
+				t.Errorf("got %d %q, want %d %q", rec.Code, body.Code, tt.status, tt.code)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+	if route != "/orders/{order_id}/pay" {
+		t.Errorf("Route = %q", route)
+	}
+	if got := rt.Patterns(); len(got) != 2 || got[0] != "POST /orders/{order_id}/pay" {
+		t.Errorf("Patterns = %q", got)
+	}
+}
+
+func TestHandleWithoutMethodPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("pattern without a method did not panic")
+		}
+	}()
+	New().HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) })
+	h := CORS([]string{"http://localhost:5173"})(next)
+
+	preflight := func(origin string) *http.Request {
+		req := httptest.NewRequest(http.MethodOptions, "/orders", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		return req
+	}
+	rec, _ := serve(h, preflight("http://localhost:5173"))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "http://localhost:5173" {
+		t.Errorf("allowed preflight: %d %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Errorf("Access-Control-Allow-Headers = %q", rec.Header().Get("Access-Control-Allow-Headers"))
+	}
+	if rec, body := serve(h, preflight("http://evil.example")); rec.Code != http.StatusForbidden || body.Code != "forbidden" {
+		t.Errorf("foreign preflight: %d %+v", rec.Code, body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("Origin", "http://evil.example")
+	if rec, _ := serve(h, req); rec.Code != http.StatusTeapot || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("foreign request: %d %v", rec.Code, rec.Header())
+	}
+
+	rec, _ = serve(CORS([]string{"*"})(next), preflight("http://anything.example"))
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("wildcard: %v", rec.Header())
+	}
+}
+
+func TestRecover(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+	rec, body := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError || body.Code != "internal_server_error" {
+		t.Errorf("got %d %+v", rec.Code, body)
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	h := LimitBody(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v any
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	rec, body := serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"too long"}`)))
+	if rec.Code != http.StatusRequestEntityTooLarge || body.Code != "request_entity_too_large" {
+		t.Errorf("declared length: %d %+v", rec.Code, body)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"too long"}`))
+	req.ContentLength = -1
+	if rec, _ := serve(h, req); rec.Code != http.StatusBadRequest {
+		t.Errorf("streamed body: %d", rec.Code)
+	}
+	if rec, _ := serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))); rec.Code != http.StatusOK {
+		t.Errorf("small body: %d", rec.Code)
+	}
+}
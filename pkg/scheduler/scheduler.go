@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/usecase"
+
+	"github.com/google/uuid"
+)
+
+// Store is the part of the orders repository the scheduler needs.
+type Store interface {
+	ListDueScheduled(ctx context.Context, now time.Time, limit int) ([]models.Order, error)
+	ReleaseScheduled(ctx context.Context, orderID uuid.UUID) error
+}
+
+// ReleaseHook is called after an order has been handed to the kitchen.
+type ReleaseHook func(ctx context.Context, order models.Order)
+
+const (
+	DefaultInterval  = 30 * time.Second
+	DefaultBatchSize = 100
+)
+
+// Scheduler periodically moves scheduled orders whose release time has come
+// from CUSTOMER_SCHEDULED to CUSTOMER_PAID, where the kitchen picks them up.
+type Scheduler struct {
+	store     Store
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+	onRelease ReleaseHook
+}
+
+func New(store Store, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Scheduler{
+		store:     store,
+		interval:  interval,
+		batchSize: DefaultBatchSize,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// OnRelease registers a hook that runs for every released order.
+func (s *Scheduler) OnRelease(hook ReleaseHook) *Scheduler {
+	s.onRelease = hook
+	return s
+}
+
+// Run releases due orders every interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	logging.FromContext(ctx).Info("scheduler: started", "interval", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Tick(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logging.FromContext(ctx).Error("scheduler: tick failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			logging.FromContext(ctx).Info("scheduler: stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick releases every order that is due now and returns how many were released.
+// Orders changed concurrently (for example cancelled) are skipped.
+func (s *Scheduler) Tick(ctx context.Context) (int, error) {
+	released := 0
+	for {
+		due, err := s.store.ListDueScheduled(ctx, s.now(), s.batchSize)
+		if err != nil {
+			return released, err
+		}
+		progressed := false
+		for _, order := range due {
+			if err := s.store.ReleaseScheduled(ctx, order.ID); err != nil {
+				logging.FromContext(ctx).Error("scheduler: release order failed", "order_id", order.ID, "error", err)
+				continue
+			}
+			progressed = true
+			released++
+			usecase.RecordTransition(models.OrderStatusCustomerScheduled, models.OrderStatusCustomerPaid)
+			logging.FromContext(ctx).Info("scheduler: released order to the kitchen", "order_id", order.ID)
+			if s.onRelease != nil {
+				s.onRelease(ctx, order)
+			}
+		}
+		if len(due) < s.batchSize || !progressed {
+			return released, nil
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+type fakeStore struct {
+	orders   map[uuid.UUID]models.Order
+	released []uuid.UUID
+}
+
+func (f *fakeStore) ListDueScheduled(ctx context.Context, now time.Time, limit int) ([]models.Order, error) {
+	var due []models.Order
+	for _, order := range f.orders {
+		if order.Status == string(models.OrderStatusCustomerScheduled) && !order.ReleaseAt.After(now) && len(due) < limit {
+			due = append(due, order)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeStore) ReleaseScheduled(ctx context.Context, orderID uuid.UUID) error {
+	order := f.orders[orderID]
+	order.Status = string(models.OrderStatusCustomerPaid)
+	f.orders[orderID] = order
+	f.released = append(f.released, orderID)
+	return nil
+}
+
+func TestTick(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+
+	store := &fakeStore{orders: map[uuid.UUID]models.Order{}}
+	add := func(status models.OrderStatus, releaseAt time.Time) uuid.UUID {
+		id := uuid.New()
+		store.orders[id] = models.Order{ID: id, Status: string(status), ReleaseAt: &releaseAt}
+		return id
+	}
+	due := add(models.OrderStatusCustomerScheduled, past)
+	add(models.OrderStatusCustomerScheduled, future)
+	add(models.OrderStatusCustomerCancelled, past)
+	for i := 0; i < 3; i++ {
+		add(models.OrderStatusCustomerScheduled, past)
+	}
+
+	var hooked int
+	s := New(store, time.Second).OnRelease(func(ctx context.Context, order models.Order) { hooked++ })
+	s.now = func() time.Time { return now }
+	s.batchSize = 2
+
+	released, err := s.Tick(context.Background())
+	if err != nil {
+		t.Fatalf("Tick() failed: %v", err)
+	}
+	if released != 4 || hooked != 4 {
+		t.Errorf("Tick() released %d orders (hook %d), want 4", released, hooked)
+	}
+	if store.orders[due].Status != string(models.OrderStatusCustomerPaid) {
+		t.Errorf("due order status = %s, want CUSTOMER_PAID", store.orders[due].Status)
+	}
+	if again, _ := s.Tick(context.Background()); again != 0 {
+		t.Errorf("second Tick() released %d orders, want 0", again)
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+	"github.com/Kabanya/YAFDS/pkg/router"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultCheckTimeout bounds each dependency check in /readyz.
+const DefaultCheckTimeout = 2 * time.Second
+
+const (
+	StatusUp       = "UP"
+	StatusDown     = "DOWN"
+	StatusDraining = "DRAINING"
+)
+
+// Check reports whether a dependency can serve requests.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// CheckResult is one dependency in a readiness report.
+type CheckResult struct {
+	Status    string  `json:"status" oneof:"UP DOWN"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the /readyz response body.
+type Report struct {
+	Status string                 `json:"status" oneof:"UP DOWN DRAINING"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Health serves /livez, which only says the process is running (also as
+// /health for older probes), and /readyz, which checks every dependency and fails while any is down or
+// the server is shutting down.
+type Health struct {
+	checks   []namedCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHealth() *Health {
+	return &Health{timeout: DefaultCheckTimeout}
+}
+
+// Add registers a dependency; call it before the server starts.
+func (h *Health) Add(name string, check Check) *Health {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+	return h
+}
+
+func (h *Health) Mount(mux router.Mux) {
+	mux.HandleFunc("GET /livez", h.Livez)
+	mux.HandleFunc("GET /health", h.Livez)
+	mux.HandleFunc("GET /readyz", h.Readyz)
+}
+
+// Operations documents the routes Mount registers.
+var Operations = map[string]openapi.Op{
+	"GET /livez": {
+		Summary:   "Process is up",
+		Responses: map[int]any{http.StatusOK: Report{}},
+	},
+	"GET /health": {
+		Summary:   "Process is up; alias of /livez",
+		Responses: map[int]any{http.StatusOK: Report{}},
+	},
+	"GET /readyz": {
+		Summary:   "Dependency status and latencies",
+		Responses: map[int]any{http.StatusOK: Report{}, http.StatusServiceUnavailable: Report{}},
+	},
+}
+
+func (h *Health) Livez(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, Report{Status: StatusUp}, http.StatusOK)
+}
+
+func (h *Health) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.Check(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	utils.WriteJSON(w, report, status)
+}
+
+// Check runs every dependency check concurrently.
+func (h *Health) Check(ctx context.Context) Report {
+	if h.draining.Load() {
+		return Report{Status: StatusDraining}
+	}
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(h.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.run(ctx, c.check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{Status: StatusUp, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status, result.Error = StatusDown, err.Error()
+	}
+	return result
+}
+
+func (h *Health) drain() {
+	h.draining.Store(true)
+}
+
+// PingDB checks a Postgres pool.
+func PingDB(db *sql.DB) Check {
+	return db.PingContext
+}
+
+// PingRedis checks a Redis client.
+func PingRedis(client *redis.Client) Check {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// PingHTTP checks that url answers with a 2xx status. Point it at another
+// service's /livez rather than /readyz so one outage does not cascade.
+func PingHTTP(url string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,144 @@
+// Package server runs a service's HTTP server and background workers and
+// stops both cleanly on SIGINT or SIGTERM.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/config"
+)
+
+// Worker is a background loop that returns once ctx is cancelled, like
+// notify.Notifier.Run or scheduler.Scheduler.Run.
+type Worker func(ctx context.Context)
+
+type worker struct {
+	name string
+	run  Worker
+}
+
+// Server is an http.Server with timeouts plus the workers that live as
+// long as it does. On a signal it reports draining, keeps serving for the
+// shutdown delay, stops accepting connections, waits for in-flight
+// requests, then cancels the workers and waits for them too, all within
+// the shutdown timeout.
+type Server struct {
+	http            *http.Server
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	health          *Health
+	workers         []worker
+}
+
+func New(addr string, handler http.Handler, cfg config.HTTP) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+		shutdownDelay:   cfg.ShutdownDelay,
+	}
+}
+
+// WithHealth makes /readyz report the service as draining once shutdown
+// starts, so load balancers stop sending it traffic.
+func (s *Server) WithHealth(h *Health) *Server {
+	s.health = h
+	return s
+}
+
+// Go registers a worker; it starts when the server does.
+func (s *Server) Go(name string, run Worker) *Server {
+	s.workers = append(s.workers, worker{name: name, run: run})
+	return s
+}
+
+// Run listens on the configured address and serves until ctx is done or
+// the process gets SIGINT or SIGTERM.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return fmt.Errorf("server: listen: %w", err)
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.Serve(ctx, ln)
+}
+
+// Serve is Run on an existing listener, without the signal handling.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+	var wg sync.WaitGroup
+	for _, w := range s.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.run(workerCtx)
+			slog.Info("server: worker stopped", "worker", w.name)
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(ln)
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		err = fmt.Errorf("server: %w", err)
+		if s.health != nil {
+			s.health.drain()
+		}
+	case <-ctx.Done():
+		if s.health != nil {
+			s.health.drain()
+		}
+		// Load balancers only see DRAINING on their next probe; until
+		// then they still route requests here.
+		if s.shutdownDelay > 0 {
+			slog.Info("server: draining, waiting before closing the listener", "delay", s.shutdownDelay)
+			select {
+			case <-time.After(s.shutdownDelay):
+			case err = <-serveErr:
+				err = fmt.Errorf("server: %w", err)
+			}
+		}
+		slog.Info("server: shutting down, draining requests", "timeout", s.shutdownTimeout)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if shutdownErr := s.http.Shutdown(shutdownCtx); shutdownErr != nil {
+		err = errors.Join(err, fmt.Errorf("server: drain requests: %w", shutdownErr))
+	}
+
+	cancelWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		err = errors.Join(err, fmt.Errorf("server: workers still running after %s", s.shutdownTimeout))
+	}
+	return err
+}
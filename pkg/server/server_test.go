@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/config"
+)
+
+var testHTTP = config.HTTP{
+	ReadHeaderTimeout: time.Second,
+	ReadTimeout:       time.Second,
+	WriteTimeout:      time.Second,
+	IdleTimeout:       time.Second,
+	ShutdownTimeout:   2 * time.Second,
+}
+
+func TestServeDrainsRequestsAndWorkers(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+	health := NewHealth()
+	health.Mount(mux)
+
+	workerStopped := make(chan struct{})
+	srv := New("", mux, testHTTP).WithHealth(health).Go("test", func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+
+	// The worker must outlive the in-flight request.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-workerStopped:
+		t.Fatal("worker stopped before requests drained")
+	default:
+	}
+	if report := health.Check(context.Background()); report.Status != StatusDraining {
+		t.Errorf("readiness while draining = %q", report.Status)
+	}
+
+	close(release)
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request = %q, want done", got)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+	select {
+	case <-workerStopped:
+	default:
+		t.Error("worker still running after Serve returned")
+	}
+}
+
+func TestServeReportsStuckWorkers(t *testing.T) {
+	cfg := testHTTP
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+	srv := New("", http.NewServeMux(), cfg).Go("stuck", func(ctx context.Context) {
+		time.Sleep(time.Second)
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := srv.Serve(ctx, ln); err == nil {
+		t.Error("Serve() = nil with a worker that ignores cancellation")
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	health := NewHealth().
+		Add("postgres", func(ctx context.Context) error { return nil }).
+		Add("redis", func(ctx context.Context) error { return errors.New("connection refused") }).
+		Add("restaurant_api", PingHTTP(upstream.URL+"/livez"))
+	mux := http.NewServeMux()
+	health.Mount(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != StatusDown || report.Checks["postgres"].Status != StatusUp ||
+		report.Checks["redis"].Error != "connection refused" || report.Checks["restaurant_api"].Status != StatusDown {
+		t.Errorf("report = %+v", report)
+	}
+
+	for _, path := range []string{"/livez", "/health"} {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s status = %d, want 200", path, rec.Code)
+		}
+	}
+}
+
+func TestServeWaitsShutdownDelay(t *testing.T) {
+	cfg := testHTTP
+	cfg.ShutdownDelay = 200 * time.Millisecond
+	health := NewHealth()
+	mux := http.NewServeMux()
+	health.Mount(mux)
+	srv := New("", mux, cfg).WithHealth(health)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+	cancel()
+
+	// During the delay new requests are still served and see DRAINING.
+	time.Sleep(50 * time.Millisecond)
+	resp, err := http.Get("http://" + ln.Addr().String() + "/readyz")
+	if err != nil {
+		t.Fatalf("request during the shutdown delay failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("/readyz during the shutdown delay = %d, want 503", resp.StatusCode)
+	}
+	select {
+	case err := <-served:
+		t.Fatalf("Serve() returned %v before the shutdown delay", err)
+	default:
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+}
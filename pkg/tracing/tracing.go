@@ -0,0 +1,110 @@
+// Package tracing wires the OpenTelemetry SDK into the services: Setup
+// installs the tracer provider, and the wrappers here start spans for
+// incoming requests, outgoing HTTP calls and SQL statements, carrying W3C
+// trace context between the services.
+//
+// Spans go to any OTLP/HTTP collector (Jaeger, Tempo, the OpenTelemetry
+// Collector) or, for local runs, to a file or stdout, see config.Trace.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scope names the tracer the wrappers in this package use.
+const scope = "github.com/Kabanya/YAFDS/pkg/tracing"
+
+const shutdownTimeout = 5 * time.Second
+
+// propagator is fixed to W3C Trace Context rather than read from the
+// otel globals, so the wrappers propagate whether or not Setup ran.
+var propagator = propagation.TraceContext{}
+
+// Setup installs the tracer provider cfg describes for service as the
+// otel global. The returned function flushes spans still queued and
+// closes the exporter.
+func Setup(cfg config.Trace, service string) (func() error, error) {
+	var (
+		exp  sdktrace.SpanExporter
+		name = "none"
+		file io.Closer
+		err  error
+	)
+	switch {
+	case cfg.OTLPEndpoint != "":
+		url := strings.TrimRight(cfg.OTLPEndpoint, "/")
+		if !strings.HasSuffix(url, "/v1/traces") {
+			url += "/v1/traces"
+		}
+		exp, err = otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(url))
+		name = "otlp"
+	case cfg.File == "-":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		name = "stdout"
+	case cfg.File != "":
+		var f *os.File
+		if f, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err == nil {
+			file = f
+			exp, err = stdouttrace.New(stdouttrace.WithWriter(f))
+		}
+		name = "file"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+	if exp != nil {
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	slog.Info("tracing: configured", "exporter", name, "sample_ratio", cfg.SampleRatio)
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}, nil
+}
+
+// tracer is looked up on every use so spans follow whichever provider is
+// installed, including the one tests swap in.
+func tracer() trace.Tracer {
+	return otel.Tracer(scope)
+}
+
+// inTrace reports whether ctx carries a span, local or from the caller.
+func inTrace(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
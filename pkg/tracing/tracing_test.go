@@ -0,0 +1,202 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record installs a tracer provider keeping spans in memory and returns
+// the spans ended so far.
+func record(t *testing.T) func() []sdktrace.ReadOnlySpan {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder.Ended
+}
+
+func find(t *testing.T, spans []sdktrace.ReadOnlySpan, kind trace.SpanKind, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, s := range spans {
+		if s.SpanKind() == kind && s.Name() == name {
+			return s
+		}
+	}
+	t.Fatalf("no %s span of kind %s in %d spans", name, kind, len(spans))
+	return nil
+}
+
+func TestSetupWritesSpansToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	closeTracing, err := Setup(config.Trace{File: path, SampleRatio: 1}, "customer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := tracer().Start(context.Background(), "POST /orders")
+	span.End()
+	if err := closeTracing(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Name     string
+		Resource []struct {
+			Key   string
+			Value struct{ Value any }
+		}
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("span file is not JSON: %v\n%s", err, data)
+	}
+	if got.Name != "POST /orders" {
+		t.Errorf("span name = %q", got.Name)
+	}
+	service := ""
+	for _, kv := range got.Resource {
+		if kv.Key == "service.name" {
+			service, _ = kv.Value.Value.(string)
+		}
+	}
+	if service != "customer" {
+		t.Errorf("service.name = %q", service)
+	}
+}
+
+func TestTraceCrossesServices(t *testing.T) {
+	spans := record(t)
+
+	restaurantMux := http.NewServeMux()
+	restaurantMux.HandleFunc("/menu/show", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "[]")
+	})
+	restaurant := httptest.NewServer(Middleware(restaurantMux))
+	defer restaurant.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+	customerMux := http.NewServeMux()
+	customerMux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, restaurant.URL+"/menu/show?restaurant_id=1", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	incoming := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set("traceparent", incoming)
+	Middleware(customerMux).ServeHTTP(httptest.NewRecorder(), req)
+	restaurant.Close()
+
+	got := spans()
+	if len(got) != 3 {
+		t.Fatalf("got %d spans, want 3", len(got))
+	}
+	server := find(t, got, trace.SpanKindServer, "POST /orders")
+	call := find(t, got, trace.SpanKindClient, "GET /menu/show")
+	upstream := find(t, got, trace.SpanKindServer, "GET /menu/show")
+	if server.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("server span did not join the incoming trace: %v", server.Parent())
+	}
+	if call.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("client span not a child of the server span: %v", call.Parent())
+	}
+	if upstream.SpanContext().TraceID() != server.SpanContext().TraceID() || upstream.Parent().SpanID() != call.SpanContext().SpanID() {
+		t.Errorf("restaurant span not linked through traceparent: %v", upstream.Parent())
+	}
+}
+
+func TestSQLSpansOnlyInsideTraces(t *testing.T) {
+	spans := record(t)
+	db, err := Open("tracingtest", "", "order_db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(context.Background(), "UPDATE orders SET status = $1", "PAID"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, span := tracer().Start(context.Background(), "POST /orders", trace.WithSpanKind(trace.SpanKindServer))
+	if _, err := db.ExecContext(ctx, "INSERT INTO\n\t\torders (id)  VALUES ($1)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM orders"); err == nil {
+		t.Fatal("fake driver accepted DELETE")
+	}
+	span.End()
+
+	got := spans()
+	if len(got) != 3 {
+		t.Fatalf("got %d spans, want 3", len(got))
+	}
+	insert := find(t, got, trace.SpanKindClient, "INSERT order_db")
+	if insert.Parent().SpanID() != span.SpanContext().SpanID() || insert.Status().Code == codes.Error {
+		t.Errorf("insert span: parent %v, status %v", insert.Parent(), insert.Status())
+	}
+	if attr(insert, "db.query.text") != "INSERT INTO orders (id) VALUES ($1)" {
+		t.Errorf("db.query.text = %v", attr(insert, "db.query.text"))
+	}
+	if find(t, got, trace.SpanKindClient, "DELETE order_db").Status().Code != codes.Error {
+		t.Error("failed statement not marked")
+	}
+}
+
+func attr(s sdktrace.ReadOnlySpan, key string) any {
+	for _, a := range s.Attributes() {
+		if string(a.Key) == key {
+			return a.Value.AsInterface()
+		}
+	}
+	return nil
+}
+
+func init() {
+	sql.Register("tracingtest", fakeDriver{})
+}
+
+// fakeDriver accepts any statement but DELETE.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if len(query) >= 6 && query[:6] == "DELETE" {
+		return nil, errors.New("permission denied")
+	}
+	return driver.RowsAffected(1), nil
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCancellationNotAllowed = errors.New("order can no longer be cancelled")
+	ErrInvalidReasonCode      = errors.New("invalid cancellation reason code")
+	ErrRefundFailed           = errors.New("refund failed")
+)
+
+// CancellationPolicy maps the status an order is cancelled from to the share
+// of the paid total that goes back to the customer. Statuses missing from
+// RefundRates cannot be cancelled by the customer.
+type CancellationPolicy struct {
+	RefundRates map[models.OrderStatus]float64
+}
+
+// DefaultCancellationPolicy is free before the kitchen accepts (scheduled
+// orders on hold included), half back while the kitchen works on the order and
+// closed once delivery has started.
+var DefaultCancellationPolicy = NewCancellationPolicy(0.5)
+
+func NewCancellationPolicy(kitchenRefundRate float64) CancellationPolicy {
+	kitchenRefundRate = math.Min(math.Max(kitchenRefundRate, 0), 1)
+	return CancellationPolicy{RefundRates: map[models.OrderStatus]float64{
+		models.OrderStatusCustomerCreated:   1,
+		models.OrderStatusCustomerPaid:      1,
+		models.OrderStatusCustomerScheduled: 1,
+		models.OrderStatusKitchenAccepted:   kitchenRefundRate,
+		models.OrderStatusKitchenPreparing:  kitchenRefundRate,
+	}}
+}
+
+// RefundRate reports the refund share for status and whether cancelling is allowed at all.
+func (p CancellationPolicy) RefundRate(status models.OrderStatus) (float64, bool) {
+	rate, ok := p.RefundRates[status]
+	return rate, ok
+}
+
+// RestaurantNotifier tells the kitchen an order was cancelled so it can stop
+// working on it and put the stock it reserved back on the menu.
+type RestaurantNotifier interface {
+	OrderCancelled(ctx context.Context, event OrderCancelledEvent) error
+}
+
+// OrderCancelledEvent only names the order: the restaurant checks the
+// cancellation and releases what it reserved at accept itself.
+type OrderCancelledEvent struct {
+	OrderID        uuid.UUID                 `json:"order_id" required:"true"`
+	PreviousStatus models.OrderStatus        `json:"previous_status"`
+	ReasonCode     models.CancellationReason `json:"reason_code"`
+	// ReleaseStock is set when the kitchen had taken the order.
+	ReleaseStock bool `json:"release_stock"`
+}
+
+// TipVoider gives checkout tips back when their order is cancelled.
+type TipVoider interface {
+	VoidOrderTips(ctx context.Context, orderID uuid.UUID) error
+}
+
+type CancelInput struct {
+	OrderID    uuid.UUID
+	CustomerID uuid.UUID
+	ReasonCode models.CancellationReason
+	Comment    string
+}
+
+type CancelResult struct {
+	OrderID        uuid.UUID           `json:"order_id"`
+	Status         models.OrderStatus  `json:"status"`
+	PreviousStatus models.OrderStatus  `json:"previous_status"`
+	RefundAmount   float64             `json:"refund_amount"`
+	RefundStatus   models.RefundStatus `json:"refund_status"`
+}
+
+func (u *orderUseCase) Cancel(ctx context.Context, input CancelInput) (CancelResult, error) {
+	if !input.ReasonCode.Valid() {
+		return CancelResult{}, ErrInvalidReasonCode
+	}
+	order, err := u.repo.Get(ctx, input.OrderID)
+	if err != nil {
+		return CancelResult{}, err
+	}
+	if order.CustomerID != input.CustomerID {
+		return CancelResult{}, ErrOrderNotOwned
+	}
+
+	previous := models.OrderStatus(order.Status)
+	rate, ok := u.policy.RefundRate(previous)
+	if !ok {
+		return CancelResult{}, fmt.Errorf("%w from %s", ErrCancellationNotAllowed, previous)
+	}
+
+	// Nothing was debited before payment, so there is nothing to refund.
+	var refund float64
+	if previous != models.OrderStatusCustomerCreated {
+		total, err := u.repo.GetOrderTotal(ctx, input.OrderID)
+		if err != nil {
+			return CancelResult{}, err
+		}
+		refund = math.Round(total*rate*100) / 100
+	}
+
+	if err := u.repo.Cancel(ctx, repositoryModels.CancelInput{
+		OrderID:        input.OrderID,
+		ExpectedStatus: previous,
+		ReasonCode:     input.ReasonCode,
+		Comment:        input.Comment,
+		RefundAmount:   refund,
+	}); err != nil {
+		return CancelResult{}, err
+	}
+	RecordTransition(previous, models.OrderStatusCustomerCancelled)
+
+	result := CancelResult{
+		OrderID:        input.OrderID,
+		Status:         models.OrderStatusCustomerCancelled,
+		PreviousStatus: previous,
+		RefundAmount:   refund,
+		RefundStatus:   models.RefundStatusNone,
+	}
+
+	// A failed tip refund is retried by the tip settler.
+	if u.tips != nil {
+		if err := u.tips.VoidOrderTips(ctx, input.OrderID); err != nil {
+			logging.FromContext(ctx).Warn("orders: refund tip of cancelled order failed", "order_id", input.OrderID, "error", err)
+		}
+	}
+
+	u.notifyCancelled(ctx, OrderCancelledEvent{
+		OrderID:        input.OrderID,
+		PreviousStatus: previous,
+		ReasonCode:     input.ReasonCode,
+		ReleaseStock:   previous == models.OrderStatusKitchenAccepted || previous == models.OrderStatusKitchenPreparing,
+	})
+	data := orderEventData(input.OrderID, models.OrderStatusCustomerCancelled)
+	data["reason_code"] = string(input.ReasonCode)
+	u.emit(ctx, notify.EventOrderCancelled, order, order.RestaurantID, data)
+
+	if refund > 0 {
+		result.RefundStatus, err = u.refund(ctx, input, refund)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (u *orderUseCase) refund(ctx context.Context, input CancelInput, amount float64) (models.RefundStatus, error) {
+	status := models.RefundStatusRefunded
+	var refundErr error
+	if u.wallet == nil {
+		status, refundErr = models.RefundStatusFailed, fmt.Errorf("%w: %v", ErrRefundFailed, ErrWalletUnavailable)
+	} else if walletAddress, err := u.repo.GetCustomerWalletAddress(ctx, input.CustomerID); err != nil {
+		status, refundErr = models.RefundStatusFailed, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	} else if err := u.wallet.Refund(ctx, walletAddress, amount); err != nil {
+		status, refundErr = models.RefundStatusFailed, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+
+	if err := u.repo.SetRefundStatus(ctx, input.OrderID, status); err != nil {
+		logging.FromContext(ctx).Error("orders: store refund status failed", "order_id", input.OrderID, "error", err)
+	}
+	return status, refundErr
+}
+
+// notifyCancelled is best effort: the cancellation already happened and the
+// restaurant also sees it through /orders.
+func (u *orderUseCase) notifyCancelled(ctx context.Context, event OrderCancelledEvent) {
+	if u.notifier == nil {
+		return
+	}
+	if err := u.notifier.OrderCancelled(ctx, event); err != nil {
+		logging.FromContext(ctx).Warn("orders: notify restaurant about cancelled order failed", "order_id", event.OrderID, "error", err)
+	}
+}
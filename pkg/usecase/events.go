@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+
+	"github.com/google/uuid"
+)
+
+// EventNotifier queues user-facing notifications, see pkg/notify.
+type EventNotifier interface {
+	Notify(ctx context.Context, notification notify.Notification) error
+}
+
+// WithEventNotifier adds an event sink; it can be given several times, e.g.
+// for user notifications and restaurant webhooks.
+func WithEventNotifier(events EventNotifier) OrderOption {
+	return func(u *orderUseCase) { u.events = append(u.events, events) }
+}
+
+// emit is best effort: a lost notification must not fail the order flow.
+func (u *orderUseCase) emit(ctx context.Context, event notify.EventType, order models.Order, userID uuid.UUID, data map[string]any) {
+	if userID == uuid.Nil && order.RestaurantID == uuid.Nil {
+		return
+	}
+	notification := notify.Notification{Event: event, UserID: userID, RestaurantID: order.RestaurantID, Data: data}
+	for _, sink := range u.events {
+		if err := sink.Notify(ctx, notification); err != nil {
+			logging.FromContext(ctx).Error("orders: queue notification failed", "event", event, "user_id", userID, "error", err)
+		}
+	}
+}
+
+func orderEventData(orderID uuid.UUID, status models.OrderStatus) map[string]any {
+	return map[string]any{"order_id": orderID.String(), "status": string(status)}
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"github.com/Kabanya/YAFDS/pkg/metrics"
+	"github.com/Kabanya/YAFDS/pkg/models"
+)
+
+// Order sources for RecordOrderCreated.
+const (
+	OrderSourceAPI       = "api"
+	OrderSourceScheduled = "scheduled"
+	OrderSourceReorder   = "reorder"
+	OrderSourceCart      = "cart"
+)
+
+// Kitchen denial reasons for RecordKitchenDenied.
+const (
+	DenialUnknownItem = "unknown_item"
+	DenialOutOfStock  = "out_of_stock"
+	DenialManual      = "manual"
+)
+
+var (
+	ordersCreated = metrics.NewCounterVec("orders_created_total",
+		"Orders created, by how they were placed.", "source")
+	orderTransitions = metrics.NewCounterVec("order_status_transitions_total",
+		"Order status changes, by previous and new status.", "from", "to")
+	paymentFailures = metrics.NewCounterVec("payment_failures_total",
+		"Order payments that did not go through, by reason.", "reason")
+	kitchenDenials = metrics.NewCounterVec("kitchen_denials_total",
+		"Orders the kitchen denied, by reason.", "reason")
+)
+
+func RecordOrderCreated(source string) {
+	ordersCreated.With(source).Inc()
+}
+
+func RecordTransition(from, to models.OrderStatus) {
+	orderTransitions.With(string(from), string(to)).Inc()
+}
+
+func RecordKitchenDenied(reason string) {
+	kitchenDenials.With(reason).Inc()
+}
+
+func recordPaymentFailure(reason string) {
+	paymentFailures.With(reason).Inc()
+}
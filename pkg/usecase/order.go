@@ -3,8 +3,12 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/Kabanya/YAFDS/pkg/logging"
 	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/notify"
 	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
 
 	"github.com/google/uuid"
@@ -15,18 +19,171 @@ var (
 	ErrInvalidStatusTransition = errors.New("invalid order status transition")
 	ErrWalletUnavailable       = errors.New("wallet service unavailable")
 	ErrInsufficientFunds       = errors.New("insufficient funds")
+	ErrPaymentInProgress       = errors.New("order is already being paid")
+	ErrOrderNotOwned           = errors.New("order belongs to another customer")
+	ErrOutOfStock              = errors.New("not enough stock for the order")
 )
 
 type WalletClient interface {
 	CheckAndDebit(ctx context.Context, walletAddress string, amount float64) (bool, error)
+	Refund(ctx context.Context, walletAddress string, amount float64) error
 }
 
 type OrderUseCase interface {
 	Pay(ctx context.Context, orderID uuid.UUID, customerID uuid.UUID) (models.OrderStatus, error)
 	ChangeStatus(ctx context.Context, orderID uuid.UUID, newStatus models.OrderStatus) (models.OrderStatus, error)
+	Cancel(ctx context.Context, input CancelInput) (CancelResult, error)
+	Reorder(ctx context.Context, input ReorderInput) (ReorderResult, error)
 }
 
 type orderUseCase struct {
-	repo   repositoryModels.Order
-	wallet WalletClient
+	repo     repositoryModels.Order
+	wallet   WalletClient
+	notifier RestaurantNotifier
+	policy   CancellationPolicy
+	menu     MenuClient
+	events   []EventNotifier
+	tips     TipVoider
+}
+
+type OrderOption func(*orderUseCase)
+
+func WithRestaurantNotifier(notifier RestaurantNotifier) OrderOption {
+	return func(u *orderUseCase) { u.notifier = notifier }
+}
+
+func WithCancellationPolicy(policy CancellationPolicy) OrderOption {
+	return func(u *orderUseCase) { u.policy = policy }
+}
+
+func WithTipVoider(tips TipVoider) OrderOption {
+	return func(u *orderUseCase) { u.tips = tips }
+}
+
+func NewOrderUseCase(repo repositoryModels.Order, wallet WalletClient, opts ...OrderOption) OrderUseCase {
+	u := &orderUseCase{repo: repo, wallet: wallet, policy: DefaultCancellationPolicy}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// Движемся по дереву состояний только вниз (assets/Order states.webp).
+var allowedTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusCustomerCreated:    {models.OrderStatusCustomerPaid, models.OrderStatusCustomerScheduled, models.OrderStatusCustomerCancelled},
+	models.OrderStatusCustomerScheduled:  {models.OrderStatusCustomerPaid, models.OrderStatusCustomerCancelled},
+	models.OrderStatusCustomerPaid:       {models.OrderStatusKitchenAccepted, models.OrderStatusKitchenDenied},
+	models.OrderStatusKitchenAccepted:    {models.OrderStatusKitchenPreparing},
+	models.OrderStatusKitchenDenied:      {models.OrderStatusCourierRefunded},
+	models.OrderStatusKitchenPreparing:   {models.OrderStatusDeliveryPending},
+	models.OrderStatusDeliveryPending:    {models.OrderStatusDeliveryPicking, models.OrderStatusDeliveryDenied},
+	models.OrderStatusDeliveryPicking:    {models.OrderStatusDeliveryDelivering},
+	models.OrderStatusDeliveryDenied:     {models.OrderStatusDeliveryRefunded},
+	models.OrderStatusDeliveryDelivering: {models.OrderStatusOrderCompleted},
+}
+
+func CanTransition(from, to models.OrderStatus) bool {
+	for _, next := range allowedTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *orderUseCase) Pay(ctx context.Context, orderID uuid.UUID, customerID uuid.UUID) (models.OrderStatus, error) {
+	order, err := u.repo.Get(ctx, orderID)
+	if err != nil {
+		return "", err
+	}
+	current := models.OrderStatus(order.Status)
+	if order.CustomerID != customerID {
+		return current, ErrOrderNotOwned
+	}
+	if !CanTransition(current, models.OrderStatusCustomerPaid) {
+		return current, fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, current, models.OrderStatusCustomerPaid)
+	}
+	if u.wallet == nil {
+		recordPaymentFailure("wallet_unavailable")
+		return current, ErrWalletUnavailable
+	}
+	walletAddress, err := u.repo.GetCustomerWalletAddress(ctx, customerID)
+	if err != nil {
+		recordPaymentFailure("no_wallet_address")
+		return current, err
+	}
+
+	// Only the request that claims the order debits the wallet; the claim
+	// also freezes the items, so the total read below is what gets paid.
+	claimed, err := u.repo.ClaimPayment(ctx, orderID)
+	if err != nil {
+		return current, err
+	}
+	if !claimed {
+		return current, ErrPaymentInProgress
+	}
+	total, err := u.repo.GetOrderTotal(ctx, orderID)
+	if err != nil {
+		recordPaymentFailure("order_total")
+		u.releaseClaim(ctx, orderID)
+		return current, err
+	}
+	ok, err := u.wallet.CheckAndDebit(ctx, walletAddress, total)
+	if err != nil {
+		recordPaymentFailure("wallet_unavailable")
+		u.releaseClaim(ctx, orderID)
+		return current, fmt.Errorf("%w: %v", ErrWalletUnavailable, err)
+	}
+	if !ok {
+		recordPaymentFailure("insufficient_funds")
+		if err := u.repo.UpdateStatus(ctx, orderID, models.OrderStatusCustomerCancelled); err != nil {
+			return current, err
+		}
+		RecordTransition(current, models.OrderStatusCustomerCancelled)
+		return models.OrderStatusCustomerCancelled, ErrInsufficientFunds
+	}
+
+	// Scheduled orders stay on hold until the scheduler releases them to the kitchen.
+	paid := models.OrderStatusCustomerPaid
+	if order.ReleaseAt != nil && order.ReleaseAt.After(time.Now().UTC()) {
+		paid = models.OrderStatusCustomerScheduled
+	}
+	if err := u.repo.UpdateStatus(ctx, orderID, paid); err != nil {
+		return current, err
+	}
+	RecordTransition(current, paid)
+	// Scheduled orders reach the kitchen later, through the scheduler release.
+	if paid == models.OrderStatusCustomerPaid {
+		u.emit(ctx, notify.EventOrderPaid, order, order.RestaurantID, orderEventData(orderID, paid))
+	}
+	return paid, nil
+}
+
+// releaseClaim puts an order whose payment failed before any debit back to
+// CUSTOMER_CREATED, so it can be paid again.
+func (u *orderUseCase) releaseClaim(ctx context.Context, orderID uuid.UUID) {
+	if err := u.repo.UpdateStatus(ctx, orderID, models.OrderStatusCustomerCreated); err != nil {
+		logging.FromContext(ctx).Error("orders: release payment claim failed", "order_id", orderID, "error", err)
+	}
+}
+
+func (u *orderUseCase) ChangeStatus(ctx context.Context, orderID uuid.UUID, newStatus models.OrderStatus) (models.OrderStatus, error) {
+	current, err := u.repo.GetOrderStatus(ctx, orderID)
+	if err != nil {
+		return "", err
+	}
+	if !CanTransition(current, newStatus) {
+		return current, fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, current, newStatus)
+	}
+	if err := u.repo.UpdateStatus(ctx, orderID, newStatus); err != nil {
+		return current, err
+	}
+	RecordTransition(current, newStatus)
+	if newStatus == models.OrderStatusKitchenDenied {
+		RecordKitchenDenied(DenialManual)
+	}
+	if order, err := u.repo.Get(ctx, orderID); err == nil {
+		u.emit(ctx, notify.EventOrderStatusChanged, order, order.CustomerID, orderEventData(orderID, newStatus))
+	}
+	return newStatus, nil
 }
@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+type mockRepo struct {
+	repositoryModels.Order
+	order        models.Order
+	total        float64
+	cancelled    *repositoryModels.CancelInput
+	refundStatus models.RefundStatus
+	// beforeClaim runs once, ahead of the next ClaimPayment.
+	beforeClaim func()
+}
+
+func (m *mockRepo) Get(ctx context.Context, orderID uuid.UUID) (models.Order, error) {
+	return m.order, nil
+}
+
+func (m *mockRepo) GetOrderStatus(ctx context.Context, orderID uuid.UUID) (models.OrderStatus, error) {
+	return models.OrderStatus(m.order.Status), nil
+}
+
+func (m *mockRepo) ListItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error) {
+	return []models.OrderItem{{RestaurantItemID: uuid.New(), Price: m.total, Quantity: 1}}, nil
+}
+
+func (m *mockRepo) GetOrderTotal(ctx context.Context, orderID uuid.UUID) (float64, error) {
+	return m.total, nil
+}
+
+func (m *mockRepo) GetCustomerWalletAddress(ctx context.Context, customerID uuid.UUID) (string, error) {
+	return "0xabc", nil
+}
+
+func (m *mockRepo) ClaimPayment(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	if hook := m.beforeClaim; hook != nil {
+		m.beforeClaim = nil
+		hook()
+	}
+	if m.order.Status != string(models.OrderStatusCustomerCreated) {
+		return false, nil
+	}
+	m.order.Status = string(models.OrderStatusCustomerPaying)
+	return true, nil
+}
+
+func (m *mockRepo) UpdateStatus(ctx context.Context, orderID uuid.UUID, status models.OrderStatus) error {
+	m.order.Status = string(status)
+	return nil
+}
+
+func (m *mockRepo) Cancel(ctx context.Context, input repositoryModels.CancelInput) error {
+	m.cancelled = &input
+	return nil
+}
+
+func (m *mockRepo) SetRefundStatus(ctx context.Context, orderID uuid.UUID, status models.RefundStatus) error {
+	m.refundStatus = status
+	return nil
+}
+
+type mockWallet struct {
+	debited   float64
+	refunded  float64
+	refundErr error
+}
+
+func (m *mockWallet) CheckAndDebit(ctx context.Context, walletAddress string, amount float64) (bool, error) {
+	m.debited += amount
+	return true, nil
+}
+
+func (m *mockWallet) Refund(ctx context.Context, walletAddress string, amount float64) error {
+	m.refunded = amount
+	return m.refundErr
+}
+
+type mockNotifier struct {
+	events []OrderCancelledEvent
+}
+
+func (m *mockNotifier) OrderCancelled(ctx context.Context, event OrderCancelledEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestCancel(t *testing.T) {
+	customerID := uuid.New()
+	newOrder := func(status models.OrderStatus) models.Order {
+		return models.Order{ID: uuid.New(), CustomerID: customerID, Status: string(status)}
+	}
+
+	tests := []struct {
+		name         string
+		status       models.OrderStatus
+		wantErr      error
+		wantRefund   float64
+		releaseStock bool
+	}{
+		{name: "unpaid order is free", status: models.OrderStatusCustomerCreated, wantRefund: 0},
+		{name: "paid order is fully refunded", status: models.OrderStatusCustomerPaid, wantRefund: 40},
+		{name: "preparing order is partially refunded", status: models.OrderStatusKitchenPreparing, wantRefund: 20, releaseStock: true},
+		{name: "delivering order cannot be cancelled", status: models.OrderStatusDeliveryDelivering, wantErr: ErrCancellationNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepo{order: newOrder(tt.status), total: 40}
+			wallet := &mockWallet{}
+			notifier := &mockNotifier{}
+			uc := NewOrderUseCase(repo, wallet, WithRestaurantNotifier(notifier))
+
+			res, err := uc.Cancel(context.Background(), CancelInput{
+				OrderID:    repo.order.ID,
+				CustomerID: customerID,
+				ReasonCode: models.CancellationReasonChangedMind,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Cancel() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if repo.cancelled != nil || len(notifier.events) != 0 {
+					t.Error("rejected cancellation must not touch the order")
+				}
+				return
+			}
+			if res.RefundAmount != tt.wantRefund || wallet.refunded != tt.wantRefund {
+				t.Errorf("refund = %v (wallet %v), want %v", res.RefundAmount, wallet.refunded, tt.wantRefund)
+			}
+			if repo.cancelled == nil || repo.cancelled.ExpectedStatus != tt.status || repo.cancelled.ReasonCode != models.CancellationReasonChangedMind {
+				t.Errorf("cancellation not recorded correctly: %+v", repo.cancelled)
+			}
+			if len(notifier.events) != 1 || notifier.events[0].ReleaseStock != tt.releaseStock {
+				t.Errorf("restaurant notification = %+v, want release_stock %v", notifier.events, tt.releaseStock)
+			}
+		})
+	}
+
+	t.Run("foreign customer", func(t *testing.T) {
+		repo := &mockRepo{order: newOrder(models.OrderStatusCustomerPaid), total: 10}
+		uc := NewOrderUseCase(repo, &mockWallet{})
+		_, err := uc.Cancel(context.Background(), CancelInput{OrderID: repo.order.ID, CustomerID: uuid.New(), ReasonCode: models.CancellationReasonOther})
+		if !errors.Is(err, ErrOrderNotOwned) {
+			t.Errorf("Cancel() error = %v, want ErrOrderNotOwned", err)
+		}
+	})
+
+	t.Run("invalid reason", func(t *testing.T) {
+		repo := &mockRepo{order: newOrder(models.OrderStatusCustomerPaid)}
+		uc := NewOrderUseCase(repo, &mockWallet{})
+		_, err := uc.Cancel(context.Background(), CancelInput{OrderID: repo.order.ID, CustomerID: customerID, ReasonCode: "BORED"})
+		if !errors.Is(err, ErrInvalidReasonCode) {
+			t.Errorf("Cancel() error = %v, want ErrInvalidReasonCode", err)
+		}
+	})
+
+	t.Run("failed refund is recorded", func(t *testing.T) {
+		repo := &mockRepo{order: newOrder(models.OrderStatusCustomerPaid), total: 10}
+		uc := NewOrderUseCase(repo, &mockWallet{refundErr: errors.New("down")})
+		res, err := uc.Cancel(context.Background(), CancelInput{OrderID: repo.order.ID, CustomerID: customerID, ReasonCode: models.CancellationReasonTooSlow})
+		if !errors.Is(err, ErrRefundFailed) {
+			t.Fatalf("Cancel() error = %v, want ErrRefundFailed", err)
+		}
+		if res.Status != models.OrderStatusCustomerCancelled || repo.refundStatus != models.RefundStatusFailed {
+			t.Errorf("result = %+v, stored refund status %s", res, repo.refundStatus)
+		}
+	})
+}
+
+func TestCanTransition(t *testing.T) {
+	if !CanTransition(models.OrderStatusCustomerCreated, models.OrderStatusCustomerPaid) {
+		t.Error("CUSTOMER_CREATED -> CUSTOMER_PAID should be allowed")
+	}
+	if CanTransition(models.OrderStatusKitchenPreparing, models.OrderStatusCustomerPaid) {
+		t.Error("moving back up the state tree should be rejected")
+	}
+}
+
+func TestConcurrentPayDebitsOnce(t *testing.T) {
+	customerID := uuid.New()
+	repo := &mockRepo{order: models.Order{ID: uuid.New(), CustomerID: customerID, Status: string(models.OrderStatusCustomerCreated)}, total: 25}
+	wallet := &mockWallet{}
+	uc := NewOrderUseCase(repo, wallet)
+
+	// The second request has read the order as CUSTOMER_CREATED too, but
+	// claims it only after the first one paid.
+	var firstStatus models.OrderStatus
+	var firstErr error
+	repo.beforeClaim = func() {
+		firstStatus, firstErr = uc.Pay(context.Background(), repo.order.ID, customerID)
+	}
+	_, err := uc.Pay(context.Background(), repo.order.ID, customerID)
+
+	if firstErr != nil || firstStatus != models.OrderStatusCustomerPaid {
+		t.Fatalf("first Pay() = %s, %v", firstStatus, firstErr)
+	}
+	if !errors.Is(err, ErrPaymentInProgress) {
+		t.Errorf("second Pay() error = %v, want ErrPaymentInProgress", err)
+	}
+	if wallet.debited != 25 {
+		t.Errorf("debited %v, want 25", wallet.debited)
+	}
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrReorderNotAllowed    = errors.New("only completed orders can be reordered")
+	ErrOrderHasNoRestaurant = errors.New("order has no restaurant recorded")
+	ErrNothingToReorder     = errors.New("none of the order items are available")
+	ErrMenuUnavailable      = errors.New("restaurant menu unavailable")
+)
+
+type MenuClient interface {
+	GetMenuItems(ctx context.Context, restaurantID uuid.UUID) ([]models.MenuItem, error)
+}
+
+func WithMenuClient(menu MenuClient) OrderOption {
+	return func(u *orderUseCase) { u.menu = menu }
+}
+
+type ReorderChange string
+
+const (
+	ReorderPriceChanged    ReorderChange = "PRICE_CHANGED"
+	ReorderQuantityReduced ReorderChange = "QUANTITY_REDUCED"
+	ReorderUnavailable     ReorderChange = "UNAVAILABLE"
+)
+
+// ReorderLineDiff explains how one line of the original order was carried
+// over. Lines copied unchanged are not reported.
+type ReorderLineDiff struct {
+	RestaurantItemID uuid.UUID       `json:"restaurant_item_id"`
+	Name             string          `json:"name,omitempty"`
+	OldPrice         float64         `json:"old_price"`
+	NewPrice         float64         `json:"new_price"`
+	OldQuantity      int             `json:"old_quantity"`
+	NewQuantity      int             `json:"new_quantity"`
+	Changes          []ReorderChange `json:"changes"`
+}
+
+type ReorderInput struct {
+	OrderID    uuid.UUID
+	CustomerID uuid.UUID
+	// CourierID defaults to the courier of the original order.
+	CourierID uuid.UUID
+}
+
+type ReorderResult struct {
+	SourceOrderID uuid.UUID          `json:"source_order_id"`
+	Order         models.Order       `json:"order"`
+	Items         []models.OrderItem `json:"items"`
+	OldTotal      float64            `json:"old_total"`
+	NewTotal      float64            `json:"new_total"`
+	Changes       []ReorderLineDiff  `json:"changes"`
+}
+
+// PlanReorder reprices original lines from the current menu, drops what is
+// no longer sold and clamps quantities to stock.
+func PlanReorder(original []models.OrderItem, menu []models.MenuItem) ([]repositoryModels.OrderItemInput, []ReorderLineDiff) {
+	menuByID := make(map[uuid.UUID]models.MenuItem, len(menu))
+	for _, item := range menu {
+		menuByID[item.OrderItemID] = item
+	}
+
+	items := make([]repositoryModels.OrderItemInput, 0, len(original))
+	diffs := make([]ReorderLineDiff, 0)
+	for _, line := range original {
+		diff := ReorderLineDiff{
+			RestaurantItemID: line.RestaurantItemID,
+			OldPrice:         line.Price,
+			OldQuantity:      line.Quantity,
+		}
+		menuItem, ok := menuByID[line.RestaurantItemID]
+		diff.Name = menuItem.Name
+		if !ok || menuItem.Quantity <= 0 {
+			diff.Changes = []ReorderChange{ReorderUnavailable}
+			diffs = append(diffs, diff)
+			continue
+		}
+
+		diff.NewPrice = menuItem.Price
+		diff.NewQuantity = line.Quantity
+		if math.Abs(menuItem.Price-line.Price) > 1e-9 {
+			diff.Changes = append(diff.Changes, ReorderPriceChanged)
+		}
+		if line.Quantity > menuItem.Quantity {
+			diff.NewQuantity = menuItem.Quantity
+			diff.Changes = append(diff.Changes, ReorderQuantityReduced)
+		}
+		if len(diff.Changes) > 0 {
+			diffs = append(diffs, diff)
+		}
+		items = append(items, repositoryModels.OrderItemInput{
+			RestaurantItemID: line.RestaurantItemID,
+			Price:            menuItem.Price,
+			Quantity:         diff.NewQuantity,
+		})
+	}
+	return items, diffs
+}
+
+func (u *orderUseCase) Reorder(ctx context.Context, input ReorderInput) (ReorderResult, error) {
+	source, err := u.repo.Get(ctx, input.OrderID)
+	if err != nil {
+		return ReorderResult{}, err
+	}
+	if source.CustomerID != input.CustomerID {
+		return ReorderResult{}, ErrOrderNotOwned
+	}
+	if models.OrderStatus(source.Status) != models.OrderStatusOrderCompleted {
+		return ReorderResult{}, ErrReorderNotAllowed
+	}
+	if source.RestaurantID == uuid.Nil {
+		return ReorderResult{}, ErrOrderHasNoRestaurant
+	}
+	if u.menu == nil {
+		return ReorderResult{}, ErrMenuUnavailable
+	}
+
+	original, err := u.repo.ListItems(ctx, source.ID)
+	if err != nil {
+		return ReorderResult{}, err
+	}
+	menu, err := u.menu.GetMenuItems(ctx, source.RestaurantID)
+	if err != nil {
+		return ReorderResult{}, fmt.Errorf("%w: %v", ErrMenuUnavailable, err)
+	}
+
+	items, diffs := PlanReorder(original, menu)
+	result := ReorderResult{SourceOrderID: source.ID, Changes: diffs}
+	for _, line := range original {
+		result.OldTotal += line.Price * float64(line.Quantity)
+	}
+	if len(items) == 0 {
+		return result, ErrNothingToReorder
+	}
+
+	courierID := input.CourierID
+	if courierID == uuid.Nil {
+		courierID = source.CourierID
+	}
+	created, err := u.repo.CreateWithItems(ctx, models.Order{
+		CustomerID:   source.CustomerID,
+		CourierID:    courierID,
+		RestaurantID: source.RestaurantID,
+	}, items)
+	if err != nil {
+		return result, err
+	}
+	RecordOrderCreated(OrderSourceReorder)
+
+	result.Order = created
+	result.Items = make([]models.OrderItem, 0, len(items))
+	for _, item := range items {
+		result.Items = append(result.Items, models.OrderItem{
+			RestaurantItemID: item.RestaurantItemID,
+			Price:            item.Price,
+			Quantity:         item.Quantity,
+		})
+		result.NewTotal += item.Price * float64(item.Quantity)
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanReorder(t *testing.T) {
+	same := uuid.New()
+	repriced := uuid.New()
+	short := uuid.New()
+	soldOut := uuid.New()
+	removed := uuid.New()
+
+	original := []models.OrderItem{
+		{RestaurantItemID: same, Price: 5, Quantity: 1},
+		{RestaurantItemID: repriced, Price: 10, Quantity: 2},
+		{RestaurantItemID: short, Price: 3, Quantity: 4},
+		{RestaurantItemID: soldOut, Price: 7, Quantity: 1},
+		{RestaurantItemID: removed, Price: 2, Quantity: 1},
+	}
+	menu := []models.MenuItem{
+		{OrderItemID: same, Name: "soup", Price: 5, Quantity: 10},
+		{OrderItemID: repriced, Name: "pizza", Price: 12, Quantity: 10},
+		{OrderItemID: short, Name: "tea", Price: 3, Quantity: 2},
+		{OrderItemID: soldOut, Name: "cake", Price: 7, Quantity: 0},
+	}
+
+	items, diffs := PlanReorder(original, menu)
+
+	if len(items) != 3 {
+		t.Fatalf("items = %+v, want 3 lines", items)
+	}
+	if items[1].Price != 12 || items[2].Quantity != 2 {
+		t.Errorf("items = %+v, want repriced pizza and tea clamped to 2", items)
+	}
+
+	want := map[uuid.UUID][]ReorderChange{
+		repriced: {ReorderPriceChanged},
+		short:    {ReorderQuantityReduced},
+		soldOut:  {ReorderUnavailable},
+		removed:  {ReorderUnavailable},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("diffs = %+v, want %d entries", diffs, len(want))
+	}
+	for _, diff := range diffs {
+		if !reflect.DeepEqual(diff.Changes, want[diff.RestaurantItemID]) {
+			t.Errorf("changes for %s = %v, want %v", diff.RestaurantItemID, diff.Changes, want[diff.RestaurantItemID])
+		}
+	}
+}
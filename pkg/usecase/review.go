@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+const MaxReviewTextLength = 2000
+
+var (
+	ErrReviewNotAllowed = errors.New("only completed orders can be reviewed")
+	ErrInvalidRating    = errors.New("ratings must be between 1 and 5")
+	ErrUnknownDish      = errors.New("dish rating refers to an item outside the order")
+	ErrDuplicateDish    = errors.New("dish rated more than once")
+	ErrReviewTooLong    = errors.New("review text is too long")
+	ErrEmptyReply       = errors.New("reply must not be empty")
+)
+
+type CreateReviewInput struct {
+	OrderID        uuid.UUID
+	CustomerID     uuid.UUID
+	FoodRating     int
+	DeliveryRating int
+	Dishes         []models.DishRating
+	Comment        string
+}
+
+type ReviewUseCase interface {
+	Create(ctx context.Context, input CreateReviewInput) (models.Review, error)
+	Get(ctx context.Context, orderID uuid.UUID) (models.Review, error)
+	ListForRestaurant(ctx context.Context, restaurantID uuid.UUID, limit, offset int) ([]models.Review, error)
+	Reply(ctx context.Context, orderID uuid.UUID, restaurantID uuid.UUID, reply string) (models.Review, error)
+}
+
+type reviewUseCase struct {
+	orders  repositoryModels.Order
+	reviews repositoryModels.Review
+}
+
+func NewReviewUseCase(orders repositoryModels.Order, reviews repositoryModels.Review) ReviewUseCase {
+	return &reviewUseCase{orders: orders, reviews: reviews}
+}
+
+func (u *reviewUseCase) Create(ctx context.Context, input CreateReviewInput) (models.Review, error) {
+	order, err := u.orders.Get(ctx, input.OrderID)
+	if err != nil {
+		return models.Review{}, err
+	}
+	if order.CustomerID != input.CustomerID {
+		return models.Review{}, ErrOrderNotOwned
+	}
+	if models.OrderStatus(order.Status) != models.OrderStatusOrderCompleted {
+		return models.Review{}, ErrReviewNotAllowed
+	}
+	if order.RestaurantID == uuid.Nil {
+		return models.Review{}, ErrOrderHasNoRestaurant
+	}
+	items, err := u.orders.ListItems(ctx, order.ID)
+	if err != nil {
+		return models.Review{}, err
+	}
+
+	input.Comment = strings.TrimSpace(input.Comment)
+	if err := validateReview(input, items); err != nil {
+		return models.Review{}, err
+	}
+
+	return u.reviews.Create(ctx, models.Review{
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		RestaurantID:   order.RestaurantID,
+		CourierID:      order.CourierID,
+		FoodRating:     input.FoodRating,
+		DeliveryRating: input.DeliveryRating,
+		Dishes:         input.Dishes,
+		Comment:        input.Comment,
+	})
+}
+
+func (u *reviewUseCase) Get(ctx context.Context, orderID uuid.UUID) (models.Review, error) {
+	return u.reviews.Get(ctx, orderID)
+}
+
+func (u *reviewUseCase) ListForRestaurant(ctx context.Context, restaurantID uuid.UUID, limit, offset int) ([]models.Review, error) {
+	return u.reviews.ListByRestaurant(ctx, restaurantID, limit, offset)
+}
+
+func (u *reviewUseCase) Reply(ctx context.Context, orderID uuid.UUID, restaurantID uuid.UUID, reply string) (models.Review, error) {
+	reply = strings.TrimSpace(reply)
+	if reply == "" {
+		return models.Review{}, ErrEmptyReply
+	}
+	if len(reply) > MaxReviewTextLength {
+		return models.Review{}, ErrReviewTooLong
+	}
+	return u.reviews.Reply(ctx, orderID, restaurantID, reply)
+}
+
+func validateReview(input CreateReviewInput, items []models.OrderItem) error {
+	if !validRating(input.FoodRating) || !validRating(input.DeliveryRating) {
+		return ErrInvalidRating
+	}
+	if len(input.Comment) > MaxReviewTextLength {
+		return ErrReviewTooLong
+	}
+
+	ordered := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		ordered[item.RestaurantItemID] = true
+	}
+	rated := make(map[uuid.UUID]bool, len(input.Dishes))
+	for _, dish := range input.Dishes {
+		if !validRating(dish.Rating) {
+			return ErrInvalidRating
+		}
+		if !ordered[dish.RestaurantItemID] {
+			return fmt.Errorf("%w: %s", ErrUnknownDish, dish.RestaurantItemID)
+		}
+		if rated[dish.RestaurantItemID] {
+			return fmt.Errorf("%w: %s", ErrDuplicateDish, dish.RestaurantItemID)
+		}
+		rated[dish.RestaurantItemID] = true
+	}
+	return nil
+}
+
+func validRating(rating int) bool {
+	return rating >= models.MinRating && rating <= models.MaxRating
+}
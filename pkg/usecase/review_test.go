@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateReview(t *testing.T) {
+	soup := uuid.New()
+	items := []models.OrderItem{{RestaurantItemID: soup, Price: 5, Quantity: 1}}
+
+	tests := []struct {
+		name    string
+		input   CreateReviewInput
+		wantErr error
+	}{
+		{name: "valid", input: CreateReviewInput{FoodRating: 5, DeliveryRating: 3, Dishes: []models.DishRating{{RestaurantItemID: soup, Rating: 4}}}},
+		{name: "food out of range", input: CreateReviewInput{FoodRating: 0, DeliveryRating: 3}, wantErr: ErrInvalidRating},
+		{name: "delivery out of range", input: CreateReviewInput{FoodRating: 4, DeliveryRating: 6}, wantErr: ErrInvalidRating},
+		{name: "dish out of range", input: CreateReviewInput{FoodRating: 4, DeliveryRating: 4, Dishes: []models.DishRating{{RestaurantItemID: soup, Rating: 9}}}, wantErr: ErrInvalidRating},
+		{name: "dish not in order", input: CreateReviewInput{FoodRating: 4, DeliveryRating: 4, Dishes: []models.DishRating{{RestaurantItemID: uuid.New(), Rating: 4}}}, wantErr: ErrUnknownDish},
+		{name: "dish rated twice", input: CreateReviewInput{FoodRating: 4, DeliveryRating: 4, Dishes: []models.DishRating{{RestaurantItemID: soup, Rating: 4}, {RestaurantItemID: soup, Rating: 2}}}, wantErr: ErrDuplicateDish},
+		{name: "comment too long", input: CreateReviewInput{FoodRating: 4, DeliveryRating: 4, Comment: strings.Repeat("a", MaxReviewTextLength+1)}, wantErr: ErrReviewTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateReview(tt.input, items); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validateReview() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
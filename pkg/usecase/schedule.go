@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	// The service images ship without a zone database.
+	_ "time/tzdata"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+)
+
+var (
+	ErrSlotTooSoon         = errors.New("deliver_at is earlier than the restaurant can prepare the order")
+	ErrSlotTooFar          = errors.New("deliver_at is too far in the future")
+	ErrOutsideOpeningHours = errors.New("deliver_at is outside restaurant opening hours")
+	ErrSchedulingDisabled  = errors.New("restaurant does not accept scheduled orders")
+	ErrInvalidOpeningHours = errors.New("invalid opening hours")
+	ErrInvalidTimeZone     = errors.New("invalid time zone")
+)
+
+const (
+	MaxScheduleAhead       = 7 * 24 * time.Hour
+	defaultSlotMinutes     = 30
+	defaultPrepLeadMinutes = 30
+	openingHoursTimeLayout = "15:04"
+)
+
+// ScheduledSlot is the capacity bucket a scheduled order falls into and the
+// moment the order has to be handed to the kitchen.
+type ScheduledSlot struct {
+	Start     time.Time
+	End       time.Time
+	Capacity  int
+	ReleaseAt time.Time
+}
+
+// PlanSlot checks deliverAt against the schedule and returns the slot it
+// occupies. Both the kitchen release time and deliverAt must fall inside the
+// same opening window, read in the restaurant's time zone.
+func PlanSlot(schedule models.RestaurantSchedule, deliverAt time.Time, now time.Time) (ScheduledSlot, error) {
+	if schedule.SlotCapacity <= 0 || len(schedule.Hours) == 0 {
+		return ScheduledSlot{}, ErrSchedulingDisabled
+	}
+	loc, err := ScheduleLocation(schedule.TZ)
+	if err != nil {
+		return ScheduledSlot{}, err
+	}
+	slotMinutes := schedule.SlotMinutes
+	if slotMinutes <= 0 {
+		slotMinutes = defaultSlotMinutes
+	}
+	lead := time.Duration(schedule.PrepLeadMinutes) * time.Minute
+	if schedule.PrepLeadMinutes <= 0 {
+		lead = time.Duration(defaultPrepLeadMinutes) * time.Minute
+	}
+
+	deliverAt = deliverAt.UTC()
+	releaseAt := deliverAt.Add(-lead)
+	if releaseAt.Before(now) {
+		return ScheduledSlot{}, ErrSlotTooSoon
+	}
+	if deliverAt.Sub(now) > MaxScheduleAhead {
+		return ScheduledSlot{}, ErrSlotTooFar
+	}
+
+	local := deliverAt.In(loc)
+	// time.Date rather than adding minutes to midnight keeps the wall clock
+	// right on days the clocks change.
+	at := func(minute int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, minute, 0, 0, loc)
+	}
+	open := false
+	for _, hours := range schedule.Hours {
+		if hours.Weekday != local.Weekday() {
+			continue
+		}
+		opens, closes, err := ParseOpeningHours(hours)
+		if err != nil {
+			return ScheduledSlot{}, err
+		}
+		windowStart := at(opens)
+		windowEnd := at(closes)
+		if !releaseAt.Before(windowStart) && !deliverAt.After(windowEnd) {
+			open = true
+			break
+		}
+	}
+	if !open {
+		return ScheduledSlot{}, ErrOutsideOpeningHours
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	start := at(minute - minute%slotMinutes).UTC()
+	return ScheduledSlot{
+		Start:     start,
+		End:       start.Add(time.Duration(slotMinutes) * time.Minute),
+		Capacity:  schedule.SlotCapacity,
+		ReleaseAt: releaseAt,
+	}, nil
+}
+
+// ScheduleLocation resolves the time zone of a schedule; empty is UTC.
+func ScheduleLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+	// "Local" would follow whatever zone the server runs in.
+	if tz == "Local" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimeZone, tz)
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimeZone, tz)
+	}
+	return loc, nil
+}
+
+// ParseOpeningHours returns the window as minutes since midnight.
+func ParseOpeningHours(hours models.OpeningHours) (int, int, error) {
+	opens, err := time.Parse(openingHoursTimeLayout, hours.Opens)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: opens %q", ErrInvalidOpeningHours, hours.Opens)
+	}
+	closes, err := time.Parse(openingHoursTimeLayout, hours.Closes)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: closes %q", ErrInvalidOpeningHours, hours.Closes)
+	}
+	opensMinute := opens.Hour()*60 + opens.Minute()
+	closesMinute := closes.Hour()*60 + closes.Minute()
+	if closesMinute <= opensMinute {
+		return 0, 0, fmt.Errorf("%w: closes must be after opens", ErrInvalidOpeningHours)
+	}
+	return opensMinute, closesMinute, nil
+}
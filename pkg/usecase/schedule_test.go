@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+)
+
+func TestPlanSlot(t *testing.T) {
+	// Monday
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	schedule := models.RestaurantSchedule{
+		SlotMinutes:     30,
+		SlotCapacity:    5,
+		PrepLeadMinutes: 45,
+		Hours: []models.OpeningHours{
+			{Weekday: time.Monday, Opens: "10:00", Closes: "22:00"},
+			{Weekday: time.Tuesday, Opens: "10:00", Closes: "14:00"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		deliverAt time.Time
+		wantErr   error
+		wantStart time.Time
+	}{
+		{name: "inside hours", deliverAt: time.Date(2026, 10, 19, 12, 40, 0, 0, time.UTC), wantStart: time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)},
+		{name: "kitchen would start before opening", deliverAt: time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC), wantErr: ErrOutsideOpeningHours},
+		{name: "after closing", deliverAt: time.Date(2026, 10, 20, 14, 30, 0, 0, time.UTC), wantErr: ErrOutsideOpeningHours},
+		{name: "closed weekday", deliverAt: time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC), wantErr: ErrOutsideOpeningHours},
+		{name: "not enough lead time", deliverAt: now.Add(30 * time.Minute), wantErr: ErrSlotTooSoon},
+		{name: "too far ahead", deliverAt: now.Add(MaxScheduleAhead + time.Hour), wantErr: ErrSlotTooFar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot, err := PlanSlot(schedule, tt.deliverAt, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlanSlot() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !slot.Start.Equal(tt.wantStart) || slot.End.Sub(slot.Start) != 30*time.Minute {
+				t.Errorf("slot = %s..%s, want start %s", slot.Start, slot.End, tt.wantStart)
+			}
+			if !slot.ReleaseAt.Equal(tt.deliverAt.Add(-45*time.Minute)) || slot.Capacity != 5 {
+				t.Errorf("slot = %+v", slot)
+			}
+		})
+	}
+
+	t.Run("scheduling disabled", func(t *testing.T) {
+		_, err := PlanSlot(models.RestaurantSchedule{}, now.Add(2*time.Hour), now)
+		if !errors.Is(err, ErrSchedulingDisabled) {
+			t.Errorf("PlanSlot() error = %v, want ErrSchedulingDisabled", err)
+		}
+	})
+}
+
+func TestPlanSlotTimeZone(t *testing.T) {
+	// Monday; Berlin is UTC+2 until 25 October 2026.
+	now := time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)
+	schedule := models.RestaurantSchedule{
+		SlotMinutes:     30,
+		SlotCapacity:    5,
+		PrepLeadMinutes: 30,
+		TZ:              "Europe/Berlin",
+		Hours: []models.OpeningHours{
+			{Weekday: time.Monday, Opens: "10:00", Closes: "22:00"},
+			{Weekday: time.Sunday, Opens: "10:00", Closes: "22:00"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		deliverAt time.Time
+		wantErr   error
+		wantStart time.Time
+	}{
+		{name: "open in Berlin", deliverAt: time.Date(2026, 10, 19, 8, 45, 0, 0, time.UTC), wantStart: time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)},
+		{name: "open in UTC but closed in Berlin", deliverAt: time.Date(2026, 10, 19, 20, 30, 0, 0, time.UTC), wantErr: ErrOutsideOpeningHours},
+		{name: "after the clocks change", deliverAt: time.Date(2026, 10, 25, 9, 45, 0, 0, time.UTC), wantStart: time.Date(2026, 10, 25, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot, err := PlanSlot(schedule, tt.deliverAt, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlanSlot() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !slot.Start.Equal(tt.wantStart) {
+				t.Errorf("slot start = %s, want %s", slot.Start, tt.wantStart)
+			}
+		})
+	}
+
+	t.Run("unknown zone", func(t *testing.T) {
+		schedule := schedule
+		schedule.TZ = "Mars/Olympus"
+		_, err := PlanSlot(schedule, now.Add(4*time.Hour), now)
+		if !errors.Is(err, ErrInvalidTimeZone) {
+			t.Errorf("PlanSlot() error = %v, want ErrInvalidTimeZone", err)
+		}
+	})
+}
@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TipWindow is how long after completion a customer may still tip.
+	TipWindow = 24 * time.Hour
+	// DefaultTipSettleInterval is how often Run retries unsettled tips.
+	DefaultTipSettleInterval = time.Minute
+	tipSettleBatchSize       = 100
+)
+
+var (
+	ErrInvalidTipAmount = errors.New("tip amount must be positive")
+	ErrTipNotAllowed    = errors.New("order cannot be tipped in its current state")
+	ErrTipWindowClosed  = errors.New("tips are accepted only within 24h after completion")
+	ErrTipPayoutFailed  = errors.New("tip debited but courier payout failed")
+	ErrTipRefundFailed  = errors.New("tip refund failed")
+)
+
+// TipWallet moves tip money: debit from the customer, credit to the courier.
+type TipWallet interface {
+	CheckAndDebit(ctx context.Context, walletAddress string, amount float64) (bool, error)
+	Credit(ctx context.Context, walletAddress string, amount float64) error
+}
+
+type TipInput struct {
+	OrderID    uuid.UUID
+	CustomerID uuid.UUID
+	Amount     float64
+}
+
+type TipUseCase interface {
+	Tip(ctx context.Context, input TipInput) (models.Tip, error)
+	Earnings(ctx context.Context, courierID uuid.UUID, from, to time.Time) (models.CourierEarnings, error)
+	TipVoider
+	// Settle retries what is left of tips: payouts of DEBITED tips and
+	// refunds of checkout tips whose order was cancelled.
+	Settle(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type tipUseCase struct {
+	orders repositoryModels.Order
+	tips   repositoryModels.Tip
+	wallet TipWallet
+	now    func() time.Time
+}
+
+func NewTipUseCase(orders repositoryModels.Order, tips repositoryModels.Tip, wallet TipWallet) TipUseCase {
+	return &tipUseCase{orders: orders, tips: tips, wallet: wallet, now: time.Now}
+}
+
+// tipStage says whether an order in this state can take a tip, and as which stage.
+// Tips open once the order is paid; unpaid, cancelled and refunded orders never take one.
+func tipStage(order models.Order, now time.Time) (models.TipStage, error) {
+	switch models.OrderStatus(order.Status) {
+	case models.OrderStatusCustomerPaid,
+		models.OrderStatusCustomerScheduled,
+		models.OrderStatusKitchenAccepted,
+		models.OrderStatusKitchenPreparing,
+		models.OrderStatusDeliveryPending,
+		models.OrderStatusDeliveryPicking,
+		models.OrderStatusDeliveryDelivering:
+		return models.TipStageCheckout, nil
+	case models.OrderStatusOrderCompleted:
+		if now.Sub(order.UpdatedAt) > TipWindow {
+			return "", ErrTipWindowClosed
+		}
+		return models.TipStageAfterDelivery, nil
+	}
+	return "", ErrTipNotAllowed
+}
+
+func (u *tipUseCase) Tip(ctx context.Context, input TipInput) (models.Tip, error) {
+	amount := math.Round(input.Amount*100) / 100
+	if amount <= 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return models.Tip{}, ErrInvalidTipAmount
+	}
+	if u.wallet == nil {
+		return models.Tip{}, ErrWalletUnavailable
+	}
+
+	order, err := u.orders.Get(ctx, input.OrderID)
+	if err != nil {
+		return models.Tip{}, err
+	}
+	if order.CustomerID != input.CustomerID {
+		return models.Tip{}, ErrOrderNotOwned
+	}
+	stage, err := tipStage(order, u.now().UTC())
+	if err != nil {
+		return models.Tip{}, err
+	}
+
+	customerWallet, err := u.orders.GetCustomerWalletAddress(ctx, order.CustomerID)
+	if err != nil {
+		return models.Tip{}, err
+	}
+	courierWallet, err := u.tips.GetCourierWalletAddress(ctx, order.CourierID)
+	if err != nil {
+		return models.Tip{}, err
+	}
+
+	// Record first so a concurrent second tip is rejected before any money moves.
+	tip, err := u.tips.Create(ctx, models.Tip{
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		CourierID:  order.CourierID,
+		Amount:     amount,
+		Stage:      stage,
+	})
+	if err != nil {
+		return models.Tip{}, err
+	}
+
+	ok, err := u.wallet.CheckAndDebit(ctx, customerWallet, amount)
+	if err != nil || !ok {
+		if statusErr := u.tips.SetStatus(ctx, tip.ID, tip.Status, models.TipStatusFailed); statusErr != nil {
+			logging.FromContext(ctx).Error("tips: mark tip failed", "tip_id", tip.ID, "error", statusErr)
+		}
+		tip.Status = models.TipStatusFailed
+		if err != nil {
+			return tip, fmt.Errorf("%w: %v", ErrWalletUnavailable, err)
+		}
+		return tip, ErrInsufficientFunds
+	}
+	if err := u.tips.SetStatus(ctx, tip.ID, tip.Status, models.TipStatusDebited); err != nil {
+		return tip, err
+	}
+	tip.Status = models.TipStatusDebited
+
+	if err := u.payout(ctx, &tip, courierWallet); err != nil {
+		return tip, err
+	}
+	return tip, nil
+}
+
+// payout credits a DEBITED tip to the courier. The status is claimed before
+// the money moves so Settle and a cancellation cannot pay the same tip again;
+// a failed credit puts it back for Settle.
+func (u *tipUseCase) payout(ctx context.Context, tip *models.Tip, courierWallet string) error {
+	if err := u.tips.SetStatus(ctx, tip.ID, models.TipStatusDebited, models.TipStatusPaidOut); err != nil {
+		return fmt.Errorf("%w: %v", ErrTipPayoutFailed, err)
+	}
+	// The whole tip goes to the courier, no platform cut.
+	if err := u.wallet.Credit(ctx, courierWallet, tip.Amount); err != nil {
+		logging.FromContext(ctx).Error("tips: payout failed", "tip_id", tip.ID, "courier_id", tip.CourierID, "error", err)
+		if statusErr := u.tips.SetStatus(ctx, tip.ID, models.TipStatusPaidOut, models.TipStatusDebited); statusErr != nil {
+			logging.FromContext(ctx).Error("tips: put tip back to debited", "tip_id", tip.ID, "error", statusErr)
+		}
+		return fmt.Errorf("%w: %v", ErrTipPayoutFailed, err)
+	}
+	tip.Status = models.TipStatusPaidOut
+	paidOutAt := u.now().UTC()
+	tip.PaidOutAt = &paidOutAt
+	return nil
+}
+
+// refund gives a checkout tip of a cancelled order back to the customer.
+// A tip already paid out is first taken back from the courier. Whatever
+// step fails leaves the tip in a status Settle picks up again.
+func (u *tipUseCase) refund(ctx context.Context, tip *models.Tip) error {
+	if tip.Status != models.TipStatusDebited && tip.Status != models.TipStatusPaidOut {
+		return nil
+	}
+	customerWallet, err := u.orders.GetCustomerWalletAddress(ctx, tip.CustomerID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTipRefundFailed, err)
+	}
+	from := tip.Status
+	if err := u.tips.SetStatus(ctx, tip.ID, from, models.TipStatusRefunded); err != nil {
+		return err
+	}
+
+	if from == models.TipStatusPaidOut {
+		courierWallet, err := u.tips.GetCourierWalletAddress(ctx, tip.CourierID)
+		if err == nil {
+			var ok bool
+			ok, err = u.wallet.CheckAndDebit(ctx, courierWallet, tip.Amount)
+			if err == nil && !ok {
+				err = ErrInsufficientFunds
+			}
+		}
+		if err != nil {
+			if statusErr := u.tips.SetStatus(ctx, tip.ID, models.TipStatusRefunded, models.TipStatusPaidOut); statusErr != nil {
+				logging.FromContext(ctx).Error("tips: put tip back to paid out", "tip_id", tip.ID, "error", statusErr)
+			}
+			return fmt.Errorf("%w: take back payout: %v", ErrTipRefundFailed, err)
+		}
+	}
+
+	if err := u.wallet.Credit(ctx, customerWallet, tip.Amount); err != nil {
+		// The courier no longer holds the money either way: DEBITED makes
+		// Settle refund the customer again.
+		if statusErr := u.tips.SetStatus(ctx, tip.ID, models.TipStatusRefunded, models.TipStatusDebited); statusErr != nil {
+			logging.FromContext(ctx).Error("tips: put tip back to debited", "tip_id", tip.ID, "error", statusErr)
+		}
+		return fmt.Errorf("%w: %v", ErrTipRefundFailed, err)
+	}
+	tip.Status = models.TipStatusRefunded
+	return nil
+}
+
+// VoidOrderTips refunds the checkout tips of a cancelled order. Tips given
+// after delivery stay with the courier.
+func (u *tipUseCase) VoidOrderTips(ctx context.Context, orderID uuid.UUID) error {
+	if u.wallet == nil {
+		return ErrWalletUnavailable
+	}
+	tips, err := u.tips.ListByOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range tips {
+		if tips[i].Stage != models.TipStageCheckout {
+			continue
+		}
+		if err := u.refund(ctx, &tips[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (u *tipUseCase) Settle(ctx context.Context) (int, error) {
+	if u.wallet == nil {
+		return 0, ErrWalletUnavailable
+	}
+	tips, err := u.tips.ListUnsettled(ctx, tipSettleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	settled := 0
+	for i := range tips {
+		tip := &tips[i]
+		if err := u.settle(ctx, tip); err != nil {
+			logging.FromContext(ctx).Warn("tips: settle tip failed", "tip_id", tip.ID, "status", tip.Status, "error", err)
+			continue
+		}
+		settled++
+		logging.FromContext(ctx).Info("tips: tip settled", "tip_id", tip.ID, "status", tip.Status)
+	}
+	return settled, nil
+}
+
+func (u *tipUseCase) settle(ctx context.Context, tip *models.Tip) error {
+	status, err := u.orders.GetOrderStatus(ctx, tip.OrderID)
+	if err != nil {
+		return err
+	}
+	if status == models.OrderStatusCustomerCancelled && tip.Stage == models.TipStageCheckout {
+		return u.refund(ctx, tip)
+	}
+	if tip.Status != models.TipStatusDebited {
+		return nil
+	}
+	courierWallet, err := u.tips.GetCourierWalletAddress(ctx, tip.CourierID)
+	if err != nil {
+		return err
+	}
+	return u.payout(ctx, tip, courierWallet)
+}
+
+// Run settles tips every interval until ctx is cancelled.
+func (u *tipUseCase) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultTipSettleInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := u.Settle(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logging.FromContext(ctx).Error("tips: settle failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *tipUseCase) Earnings(ctx context.Context, courierID uuid.UUID, from, to time.Time) (models.CourierEarnings, error) {
+	tips, err := u.tips.ListByCourier(ctx, courierID, from, to)
+	if err != nil {
+		return models.CourierEarnings{}, err
+	}
+	completed, err := u.tips.CountCompletedOrders(ctx, courierID, from, to)
+	if err != nil {
+		return models.CourierEarnings{}, err
+	}
+
+	earnings := models.CourierEarnings{
+		CourierID:       courierID,
+		From:            from.UTC(),
+		To:              to.UTC(),
+		CompletedOrders: completed,
+		TipsCount:       len(tips),
+		Tips:            tips,
+	}
+	for _, tip := range tips {
+		switch tip.Status {
+		case models.TipStatusPaidOut:
+			earnings.TipsPaidOut += tip.Amount
+		case models.TipStatusPending, models.TipStatusDebited:
+			earnings.TipsPending += tip.Amount
+		}
+	}
+	return earnings, nil
+}
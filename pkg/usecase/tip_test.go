@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+	repositoryModels "github.com/Kabanya/YAFDS/pkg/repository/models"
+
+	"github.com/google/uuid"
+)
+
+type mockTips struct {
+	repositoryModels.Tip
+	tips     []models.Tip
+	statuses []models.TipStatus
+}
+
+func (m *mockTips) Create(ctx context.Context, tip models.Tip) (models.Tip, error) {
+	tip.ID = uuid.New()
+	tip.Status = models.TipStatusPending
+	m.tips = append(m.tips, tip)
+	return tip, nil
+}
+
+func (m *mockTips) SetStatus(ctx context.Context, tipID uuid.UUID, from, to models.TipStatus) error {
+	for i := range m.tips {
+		if m.tips[i].ID != tipID {
+			continue
+		}
+		if m.tips[i].Status != from {
+			return errors.New("tip status changed")
+		}
+		m.tips[i].Status = to
+		m.statuses = append(m.statuses, to)
+		return nil
+	}
+	return errors.New("tip not found")
+}
+
+func (m *mockTips) ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Tip, error) {
+	var tips []models.Tip
+	for _, tip := range m.tips {
+		if tip.OrderID == orderID && tip.Status != models.TipStatusFailed {
+			tips = append(tips, tip)
+		}
+	}
+	return tips, nil
+}
+
+func (m *mockTips) ListUnsettled(ctx context.Context, limit int) ([]models.Tip, error) {
+	var tips []models.Tip
+	for _, tip := range m.tips {
+		if tip.Status == models.TipStatusDebited || tip.Status == models.TipStatusPaidOut {
+			tips = append(tips, tip)
+		}
+	}
+	return tips, nil
+}
+
+func (m *mockTips) status(tipID uuid.UUID) models.TipStatus {
+	for _, tip := range m.tips {
+		if tip.ID == tipID {
+			return tip.Status
+		}
+	}
+	return ""
+}
+
+func (m *mockTips) GetCourierWalletAddress(ctx context.Context, courierID uuid.UUID) (string, error) {
+	return "0xcourier", nil
+}
+
+type mockTipWallet struct {
+	debitOK   bool
+	creditErr error
+	credited  map[string]float64
+	debited   map[string]float64
+}
+
+func (m *mockTipWallet) CheckAndDebit(ctx context.Context, walletAddress string, amount float64) (bool, error) {
+	if m.debitOK && m.debited != nil {
+		m.debited[walletAddress] += amount
+	}
+	return m.debitOK, nil
+}
+
+func (m *mockTipWallet) Credit(ctx context.Context, walletAddress string, amount float64) error {
+	if m.creditErr != nil {
+		return m.creditErr
+	}
+	m.credited[walletAddress] += amount
+	return nil
+}
+
+func TestTip(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	customerID := uuid.New()
+
+	tests := []struct {
+		name         string
+		status       models.OrderStatus
+		updatedAt    time.Time
+		amount       float64
+		wallet       *mockTipWallet
+		wantErr      error
+		wantStage    models.TipStage
+		wantStatuses []models.TipStatus
+	}{
+		{name: "at checkout", status: models.OrderStatusCustomerPaid, amount: 3.333, wallet: &mockTipWallet{debitOK: true},
+			wantStage: models.TipStageCheckout, wantStatuses: []models.TipStatus{models.TipStatusDebited, models.TipStatusPaidOut}},
+		{name: "after delivery", status: models.OrderStatusOrderCompleted, updatedAt: now.Add(-23 * time.Hour), amount: 2, wallet: &mockTipWallet{debitOK: true},
+			wantStage: models.TipStageAfterDelivery, wantStatuses: []models.TipStatus{models.TipStatusDebited, models.TipStatusPaidOut}},
+		{name: "window closed", status: models.OrderStatusOrderCompleted, updatedAt: now.Add(-25 * time.Hour), amount: 2, wallet: &mockTipWallet{debitOK: true},
+			wantErr: ErrTipWindowClosed},
+		{name: "unpaid order", status: models.OrderStatusCustomerCreated, amount: 2, wallet: &mockTipWallet{debitOK: true}, wantErr: ErrTipNotAllowed},
+		{name: "cancelled order", status: models.OrderStatusCustomerCancelled, amount: 2, wallet: &mockTipWallet{debitOK: true}, wantErr: ErrTipNotAllowed},
+		{name: "non-positive amount", status: models.OrderStatusCustomerPaid, amount: 0.001, wallet: &mockTipWallet{debitOK: true}, wantErr: ErrInvalidTipAmount},
+		{name: "insufficient funds", status: models.OrderStatusCustomerPaid, amount: 2, wallet: &mockTipWallet{debitOK: false},
+			wantErr: ErrInsufficientFunds, wantStatuses: []models.TipStatus{models.TipStatusFailed}},
+		{name: "payout fails", status: models.OrderStatusCustomerPaid, amount: 2, wallet: &mockTipWallet{debitOK: true, creditErr: errors.New("down")},
+			wantErr: ErrTipPayoutFailed, wantStatuses: []models.TipStatus{models.TipStatusDebited, models.TipStatusPaidOut, models.TipStatusDebited}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepo{order: models.Order{ID: uuid.New(), CustomerID: customerID, CourierID: uuid.New(), Status: string(tt.status), UpdatedAt: tt.updatedAt}}
+			tips := &mockTips{}
+			tt.wallet.credited = map[string]float64{}
+			uc := &tipUseCase{orders: repo, tips: tips, wallet: tt.wallet, now: func() time.Time { return now }}
+
+			tip, err := uc.Tip(context.Background(), TipInput{OrderID: repo.order.ID, CustomerID: customerID, Amount: tt.amount})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Tip() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(tips.statuses) != len(tt.wantStatuses) {
+				t.Fatalf("statuses = %v, want %v", tips.statuses, tt.wantStatuses)
+			}
+			for i := range tt.wantStatuses {
+				if tips.statuses[i] != tt.wantStatuses[i] {
+					t.Errorf("statuses = %v, want %v", tips.statuses, tt.wantStatuses)
+				}
+			}
+			if err != nil {
+				return
+			}
+			if tip.Stage != tt.wantStage {
+				t.Errorf("stage = %s, want %s", tip.Stage, tt.wantStage)
+			}
+			if tt.wallet.credited["0xcourier"] != tip.Amount {
+				t.Errorf("courier credited %v, want full tip %v", tt.wallet.credited["0xcourier"], tip.Amount)
+			}
+		})
+	}
+
+	t.Run("not owner", func(t *testing.T) {
+		repo := &mockRepo{order: models.Order{ID: uuid.New(), CustomerID: uuid.New(), Status: string(models.OrderStatusCustomerPaid)}}
+		uc := &tipUseCase{orders: repo, tips: &mockTips{}, wallet: &mockTipWallet{debitOK: true}, now: func() time.Time { return now }}
+		if _, err := uc.Tip(context.Background(), TipInput{OrderID: repo.order.ID, CustomerID: customerID, Amount: 1}); !errors.Is(err, ErrOrderNotOwned) {
+			t.Errorf("Tip() error = %v, want ErrOrderNotOwned", err)
+		}
+	})
+}
+
+func TestSettleTips(t *testing.T) {
+	customerID := uuid.New()
+
+	tests := []struct {
+		name        string
+		orderStatus models.OrderStatus
+		stage       models.TipStage
+		tipStatus   models.TipStatus
+		wallet      *mockTipWallet
+		wantStatus  models.TipStatus
+		wantCourier float64
+		wantRefund  float64
+	}{
+		{name: "payout retried", orderStatus: models.OrderStatusDeliveryPicking, stage: models.TipStageCheckout, tipStatus: models.TipStatusDebited,
+			wallet: &mockTipWallet{debitOK: true}, wantStatus: models.TipStatusPaidOut, wantCourier: 4},
+		{name: "payout still failing", orderStatus: models.OrderStatusOrderCompleted, stage: models.TipStageAfterDelivery, tipStatus: models.TipStatusDebited,
+			wallet: &mockTipWallet{debitOK: true, creditErr: errors.New("down")}, wantStatus: models.TipStatusDebited},
+		{name: "debited tip of cancelled order refunded", orderStatus: models.OrderStatusCustomerCancelled, stage: models.TipStageCheckout, tipStatus: models.TipStatusDebited,
+			wallet: &mockTipWallet{debitOK: true}, wantStatus: models.TipStatusRefunded, wantRefund: 4},
+		{name: "paid out tip of cancelled order taken back", orderStatus: models.OrderStatusCustomerCancelled, stage: models.TipStageCheckout, tipStatus: models.TipStatusPaidOut,
+			wallet: &mockTipWallet{debitOK: true}, wantStatus: models.TipStatusRefunded, wantCourier: -4, wantRefund: 4},
+		{name: "courier cannot give payout back", orderStatus: models.OrderStatusCustomerCancelled, stage: models.TipStageCheckout, tipStatus: models.TipStatusPaidOut,
+			wallet: &mockTipWallet{debitOK: false}, wantStatus: models.TipStatusPaidOut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepo{order: models.Order{ID: uuid.New(), CustomerID: customerID, CourierID: uuid.New(), Status: string(tt.orderStatus)}}
+			tip := models.Tip{ID: uuid.New(), OrderID: repo.order.ID, CustomerID: customerID, CourierID: repo.order.CourierID,
+				Amount: 4, Stage: tt.stage, Status: tt.tipStatus}
+			tips := &mockTips{tips: []models.Tip{tip}}
+			tt.wallet.credited = map[string]float64{}
+			tt.wallet.debited = map[string]float64{}
+			uc := &tipUseCase{orders: repo, tips: tips, wallet: tt.wallet, now: time.Now}
+
+			if _, err := uc.Settle(context.Background()); err != nil {
+				t.Fatalf("Settle() error = %v", err)
+			}
+			if got := tips.status(tip.ID); got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+			if got := tt.wallet.credited["0xcourier"] - tt.wallet.debited["0xcourier"]; got != tt.wantCourier {
+				t.Errorf("courier balance change = %v, want %v", got, tt.wantCourier)
+			}
+			if got := tt.wallet.credited["0xabc"]; got != tt.wantRefund {
+				t.Errorf("customer refunded %v, want %v", got, tt.wantRefund)
+			}
+		})
+	}
+}
+
+func TestCancelRefundsCheckoutTip(t *testing.T) {
+	customerID := uuid.New()
+	repo := &mockRepo{
+		order: models.Order{ID: uuid.New(), CustomerID: customerID, CourierID: uuid.New(), Status: string(models.OrderStatusKitchenAccepted)},
+		total: 20,
+	}
+	tips := &mockTips{}
+	tipWallet := &mockTipWallet{debitOK: true, credited: map[string]float64{}, debited: map[string]float64{}}
+	tipUC := &tipUseCase{orders: repo, tips: tips, wallet: tipWallet, now: time.Now}
+
+	tip, err := tipUC.Tip(context.Background(), TipInput{OrderID: repo.order.ID, CustomerID: customerID, Amount: 3})
+	if err != nil {
+		t.Fatalf("Tip() error = %v", err)
+	}
+
+	uc := NewOrderUseCase(repo, &mockWallet{}, WithTipVoider(tipUC))
+	if _, err := uc.Cancel(context.Background(), CancelInput{OrderID: repo.order.ID, CustomerID: customerID, ReasonCode: models.CancellationReasonChangedMind}); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if got := tips.status(tip.ID); got != models.TipStatusRefunded {
+		t.Errorf("tip status = %s, want REFUNDED", got)
+	}
+	if tipWallet.debited["0xcourier"] != 3 || tipWallet.credited["0xabc"] != 3 {
+		t.Errorf("courier debited %v, customer credited %v, want 3 each", tipWallet.debited["0xcourier"], tipWallet.credited["0xabc"])
+	}
+}
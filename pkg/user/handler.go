@@ -0,0 +1,555 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/id"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/router"
+	"github.com/Kabanya/YAFDS/pkg/utils"
+)
+
+// Handler serves the account endpoints of a service.
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Mount registers the endpoints on mux; wallet login, two-factor and
+// recovery email endpoints only when the service has them enabled.
+func (h *Handler) Mount(mux router.Mux) {
+	mux.HandleFunc("POST /register", h.Register)
+	mux.HandleFunc("POST /login", h.Login)
+	if h.service.WalletLoginEnabled() {
+		mux.HandleFunc("POST /login/wallet/challenge", h.WalletChallenge)
+		mux.HandleFunc("POST /login/wallet", h.LoginWithWallet)
+	}
+	if h.service.MFAEnabled() {
+		mux.HandleFunc("POST /login/mfa", h.VerifyMFA)
+		mux.HandleFunc("POST /mfa/enroll", h.EnrollMFA)
+		mux.HandleFunc("POST /mfa/confirm", h.ConfirmMFA)
+		mux.HandleFunc("POST /mfa/disable", h.DisableMFA)
+	}
+	if h.service.RecoveryEmailEnabled() {
+		mux.HandleFunc("POST /recovery-email", h.RequestRecoveryEmail)
+		mux.HandleFunc("POST /recovery-email/confirm", h.ConfirmRecoveryEmail)
+	}
+	mux.HandleFunc("POST /token/refresh", h.Refresh)
+	mux.HandleFunc("POST /password/change", h.ChangePassword)
+	mux.HandleFunc("POST /password/reset", h.RequestPasswordReset)
+	mux.HandleFunc("POST /password/reset/confirm", h.ResetPassword)
+}
+
+// Register user with password and the schema's fields
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	walletAddress, _ := body["wallet_address"].(string)
+	password, _ := body["password"].(string)
+	name, _ := body["name"].(string)
+
+	// Validate required fields
+	if walletAddress == "" {
+		utils.WriteError(w, "wallet_address is required", http.StatusBadRequest)
+		return
+	}
+	if password == "" {
+		utils.WriteError(w, "password is required", http.StatusBadRequest)
+		return
+	}
+	if name == "" {
+		utils.WriteError(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	fields, err := h.service.schema.fields(body)
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Derive deterministic ID from wallet to keep seeded data stable across runs
+	userID := id.FromWallet(walletAddress)
+
+	err = h.service.Register(r.Context(), userID, name, walletAddress, password, fields)
+	if err != nil {
+		var policyErr *auth.ValidationError
+		if errors.As(err, &policyErr) {
+			utils.WriteJSON(w, policyErr, http.StatusBadRequest)
+			return
+		}
+		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, RegisterResponse{Id: userID}, http.StatusCreated)
+	logger.Info("user registered", "wallet_address", walletAddress)
+}
+
+// Login user with password
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" {
+		utils.WriteError(w, "wallet_address is required", http.StatusBadRequest)
+		return
+	}
+	if req.Password == "" {
+		utils.WriteError(w, "password is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := auth.WithClientIP(r.Context(), auth.ClientIPFromRequest(r))
+	loginResp, err := h.service.Login(ctx, req.WalletAddress, req.Password)
+	if err != nil {
+		if retryAfter, ok := auth.RetryAfter(err); ok {
+			w.Header().Set("Retry-After", auth.RetryAfterSeconds(retryAfter))
+			utils.WriteError(w, "too many login attempts, try again later", http.StatusTooManyRequests)
+			logger.Warn("login throttled", "wallet_address", req.WalletAddress, "error", err)
+			return
+		}
+		if isInvalidLogin(err) {
+			utils.WriteError(w, "invalid wallet address or password", http.StatusUnauthorized)
+		} else {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		}
+		logger.Info("login failed", "wallet_address", req.WalletAddress, "error", err)
+		return
+	}
+
+	utils.WriteJSON(w, loginResp, http.StatusOK)
+	if loginResp.MFARequired {
+		logger.Info("login needs a two-factor code", "wallet_address", req.WalletAddress)
+		return
+	}
+	logger.Info("user logged in", "wallet_address", req.WalletAddress)
+}
+
+// WalletChallenge issues the message a wallet signs to log in without a password
+func (h *Handler) WalletChallenge(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var req WalletChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	challenge, err := h.service.WalletChallenge(r.Context(), req.WalletAddress)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidWalletAddress) {
+			utils.WriteError(w, "wallet_address must be 0x followed by 40 hex digits", http.StatusBadRequest)
+			return
+		}
+		logger.Error("wallet challenge failed", "wallet_address", req.WalletAddress, "error", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, challenge, http.StatusOK)
+}
+
+// LoginWithWallet logs in with a signed wallet challenge
+func (h *Handler) LoginWithWallet(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var req WalletLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Message == "" || req.Signature == "" {
+		utils.WriteError(w, "message and signature are required", http.StatusBadRequest)
+		return
+	}
+
+	loginResp, err := h.service.LoginWithWallet(r.Context(), req.Message, req.Signature)
+	if err != nil {
+		logger.Info("wallet login failed", "error", err)
+		if errors.Is(err, auth.ErrInvalidChallenge) || errors.Is(err, auth.ErrInvalidSignature) || errors.Is(err, auth.ErrInvalidCredentials) {
+			utils.WriteError(w, "invalid or expired wallet signature", http.StatusUnauthorized)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, loginResp, http.StatusOK)
+	if loginResp.MFARequired {
+		logger.Info("login needs a two-factor code", "wallet_address", loginResp.WalletAddress)
+		return
+	}
+	logger.Info("user logged in with wallet signature", "wallet_address", loginResp.WalletAddress)
+}
+
+// Refresh trades a refresh token for a new token pair. Presenting an
+// already used refresh token ends the whole login.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		utils.WriteError(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := auth.WithClientIP(r.Context(), auth.ClientIPFromRequest(r))
+	tokens, err := h.service.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		logger.Info("refresh failed", "error", err)
+		if errors.Is(err, auth.ErrInvalidSession) || errors.Is(err, auth.ErrRefreshTokenReused) {
+			utils.WriteError(w, "invalid or expired refresh token", http.StatusUnauthorized)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, tokens, http.StatusOK)
+}
+
+// ChangePassword replaces the password of the logged-in user; all sessions end.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	token := auth.BearerToken(r)
+	if token == "" {
+		utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" || req.OldPassword == "" || req.NewPassword == "" {
+		utils.WriteError(w, "wallet_address, old_password and new_password are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := auth.WithClientIP(r.Context(), auth.ClientIPFromRequest(r))
+	if err := h.service.ChangePassword(ctx, token, req.WalletAddress, req.OldPassword, req.NewPassword); err != nil {
+		logger.Info("password change failed", "wallet_address", req.WalletAddress, "error", err)
+		writePasswordError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("user changed password", "wallet_address", req.WalletAddress)
+}
+
+// RequestPasswordReset always answers 202 so it cannot reveal which wallets exist.
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" {
+		utils.WriteError(w, "wallet_address is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := auth.WithClientIP(r.Context(), auth.ClientIPFromRequest(r))
+	if err := h.service.RequestPasswordReset(ctx, req.WalletAddress); err != nil {
+		if retryAfter, ok := auth.RetryAfter(err); ok {
+			w.Header().Set("Retry-After", auth.RetryAfterSeconds(retryAfter))
+			utils.WriteError(w, "too many reset requests, try again later", http.StatusTooManyRequests)
+			return
+		}
+		if errors.Is(err, auth.ErrPasswordResetDisabled) {
+			utils.WriteError(w, "password reset is not available", http.StatusServiceUnavailable)
+			return
+		}
+		logger.Error("password reset request failed", "wallet_address", req.WalletAddress, "error", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, PasswordResetResponse{Status: "if the wallet is registered, a reset token has been sent"}, http.StatusAccepted)
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var req PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" || req.NewPassword == "" {
+		utils.WriteError(w, "token and new_password are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		logger.Info("password reset failed", "error", err)
+		writePasswordError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("password reset completed")
+}
+
+// RequestRecoveryEmail sends a confirmation token to the address the user wants reset tokens at
+func (h *Handler) RequestRecoveryEmail(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	token := auth.BearerToken(r)
+	if token == "" {
+		utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	var req RecoveryEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" || req.Email == "" {
+		utils.WriteError(w, "wallet_address and email are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := auth.WithClientIP(r.Context(), auth.ClientIPFromRequest(r))
+	if err := h.service.RequestRecoveryEmail(ctx, token, req.WalletAddress, req.Email); err != nil {
+		logger.Info("recovery email request failed", "wallet_address", req.WalletAddress, "error", err)
+		writeRecoveryEmailError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmRecoveryEmail makes the address the token was sent to the user's recovery email
+func (h *Handler) ConfirmRecoveryEmail(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	token := auth.BearerToken(r)
+	if token == "" {
+		utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	var req RecoveryEmailConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" || req.Token == "" {
+		utils.WriteError(w, "wallet_address and token are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ConfirmRecoveryEmail(r.Context(), token, req.WalletAddress, req.Token); err != nil {
+		logger.Info("recovery email confirmation failed", "wallet_address", req.WalletAddress, "error", err)
+		writeRecoveryEmailError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("user confirmed a recovery email", "wallet_address", req.WalletAddress)
+}
+
+// EnrollMFA starts two-factor enrollment and returns the authenticator secret
+func (h *Handler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	token := auth.BearerToken(r)
+	if token == "" {
+		utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	var req MFAEnrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" {
+		utils.WriteError(w, "wallet_address is required", http.StatusBadRequest)
+		return
+	}
+	enrollment, err := h.service.EnrollMFA(r.Context(), token, req.WalletAddress)
+	if err != nil {
+		logger.Info("two-factor enrollment failed", "wallet_address", req.WalletAddress, "error", err)
+		writeMFAError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, enrollment, http.StatusOK)
+}
+
+// ConfirmMFA enables two-factor login with a first code and returns backup codes
+func (h *Handler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	token := auth.BearerToken(r)
+	if token == "" {
+		utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" || req.Code == "" {
+		utils.WriteError(w, "wallet_address and code are required", http.StatusBadRequest)
+		return
+	}
+	backupCodes, err := h.service.ConfirmMFA(r.Context(), token, req.WalletAddress, req.Code)
+	if err != nil {
+		logger.Info("two-factor confirmation failed", "wallet_address", req.WalletAddress, "error", err)
+		writeMFAError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, backupCodes, http.StatusOK)
+	logger.Info("user enabled two-factor authentication", "wallet_address", req.WalletAddress)
+}
+
+// DisableMFA turns two-factor login off; it takes a code or a backup code
+func (h *Handler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	token := auth.BearerToken(r)
+	if token == "" {
+		utils.WriteError(w, "authorization bearer token is required", http.StatusUnauthorized)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" {
+		utils.WriteError(w, "wallet_address is required", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.DisableMFA(r.Context(), token, req.WalletAddress, req.Code); err != nil {
+		logger.Info("disabling two-factor authentication failed", "wallet_address", req.WalletAddress, "error", err)
+		writeMFAError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("user disabled two-factor authentication", "wallet_address", req.WalletAddress)
+}
+
+// VerifyMFA finishes a login with the MFA token from /login and a code
+func (h *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var req MFALoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.MFAToken == "" || req.Code == "" {
+		utils.WriteError(w, "mfa_token and code are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := auth.WithClientIP(r.Context(), auth.ClientIPFromRequest(r))
+	loginResp, err := h.service.VerifyMFA(ctx, req.MFAToken, req.Code)
+	if err != nil {
+		logger.Info("two-factor verification failed", "error", err)
+		writeMFAError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, loginResp, http.StatusOK)
+	logger.Info("user logged in with two-factor code", "wallet_address", loginResp.WalletAddress)
+}
+
+func writeMFAError(w http.ResponseWriter, err error) {
+	if retryAfter, ok := auth.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", auth.RetryAfterSeconds(retryAfter))
+		utils.WriteError(w, "too many attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	switch {
+	case errors.Is(err, auth.ErrInvalidSession):
+		utils.WriteError(w, "invalid or expired session", http.StatusUnauthorized)
+	case errors.Is(err, auth.ErrInvalidMFAToken):
+		utils.WriteError(w, "invalid or expired two-factor challenge, log in again", http.StatusUnauthorized)
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		utils.WriteError(w, "invalid two-factor code", http.StatusUnauthorized)
+	case errors.Is(err, auth.ErrMFANotEnrolled):
+		utils.WriteError(w, "two-factor authentication is not set up", http.StatusConflict)
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		utils.WriteError(w, "two-factor authentication is already enabled", http.StatusConflict)
+	default:
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func writeRecoveryEmailError(w http.ResponseWriter, err error) {
+	if retryAfter, ok := auth.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", auth.RetryAfterSeconds(retryAfter))
+		utils.WriteError(w, "too many attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	switch {
+	case errors.Is(err, auth.ErrInvalidSession):
+		utils.WriteError(w, "invalid or expired session", http.StatusUnauthorized)
+	case errors.Is(err, auth.ErrInvalidEmail):
+		utils.WriteError(w, "email must be a plain address such as name@example.com", http.StatusBadRequest)
+	case errors.Is(err, auth.ErrInvalidEmailToken):
+		utils.WriteError(w, "invalid or expired confirmation token", http.StatusBadRequest)
+	default:
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func writePasswordError(w http.ResponseWriter, err error) {
+	var policyErr *auth.ValidationError
+	if errors.As(err, &policyErr) {
+		utils.WriteJSON(w, policyErr, http.StatusBadRequest)
+		return
+	}
+	if retryAfter, ok := auth.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", auth.RetryAfterSeconds(retryAfter))
+		utils.WriteError(w, "too many attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	switch {
+	case errors.Is(err, auth.ErrInvalidSession):
+		utils.WriteError(w, "invalid or expired session", http.StatusUnauthorized)
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		utils.WriteError(w, "old password is incorrect", http.StatusUnauthorized)
+	case errors.Is(err, auth.ErrInvalidResetToken):
+		utils.WriteError(w, "invalid or expired reset token", http.StatusBadRequest)
+	case errors.Is(err, auth.ErrPasswordResetDisabled):
+		utils.WriteError(w, "password reset is not available", http.StatusServiceUnavailable)
+	default:
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,136 @@
+package user
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+type RegisterResponse struct {
+	Id uuid.UUID `json:"id"`
+}
+
+type LoginRequest struct {
+	WalletAddress string `json:"wallet_address" required:"true"`
+	Password      string `json:"password" required:"true"`
+}
+
+// LoginResponse carries the schema's fields at the top level next to the
+// common ones, so each service keeps its own response shape.
+type LoginResponse struct {
+	Id            uuid.UUID      `json:"id"`
+	Name          string         `json:"name"`
+	WalletAddress string         `json:"wallet_address"`
+	Fields        map[string]any `json:"-"`
+	Token         string         `json:"token"`
+	Expiration    int64          `json:"expiration"`
+	// Set only when signed access tokens are enabled (AUTH_KEYS_DIR).
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	RefreshExpiration int64  `json:"refresh_expiration,omitempty"`
+	// With two-factor authentication Login returns no token, only an MFA
+	// token to send with a code to /login/mfa.
+	MFARequired   bool   `json:"mfa_required,omitempty"`
+	MFAToken      string `json:"mfa_token,omitempty"`
+	MFAExpiration int64  `json:"mfa_expiration,omitempty"`
+}
+
+func (r LoginResponse) MarshalJSON() ([]byte, error) {
+	type plain LoginResponse
+	data, err := json.Marshal(plain(r))
+	if err != nil || len(r.Fields) == 0 {
+		return data, err
+	}
+	fields, err := json.Marshal(r.Fields)
+	if err != nil {
+		return nil, err
+	}
+	// Both are objects: drop the closing brace of one and the opening
+	// brace of the other.
+	data = append(data[:len(data)-1], ',')
+	return append(data, fields[1:]...), nil
+}
+
+type ChangePasswordRequest struct {
+	WalletAddress string `json:"wallet_address" required:"true"`
+	OldPassword   string `json:"old_password" required:"true"`
+	NewPassword   string `json:"new_password" required:"true"`
+}
+
+type PasswordResetRequest struct {
+	WalletAddress string `json:"wallet_address" required:"true"`
+}
+
+type PasswordResetResponse struct {
+	Status string `json:"status"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" required:"true"`
+	NewPassword string `json:"new_password" required:"true"`
+}
+
+// RecoveryEmailRequest asks for a confirmation token at Email; reset
+// tokens only go to an address confirmed this way.
+type RecoveryEmailRequest struct {
+	WalletAddress string `json:"wallet_address" required:"true"`
+	Email         string `json:"email" required:"true"`
+}
+
+type RecoveryEmailConfirmRequest struct {
+	WalletAddress string `json:"wallet_address" required:"true"`
+	Token         string `json:"token" required:"true"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" required:"true"`
+}
+
+type TokenResponse struct {
+	Token             string `json:"token"`
+	Expiration        int64  `json:"expiration"`
+	RefreshToken      string `json:"refresh_token"`
+	RefreshExpiration int64  `json:"refresh_expiration"`
+}
+
+type WalletChallengeRequest struct {
+	WalletAddress string `json:"wallet_address"`
+}
+
+type WalletChallengeResponse struct {
+	Message   string `json:"message"`
+	Nonce     string `json:"nonce"`
+	IssuedAt  int64  `json:"issued_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// WalletLoginRequest carries the challenge message exactly as issued and
+// the wallet's personal_sign signature of it (0x-prefixed hex).
+type WalletLoginRequest struct {
+	Message   string `json:"message" required:"true"`
+	Signature string `json:"signature" required:"true"`
+}
+
+type MFAEnrollRequest struct {
+	WalletAddress string `json:"wallet_address" required:"true"`
+}
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// MFACodeRequest confirms enrollment with a code, or disables two-factor
+// login with a code or a backup code.
+type MFACodeRequest struct {
+	WalletAddress string `json:"wallet_address" required:"true"`
+	Code          string `json:"code"`
+}
+
+type MFABackupCodesResponse struct {
+	BackupCodes []string `json:"backup_codes"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" required:"true"`
+	Code     string `json:"code" required:"true"`
+}
@@ -0,0 +1,166 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+)
+
+// Operations documents the routes Mount registers, with the registration
+// body of schema. Wallet, two-factor and recovery email routes are listed
+// even though Mount skips them while the service has them off.
+func Operations(schema Schema) map[string]openapi.Op {
+	register := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"wallet_address": openapi.String(),
+			"password":       openapi.String(),
+			"name":           openapi.String(),
+		},
+		Required: []string{"wallet_address", "password", "name"},
+	}
+	for _, f := range schema.Fields {
+		property := openapi.String()
+		if f.Kind == Bool {
+			property = openapi.Boolean()
+		}
+		register.Properties[f.key()] = property
+		if f.Required {
+			register.Required = append(register.Required, f.key())
+		}
+	}
+	nonEmpty := 1
+	for _, name := range register.Required {
+		if p := register.Properties[name]; p.Type == "string" {
+			p.MinLength = &nonEmpty
+		}
+	}
+
+	login := map[int]any{
+		http.StatusOK:              LoginResponse{},
+		http.StatusUnauthorized:    models.ErrorResponce{},
+		http.StatusTooManyRequests: models.ErrorResponce{},
+	}
+	mfa := map[int]any{
+		http.StatusUnauthorized: models.ErrorResponce{},
+		http.StatusConflict:     models.ErrorResponce{},
+	}
+	return map[string]openapi.Op{
+		"POST /register": {
+			Summary:     "Register user with password",
+			Description: "Besides the common fields the body carries the fields of the service's user table.",
+			Body:        register,
+			Responses: map[int]any{
+				http.StatusCreated:    RegisterResponse{},
+				http.StatusBadRequest: auth.ValidationError{},
+			},
+		},
+		"POST /login": {
+			Summary:     "Login user with password",
+			Description: "The response also carries the fields of the service's user table. With two-factor login on, it holds an MFA token for /login/mfa instead of a token.",
+			Body:        LoginRequest{},
+			Responses:   login,
+		},
+		"POST /login/wallet/challenge": {
+			Summary:   "Get a message to sign with the wallet",
+			Body:      WalletChallengeRequest{},
+			Responses: map[int]any{http.StatusOK: WalletChallengeResponse{}},
+		},
+		"POST /login/wallet": {
+			Summary:   "Login with a signed wallet challenge",
+			Body:      WalletLoginRequest{},
+			Responses: login,
+		},
+		"POST /login/mfa": {
+			Summary:   "Finish a login with a two-factor code",
+			Body:      MFALoginRequest{},
+			Responses: login,
+		},
+		"POST /mfa/enroll": {
+			Summary:   "Start two-factor enrollment",
+			Security:  []string{openapi.Bearer},
+			Body:      MFAEnrollRequest{},
+			Responses: withStatus(mfa, http.StatusOK, MFAEnrollResponse{}),
+		},
+		"POST /mfa/confirm": {
+			Summary:   "Enable two-factor login and get backup codes",
+			Security:  []string{openapi.Bearer},
+			Body:      MFACodeRequest{},
+			Responses: withStatus(mfa, http.StatusOK, MFABackupCodesResponse{}),
+		},
+		"POST /mfa/disable": {
+			Summary:   "Disable two-factor login with a code or a backup code",
+			Security:  []string{openapi.Bearer},
+			Body:      MFACodeRequest{},
+			Responses: withStatus(mfa, http.StatusNoContent, nil),
+		},
+		"POST /recovery-email": {
+			Summary:     "Send a confirmation token to a recovery email",
+			Description: "Password reset tokens only go to a recovery email confirmed with /recovery-email/confirm.",
+			Security:    []string{openapi.Bearer},
+			Body:        RecoveryEmailRequest{},
+			Responses: map[int]any{
+				http.StatusAccepted:        nil,
+				http.StatusBadRequest:      models.ErrorResponce{},
+				http.StatusUnauthorized:    models.ErrorResponce{},
+				http.StatusTooManyRequests: models.ErrorResponce{},
+			},
+		},
+		"POST /recovery-email/confirm": {
+			Summary:  "Confirm a recovery email with the token sent to it",
+			Security: []string{openapi.Bearer},
+			Body:     RecoveryEmailConfirmRequest{},
+			Responses: map[int]any{
+				http.StatusNoContent:    nil,
+				http.StatusBadRequest:   models.ErrorResponce{},
+				http.StatusUnauthorized: models.ErrorResponce{},
+			},
+		},
+		"POST /token/refresh": {
+			Summary:   "Trade a refresh token for a new token pair",
+			Body:      RefreshRequest{},
+			Responses: map[int]any{http.StatusOK: TokenResponse{}, http.StatusUnauthorized: models.ErrorResponce{}},
+		},
+		"POST /password/change": {
+			Summary:  "Change password; all sessions end",
+			Security: []string{openapi.Bearer},
+			Body:     ChangePasswordRequest{},
+			Responses: map[int]any{
+				http.StatusNoContent:       nil,
+				http.StatusBadRequest:      auth.ValidationError{},
+				http.StatusUnauthorized:    models.ErrorResponce{},
+				http.StatusTooManyRequests: models.ErrorResponce{},
+			},
+		},
+		"POST /password/reset": {
+			Summary: "Send a password reset token to the wallet owner's recovery email",
+			Body:    PasswordResetRequest{},
+			Responses: map[int]any{
+				http.StatusAccepted:           PasswordResetResponse{},
+				http.StatusTooManyRequests:    models.ErrorResponce{},
+				http.StatusServiceUnavailable: models.ErrorResponce{},
+			},
+		},
+		"POST /password/reset/confirm": {
+			Summary: "Set a new password with a reset token",
+			Body:    PasswordResetConfirmRequest{},
+			Responses: map[int]any{
+				http.StatusNoContent:          nil,
+				http.StatusBadRequest:         auth.ValidationError{},
+				http.StatusTooManyRequests:    models.ErrorResponce{},
+				http.StatusServiceUnavailable: models.ErrorResponce{},
+			},
+		},
+	}
+}
+
+// withStatus copies responses with one more status.
+func withStatus(responses map[int]any, status int, body any) map[int]any {
+	out := map[int]any{status: body}
+	for s, b := range responses {
+		out[s] = b
+	}
+	return out
+}
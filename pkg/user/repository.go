@@ -0,0 +1,164 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+
+	"github.com/google/uuid"
+)
+
+// Repository is the Postgres auth.Store of a service's user table.
+type Repository struct {
+	db     *sql.DB
+	schema Schema
+
+	insertQuery string
+	selectQuery string
+	updateQuery string
+	emailQuery  string
+}
+
+func NewRepository(db *sql.DB, schema Schema) (*Repository, error) {
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+	columns := []string{"emp_id", "name", "wallet_address"}
+	for _, f := range schema.Fields {
+		columns = append(columns, f.Column)
+	}
+	selectColumns := append(columns, "password_hash", "password_salt")
+	insertColumns := slices.Clone(selectColumns)
+	for _, v := range schema.Insert {
+		insertColumns = append(insertColumns, v.Column)
+	}
+	// The recovery email is only ever set once the user confirms it.
+	selectColumns = append(selectColumns, "recovery_email")
+	placeholders := make([]string, len(insertColumns))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	return &Repository{
+		db:     db,
+		schema: schema,
+		insertQuery: fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`,
+			schema.Table, strings.Join(insertColumns, ", "), strings.Join(placeholders, ", ")),
+		selectQuery: fmt.Sprintf(`SELECT %s FROM %s WHERE wallet_address = $1 LIMIT 1`,
+			strings.Join(selectColumns, ", "), schema.Table),
+		updateQuery: fmt.Sprintf(`UPDATE %s SET password_hash = $1, password_salt = $2 WHERE emp_id = $3`,
+			schema.Table),
+		emailQuery: fmt.Sprintf(`UPDATE %s SET recovery_email = $1 WHERE emp_id = $2`, schema.Table),
+	}, nil
+}
+
+func (r *Repository) SaveWithPassword(ctx context.Context, data auth.RegisterInput, passwordHash string, passwordSalt []byte) error {
+	if r.db == nil {
+		return errors.New("user: repository not initialized")
+	}
+	args := []any{data.ID, data.Name, data.WalletAddress}
+	for _, f := range r.schema.Fields {
+		value, ok := data.Fields[f.key()]
+		if !ok {
+			value = f.zero()
+		}
+		args = append(args, value)
+	}
+	args = append(args, passwordHash, passwordSalt)
+	for _, v := range r.schema.Insert {
+		args = append(args, v.Value)
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.insertQuery, args...); err != nil {
+		logging.FromContext(ctx).Error("user: save failed", "entity", r.schema.entity(), "error", err)
+		return err
+	}
+	logging.FromContext(ctx).Debug("user: saved with password", "entity", r.schema.entity(), "user_id", data.ID)
+	return nil
+}
+
+// LoadByWalletAddress returns sql.ErrNoRows for unknown wallets.
+func (r *Repository) LoadByWalletAddress(ctx context.Context, walletAddress string) (auth.StoredUser, error) {
+	if r.db == nil {
+		return auth.StoredUser{}, errors.New("user: repository not initialized")
+	}
+	var user auth.StoredUser
+	var passwordHash, recoveryEmail sql.NullString
+	dest := []any{&user.ID, &user.Name, &user.WalletAddress}
+	texts := make([]sql.NullString, len(r.schema.Fields))
+	bools := make([]sql.NullBool, len(r.schema.Fields))
+	for i, f := range r.schema.Fields {
+		if f.Kind == Bool {
+			dest = append(dest, &bools[i])
+		} else {
+			dest = append(dest, &texts[i])
+		}
+	}
+	dest = append(dest, &passwordHash, &user.PasswordSalt, &recoveryEmail)
+
+	err := r.db.QueryRowContext(ctx, r.selectQuery, walletAddress).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		logging.FromContext(ctx).Debug("user: not found", "entity", r.schema.entity(), "wallet_address", walletAddress)
+		return auth.StoredUser{}, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("user: load failed", "entity", r.schema.entity(), "error", err)
+		return auth.StoredUser{}, err
+	}
+	if !passwordHash.Valid {
+		logging.FromContext(ctx).Error("user: password hash is NULL", "entity", r.schema.entity(), "wallet_address", walletAddress)
+		return auth.StoredUser{}, errors.New("password hash is null")
+	}
+	user.PasswordHash = passwordHash.String
+	user.RecoveryEmail = recoveryEmail.String
+
+	user.Fields = make(map[string]any, len(r.schema.Fields))
+	for i, f := range r.schema.Fields {
+		if f.Kind == Bool {
+			user.Fields[f.key()] = bools[i].Bool
+		} else {
+			user.Fields[f.key()] = texts[i].String
+		}
+	}
+	return user, nil
+}
+
+// UpdatePassword returns sql.ErrNoRows when no user has the ID.
+func (r *Repository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string, passwordSalt []byte) error {
+	if r.db == nil {
+		return errors.New("user: repository not initialized")
+	}
+	res, err := r.db.ExecContext(ctx, r.updateQuery, passwordHash, passwordSalt, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("user: update password failed", "entity", r.schema.entity(), "error", err)
+		return err
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	logging.FromContext(ctx).Debug("user: updated password", "entity", r.schema.entity(), "user_id", userID)
+	return nil
+}
+
+// UpdateRecoveryEmail returns sql.ErrNoRows when no user has the ID.
+func (r *Repository) UpdateRecoveryEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	if r.db == nil {
+		return errors.New("user: repository not initialized")
+	}
+	res, err := r.db.ExecContext(ctx, r.emailQuery, email, userID)
+	if err != nil {
+		logging.FromContext(ctx).Error("user: update recovery email failed", "entity", r.schema.entity(), "error", err)
+		return err
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	logging.FromContext(ctx).Debug("user: updated recovery email", "entity", r.schema.entity(), "user_id", userID)
+	return nil
+}
@@ -0,0 +1,130 @@
+// Package user is the account module shared by the customer, courier and
+// restaurant services: registration, login, sessions, password changes,
+// wallet login and two-factor login on top of pkg/auth. Each service
+// describes its table with a Schema; everything else is the same code.
+package user
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Kind is the type of an extra field, in JSON and in the table.
+type Kind int
+
+const (
+	String Kind = iota
+	Bool
+)
+
+// Field is a column a service keeps next to the common ones (emp_id, name,
+// wallet_address, password_hash, password_salt).
+type Field struct {
+	// Column is the table column; JSON is the request and response key and
+	// defaults to Column.
+	Column string
+	JSON   string
+	Kind   Kind
+	// Required rejects registrations without the field. Otherwise a
+	// missing field is stored as Default, or the zero value of Kind.
+	Required bool
+	Default  any
+}
+
+func (f Field) key() string {
+	if f.JSON != "" {
+		return f.JSON
+	}
+	return f.Column
+}
+
+func (f Field) zero() any {
+	if f.Default != nil {
+		return f.Default
+	}
+	if f.Kind == Bool {
+		return false
+	}
+	return ""
+}
+
+// Value is a column set to a fixed value on registration and never read
+// back, e.g. a courier's starting geolocation.
+type Value struct {
+	Column string
+	Value  any
+}
+
+// Schema maps a service's user table.
+type Schema struct {
+	// Table is the user table, e.g. "COURIERS". It comes from code, never
+	// from input.
+	Table string
+	// Entity names the users in log lines, e.g. "courier", and keeps the
+	// service's tokens in the shared Redis apart from the other services'.
+	Entity string
+	// Fields are registered, stored and returned on login.
+	Fields []Field
+	// Insert are written on registration only.
+	Insert []Value
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (s Schema) validate() error {
+	if !identifierPattern.MatchString(s.Table) {
+		return fmt.Errorf("user: invalid table name %q", s.Table)
+	}
+	for _, f := range s.Fields {
+		if !identifierPattern.MatchString(f.Column) {
+			return fmt.Errorf("user: invalid column name %q", f.Column)
+		}
+		if f.Kind != String && f.Kind != Bool {
+			return fmt.Errorf("user: column %s has unknown kind %d", f.Column, f.Kind)
+		}
+	}
+	for _, v := range s.Insert {
+		if !identifierPattern.MatchString(v.Column) {
+			return fmt.Errorf("user: invalid column name %q", v.Column)
+		}
+	}
+	return nil
+}
+
+func (s Schema) entity() string {
+	if s.Entity != "" {
+		return s.Entity
+	}
+	return "user"
+}
+
+// fields checks the extra fields of a registration request and fills in
+// defaults. The error message is meant for the client.
+func (s Schema) fields(body map[string]any) (map[string]any, error) {
+	fields := make(map[string]any, len(s.Fields))
+	for _, f := range s.Fields {
+		raw, ok := body[f.key()]
+		if !ok || raw == nil || raw == "" {
+			if f.Required {
+				return nil, fmt.Errorf("%s is required", f.key())
+			}
+			fields[f.key()] = f.zero()
+			continue
+		}
+		switch f.Kind {
+		case String:
+			value, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a string", f.key())
+			}
+			fields[f.key()] = value
+		case Bool:
+			value, ok := raw.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s must be true or false", f.key())
+			}
+			fields[f.key()] = value
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,249 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Mailer delivers the account emails: recovery email confirmations and
+// password reset tokens.
+type Mailer interface {
+	auth.ResetSender
+	auth.EmailSender
+}
+
+// Config is what a service passes to get its user module.
+type Config struct {
+	Schema Schema
+	DB     *sql.DB
+	// Store replaces the Postgres repository on DB, e.g. in tests.
+	Store auth.Store
+	// Redis keeps login throttling, reset tokens and wallet and two-factor
+	// challenges; without it those features are off.
+	Redis      *redis.Client
+	Sessions   auth.SessionManager
+	SessionTTL time.Duration
+	Validator  auth.Validator
+	// Mailer enables recovery emails and password reset; without it both
+	// are off.
+	Mailer Mailer
+	// WalletLogin enables sign-in with a wallet signature.
+	WalletLogin *auth.WalletLoginConfig
+	// MFAStore enables optional two-factor login; MFAIssuer is the name
+	// authenticator apps show.
+	MFAStore  auth.MFAStore
+	MFAIssuer string
+}
+
+// Service is the account use cases of one service.
+type Service struct {
+	auth   *auth.Service
+	schema Schema
+
+	walletLogin   bool
+	mfa           bool
+	recoveryEmail bool
+}
+
+func NewService(cfg Config) (*Service, error) {
+	if err := cfg.Schema.validate(); err != nil {
+		return nil, err
+	}
+	store := cfg.Store
+	if store == nil {
+		repo, err := NewRepository(cfg.DB, cfg.Schema)
+		if err != nil {
+			return nil, err
+		}
+		store = repo
+	}
+	authCfg := auth.ServiceConfig{
+		Store:      store,
+		Hasher:     auth.NewArgon2Hasher(auth.DefaultArgonParams),
+		Sessions:   cfg.Sessions,
+		Validator:  cfg.Validator,
+		SessionTTL: cfg.SessionTTL,
+	}
+	if cfg.Mailer != nil {
+		authCfg.ResetSender = cfg.Mailer
+		authCfg.EmailSender = cfg.Mailer
+	}
+	if cfg.Redis != nil {
+		authCfg.Throttle = auth.NewRedisLoginThrottle(cfg.Redis, auth.DefaultThrottleConfig, auth.LogAudit)
+		authCfg.ResetTokens = auth.NewRedisResetTokenStore(cfg.Redis, cfg.Schema.entity())
+		authCfg.ResetLimiter = auth.NewRedisRateLimiter(cfg.Redis)
+		authCfg.EmailTokens = auth.NewRedisEmailTokenStore(cfg.Redis, cfg.Schema.entity())
+		if cfg.WalletLogin != nil {
+			authCfg.WalletLogin = *cfg.WalletLogin
+			authCfg.WalletLogin.Challenges = auth.NewRedisChallengeStore(cfg.Redis)
+		}
+		if cfg.MFAStore != nil {
+			authCfg.MFA = auth.MFAConfig{
+				Store:      cfg.MFAStore,
+				Challenges: auth.NewRedisMFAChallengeStore(cfg.Redis),
+				Issuer:     cfg.MFAIssuer,
+				Role:       cfg.Schema.entity(),
+			}
+		}
+	}
+	return newService(cfg.Schema, authCfg)
+}
+
+func newService(schema Schema, authCfg auth.ServiceConfig) (*Service, error) {
+	service, err := auth.NewService(authCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		auth:          service,
+		schema:        schema,
+		walletLogin:   authCfg.WalletLogin.Challenges != nil,
+		mfa:           authCfg.MFA.Store != nil && authCfg.MFA.Challenges != nil,
+		recoveryEmail: authCfg.EmailTokens != nil && authCfg.EmailSender != nil,
+	}, nil
+}
+
+func (s *Service) WalletLoginEnabled() bool   { return s.walletLogin }
+func (s *Service) MFAEnabled() bool           { return s.mfa }
+func (s *Service) RecoveryEmailEnabled() bool { return s.recoveryEmail }
+
+// Register stores a user; fields are the schema's extra fields, already
+// checked by the handler.
+func (s *Service) Register(ctx context.Context, id uuid.UUID, name, walletAddress, password string, fields map[string]any) error {
+	return s.auth.Register(ctx, auth.RegisterInput{
+		ID:            id,
+		Name:          name,
+		WalletAddress: walletAddress,
+		Password:      password,
+		Fields:        fields,
+	})
+}
+
+func (s *Service) Login(ctx context.Context, walletAddress, password string) (LoginResponse, error) {
+	res, err := s.auth.Login(ctx, walletAddress, password)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	return loginResponse(res), nil
+}
+
+// loginResponse carries either a session or, for users with two-factor
+// authentication, the MFA token to pass to VerifyMFA.
+func loginResponse(res auth.LoginResult) LoginResponse {
+	response := LoginResponse{
+		Id:            res.User.ID,
+		Name:          res.User.Name,
+		WalletAddress: res.User.WalletAddress,
+		Fields:        res.User.Fields,
+		Token:         res.Token,
+		Expiration:    res.Expiration.Unix(),
+		RefreshToken:  res.RefreshToken,
+	}
+	if res.RefreshToken != "" {
+		response.RefreshExpiration = res.RefreshExpiration.Unix()
+	}
+	if res.MFAToken != "" {
+		response.Expiration = 0
+		response.MFARequired = true
+		response.MFAToken = res.MFAToken
+		response.MFAExpiration = res.MFAExpiration.Unix()
+	}
+	return response
+}
+
+// WalletChallenge returns the message the wallet has to sign for LoginWithWallet.
+func (s *Service) WalletChallenge(ctx context.Context, walletAddress string) (WalletChallengeResponse, error) {
+	challenge, err := s.auth.WalletChallenge(ctx, walletAddress)
+	if err != nil {
+		return WalletChallengeResponse{}, err
+	}
+	return WalletChallengeResponse{
+		Message:   challenge.Message,
+		Nonce:     challenge.Nonce,
+		IssuedAt:  challenge.IssuedAt.Unix(),
+		ExpiresAt: challenge.ExpiresAt.Unix(),
+	}, nil
+}
+
+func (s *Service) LoginWithWallet(ctx context.Context, message, signature string) (LoginResponse, error) {
+	res, err := s.auth.LoginWithWallet(ctx, message, signature)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	return loginResponse(res), nil
+}
+
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (TokenResponse, error) {
+	pair, err := s.auth.Refresh(ctx, refreshToken)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	return TokenResponse{
+		Token:             pair.AccessToken,
+		Expiration:        pair.AccessExpiresAt.Unix(),
+		RefreshToken:      pair.RefreshToken,
+		RefreshExpiration: pair.RefreshExpiresAt.Unix(),
+	}, nil
+}
+
+func (s *Service) ChangePassword(ctx context.Context, sessionToken, walletAddress, oldPassword, newPassword string) error {
+	return s.auth.ChangePassword(ctx, sessionToken, walletAddress, oldPassword, newPassword)
+}
+
+func (s *Service) RequestPasswordReset(ctx context.Context, walletAddress string) error {
+	return s.auth.RequestPasswordReset(ctx, walletAddress)
+}
+
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	return s.auth.ResetPassword(ctx, token, newPassword)
+}
+
+func (s *Service) RequestRecoveryEmail(ctx context.Context, sessionToken, walletAddress, email string) error {
+	return s.auth.RequestRecoveryEmail(ctx, sessionToken, walletAddress, email)
+}
+
+func (s *Service) ConfirmRecoveryEmail(ctx context.Context, sessionToken, walletAddress, token string) error {
+	return s.auth.ConfirmRecoveryEmail(ctx, sessionToken, walletAddress, token)
+}
+
+func (s *Service) EnrollMFA(ctx context.Context, sessionToken, walletAddress string) (MFAEnrollResponse, error) {
+	enrollment, err := s.auth.EnrollMFA(ctx, sessionToken, walletAddress)
+	if err != nil {
+		return MFAEnrollResponse{}, err
+	}
+	return MFAEnrollResponse{Secret: enrollment.Secret, OtpauthURI: enrollment.URI}, nil
+}
+
+func (s *Service) ConfirmMFA(ctx context.Context, sessionToken, walletAddress, code string) (MFABackupCodesResponse, error) {
+	codes, err := s.auth.ConfirmMFA(ctx, sessionToken, walletAddress, code)
+	if err != nil {
+		return MFABackupCodesResponse{}, err
+	}
+	return MFABackupCodesResponse{BackupCodes: codes}, nil
+}
+
+func (s *Service) DisableMFA(ctx context.Context, sessionToken, walletAddress, code string) error {
+	return s.auth.DisableMFA(ctx, sessionToken, walletAddress, code)
+}
+
+func (s *Service) VerifyMFA(ctx context.Context, mfaToken, code string) (LoginResponse, error) {
+	res, err := s.auth.VerifyMFA(ctx, mfaToken, code)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	return loginResponse(res), nil
+}
+
+// isInvalidLogin reports whether a login failed on the credentials rather
+// than on the server; unknown wallets come back from the store as
+// sql.ErrNoRows.
+func isInvalidLogin(err error) bool {
+	return errors.Is(err, auth.ErrInvalidCredentials) || errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,232 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/config"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+
+	"github.com/google/uuid"
+)
+
+type memoryStore struct {
+	users map[string]auth.StoredUser
+}
+
+func (m *memoryStore) SaveWithPassword(ctx context.Context, data auth.RegisterInput, hash string, salt []byte) error {
+	m.users[data.WalletAddress] = auth.StoredUser{
+		ID:            data.ID,
+		Name:          data.Name,
+		WalletAddress: data.WalletAddress,
+		PasswordHash:  hash,
+		PasswordSalt:  salt,
+		Fields:        data.Fields,
+	}
+	return nil
+}
+
+func (m *memoryStore) LoadByWalletAddress(ctx context.Context, walletAddress string) (auth.StoredUser, error) {
+	u, ok := m.users[walletAddress]
+	if !ok {
+		return auth.StoredUser{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (m *memoryStore) UpdatePassword(ctx context.Context, userID uuid.UUID, hash string, salt []byte) error {
+	return nil
+}
+
+func (m *memoryStore) UpdateRecoveryEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	for wallet, u := range m.users {
+		if u.ID == userID {
+			u.RecoveryEmail = email
+			m.users[wallet] = u
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+type memorySessions struct{}
+
+func (memorySessions) Create(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	return "token-" + userID.String(), time.Now().Add(ttl), nil
+}
+
+func (memorySessions) Validate(ctx context.Context, token string) (uuid.UUID, error) {
+	return uuid.Parse(strings.TrimPrefix(token, "token-"))
+}
+
+func (memorySessions) RevokeAll(ctx context.Context, userID uuid.UUID) error { return nil }
+
+var courierSchema = Schema{
+	Table:  "COURIERS",
+	Entity: "courier",
+	Fields: []Field{{Column: "transport_type", Default: "bicycle"}},
+	Insert: []Value{{Column: "is_active", Value: true}, {Column: "geolocation", Value: "0,0"}},
+}
+
+var restaurantSchema = Schema{
+	Table:  "RESTAURANTS",
+	Entity: "restaurant",
+	Fields: []Field{
+		{Column: "address", Required: true},
+		{Column: "status", JSON: "is_active", Kind: Bool},
+	},
+}
+
+func post(t *testing.T, mux *http.ServeMux, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)))
+	return rec
+}
+
+func TestHandlerRegisterAndLogin(t *testing.T) {
+	store := &memoryStore{users: make(map[string]auth.StoredUser)}
+	service, err := NewService(Config{Schema: restaurantSchema, Store: store, Sessions: memorySessions{}})
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(service).Mount(mux)
+
+	wallet := "0x00000000000000000000000000000000000000aa"
+	rec := post(t, mux, "/register", map[string]any{"name": "Pizza", "wallet_address": wallet, "password": "correct horse"})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "address is required") {
+		t.Fatalf("register without address = %d %s", rec.Code, rec.Body)
+	}
+	rec = post(t, mux, "/register", map[string]any{"name": "Pizza", "wallet_address": wallet, "password": "correct horse", "address": "Main St", "is_active": "yes"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("register with string is_active = %d %s", rec.Code, rec.Body)
+	}
+	rec = post(t, mux, "/register", map[string]any{"name": "Pizza", "wallet_address": wallet, "password": "correct horse", "address": "Main St", "is_active": true})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = post(t, mux, "/login", LoginRequest{WalletAddress: wallet, Password: "wrong password"})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("login with wrong password = %d", rec.Code)
+	}
+	rec = post(t, mux, "/login", LoginRequest{WalletAddress: "0xunknown", Password: "correct horse"})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("login with unknown wallet = %d", rec.Code)
+	}
+	rec = post(t, mux, "/login", LoginRequest{WalletAddress: wallet, Password: "correct horse"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login = %d %s", rec.Code, rec.Body)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["address"] != "Main St" || body["is_active"] != true || body["name"] != "Pizza" || body["token"] == "" {
+		t.Errorf("login response = %v", body)
+	}
+
+	// Wallet login and two-factor endpoints are off in this config.
+	if rec := post(t, mux, "/login/wallet", WalletLoginRequest{}); rec.Code != http.StatusNotFound {
+		t.Errorf("/login/wallet = %d, want 404", rec.Code)
+	}
+	if rec := post(t, mux, "/mfa/enroll", MFAEnrollRequest{}); rec.Code != http.StatusNotFound {
+		t.Errorf("/mfa/enroll = %d, want 404", rec.Code)
+	}
+}
+
+func TestSchemaFields(t *testing.T) {
+	fields, err := courierSchema.fields(map[string]any{"transport_type": ""})
+	if err != nil || fields["transport_type"] != "bicycle" {
+		t.Errorf("default transport_type = %v, %v", fields, err)
+	}
+	fields, err = restaurantSchema.fields(map[string]any{"address": "Main St"})
+	if err != nil || fields["is_active"] != false {
+		t.Errorf("missing bool = %v, %v", fields, err)
+	}
+	if err := (Schema{Table: "USERS; DROP TABLE X"}).validate(); err == nil {
+		t.Error("validate accepted a bad table name")
+	}
+}
+
+func TestRepositoryQueries(t *testing.T) {
+	repo, err := NewRepository(nil, courierSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantInsert := `INSERT INTO COURIERS (emp_id, name, wallet_address, transport_type, password_hash, password_salt, is_active, geolocation) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	if repo.insertQuery != wantInsert {
+		t.Errorf("insert = %s", repo.insertQuery)
+	}
+	wantSelect := `SELECT emp_id, name, wallet_address, transport_type, password_hash, password_salt, recovery_email FROM COURIERS WHERE wallet_address = $1 LIMIT 1`
+	if repo.selectQuery != wantSelect {
+		t.Errorf("select = %s", repo.selectQuery)
+	}
+}
+
+func TestLoginResponseJSON(t *testing.T) {
+	data, err := json.Marshal(LoginResponse{Name: "Bob", Fields: map[string]any{"transport_type": "car"}, Token: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if body["transport_type"] != "car" || body["token"] != "t" || body["name"] != "Bob" {
+		t.Errorf("login response = %s", data)
+	}
+	if _, ok := body["Fields"]; ok {
+		t.Errorf("Fields leaked into %s", data)
+	}
+}
+
+func TestHandlerMatchesOperations(t *testing.T) {
+	store := &memoryStore{users: make(map[string]auth.StoredUser)}
+	service, err := NewService(Config{Schema: restaurantSchema, Store: store, Sessions: memorySessions{}})
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(service).Mount(mux)
+	doc := openapi.New("test", "1")
+	doc.AddAll(Operations(restaurantSchema))
+	h := openapi.Validate(doc, config.OpenAPI{ValidateRequests: true, ValidateResponses: true})(mux)
+
+	send := func(path string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)))
+		return rec
+	}
+
+	wallet := "0x00000000000000000000000000000000000000bb"
+	if rec := send("/register", map[string]any{"name": "Pizza", "wallet_address": wallet, "password": "correct horse"}); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"address"`) {
+		t.Errorf("register without address = %d %s", rec.Code, rec.Body)
+	}
+	if rec := send("/register", map[string]any{"name": "Pizza", "wallet_address": wallet, "password": "correct horse", "address": "Main St", "is_active": true}); rec.Code != http.StatusCreated {
+		t.Errorf("register = %d %s", rec.Code, rec.Body)
+	}
+	if rec := send("/login", LoginRequest{WalletAddress: wallet, Password: "wrong password"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("login with wrong password = %d %s", rec.Code, rec.Body)
+	}
+	if rec := send("/login", LoginRequest{WalletAddress: wallet, Password: "correct horse"}); rec.Code != http.StatusOK {
+		t.Errorf("login = %d %s", rec.Code, rec.Body)
+	}
+}
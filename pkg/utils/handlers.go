@@ -3,7 +3,9 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
+	"github.com/Kabanya/YAFDS/pkg/logging"
 	"github.com/Kabanya/YAFDS/pkg/models"
 
 	"github.com/google/uuid"
@@ -18,7 +20,17 @@ func WriteJSON(w http.ResponseWriter, data interface{}, statusCode int) {
 }
 
 func WriteError(w http.ResponseWriter, message string, statusCode int) {
-	WriteJSON(w, models.ErrorResponce{ErrorMessage: message}, statusCode)
+	WriteJSON(w, ErrorBody(w, message, statusCode), statusCode)
+}
+
+// ErrorBody builds the error envelope for responses that embed it next to
+// extra fields.
+func ErrorBody(w http.ResponseWriter, message string, statusCode int) models.ErrorResponce {
+	return models.ErrorResponce{
+		ErrorMessage: message,
+		Code:         strings.ReplaceAll(strings.ToLower(http.StatusText(statusCode)), " ", "_"),
+		RequestID:    w.Header().Get(logging.RequestIDHeader),
+	}
 }
 
 func NewUUID() uuid.UUID {
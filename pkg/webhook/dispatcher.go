@@ -0,0 +1,419 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultMaxAttempts = 8
+	DefaultBaseBackoff = 10 * time.Second
+	DefaultMaxBackoff  = 6 * time.Hour
+	DefaultInterval    = 5 * time.Second
+	DefaultBatchSize   = 50
+	DefaultLease       = 2 * time.Minute
+	DefaultTimeout     = 10 * time.Second
+
+	// maxLoggedBody bounds the response excerpt kept in the delivery log.
+	maxLoggedBody = 1024
+)
+
+// Dispatcher fans order events out to restaurant subscriptions and delivers
+// them with signed POSTs and exponential backoff.
+type Dispatcher struct {
+	store       Store
+	client      *http.Client
+	resolver    *net.Resolver
+	now         func() time.Time
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	// allowPrivate lets tests deliver to httptest servers on loopback.
+	allowPrivate bool
+}
+
+func NewDispatcher(store Store) *Dispatcher {
+	d := &Dispatcher{
+		store:       store,
+		resolver:    net.DefaultResolver,
+		now:         func() time.Time { return time.Now().UTC() },
+		maxAttempts: DefaultMaxAttempts,
+		baseBackoff: DefaultBaseBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+	}
+	// The address is checked again right before connecting: a host that
+	// resolved to a public address at subscribe time may not anymore, and
+	// redirects are dialed through here too. No proxy, so the dialed
+	// address is the receiver's.
+	dialer := &net.Dialer{Timeout: DefaultTimeout, Control: d.checkDial}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	d.client = &http.Client{Timeout: DefaultTimeout, Transport: transport}
+	return d
+}
+
+type payload struct {
+	ID        uuid.UUID        `json:"id"`
+	Event     notify.EventType `json:"event"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      map[string]any   `json:"data"`
+}
+
+// Subscribe validates and stores a subscription. A secret is generated when
+// none is given; it is only ever returned here.
+func (d *Dispatcher) Subscribe(ctx context.Context, sub Subscription) (Subscription, error) {
+	parsed, err := url.Parse(sub.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return Subscription{}, ErrInvalidURL
+	}
+	for _, event := range sub.Events {
+		if !subscribable(event) {
+			return Subscription{}, fmt.Errorf("%w: %s", ErrInvalidEvent, event)
+		}
+	}
+	if err := d.checkHost(ctx, parsed.Hostname()); err != nil {
+		return Subscription{}, err
+	}
+	if sub.Secret == "" {
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			return Subscription{}, err
+		}
+		sub.Secret = "whsec_" + hex.EncodeToString(secret)
+	}
+	sub.ID = uuid.New()
+	sub.CreatedAt = d.now()
+	if sub.Events == nil {
+		sub.Events = []notify.EventType{}
+	}
+	if err := d.store.CreateSubscription(ctx, sub); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Subscriptions lists a restaurant's subscriptions with secrets removed.
+func (d *Dispatcher) Subscriptions(ctx context.Context, restaurantID uuid.UUID) ([]Subscription, error) {
+	subs, err := d.store.ListSubscriptions(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+// Subscription returns one subscription with its secret removed.
+func (d *Dispatcher) Subscription(ctx context.Context, subscriptionID uuid.UUID) (Subscription, error) {
+	sub, err := d.store.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+// Delivery returns one logged delivery.
+func (d *Dispatcher) Delivery(ctx context.Context, deliveryID uuid.UUID) (Delivery, error) {
+	return d.store.GetDelivery(ctx, deliveryID)
+}
+
+func (d *Dispatcher) Unsubscribe(ctx context.Context, subscriptionID uuid.UUID) error {
+	return d.store.DeleteSubscription(ctx, subscriptionID)
+}
+
+// Deliveries returns the newest deliveries of a subscription first.
+func (d *Dispatcher) Deliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]Delivery, error) {
+	if _, err := d.store.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return d.store.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+// Notify makes the dispatcher usable wherever a notify event sink is
+// expected; events go to the webhooks of notification.RestaurantID.
+func (d *Dispatcher) Notify(ctx context.Context, notification notify.Notification) error {
+	if notification.RestaurantID == uuid.Nil {
+		return nil
+	}
+	return d.Publish(ctx, notification.RestaurantID, notification.Event, notification.Data)
+}
+
+// Publish queues the event for every subscription of the restaurant that wants it.
+func (d *Dispatcher) Publish(ctx context.Context, restaurantID uuid.UUID, event notify.EventType, data map[string]any) error {
+	if !subscribable(event) {
+		return nil
+	}
+	subs, err := d.store.ListSubscriptions(ctx, restaurantID)
+	if err != nil {
+		return err
+	}
+	var eventID uuid.UUID
+	var body []byte
+	for _, sub := range subs {
+		if !sub.Wants(event) {
+			continue
+		}
+		if body == nil {
+			eventID = uuid.New()
+			if body, err = json.Marshal(payload{ID: eventID, Event: event, CreatedAt: d.now(), Data: data}); err != nil {
+				return err
+			}
+		}
+		if err := d.store.EnqueueDelivery(ctx, d.newDelivery(sub.ID, eventID, event, body)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendTest delivers a WEBHOOK_TEST event right away and returns the log entry.
+func (d *Dispatcher) SendTest(ctx context.Context, subscriptionID uuid.UUID) (Delivery, error) {
+	sub, err := d.store.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	eventID := uuid.New()
+	body, err := json.Marshal(payload{ID: eventID, Event: EventTest, CreatedAt: d.now(), Data: map[string]any{"restaurant_id": sub.RestaurantID.String()}})
+	if err != nil {
+		return Delivery{}, err
+	}
+	delivery := d.newDelivery(sub.ID, eventID, EventTest, body)
+	if err := d.store.EnqueueDelivery(ctx, delivery); err != nil {
+		return Delivery{}, err
+	}
+	return d.attempt(ctx, sub, delivery)
+}
+
+// Redeliver sends the payload of an earlier delivery again as a new
+// delivery, keeping the event ID so receivers can deduplicate.
+func (d *Dispatcher) Redeliver(ctx context.Context, deliveryID uuid.UUID) (Delivery, error) {
+	previous, err := d.store.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	sub, err := d.store.GetSubscription(ctx, previous.SubscriptionID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	delivery := d.newDelivery(sub.ID, previous.EventID, previous.Event, previous.Payload)
+	if err := d.store.EnqueueDelivery(ctx, delivery); err != nil {
+		return Delivery{}, err
+	}
+	return d.attempt(ctx, sub, delivery)
+}
+
+// Process sends one batch of due deliveries and returns how many succeeded.
+func (d *Dispatcher) Process(ctx context.Context) (int, error) {
+	now := d.now()
+	deliveries, err := d.store.ClaimDeliveries(ctx, now, now.Add(DefaultLease), DefaultBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+		sub, err := d.store.GetSubscription(ctx, delivery.SubscriptionID)
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			// Unsubscribed meanwhile: nothing to deliver to.
+			delivery.Status = DeliveryDead
+			delivery.LastError = err.Error()
+			if err := d.store.UpdateDelivery(ctx, delivery); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+		if err != nil {
+			return delivered, err
+		}
+		result, err := d.attempt(ctx, sub, delivery)
+		if err != nil {
+			return delivered, err
+		}
+		if result.Status == DeliveryDelivered {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// Run drains due deliveries every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Process(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logging.FromContext(ctx).Error("webhook: process deliveries failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) newDelivery(subscriptionID, eventID uuid.UUID, event notify.EventType, body []byte) Delivery {
+	now := d.now()
+	return Delivery{
+		ID:             uuid.New(),
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		Event:          event,
+		Payload:        body,
+		Status:         DeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
+
+// attempt performs one POST and records its outcome on the delivery.
+func (d *Dispatcher) attempt(ctx context.Context, sub Subscription, delivery Delivery) (Delivery, error) {
+	delivery.Attempts++
+	code, body, sendErr := d.post(ctx, sub, delivery)
+	delivery.ResponseCode = code
+	delivery.ResponseBody = body
+
+	switch {
+	case sendErr == nil && code >= 200 && code < 300:
+		deliveredAt := d.now()
+		delivery.Status = DeliveryDelivered
+		delivery.DeliveredAt = &deliveredAt
+		delivery.LastError = ""
+	default:
+		if sendErr != nil {
+			delivery.LastError = sendErr.Error()
+		} else {
+			delivery.LastError = "unexpected status " + strconv.Itoa(code)
+		}
+		if delivery.Attempts >= d.maxAttempts {
+			delivery.Status = DeliveryDead
+		} else {
+			delivery.NextAttemptAt = d.now().Add(d.backoff(delivery.Attempts))
+		}
+	}
+	if err := d.store.UpdateDelivery(ctx, delivery); err != nil {
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+func (d *Dispatcher) post(ctx context.Context, sub Subscription, delivery Delivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "YAFDS-Webhooks/1")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+	return resp.StatusCode, string(excerpt), nil
+}
+
+// checkHost rejects hosts that are or resolve to addresses inside our
+// network, so a subscription cannot be used to reach internal services.
+func (d *Dispatcher) checkHost(ctx context.Context, host string) error {
+	if d.allowPrivate {
+		return nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s", ErrPrivateURL, host)
+		}
+		return nil
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%w: %s", ErrPrivateURL, host)
+	}
+	addrs, err := d.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve %s", ErrInvalidURL, host)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrPrivateURL, host, addr)
+		}
+	}
+	return nil
+}
+
+// checkDial is the dialer's Control hook; address is the resolved ip:port.
+func (d *Dispatcher) checkDial(network, address string, _ syscall.RawConn) error {
+	if d.allowPrivate {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateURL, addrPort.Addr())
+	}
+	return nil
+}
+
+// sharedAddressSpace is carrier-grade NAT, 100.64.0.0/10; like private
+// ranges it never belongs to a restaurant's public endpoint.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// backoff doubles from baseBackoff per failed attempt, capped at maxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.maxBackoff {
+			return d.maxBackoff
+		}
+	}
+	return delay
+}
+
+func subscribable(event notify.EventType) bool {
+	for _, e := range SubscribableEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,322 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/notify"
+
+	"github.com/google/uuid"
+)
+
+type memoryStore struct {
+	mu         sync.Mutex
+	subs       map[uuid.UUID]Subscription
+	deliveries map[uuid.UUID]Delivery
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{subs: map[uuid.UUID]Subscription{}, deliveries: map[uuid.UUID]Delivery{}}
+}
+
+func (s *memoryStore) CreateSubscription(ctx context.Context, sub Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs[sub.ID] = sub
+	return nil
+}
+
+func (s *memoryStore) GetSubscription(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (s *memoryStore) ListSubscriptions(ctx context.Context, restaurantID uuid.UUID) ([]Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subs []Subscription
+	for _, sub := range s.subs {
+		if sub.RestaurantID == restaurantID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (s *memoryStore) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[id]; !ok {
+		return ErrSubscriptionNotFound
+	}
+	delete(s.subs, id)
+	return nil
+}
+
+func (s *memoryStore) EnqueueDelivery(ctx context.Context, d Delivery) error {
+	return s.UpdateDelivery(ctx, d)
+}
+
+func (s *memoryStore) GetDelivery(ctx context.Context, id uuid.UUID) (Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+func (s *memoryStore) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Delivery
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *memoryStore) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Delivery
+	for id, d := range s.deliveries {
+		if d.Status == DeliveryPending && !d.NextAttemptAt.After(now) && len(out) < limit {
+			d.NextAttemptAt = leaseUntil
+			s.deliveries[id] = d
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) UpdateDelivery(ctx context.Context, d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+// receiver verifies signatures and answers with the next queued status.
+type receiver struct {
+	t        *testing.T
+	secret   string
+	now      func() time.Time
+	statuses []int
+	events   []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := Verify(rc.secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, rc.now(), DefaultTolerance); err != nil {
+		rc.t.Errorf("receiver: %v", err)
+	}
+	rc.events = append(rc.events, r.Header.Get(HeaderEvent))
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte("ok"))
+}
+
+func TestDispatcherRetriesUntilDelivered(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	rc := &receiver{t: t, now: clock, statuses: []int{http.StatusInternalServerError}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	store := newMemoryStore()
+	d := NewDispatcher(store)
+	d.now = clock
+	d.allowPrivate = true
+
+	restaurantID := uuid.New()
+	sub, err := d.Subscribe(ctx, Subscription{RestaurantID: restaurantID, URL: srv.URL, Events: []notify.EventType{notify.EventOrderPaid}})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	rc.secret = sub.Secret
+
+	if err := d.Notify(ctx, notify.Notification{Event: notify.EventOrderCancelled, UserID: restaurantID, RestaurantID: restaurantID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Notify(ctx, notify.Notification{Event: notify.EventOrderPaid, UserID: restaurantID, RestaurantID: restaurantID, Data: map[string]any{"order_id": "42"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := d.Process(ctx); err != nil || n != 0 {
+		t.Fatalf("first Process() = %d, %v; want failed attempt", n, err)
+	}
+	deliveries, _ := store.ListDeliveries(ctx, sub.ID, 0)
+	if len(deliveries) != 1 {
+		t.Fatalf("queued %d deliveries, want 1 (filtered event skipped)", len(deliveries))
+	}
+	first := deliveries[0]
+	if first.ResponseCode != http.StatusInternalServerError || first.Status != DeliveryPending {
+		t.Fatalf("after failure: code %d status %s", first.ResponseCode, first.Status)
+	}
+	if got := first.NextAttemptAt.Sub(now); got != DefaultBaseBackoff {
+		t.Fatalf("backoff = %v, want %v", got, DefaultBaseBackoff)
+	}
+
+	if n, _ := d.Process(ctx); n != 0 {
+		t.Fatalf("Process() before backoff delivered %d", n)
+	}
+	now = now.Add(DefaultBaseBackoff)
+	if n, err := d.Process(ctx); err != nil || n != 1 {
+		t.Fatalf("retry Process() = %d, %v; want 1", n, err)
+	}
+	delivered, _ := store.GetDelivery(ctx, first.ID)
+	if delivered.Status != DeliveryDelivered || delivered.Attempts != 2 || delivered.ResponseBody != "ok" {
+		t.Fatalf("delivered = %+v", delivered)
+	}
+
+	redelivered, err := d.Redeliver(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	if redelivered.ID == first.ID || redelivered.EventID != first.EventID || redelivered.Status != DeliveryDelivered {
+		t.Fatalf("redelivered = %+v", redelivered)
+	}
+	if len(rc.events) != 3 {
+		t.Fatalf("receiver saw %v, want three ORDER_PAID posts", rc.events)
+	}
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	store := newMemoryStore()
+	d := NewDispatcher(store)
+	d.now = clock
+	d.allowPrivate = true
+	d.maxAttempts = 3
+
+	sub, err := d.Subscribe(ctx, Subscription{RestaurantID: uuid.New(), URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test, err := d.SendTest(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	if test.Event != EventTest || test.ResponseCode != http.StatusGone || test.Attempts != 1 {
+		t.Fatalf("test delivery = %+v", test)
+	}
+	for i := 0; i < 5; i++ {
+		now = now.Add(DefaultMaxBackoff)
+		_, _ = d.Process(ctx)
+	}
+	dead, _ := store.GetDelivery(ctx, test.ID)
+	if dead.Status != DeliveryDead || dead.Attempts != 3 {
+		t.Fatalf("delivery = %s after %d attempts, want DEAD after 3", dead.Status, dead.Attempts)
+	}
+}
+
+func TestSubscribeValidation(t *testing.T) {
+	d := NewDispatcher(newMemoryStore())
+	ctx := context.Background()
+	if _, err := d.Subscribe(ctx, Subscription{URL: "ftp://pos.example"}); err != ErrInvalidURL {
+		t.Errorf("ftp url error = %v", err)
+	}
+	if _, err := d.Subscribe(ctx, Subscription{URL: "https://203.0.113.10/hook", Events: []notify.EventType{"MENU_CHANGED"}}); !errors.Is(err, ErrInvalidEvent) {
+		t.Errorf("unknown event error = %v", err)
+	}
+	if _, err := d.Subscribe(ctx, Subscription{URL: "https://203.0.113.10/hook", Events: []notify.EventType{notify.EventOrderStatusChanged}}); err != nil {
+		t.Errorf("public address error = %v", err)
+	}
+	for _, u := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.1.2.3/hook",
+		"http://192.168.0.10/hook",
+		"http://100.64.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		if _, err := d.Subscribe(ctx, Subscription{URL: u}); !errors.Is(err, ErrPrivateURL) {
+			t.Errorf("Subscribe(%s) error = %v, want ErrPrivateURL", u, err)
+		}
+	}
+}
+
+func TestDispatcherRefusesPrivateAddressAtDial(t *testing.T) {
+	ctx := context.Background()
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+
+	// Stored directly, as if the host resolved elsewhere at subscribe time.
+	store := newMemoryStore()
+	sub := Subscription{ID: uuid.New(), RestaurantID: uuid.New(), URL: srv.URL, Secret: "s"}
+	_ = store.CreateSubscription(ctx, sub)
+
+	d := NewDispatcher(store)
+	delivery, err := d.SendTest(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	if hit || delivery.ResponseCode != 0 || !strings.Contains(delivery.LastError, ErrPrivateURL.Error()) {
+		t.Fatalf("delivery = %+v, hit = %v; want refused before connecting", delivery, hit)
+	}
+}
+
+func TestNotifyUsesRestaurantID(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	d := NewDispatcher(store)
+	restaurantID, customerID := uuid.New(), uuid.New()
+	sub := Subscription{ID: uuid.New(), RestaurantID: restaurantID, URL: "https://203.0.113.10/hook"}
+	_ = store.CreateSubscription(ctx, sub)
+
+	if err := d.Notify(ctx, notify.Notification{Event: notify.EventOrderStatusChanged, UserID: customerID, RestaurantID: restaurantID}); err != nil {
+		t.Fatal(err)
+	}
+	deliveries, _ := store.ListDeliveries(ctx, sub.ID, 0)
+	if len(deliveries) != 1 || deliveries[0].Event != notify.EventOrderStatusChanged {
+		t.Fatalf("deliveries = %+v, want one ORDER_STATUS_CHANGED", deliveries)
+	}
+}
+
+func TestBackoffCapped(t *testing.T) {
+	d := NewDispatcher(newMemoryStore())
+	if got := d.backoff(3); got != 4*DefaultBaseBackoff {
+		t.Errorf("backoff(3) = %v", got)
+	}
+	if got := d.backoff(40); got != DefaultMaxBackoff {
+		t.Errorf("backoff(40) = %v", got)
+	}
+}
@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/notify"
+
+	"github.com/google/uuid"
+)
+
+// PostgresStore keeps subscriptions in WEBHOOK_SUBSCRIPTIONS and the delivery
+// queue/log in WEBHOOK_DELIVERIES. Secrets are stored as-is because every
+// delivery has to be signed with them.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const (
+	subscriptionColumns = `emp_id, restaurant_id, url, events, secret, created_at`
+	deliveryColumns     = `emp_id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, response_code, response_body, last_error, created_at, delivered_at`
+)
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (s *PostgresStore) CreateSubscription(ctx context.Context, sub Subscription) error {
+	if s.db == nil {
+		return errors.New("webhook: store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO WEBHOOK_SUBSCRIPTIONS (`+subscriptionColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		sub.ID, sub.RestaurantID, sub.URL, joinEvents(sub.Events), sub.Secret, sub.CreatedAt)
+	return err
+}
+
+func (s *PostgresStore) GetSubscription(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	if s.db == nil {
+		return Subscription{}, errors.New("webhook: store not initialized")
+	}
+	sub, err := scanSubscription(s.db.QueryRowContext(ctx, `SELECT `+subscriptionColumns+` FROM WEBHOOK_SUBSCRIPTIONS WHERE emp_id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+	return sub, err
+}
+
+func (s *PostgresStore) ListSubscriptions(ctx context.Context, restaurantID uuid.UUID) ([]Subscription, error) {
+	if s.db == nil {
+		return nil, errors.New("webhook: store not initialized")
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT `+subscriptionColumns+` FROM WEBHOOK_SUBSCRIPTIONS WHERE restaurant_id = $1 ORDER BY created_at`, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]Subscription, 0)
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (s *PostgresStore) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("webhook: store not initialized")
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM WEBHOOK_SUBSCRIPTIONS WHERE emp_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err == nil && rows == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return err
+}
+
+func (s *PostgresStore) EnqueueDelivery(ctx context.Context, d Delivery) error {
+	if s.db == nil {
+		return errors.New("webhook: store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO WEBHOOK_DELIVERIES (`+deliveryColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		d.ID, d.SubscriptionID, d.EventID, string(d.Event), []byte(d.Payload), string(d.Status), d.Attempts, d.NextAttemptAt,
+		d.ResponseCode, d.ResponseBody, d.LastError, d.CreatedAt, d.DeliveredAt)
+	return err
+}
+
+func (s *PostgresStore) GetDelivery(ctx context.Context, id uuid.UUID) (Delivery, error) {
+	if s.db == nil {
+		return Delivery{}, errors.New("webhook: store not initialized")
+	}
+	d, err := scanDelivery(s.db.QueryRowContext(ctx, `SELECT `+deliveryColumns+` FROM WEBHOOK_DELIVERIES WHERE emp_id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	return d, err
+}
+
+func (s *PostgresStore) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]Delivery, error) {
+	if s.db == nil {
+		return nil, errors.New("webhook: store not initialized")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT `+deliveryColumns+` FROM WEBHOOK_DELIVERIES WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT $2`,
+		subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
+
+func (s *PostgresStore) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Delivery, error) {
+	if s.db == nil {
+		return nil, errors.New("webhook: store not initialized")
+	}
+	const query = `
+		UPDATE WEBHOOK_DELIVERIES SET next_attempt_at = $1
+		WHERE emp_id IN (
+			SELECT emp_id FROM WEBHOOK_DELIVERIES
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+	rows, err := s.db.QueryContext(ctx, query, leaseUntil, string(DeliveryPending), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
+
+func (s *PostgresStore) UpdateDelivery(ctx context.Context, d Delivery) error {
+	if s.db == nil {
+		return errors.New("webhook: store not initialized")
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE WEBHOOK_DELIVERIES
+		SET status = $1, attempts = $2, next_attempt_at = $3, response_code = $4, response_body = $5, last_error = $6, delivered_at = $7
+		WHERE emp_id = $8`,
+		string(d.Status), d.Attempts, d.NextAttemptAt, d.ResponseCode, d.ResponseBody, d.LastError, d.DeliveredAt, d.ID)
+	return err
+}
+
+func collectDeliveries(rows *sql.Rows) ([]Delivery, error) {
+	defer rows.Close()
+	deliveries := make([]Delivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func scanSubscription(row rowScanner) (Subscription, error) {
+	var (
+		sub    Subscription
+		events string
+	)
+	if err := row.Scan(&sub.ID, &sub.RestaurantID, &sub.URL, &events, &sub.Secret, &sub.CreatedAt); err != nil {
+		return Subscription{}, err
+	}
+	sub.Events = splitEvents(events)
+	return sub, nil
+}
+
+func scanDelivery(row rowScanner) (Delivery, error) {
+	var (
+		d             Delivery
+		event, status string
+		payload       []byte
+		deliveredAt   sql.NullTime
+	)
+	if err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &event, &payload, &status, &d.Attempts, &d.NextAttemptAt,
+		&d.ResponseCode, &d.ResponseBody, &d.LastError, &d.CreatedAt, &deliveredAt); err != nil {
+		return Delivery{}, err
+	}
+	d.Event = notify.EventType(event)
+	d.Status = DeliveryStatus(status)
+	d.Payload = payload
+	if deliveredAt.Valid {
+		t := deliveredAt.Time
+		d.DeliveredAt = &t
+	}
+	return d, nil
+}
+
+func joinEvents(events []notify.EventType) string {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, string(e))
+	}
+	return strings.Join(names, ",")
+}
+
+func splitEvents(s string) []notify.EventType {
+	events := make([]notify.EventType, 0)
+	for _, name := range strings.Split(s, ",") {
+		if name != "" {
+			events = append(events, notify.EventType(name))
+		}
+	}
+	return events
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-YAFDS-Event"
+	HeaderDelivery  = "X-YAFDS-Delivery"
+	HeaderTimestamp = "X-YAFDS-Timestamp"
+	HeaderSignature = "X-YAFDS-Signature"
+
+	// DefaultTolerance is how old a signed request receivers should accept.
+	DefaultTolerance = 5 * time.Minute
+)
+
+// Sign returns "sha256=<hex>" of HMAC-SHA256(secret, "<timestamp>.<body>").
+// Binding the timestamp stops replays of captured requests.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature the way a receiving POS should.
+func Verify(secret, timestampHeader, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(strings.TrimSpace(timestampHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("webhook: bad timestamp: %w", err)
+	}
+	ts := time.Unix(unix, 0)
+	if d := now.Sub(ts); d > tolerance || d < -tolerance {
+		return fmt.Errorf("webhook: timestamp outside tolerance")
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return fmt.Errorf("webhook: signature mismatch")
+	}
+	return nil
+}
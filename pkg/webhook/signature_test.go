@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"event":"ORDER_PAID"}`)
+	sig := Sign("whsec_test", now, body)
+	ts := "1792411200"
+
+	tests := []struct {
+		name    string
+		secret  string
+		ts      string
+		body    []byte
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", secret: "whsec_test", ts: ts, body: body, now: now},
+		{name: "within tolerance", secret: "whsec_test", ts: ts, body: body, now: now.Add(DefaultTolerance)},
+		{name: "too old", secret: "whsec_test", ts: ts, body: body, now: now.Add(DefaultTolerance + time.Second), wantErr: true},
+		{name: "wrong secret", secret: "whsec_other", ts: ts, body: body, now: now, wantErr: true},
+		{name: "tampered body", secret: "whsec_test", ts: ts, body: []byte(`{"event":"ORDER_CANCELLED"}`), now: now, wantErr: true},
+		{name: "bad timestamp", secret: "whsec_test", ts: "yesterday", body: body, now: now, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.ts, sig, tt.body, tt.now, DefaultTolerance)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
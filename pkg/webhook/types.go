@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Kabanya/YAFDS/pkg/notify"
+
+	"github.com/google/uuid"
+)
+
+// EventTest is sent by the "send test event" endpoint only.
+const EventTest notify.EventType = "WEBHOOK_TEST"
+
+// SubscribableEvents are the order events a restaurant can filter on.
+var SubscribableEvents = []notify.EventType{
+	notify.EventOrderPaid,
+	notify.EventOrderReleased,
+	notify.EventOrderCancelled,
+	notify.EventOrderStatusChanged,
+}
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook: subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook: delivery not found")
+	ErrInvalidURL           = errors.New("webhook: url must be absolute http(s)")
+	ErrPrivateURL           = errors.New("webhook: url must point to a public address")
+	ErrInvalidEvent         = errors.New("webhook: unknown event")
+)
+
+// Subscription sends matching events of one restaurant to URL. An empty
+// Events list subscribes to everything.
+type Subscription struct {
+	ID           uuid.UUID          `json:"id"`
+	RestaurantID uuid.UUID          `json:"restaurant_id"`
+	URL          string             `json:"url"`
+	Events       []notify.EventType `json:"events"`
+	Secret       string             `json:"secret,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+func (s Subscription) Wants(event notify.EventType) bool {
+	if event == EventTest || len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliveryDelivered DeliveryStatus = "DELIVERED"
+	DeliveryDead      DeliveryStatus = "DEAD"
+)
+
+// Delivery is one attempt series of an event to one subscription. It doubles
+// as the delivery log: the last response code, body excerpt and error stay
+// on the row.
+type Delivery struct {
+	ID             uuid.UUID        `json:"id"`
+	SubscriptionID uuid.UUID        `json:"subscription_id"`
+	EventID        uuid.UUID        `json:"event_id"`
+	Event          notify.EventType `json:"event"`
+	Payload        json.RawMessage  `json:"payload"`
+	Status         DeliveryStatus   `json:"status"`
+	Attempts       int              `json:"attempts"`
+	NextAttemptAt  time.Time        `json:"next_attempt_at"`
+	ResponseCode   int              `json:"response_code,omitempty"`
+	ResponseBody   string           `json:"response_body,omitempty"`
+	LastError      string           `json:"last_error,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
+}
+
+// Store persists subscriptions and deliveries.
+type Store interface {
+	CreateSubscription(ctx context.Context, sub Subscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (Subscription, error)
+	ListSubscriptions(ctx context.Context, restaurantID uuid.UUID) ([]Subscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+
+	EnqueueDelivery(ctx context.Context, delivery Delivery) error
+	GetDelivery(ctx context.Context, id uuid.UUID) (Delivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]Delivery, error)
+	// ClaimDeliveries leases due PENDING deliveries until leaseUntil.
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, delivery Delivery) error
+}
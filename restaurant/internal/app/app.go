@@ -1,180 +1,318 @@
-// 0. на go структру конфига, который будет парсить переменные среды.
-// Три разных комплекта перемных среды. .env1
-// docker - единица деплоя
-
 package app
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
-	"net/http"
 	"os"
 	"strconv"
-	"time"
 
 	"restaurant/internal/repository"
 	"restaurant/internal/service"
 	"restaurant/internal/usecase"
 
-	"github.com/Kabanya/YAFDS/pkg/utils"
+	orderapp "github.com/Kabanya/YAFDS/pkg/app"
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/config"
+	"github.com/Kabanya/YAFDS/pkg/logging"
+	"github.com/Kabanya/YAFDS/pkg/metrics"
+	"github.com/Kabanya/YAFDS/pkg/notify"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+	orderrepo "github.com/Kabanya/YAFDS/pkg/repository"
+	"github.com/Kabanya/YAFDS/pkg/router"
+	"github.com/Kabanya/YAFDS/pkg/server"
+	"github.com/Kabanya/YAFDS/pkg/tracing"
+	orderusecase "github.com/Kabanya/YAFDS/pkg/usecase"
+	"github.com/Kabanya/YAFDS/pkg/user"
+	"github.com/Kabanya/YAFDS/pkg/webhook"
 
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 )
 
-func Run() {
-	utils.InitFileLogger("restaurant_log_info.txt")
-	logger, err := utils.Logger()
+func Run() error {
+	var cfg Config
+	cfgErr := config.Load(&cfg, config.Options{})
+	if config.PrintRequested(os.Args[1:]) {
+		config.Fprint(os.Stdout, &cfg)
+		return cfgErr
+	}
+	if openapi.PrintRequested(os.Args[1:]) {
+		return openapi.Fprint(os.Stdout, apiDocument())
+	}
+
+	if cfgErr != nil {
+		return cfgErr
+	}
+
+	logger, closeLog, err := logging.Setup(cfg.Log)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+		return err
 	}
-	logger.Println("restaurant service started")
+	defer closeLog()
 
-	// Load environment variables from .env
-	err = utils.LoadEnv(utils.PathToEnv)
+	closeTracing, err := tracing.Setup(cfg.Trace, "restaurant")
 	if err != nil {
-		logger.Printf("Failed to load .env file: %v", err)
-		panic(err)
+		return err
 	}
+	defer closeTracing()
+	logger.Info("restaurant service started")
 
 	// Connection to db
-	dbName := os.Getenv("RESTAURANT_DB")
-	if dbName == "" {
-		dbName = "restaurant_db"
-	}
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), dbName)
-	db, err := sql.Open("postgres", connStr)
+	db, err := tracing.Open("postgres", cfg.Postgres.DSN(cfg.DBName), "restaurant_db")
 	if err != nil {
-		logger.Printf("Failed to open database: %v", err)
-		panic(err)
+		logger.Error("Failed to open database", "error", err)
+		return err
 	}
 	defer db.Close()
 
 	err = db.Ping()
 	if err != nil {
-		logger.Printf("Failed to ping database: %v", err)
-		panic(err)
+		logger.Error("Failed to ping database", "error", err)
+		return err
 	}
-	logger.Println("Successfully connected to database")
+	logger.Info("Successfully connected to database")
 
-	ordersDBName := os.Getenv("ORDER_DB")
-	if ordersDBName == "" {
-		ordersDBName = "order_db"
-	}
-	ordersConnStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), ordersDBName)
-	ordersDB, err := sql.Open("postgres", ordersConnStr)
+	ordersDB, err := tracing.Open("postgres", cfg.Postgres.DSN(cfg.OrdersDB), "order_db")
 	if err != nil {
-		logger.Printf("Failed to open orders database: %v", err)
-		panic(err)
+		logger.Error("Failed to open orders database", "error", err)
+		return err
 	}
 	defer ordersDB.Close()
 
 	if err := ordersDB.Ping(); err != nil {
-		logger.Printf("Failed to ping orders database: %v", err)
-		panic(err)
+		logger.Error("Failed to ping orders database", "error", err)
+		return err
 	}
-	logger.Println("Successfully connected to orders database")
-
-	userRepository := repository.NewUser(db)
-	logger.Println("Initialized user repository")
+	logger.Info("Successfully connected to orders database")
 
 	restaurantMenuItemsRepo := repository.NewRestaurantMenuItemsRepo(db)
-	logger.Println("Initialized restaurant menu items repository")
+	logger.Info("Initialized restaurant menu items repository")
 
 	ordersRepository := repository.NewOrdersRepo(ordersDB, db)
-	logger.Println("Initialized orders repository")
+	logger.Info("Initialized orders repository")
 
-	redisDB := 0
-	if redisDBStr := os.Getenv("REDIS_DB"); redisDBStr != "" {
-		if parsed, err := strconv.Atoi(redisDBStr); err == nil {
-			redisDB = parsed
-		} else {
-			logger.Printf("Invalid REDIS_DB value '%s', defaulting to 0: %v", redisDBStr, err)
-		}
-	}
+	scheduleRepository := repository.NewScheduleRepo(db)
+	logger.Info("Initialized schedule repository")
 
 	redisClient := redis.NewClient(&redis.Options{
-		Addr:     os.Getenv("REDIS_ADDRESS"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-		DB:       redisDB,
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
 	})
 	if err := redisClient.Ping(context.Background()).Err(); err != nil {
-		logger.Printf("Failed to connect to Redis: %v", err)
-		panic(err)
+		logger.Error("Failed to connect to Redis", "error", err)
+		return err
 	}
 	defer redisClient.Close()
-	logger.Println("Successfully connected to Redis")
-
-	sessionTTL := utils.TimeTtl30Minutes
-	if ttlStr := os.Getenv("SESSION_TTL"); ttlStr != "" {
-		var parsed time.Duration
-		if d, err := time.ParseDuration(ttlStr); err == nil {
-			parsed = d
-		} else if sec, err := strconv.ParseInt(ttlStr, 10, 64); err == nil {
-			parsed = time.Duration(sec) * time.Second
+	logger.Info("Successfully connected to Redis")
+
+	health := server.NewHealth().
+		Add("restaurant_db", server.PingDB(db)).
+		Add("order_db", server.PingDB(ordersDB)).
+		Add("redis", server.PingRedis(redisClient))
+
+	metrics.InstrumentDB("restaurant_db", db)
+	metrics.InstrumentDB("order_db", ordersDB)
+	metrics.InstrumentRedis(redisClient)
+
+	port := strconv.Itoa(cfg.Port)
+	addr := ":" + port
+	routes := router.New()
+	doc := apiDocument()
+	srv := server.New(addr, router.Chain(routes,
+		logging.Middleware,
+		router.CORS(cfg.HTTP.AllowedOrigins()),
+		router.LimitBody(cfg.HTTP.MaxBodyBytes),
+		tracing.Middleware,
+		metrics.Middleware,
+		openapi.Validate(doc, cfg.OpenAPI),
+		router.Recover,
+	), cfg.HTTP).WithHealth(health)
+
+	passwordPolicy := auth.DefaultPasswordPolicy
+	passwordPolicy.MinLength = cfg.Auth.PasswordMinLength
+	if breachedPath := cfg.Auth.BreachedPasswordsFile; breachedPath != "" {
+		breached, err := auth.LoadBreachedPasswords(breachedPath)
+		if err != nil {
+			logger.Warn("Breached password check disabled", "error", err)
 		} else {
-			logger.Printf("Invalid SESSION_TTL '%s', using default %v: %v", ttlStr, sessionTTL, err)
-			parsed = 0
+			passwordPolicy.Breached = breached
+			logger.Info("Loaded breached passwords", "count", breached.Len(), "file", breachedPath)
 		}
-		if parsed > 0 {
-			sessionTTL = parsed
+	}
+
+	// Opaque Redis sessions by default; with AUTH_KEYS_DIR the services issue
+	// signed access tokens that any of them can verify with the shared keys.
+	var sessionManager auth.SessionManager = auth.NewRedisSessionManager(redisClient, "restaurant")
+	var signingKeys *auth.KeySet
+	if keysDir := cfg.Auth.KeysDir; keysDir != "" {
+		keys, err := auth.LoadOrCreateKeySet(keysDir)
+		if err != nil {
+			logger.Error("Failed to load signing keys", "dir", keysDir, "error", err)
+			return err
+		}
+		jwtSessions, err := auth.NewJWTSessionManager(auth.JWTConfig{
+			Keys:    keys,
+			Refresh: auth.NewRedisRefreshStore(redisClient),
+			Issuer:  "yafds-restaurant",
+			Role:    "restaurant",
+		})
+		if err != nil {
+			logger.Error("Failed to initialize token sessions", "error", err)
+			return err
 		}
+		sessionManager, signingKeys = jwtSessions, keys
+		logger.Info("Sessions: signed access tokens", "keys_dir", keysDir)
 	}
-	if sessionTTL <= 0 {
-		logger.Printf("SESSION_TTL must be positive, using default %v", utils.TimeTtl30Minutes)
-		sessionTTL = utils.TimeTtl30Minutes
+
+	// Integrations such as POS systems authenticate with scoped API keys;
+	// the restaurant's own sessions work on the same endpoints.
+	apiKeys := auth.NewAPIKeys(auth.NewPostgresAPIKeyStore(db), auth.NewRedisRateLimiter(redisClient))
+	authn := auth.NewAuthenticator(sessionManager, apiKeys)
+
+	var smtpChannel *notify.SMTPChannel
+	if cfg.SMTP.Addr != "" {
+		smtpChannel = notify.NewSMTPChannel(notify.SMTPConfig{
+			Addr:     cfg.SMTP.Addr,
+			From:     cfg.SMTP.From,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+		})
+	}
+	// Reset tokens go out only by email, to the recovery email the user
+	// confirmed; without SMTP both flows stay off instead of leaving tokens
+	// in logs or sink files.
+	var mailer user.Mailer
+	if smtpChannel != nil {
+		mailer = notify.NewAccountMailer(notify.NewPostgresPreferences(ordersDB), nil, smtpChannel)
+	} else {
+		logger.Warn("Password reset and recovery email disabled: SMTP_ADDR not set")
 	}
 
-	userService := service.NewUserService(userRepository, redisClient, sessionTTL)
-	logger.Println("Initialized user service")
+	userService, err := user.NewService(user.Config{
+		Schema:     restaurantSchema,
+		DB:         db,
+		Redis:      redisClient,
+		Sessions:   sessionManager,
+		SessionTTL: cfg.Auth.SessionTTL,
+		Validator:  auth.NewPolicyValidator(passwordPolicy),
+		Mailer:     mailer,
+		MFAStore:   auth.NewPostgresMFAStore(db, "RESTAURANT_MFA"),
+		MFAIssuer:  "YAFDS Restaurant",
+	})
+	if err != nil {
+		logger.Error("Failed to initialize user service", "error", err)
+		return err
+	}
+	logger.Info("Initialized user service")
 
 	restaurantMenuItemsService := service.NewRestaurantMenuItemsService(restaurantMenuItemsRepo)
-	logger.Println("Initialized restaurant menu items service")
+	logger.Info("Initialized restaurant menu items service")
 
-	ordersService := service.NewOrdersService(ordersRepository)
-	logger.Println("Initialized orders service")
+	ordersService := service.NewOrdersService(ordersRepository, restaurantMenuItemsRepo)
+	logger.Info("Initialized orders service")
 
-	userUseCase := usecase.NewUserUseCase(userService)
-	logger.Println("Initialized user usecase")
+	scheduleService := service.NewScheduleService(scheduleRepository)
+	logger.Info("Initialized schedule service")
 
 	restaurantMenuItemsUseCase := usecase.NewRestaurantMenuItemsUseCase(restaurantMenuItemsService)
-	logger.Println("Initialized restaurant menu items usecase")
+	logger.Info("Initialized restaurant menu items usecase")
 
 	ordersUseCase := usecase.NewOrdersUseCase(ordersService)
-	logger.Println("Initialized orders usecase")
+	logger.Info("Initialized orders usecase")
 
-	handler := NewHandler(userUseCase, restaurantMenuItemsUseCase, ordersUseCase)
-	logger.Println("Initialized handler")
+	scheduleUseCase := usecase.NewScheduleUseCase(scheduleService)
+	logger.Info("Initialized schedule usecase")
 
-	// registry endpoints
-	http.HandleFunc("/health", handler.Health)
-	http.HandleFunc("/register", handler.Register)
-	http.HandleFunc("/login", handler.Login)
-	http.HandleFunc("/orders", handler.ListOrders)
-	http.HandleFunc("/menu/show", handler.ShowMenuItems)
-	http.HandleFunc("/menu/upload", handler.UploadMenuItem)
+	// Replies only touch REVIEWS, so the shared orders repository needs no customer/courier DBs here.
+	reviewUseCase := orderusecase.NewReviewUseCase(orderrepo.NewPostgresRepository(ordersDB, nil, nil), orderrepo.NewReviewRepository(ordersDB))
+	logger.Info("Initialized review usecase")
+
+	// Deliveries are queued and retried by the customer service, which emits
+	// the order events; here the dispatcher only manages subscriptions and
+	// sends test events and redeliveries synchronously.
+	webhookDispatcher := webhook.NewDispatcher(webhook.NewPostgresStore(ordersDB))
+	logger.Info("Initialized webhook dispatcher")
 
-	port := os.Getenv("RESTAURANT_PORT")
-	if port == "" {
-		port = "8092"
+	handler := NewHandler(restaurantMenuItemsUseCase, ordersUseCase, scheduleUseCase)
+	logger.Info("Initialized handler")
+
+	// registry endpoints
+	health.Mount(routes)
+	routes.Handle("GET /metrics", metrics.Handler())
+	routes.Handle("GET /openapi.json", doc.Handler())
+	routes.Handle("GET /docs", doc.DocsHandler("/openapi.json"))
+	routes.Handle("GET /docs/{file}", openapi.DocsAssetsHandler())
+	user.NewHandler(userService).Mount(routes)
+	if signingKeys != nil {
+		routes.HandleFunc("GET /.well-known/jwks.json", orderapp.NewJWKSHandler(signingKeys))
 	}
-	addr := ":" + port
-	logger.Println("Endpoints registered:")
-	logger.Printf("  POST http://localhost:%s/register - Register user with password", port)
-	logger.Printf("  POST http://localhost:%s/login - Login user with password", port)
-	logger.Printf("  GET  http://localhost:%s/orders?restaurant_id=<uuid> - List restaurant orders", port)
-	logger.Printf("  GET  http://localhost:%s/menu/show?restaurant_id=<uuid> - Show menu items", port)
-	logger.Printf("  POST http://localhost:%s/menu/upload - Upload menu item", port)
-	logger.Printf("Starting HTTP server on %s", addr)
-
-	err = http.ListenAndServe(addr, nil)
+	apiKeysHandler := authn.RequireSession(orderapp.NewAPIKeysHandler(apiKeys))
+	routes.HandleFunc("GET /api-keys", apiKeysHandler)
+	routes.HandleFunc("POST /api-keys", apiKeysHandler)
+	routes.HandleFunc("DELETE /api-keys/{key_id}", authn.RequireSession(orderapp.NewRevokeAPIKeyHandler(apiKeys)))
+	routes.HandleFunc("GET /orders", authn.Require(auth.ScopeOrdersRead, handler.ListOrders))
+	routes.HandleFunc("POST /orders/{order_id}/reserve", auth.RequireServiceToken(cfg.Auth.ServiceToken, handler.ReserveStock))
+	routes.HandleFunc("POST /orders/cancelled", auth.RequireServiceToken(cfg.Auth.ServiceToken, handler.OrderCancelled))
+	routes.HandleFunc("GET /menu/show", handler.ShowMenuItems)
+	routes.HandleFunc("POST /menu/upload", authn.Require(auth.ScopeMenuWrite, handler.UploadMenuItem))
+	schedule := authn.RequireForWrites(auth.ScopeScheduleWrite, handler.Schedule)
+	routes.HandleFunc("GET /schedule", schedule)
+	routes.HandleFunc("POST /schedule", schedule)
+	routes.HandleFunc("GET /reviews", orderapp.NewReviewsHandler(reviewUseCase))
+	routes.HandleFunc("POST /reviews/reply", authn.Require(auth.ScopeReviewsWrite, orderapp.NewReviewReplyHandler(reviewUseCase)))
+	preferences := orderapp.NewNotificationPreferencesHandler(notify.NewPostgresPreferences(ordersDB))
+	routes.HandleFunc("GET /notifications/preferences", authn.RequireSession(preferences))
+	routes.HandleFunc("PUT /notifications/preferences", authn.RequireSession(preferences))
+	webhooks := authn.Require(auth.ScopeWebhooksWrite, orderapp.NewWebhooksHandler(webhookDispatcher))
+	routes.HandleFunc("GET /webhooks", webhooks)
+	routes.HandleFunc("POST /webhooks", webhooks)
+	routes.HandleFunc("DELETE /webhooks/{webhook_id}", authn.Require(auth.ScopeWebhooksWrite, orderapp.NewDeleteWebhookHandler(webhookDispatcher)))
+	routes.HandleFunc("POST /webhooks/{webhook_id}/test", authn.Require(auth.ScopeWebhooksWrite, orderapp.NewTestWebhookHandler(webhookDispatcher)))
+	routes.HandleFunc("GET /webhooks/{webhook_id}/deliveries", authn.Require(auth.ScopeWebhooksWrite, orderapp.NewWebhookDeliveriesHandler(webhookDispatcher)))
+	routes.HandleFunc("POST /webhooks/deliveries/{delivery_id}/redeliver", authn.Require(auth.ScopeWebhooksWrite, orderapp.NewRedeliverHandler(webhookDispatcher)))
+	for _, pattern := range doc.Missing(routes.Patterns()) {
+		logger.Warn("Route missing from the OpenAPI document", "route", pattern)
+	}
+
+	logger.Debug("Endpoint", "route", "GET /livez", "description", "Process is up (also GET /health)")
+	logger.Debug("Endpoint", "route", "GET /readyz", "description", "Dependency status and latencies")
+	logger.Debug("Endpoint", "route", "GET /metrics", "description", "Prometheus metrics")
+	logger.Debug("Endpoint", "route", "GET /openapi.json", "description", "OpenAPI document")
+	logger.Debug("Endpoint", "route", "GET /docs", "description", "API documentation page")
+	logger.Debug("Endpoint", "route", "POST /register", "description", "Register user with password")
+	logger.Debug("Endpoint", "route", "POST /login", "description", "Login user with password")
+	logger.Debug("Endpoint", "route", "POST /login/mfa", "description", "Finish a two-factor login with mfa_token and code")
+	logger.Debug("Endpoint", "route", "POST /mfa/enroll", "description", "Start two-factor setup (Authorization: Bearer <token>)")
+	logger.Debug("Endpoint", "route", "POST /mfa/confirm", "description", "Enable two-factor login with a first code")
+	logger.Debug("Endpoint", "route", "POST /mfa/disable", "description", "Disable two-factor login with a code")
+	logger.Debug("Endpoint", "route", "POST /token/refresh", "description", "Exchange a refresh token (AUTH_KEYS_DIR only)")
+	logger.Debug("Endpoint", "route", "POST /password/change", "description", "Change password (Authorization: Bearer <token>)")
+	logger.Debug("Endpoint", "route", "POST /password/reset", "description", "Request a password reset token")
+	logger.Debug("Endpoint", "route", "POST /password/reset/confirm", "description", "Set a new password with a reset token")
+	logger.Debug("Endpoint", "route", "GET/POST /api-keys", "description", "List or create API keys (session only)")
+	logger.Debug("Endpoint", "route", "DELETE /api-keys/{key_id}", "description", "Revoke an API key (session only)")
+	logger.Debug("Guarded endpoints take Authorization: Bearer <session token or API key>, or X-API-Key")
+	logger.Debug("Endpoint", "route", "GET /orders?restaurant_id=<uuid>", "description", "List restaurant orders (orders:read)")
+	logger.Debug("Endpoint", "route", "POST /orders/{order_id}/reserve", "description", "Reserve stock of an accepted order (X-Service-Token)")
+	logger.Debug("Endpoint", "route", "POST /orders/cancelled", "description", "Customer cancellation notice (X-Service-Token)")
+	logger.Debug("Endpoint", "route", "GET /menu/show?restaurant_id=<uuid>", "description", "Show menu items")
+	logger.Debug("Endpoint", "route", "POST /menu/upload", "description", "Upload menu item (menu:write)")
+	logger.Debug("Endpoint", "route", "GET/POST /schedule", "description", "Show/replace opening hours and slot settings (POST: schedule:write)")
+	logger.Debug("Endpoint", "route", "GET /reviews?restaurant_id=<uuid>", "description", "List restaurant reviews")
+	logger.Debug("Endpoint", "route", "POST /reviews/reply", "description", "Reply to a review (reviews:write)")
+	logger.Debug("Endpoint", "route", "GET/PUT /notifications/preferences", "description", "Notification contacts and channels (instead of polling /orders; session only)")
+	logger.Debug("Endpoint", "route", "GET/POST /webhooks", "description", "List (?restaurant_id=<uuid>) or create webhook subscriptions (webhooks:write)")
+	logger.Debug("Endpoint", "route", "DELETE /webhooks/{webhook_id}", "description", "Delete webhook subscription")
+	logger.Debug("Endpoint", "route", "POST /webhooks/{webhook_id}/test", "description", "Send test event")
+	logger.Debug("Endpoint", "route", "GET /webhooks/{webhook_id}/deliveries", "description", "Delivery log")
+	logger.Debug("Endpoint", "route", "POST /webhooks/deliveries/{delivery_id}/redeliver", "description", "Redeliver an event")
+	logger.Info("Starting HTTP server", "addr", addr)
+
+	err = srv.Run(context.Background())
 	if err != nil {
-		logger.Printf("Server error: %v", err)
+		logger.Error("Server error", "error", err)
 	}
 
-	logger.Println("Process of restaurant is finished")
-	utils.CloseLogger()
+	logger.Info("Process of restaurant is finished")
+	return err
 }
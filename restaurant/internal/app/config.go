@@ -0,0 +1,20 @@
+package app
+
+import "github.com/Kabanya/YAFDS/pkg/config"
+
+// Config is everything the restaurant service reads at startup; run the
+// server with --print-config to see the values it would use.
+type Config struct {
+	Port     int    `env:"RESTAURANT_PORT" default:"8092" min:"1" max:"65535"`
+	DBName   string `env:"RESTAURANT_DB" default:"restaurant_db"`
+	OrdersDB string `env:"ORDER_DB" default:"order_db"`
+
+	Postgres config.Postgres
+	SMTP     config.SMTP
+	Redis    config.Redis
+	Auth     config.Auth
+	HTTP     config.HTTP
+	Log      config.Log
+	Trace    config.Trace
+	OpenAPI  config.OpenAPI
+}
@@ -1,173 +1,52 @@
 package app
 
 import (
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"restaurant/internal/repository"
+	"restaurant/internal/service"
 	"restaurant/internal/usecase"
-	"restaurant/models"
 
-	"github.com/Kabanya/YAFDS/pkg/id"
+	"github.com/Kabanya/YAFDS/pkg/auth"
+	"github.com/Kabanya/YAFDS/pkg/logging"
 	pkgmodels "github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/router"
+	pkgusecase "github.com/Kabanya/YAFDS/pkg/usecase"
 	"github.com/Kabanya/YAFDS/pkg/utils"
+
+	"github.com/google/uuid"
 )
 
 const TransportType = "HTTP"
 
 type Handler struct {
-	userUseCase                usecase.UserUseCase
 	restaurantMenuItemsUseCase usecase.RestaurantMenuItemsUseCase
 	ordersUseCase              usecase.OrdersUseCase
+	scheduleUseCase            usecase.ScheduleUseCase
 }
 
-func NewHandler(userUC usecase.UserUseCase, menuItemsUC usecase.RestaurantMenuItemsUseCase, ordersUC usecase.OrdersUseCase) *Handler {
-	return &Handler{
-		userUseCase:                userUC,
-		restaurantMenuItemsUseCase: menuItemsUC,
-		ordersUseCase:              ordersUC,
-	}
+type uploadMenuItemResponse struct {
+	Message     string    `json:"message"`
+	OrderItemID uuid.UUID `json:"order_item_id"`
 }
 
-// Health check endpoint
-func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
-	utils.WriteJSON(w, map[string]string{"status": "UP"}, http.StatusOK)
+type orderCancelledResponse struct {
+	OrderID uuid.UUID `json:"order_id"`
+	Status  string    `json:"status"`
 }
 
-// Register user with password
-func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
-	logger, _ := utils.Logger()
-	logger.Println("Register called")
-
-	// CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	if r.Method != http.MethodPost {
-		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var req models.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// Validate required fields
-	if req.WalletAddress == "" {
-		utils.WriteError(w, "wallet_address is required", http.StatusBadRequest)
-		return
-	}
-
-	if req.Password == "" {
-		utils.WriteError(w, "password is required", http.StatusBadRequest)
-		return
-	}
-
-	if req.Name == "" {
-		utils.WriteError(w, "name is required", http.StatusBadRequest)
-		return
-	}
-
-	if req.Address == "" {
-		utils.WriteError(w, "address is required", http.StatusBadRequest)
-		return
-	}
-
-	// Derive deterministic ID from wallet to keep seeded data stable across runs
-	userID := id.FromWallet(req.WalletAddress)
-
-	// Register user with password
-	err := h.userUseCase.Register(userID, req.Name, req.WalletAddress, req.Address, req.IsActive, req.Password)
-	if err != nil {
-		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	utils.WriteJSON(w, models.RegisterResponce{Id: userID}, http.StatusCreated)
-	logger.Printf("User %s registered successfully", req.WalletAddress)
-}
-
-// Login user with password
-func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
-	logger, _ := utils.Logger()
-	logger.Println("Login called")
-
-	// CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	if r.Method != http.MethodPost {
-		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var req models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	if req.WalletAddress == "" {
-		utils.WriteError(w, "wallet_address is required", http.StatusBadRequest)
-		return
-	}
-
-	if req.Password == "" {
-		utils.WriteError(w, "password is required", http.StatusBadRequest)
-		return
-	}
-
-	// Authenticate user and issue session token
-	loginResp, err := h.userUseCase.Login(req.WalletAddress, req.Password)
-	if err != nil {
-		statusCode := http.StatusInternalServerError
-		message := "internal server error"
-		if errors.Is(err, models.ErrInvalidCredentials) || errors.Is(err, sql.ErrNoRows) {
-			statusCode = http.StatusUnauthorized
-			message = "invalid wallet address or password"
-		}
-		utils.WriteError(w, message, statusCode)
-		logger.Printf("Login failed for user: %s, error: %v", req.WalletAddress, err)
-		return
+func NewHandler(menuItemsUC usecase.RestaurantMenuItemsUseCase, ordersUC usecase.OrdersUseCase, scheduleUC usecase.ScheduleUseCase) *Handler {
+	return &Handler{
+		restaurantMenuItemsUseCase: menuItemsUC,
+		ordersUseCase:              ordersUC,
+		scheduleUseCase:            scheduleUC,
 	}
-
-	utils.WriteJSON(w, loginResp, http.StatusOK)
-	logger.Printf("User %s logged in successfully", req.WalletAddress)
 }
 
 // ShowMenuItems returns menu items for a specific restaurant
 func (h *Handler) ShowMenuItems(w http.ResponseWriter, r *http.Request) {
-	logger, _ := utils.Logger()
-	logger.Println("ShowMenuItems called")
-
-	// CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	if r.Method != http.MethodGet {
-		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	logger := logging.FromContext(r.Context())
 
 	// Get restaurant_id from query parameter
 	restaurantIDStr := r.URL.Query().Get("restaurant_id")
@@ -185,33 +64,17 @@ func (h *Handler) ShowMenuItems(w http.ResponseWriter, r *http.Request) {
 	menuItems, err := h.restaurantMenuItemsUseCase.ShowMenuItemsByRestaurantID(restaurantID)
 	if err != nil {
 		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
-		logger.Printf("Failed to get menu items for restaurant %s: %v", restaurantID, err)
+		logger.Error("failed to get menu items", "restaurant_id", restaurantID, "error", err)
 		return
 	}
 
 	utils.WriteJSON(w, menuItems, http.StatusOK)
-	logger.Printf("Successfully retrieved %d menu items for restaurant %s", len(menuItems), restaurantID)
+	logger.Debug("retrieved menu items", "restaurant_id", restaurantID, "count", len(menuItems))
 }
 
 // UploadMenuItem uploads a new menu item for a restaurant
 func (h *Handler) UploadMenuItem(w http.ResponseWriter, r *http.Request) {
-	logger, _ := utils.Logger()
-	logger.Println("UploadMenuItem called")
-
-	// CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	if r.Method != http.MethodPost {
-		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	logger := logging.FromContext(r.Context())
 
 	var menuItem pkgmodels.MenuItem
 	if err := json.NewDecoder(r.Body).Decode(&menuItem); err != nil {
@@ -224,6 +87,10 @@ func (h *Handler) UploadMenuItem(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, "restaurant_id is required", http.StatusBadRequest)
 		return
 	}
+	if !auth.ActsFor(r.Context(), menuItem.RestaurantID) {
+		utils.WriteError(w, "credentials do not belong to this restaurant", http.StatusForbidden)
+		return
+	}
 
 	if menuItem.Name == "" {
 		utils.WriteError(w, "name is required", http.StatusBadRequest)
@@ -243,36 +110,20 @@ func (h *Handler) UploadMenuItem(w http.ResponseWriter, r *http.Request) {
 	err := h.restaurantMenuItemsUseCase.UploadMenuItemsByRestaurantID(menuItem)
 	if err != nil {
 		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
-		logger.Printf("Failed to upload menu item: %v", err)
+		logger.Error("failed to upload menu item", "error", err)
 		return
 	}
 
-	utils.WriteJSON(w, map[string]interface{}{
-		"message":       "menu item uploaded successfully",
-		"order_item_id": menuItem.OrderItemID,
+	utils.WriteJSON(w, uploadMenuItemResponse{
+		Message:     "menu item uploaded successfully",
+		OrderItemID: menuItem.OrderItemID,
 	}, http.StatusCreated)
-	logger.Printf("Menu item %s uploaded successfully for restaurant %s", menuItem.Name, menuItem.RestaurantID)
+	logger.Info("menu item uploaded", "restaurant_id", menuItem.RestaurantID, "name", menuItem.Name)
 }
 
 // ListOrders returns orders for a specific restaurant
 func (h *Handler) ListOrders(w http.ResponseWriter, r *http.Request) {
-	logger, _ := utils.Logger()
-	logger.Println("ListOrders called")
-
-	// CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	if r.Method != http.MethodGet {
-		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+	logger := logging.FromContext(r.Context())
 
 	restaurantIDStr := r.URL.Query().Get("restaurant_id")
 	if restaurantIDStr == "" {
@@ -285,16 +136,141 @@ func (h *Handler) ListOrders(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, "invalid restaurant_id format", http.StatusBadRequest)
 		return
 	}
+	if !auth.ActsFor(r.Context(), restaurantID) {
+		utils.WriteError(w, "credentials do not belong to this restaurant", http.StatusForbidden)
+		return
+	}
 
 	status := r.URL.Query().Get("status")
 
 	orders, err := h.ordersUseCase.ListOrdersByRestaurantID(r.Context(), restaurantID, status)
 	if err != nil {
 		utils.WriteError(w, err.Error(), http.StatusInternalServerError)
-		logger.Printf("Failed to list orders for restaurant %s: %v", restaurantID, err)
+		logger.Error("failed to list orders", "restaurant_id", restaurantID, "error", err)
 		return
 	}
 
 	utils.WriteJSON(w, orders, http.StatusOK)
-	logger.Printf("Successfully retrieved %d orders for restaurant %s", len(orders), restaurantID)
+	logger.Debug("retrieved orders", "restaurant_id", restaurantID, "count", len(orders))
+}
+
+// ReserveStock takes the items of an order the kitchen accepted off the menu,
+// for the customer service
+func (h *Handler) ReserveStock(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	orderID, ok := router.UUIDParam(w, r, "order_id")
+	if !ok {
+		return
+	}
+	if err := h.ordersUseCase.ReserveStock(r.Context(), orderID); err != nil {
+		writeStockError(w, err)
+		logger.Info("stock not reserved", "order_id", orderID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("reserved stock", "order_id", orderID)
+}
+
+// OrderCancelled receives cancellation notices from the customer service and
+// returns the stock reserved for the order to the menu. The order must be
+// cancelled in the orders database; the notice only names it.
+func (h *Handler) OrderCancelled(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	var event pkgusecase.OrderCancelledEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if event.OrderID == utils.UuidNil {
+		utils.WriteError(w, "order_id is required", http.StatusBadRequest)
+		return
+	}
+
+	logger.Info("order cancelled by customer", "order_id", event.OrderID, "previous_status", event.PreviousStatus, "reason", event.ReasonCode)
+	released, err := h.ordersUseCase.ReleaseStock(r.Context(), event.OrderID)
+	if err != nil {
+		writeStockError(w, err)
+		logger.Error("failed to release stock", "order_id", event.OrderID, "error", err)
+		return
+	}
+	if released > 0 {
+		logger.Info("released stock", "order_id", event.OrderID, "items", released)
+	}
+
+	utils.WriteJSON(w, orderCancelledResponse{OrderID: event.OrderID, Status: "acknowledged"}, http.StatusOK)
+}
+
+func writeStockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		utils.WriteError(w, "order_id not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrOutOfStock), errors.Is(err, service.ErrOrderState):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	default:
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// Schedule shows (GET) or replaces (POST) opening hours and slot settings
+// used for scheduled orders
+func (h *Handler) Schedule(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		restaurantIDStr := r.URL.Query().Get("restaurant_id")
+		if restaurantIDStr == "" {
+			utils.WriteError(w, "restaurant_id is required", http.StatusBadRequest)
+			return
+		}
+		restaurantID, err := utils.ParseUUID(restaurantIDStr)
+		if err != nil {
+			utils.WriteError(w, "invalid restaurant_id format", http.StatusBadRequest)
+			return
+		}
+
+		schedule, err := h.scheduleUseCase.GetSchedule(r.Context(), restaurantID)
+		if err != nil {
+			if errors.Is(err, repository.ErrRestaurantNotFound) {
+				utils.WriteError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			utils.WriteError(w, err.Error(), http.StatusInternalServerError)
+			logger.Error("failed to get schedule", "restaurant_id", restaurantID, "error", err)
+			return
+		}
+		utils.WriteJSON(w, schedule, http.StatusOK)
+	case http.MethodPost:
+		var schedule pkgmodels.RestaurantSchedule
+		if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if schedule.RestaurantID == utils.UuidNil {
+			utils.WriteError(w, "restaurant_id is required", http.StatusBadRequest)
+			return
+		}
+		if !auth.ActsFor(r.Context(), schedule.RestaurantID) {
+			utils.WriteError(w, "credentials do not belong to this restaurant", http.StatusForbidden)
+			return
+		}
+
+		if err := h.scheduleUseCase.SaveSchedule(r.Context(), schedule); err != nil {
+			switch {
+			case errors.Is(err, usecase.ErrInvalidSchedule):
+				utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, repository.ErrRestaurantNotFound):
+				utils.WriteError(w, err.Error(), http.StatusNotFound)
+			default:
+				utils.WriteError(w, err.Error(), http.StatusInternalServerError)
+				logger.Error("failed to save schedule", "restaurant_id", schedule.RestaurantID, "error", err)
+			}
+			return
+		}
+		utils.WriteJSON(w, schedule, http.StatusOK)
+		logger.Info("schedule saved", "restaurant_id", schedule.RestaurantID)
+	}
 }
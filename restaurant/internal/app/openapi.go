@@ -0,0 +1,118 @@
+package app
+
+import (
+	"net/http"
+
+	orderapp "github.com/Kabanya/YAFDS/pkg/app"
+	"github.com/Kabanya/YAFDS/pkg/metrics"
+	pkgmodels "github.com/Kabanya/YAFDS/pkg/models"
+	"github.com/Kabanya/YAFDS/pkg/openapi"
+	"github.com/Kabanya/YAFDS/pkg/server"
+	pkgusecase "github.com/Kabanya/YAFDS/pkg/usecase"
+	"github.com/Kabanya/YAFDS/pkg/user"
+)
+
+// apiDocument describes every route Run mounts.
+func apiDocument() *openapi.Document {
+	doc := openapi.New("YAFDS restaurant API", "1.0.0")
+	doc.AddAll(server.Operations)
+	doc.Add("GET /metrics", metrics.Operation)
+	doc.AddAll(openapi.Operations)
+	doc.AddAll(user.Operations(restaurantSchema))
+	doc.AddAll(orderapp.Operations,
+		"GET /.well-known/jwks.json",
+		"GET /api-keys",
+		"POST /api-keys",
+		"DELETE /api-keys/{key_id}",
+		"GET /reviews",
+		"POST /reviews/reply",
+		"GET /notifications/preferences",
+		"PUT /notifications/preferences",
+		"GET /webhooks",
+		"POST /webhooks",
+		"DELETE /webhooks/{webhook_id}",
+		"POST /webhooks/{webhook_id}/test",
+		"GET /webhooks/{webhook_id}/deliveries",
+		"POST /webhooks/deliveries/{delivery_id}/redeliver",
+	)
+	doc.AddAll(restaurantOperations)
+	return doc
+}
+
+var restaurantIDParam = openapi.Param{Name: "restaurant_id", Required: true, Schema: openapi.UUID()}
+
+// guarded are the answers of auth.Authenticator.Require besides success.
+func guarded(status int, body any) map[int]any {
+	return map[int]any{
+		status:                  body,
+		http.StatusUnauthorized: pkgmodels.ErrorResponce{},
+		http.StatusForbidden:    pkgmodels.ErrorResponce{},
+	}
+}
+
+// serviceCall are the answers of auth.RequireServiceToken and
+// writeStockError besides success.
+func serviceCall(status int, body any) map[int]any {
+	return map[int]any{
+		status:                        body,
+		http.StatusUnauthorized:       pkgmodels.ErrorResponce{},
+		http.StatusNotFound:           pkgmodels.ErrorResponce{},
+		http.StatusConflict:           pkgmodels.ErrorResponce{},
+		http.StatusServiceUnavailable: pkgmodels.ErrorResponce{},
+	}
+}
+
+var restaurantOperations = map[string]openapi.Op{
+	"GET /orders": {
+		Summary:     "List restaurant orders",
+		Description: "Needs the orders:read scope.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Query: []openapi.Param{
+			restaurantIDParam,
+			{Name: "status", Description: "Order status, e.g. CUSTOMER_PAID"},
+		},
+		Responses: guarded(http.StatusOK, []pkgmodels.Order{}),
+	},
+	"POST /orders/{order_id}/reserve": {
+		Summary:     "Reserve stock of an accepted order",
+		Description: "Sent by the customer service once the kitchen accepts. The items are read from the order; 409 means the menu cannot cover them.",
+		Security:    []string{openapi.ServiceToken},
+		Responses:   serviceCall(http.StatusNoContent, nil),
+	},
+	"POST /orders/cancelled": {
+		Summary:     "Customer cancellation notice",
+		Description: "Sent by the customer service; returns the stock reserved for the cancelled order to the menu.",
+		Security:    []string{openapi.ServiceToken},
+		Body:        pkgusecase.OrderCancelledEvent{},
+		Responses:   serviceCall(http.StatusOK, orderCancelledResponse{}),
+	},
+	"GET /menu/show": {
+		Summary:   "Show menu items",
+		Query:     []openapi.Param{restaurantIDParam},
+		Responses: map[int]any{http.StatusOK: []pkgmodels.MenuItem{}},
+	},
+	"POST /menu/upload": {
+		Summary:     "Upload menu item",
+		Description: "Needs the menu:write scope. The price must be greater than 0; order_item_id is generated when omitted.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Body:        pkgmodels.MenuItem{},
+		Responses:   guarded(http.StatusCreated, uploadMenuItemResponse{}),
+	},
+	"GET /schedule": {
+		Summary:   "Show opening hours and slot settings",
+		Query:     []openapi.Param{restaurantIDParam},
+		Responses: map[int]any{http.StatusOK: pkgmodels.RestaurantSchedule{}, http.StatusNotFound: pkgmodels.ErrorResponce{}},
+	},
+	"POST /schedule": {
+		Summary:     "Replace opening hours and slot settings",
+		Description: "Needs the schedule:write scope.",
+		Security:    []string{openapi.Bearer, openapi.APIKey},
+		Body:        pkgmodels.RestaurantSchedule{},
+		Responses: map[int]any{
+			http.StatusOK:           pkgmodels.RestaurantSchedule{},
+			http.StatusUnauthorized: pkgmodels.ErrorResponce{},
+			http.StatusForbidden:    pkgmodels.ErrorResponce{},
+			http.StatusNotFound:     pkgmodels.ErrorResponce{},
+		},
+	},
+}
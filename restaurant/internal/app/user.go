@@ -0,0 +1,14 @@
+package app
+
+import "github.com/Kabanya/YAFDS/pkg/user"
+
+// restaurantSchema maps the RESTAURANTS table for the shared user module;
+// the status column is exposed as is_active.
+var restaurantSchema = user.Schema{
+	Table:  "RESTAURANTS",
+	Entity: "restaurant",
+	Fields: []user.Field{
+		{Column: "address", Required: true},
+		{Column: "status", JSON: "is_active", Kind: user.Bool},
+	},
+}
@@ -14,8 +14,12 @@ import (
 
 type OrdersRepo interface {
 	ListOrdersByRestaurantID(ctx context.Context, restaurantID uuid.UUID, status string) ([]models.Order, error)
+	GetOrderStatus(ctx context.Context, orderID uuid.UUID) (models.OrderStatus, error)
+	ListOrderItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error)
 }
 
+var ErrOrderNotFound = errors.New("order not found")
+
 type ordersRepo struct {
 	ordersDB     *sql.DB
 	restaurantDB *sql.DB
@@ -97,3 +101,32 @@ func (r *ordersRepo) ListOrdersByRestaurantID(ctx context.Context, restaurantID
 
 	return result, nil
 }
+
+func (r *ordersRepo) GetOrderStatus(ctx context.Context, orderID uuid.UUID) (models.OrderStatus, error) {
+	var status string
+	err := r.ordersDB.QueryRowContext(ctx, "SELECT status FROM ORDERS WHERE emp_id = $1", orderID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrOrderNotFound
+	}
+	return models.OrderStatus(status), err
+}
+
+// ListOrderItems reads the items of an order from the orders database, the
+// source of truth for what the kitchen reserves.
+func (r *ordersRepo) ListOrderItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error) {
+	rows, err := r.ordersDB.QueryContext(ctx, "SELECT restaurant_item_id, price, quantity FROM ORDERS_ITEMS WHERE order_id = $1", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.OrderItem
+	for rows.Next() {
+		var item models.OrderItem
+		if err := rows.Scan(&item.RestaurantItemID, &item.Price, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
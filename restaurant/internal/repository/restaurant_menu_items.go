@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
 
 	"github.com/Kabanya/YAFDS/pkg/models"
-	"github.com/Kabanya/YAFDS/pkg/utils"
+	pkgusecase "github.com/Kabanya/YAFDS/pkg/usecase"
 
 	"github.com/google/uuid"
 )
@@ -12,8 +14,13 @@ import (
 type RestaurantMenuItemsRepo interface {
 	ShowMenuItemsByRestaurantID(restaurantID uuid.UUID) ([]models.MenuItem, error)
 	UploadMenuItemsByRestaurantID(menuItem models.MenuItem) error
+	ReserveStock(ctx context.Context, orderID uuid.UUID, items []models.OrderItem) error
+	ReleaseStock(ctx context.Context, orderID uuid.UUID) (int, error)
 }
 
+// ErrOutOfStock means the menu cannot cover an order's items.
+var ErrOutOfStock = pkgusecase.ErrOutOfStock
+
 type restaurantMenuItemsRepo struct { //с маленькой = private; большая - public
 	db *sql.DB
 }
@@ -23,10 +30,6 @@ func NewRestaurantMenuItemsRepo(db *sql.DB) *restaurantMenuItemsRepo {
 }
 
 func (r *restaurantMenuItemsRepo) ShowMenuItemsByRestaurantID(restaurantID uuid.UUID) ([]models.MenuItem, error) {
-	logger, err := utils.Logger()
-	if err != nil {
-		return nil, err
-	}
 	sqlStatement := `
 	       SELECT order_item_id, restaurant_id, name, price, quantity, description
 	       FROM restaurant_menu_items
@@ -34,7 +37,7 @@ func (r *restaurantMenuItemsRepo) ShowMenuItemsByRestaurantID(restaurantID uuid.
        `
 	rows, err := r.db.Query(sqlStatement, restaurantID)
 	if err != nil {
-		logger.Printf("Failed to execute query: %v", err)
+		slog.Error("menu items: query failed", "restaurant_id", restaurantID, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -44,35 +47,123 @@ func (r *restaurantMenuItemsRepo) ShowMenuItemsByRestaurantID(restaurantID uuid.
 		var item models.MenuItem
 		err := rows.Scan(&item.OrderItemID, &item.RestaurantID, &item.Name, &item.Price, &item.Quantity, &item.Description)
 		if err != nil {
-			logger.Printf("Failed to scan row: %v", err)
+			slog.Error("menu items: scan failed", "error", err)
 			return nil, err
 		}
 		menuItems = append(menuItems, item)
 	}
 	if err = rows.Err(); err != nil {
-		logger.Printf("Rows error: %v", err)
+		slog.Error("menu items: rows failed", "error", err)
 		return nil, err
 	}
 	return menuItems, nil
 }
 
 func (r *restaurantMenuItemsRepo) UploadMenuItemsByRestaurantID(menuItem models.MenuItem) error {
-	logger, err := utils.Logger()
-	if err != nil {
-		return err
-	}
-
 	sqlStatement := `
 	       INSERT INTO restaurant_menu_items (order_item_id, restaurant_id, name, price, quantity, description)
 	       VALUES ($1, $2, $3, $4, $5, $6)
 	   `
 
-	_, err = r.db.Exec(sqlStatement, menuItem.OrderItemID, menuItem.RestaurantID, menuItem.Name, menuItem.Price, menuItem.Quantity, menuItem.Description)
+	_, err := r.db.Exec(sqlStatement, menuItem.OrderItemID, menuItem.RestaurantID, menuItem.Name, menuItem.Price, menuItem.Quantity, menuItem.Description)
 	if err != nil {
-		logger.Printf("Failed to execute insert: %v", err)
+		slog.Error("menu items: insert failed", "restaurant_id", menuItem.RestaurantID, "error", err)
 		return err
 	}
 
-	logger.Printf("Successfully inserted menu item: %s for restaurant: %s", menuItem.Name, menuItem.RestaurantID)
+	slog.Debug("menu items: inserted", "restaurant_id", menuItem.RestaurantID, "name", menuItem.Name)
 	return nil
 }
+
+// ReserveStock takes the items of an accepted order off the menu and
+// records what it took, all or nothing. Reserving an order twice changes
+// nothing.
+func (r *restaurantMenuItemsRepo) ReserveStock(ctx context.Context, orderID uuid.UUID, items []models.OrderItem) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var reserved bool
+	if err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM restaurant_stock_reservations WHERE order_id = $1)", orderID).Scan(&reserved); err != nil {
+		return err
+	}
+	if reserved {
+		return tx.Commit()
+	}
+
+	for _, item := range items {
+		var res sql.Result
+		res, err = tx.ExecContext(ctx, "UPDATE restaurant_menu_items SET quantity = quantity - $1 WHERE order_item_id = $2 AND quantity >= $1", item.Quantity, item.RestaurantItemID)
+		if err != nil {
+			return err
+		}
+		var rows int64
+		if rows, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		if rows == 0 {
+			err = ErrOutOfStock
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `
+			INSERT INTO restaurant_stock_reservations (order_id, order_item_id, quantity)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (order_id, order_item_id) DO UPDATE SET quantity = restaurant_stock_reservations.quantity + EXCLUDED.quantity
+		`, orderID, item.RestaurantItemID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReleaseStock puts back what ReserveStock took for a cancelled order and
+// reports how many lines it returned. Releasing twice returns nothing.
+func (r *restaurantMenuItemsRepo) ReleaseStock(ctx context.Context, orderID uuid.UUID) (released int, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE restaurant_stock_reservations SET released_at = NOW()
+		WHERE order_id = $1 AND released_at IS NULL
+		RETURNING order_item_id, quantity
+	`, orderID)
+	if err != nil {
+		return 0, err
+	}
+	var items []models.OrderItem
+	for rows.Next() {
+		var item models.OrderItem
+		if err = rows.Scan(&item.RestaurantItemID, &item.Quantity); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		if _, err = tx.ExecContext(ctx, "UPDATE restaurant_menu_items SET quantity = quantity + $1 WHERE order_item_id = $2", item.Quantity, item.RestaurantItemID); err != nil {
+			return 0, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
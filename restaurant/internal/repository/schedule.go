@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrRestaurantNotFound = errors.New("restaurant not found")
+
+type ScheduleRepo interface {
+	GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error)
+	SaveSchedule(ctx context.Context, schedule models.RestaurantSchedule) error
+}
+
+type scheduleRepo struct {
+	db *sql.DB
+}
+
+func NewScheduleRepo(db *sql.DB) ScheduleRepo {
+	return &scheduleRepo{db: db}
+}
+
+func (r *scheduleRepo) GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error) {
+	schedule := models.RestaurantSchedule{RestaurantID: restaurantID, Hours: []models.OpeningHours{}}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT slot_minutes, slot_capacity, prep_lead_minutes, tz
+		FROM RESTAURANTS
+		WHERE emp_id = $1
+	`, restaurantID).Scan(&schedule.SlotMinutes, &schedule.SlotCapacity, &schedule.PrepLeadMinutes, &schedule.TZ)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RestaurantSchedule{}, ErrRestaurantNotFound
+		}
+		return models.RestaurantSchedule{}, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT weekday, opens, closes
+		FROM RESTAURANT_OPENING_HOURS
+		WHERE restaurant_id = $1
+		ORDER BY weekday
+	`, restaurantID)
+	if err != nil {
+		return models.RestaurantSchedule{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hours models.OpeningHours
+		if err := rows.Scan(&hours.Weekday, &hours.Opens, &hours.Closes); err != nil {
+			return models.RestaurantSchedule{}, err
+		}
+		schedule.Hours = append(schedule.Hours, hours)
+	}
+	if err := rows.Err(); err != nil {
+		return models.RestaurantSchedule{}, err
+	}
+	return schedule, nil
+}
+
+// SaveSchedule replaces the slot settings and all opening hours of a restaurant.
+func (r *scheduleRepo) SaveSchedule(ctx context.Context, schedule models.RestaurantSchedule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE RESTAURANTS SET slot_minutes = $1, slot_capacity = $2, prep_lead_minutes = $3, tz = $4
+		WHERE emp_id = $5
+	`, schedule.SlotMinutes, schedule.SlotCapacity, schedule.PrepLeadMinutes, schedule.TZ, schedule.RestaurantID)
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		err = ErrRestaurantNotFound
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM RESTAURANT_OPENING_HOURS WHERE restaurant_id = $1", schedule.RestaurantID); err != nil {
+		return err
+	}
+	for _, hours := range schedule.Hours {
+		if _, err = tx.ExecContext(ctx, `
+			INSERT INTO RESTAURANT_OPENING_HOURS (restaurant_id, weekday, opens, closes)
+			VALUES ($1, $2, $3, $4)
+		`, schedule.RestaurantID, int(hours.Weekday), hours.Opens, hours.Closes); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"restaurant/internal/repository"
 
@@ -10,18 +12,53 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrOrderState means the order is not in the status the call is for.
+var ErrOrderState = errors.New("order is not in the required status")
+
 type OrdersService interface {
 	ListOrdersByRestaurantID(ctx context.Context, restaurantID uuid.UUID, status string) ([]models.Order, error)
+	ReserveStock(ctx context.Context, orderID uuid.UUID) error
+	ReleaseStock(ctx context.Context, orderID uuid.UUID) (int, error)
 }
 
 type ordersService struct {
-	repo repository.OrdersRepo
+	repo  repository.OrdersRepo
+	stock repository.RestaurantMenuItemsRepo
 }
 
-func NewOrdersService(repo repository.OrdersRepo) OrdersService {
-	return &ordersService{repo: repo}
+func NewOrdersService(repo repository.OrdersRepo, stock repository.RestaurantMenuItemsRepo) OrdersService {
+	return &ordersService{repo: repo, stock: stock}
 }
 
 func (s *ordersService) ListOrdersByRestaurantID(ctx context.Context, restaurantID uuid.UUID, status string) ([]models.Order, error) {
 	return s.repo.ListOrdersByRestaurantID(ctx, restaurantID, status)
 }
+
+// ReserveStock takes the items of an order the kitchen accepted off the
+// menu. The items come from the order, never from the caller.
+func (s *ordersService) ReserveStock(ctx context.Context, orderID uuid.UUID) error {
+	status, err := s.repo.GetOrderStatus(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if status != models.OrderStatusKitchenAccepted {
+		return fmt.Errorf("%w: %s", ErrOrderState, status)
+	}
+	items, err := s.repo.ListOrderItems(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return s.stock.ReserveStock(ctx, orderID, items)
+}
+
+// ReleaseStock returns what was reserved for an order once it is cancelled.
+func (s *ordersService) ReleaseStock(ctx context.Context, orderID uuid.UUID) (int, error) {
+	status, err := s.repo.GetOrderStatus(ctx, orderID)
+	if err != nil {
+		return 0, err
+	}
+	if status != models.OrderStatusCustomerCancelled {
+		return 0, fmt.Errorf("%w: %s", ErrOrderState, status)
+	}
+	return s.stock.ReleaseStock(ctx, orderID)
+}
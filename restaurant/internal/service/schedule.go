@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"restaurant/internal/repository"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+type ScheduleService interface {
+	GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error)
+	SaveSchedule(ctx context.Context, schedule models.RestaurantSchedule) error
+}
+
+type scheduleService struct {
+	repo repository.ScheduleRepo
+}
+
+func NewScheduleService(repo repository.ScheduleRepo) ScheduleService {
+	return &scheduleService{repo: repo}
+}
+
+func (s *scheduleService) GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error) {
+	return s.repo.GetSchedule(ctx, restaurantID)
+}
+
+func (s *scheduleService) SaveSchedule(ctx context.Context, schedule models.RestaurantSchedule) error {
+	return s.repo.SaveSchedule(ctx, schedule)
+}
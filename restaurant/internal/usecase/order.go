@@ -12,6 +12,8 @@ import (
 
 type OrdersUseCase interface {
 	ListOrdersByRestaurantID(ctx context.Context, restaurantID uuid.UUID, status string) ([]models.Order, error)
+	ReserveStock(ctx context.Context, orderID uuid.UUID) error
+	ReleaseStock(ctx context.Context, orderID uuid.UUID) (int, error)
 }
 
 type ordersUseCase struct {
@@ -25,3 +27,11 @@ func NewOrdersUseCase(service service.OrdersService) OrdersUseCase {
 func (u *ordersUseCase) ListOrdersByRestaurantID(ctx context.Context, restaurantID uuid.UUID, status string) ([]models.Order, error) {
 	return u.service.ListOrdersByRestaurantID(ctx, restaurantID, status)
 }
+
+func (u *ordersUseCase) ReserveStock(ctx context.Context, orderID uuid.UUID) error {
+	return u.service.ReserveStock(ctx, orderID)
+}
+
+func (u *ordersUseCase) ReleaseStock(ctx context.Context, orderID uuid.UUID) (int, error) {
+	return u.service.ReleaseStock(ctx, orderID)
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"restaurant/internal/service"
+
+	"github.com/Kabanya/YAFDS/pkg/models"
+	pkgusecase "github.com/Kabanya/YAFDS/pkg/usecase"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+type ScheduleUseCase interface {
+	GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error)
+	SaveSchedule(ctx context.Context, schedule models.RestaurantSchedule) error
+}
+
+type scheduleUseCase struct {
+	service service.ScheduleService
+}
+
+func NewScheduleUseCase(service service.ScheduleService) ScheduleUseCase {
+	return &scheduleUseCase{service: service}
+}
+
+func (u *scheduleUseCase) GetSchedule(ctx context.Context, restaurantID uuid.UUID) (models.RestaurantSchedule, error) {
+	return u.service.GetSchedule(ctx, restaurantID)
+}
+
+func (u *scheduleUseCase) SaveSchedule(ctx context.Context, schedule models.RestaurantSchedule) error {
+	if schedule.SlotMinutes <= 0 || schedule.SlotCapacity < 0 || schedule.PrepLeadMinutes < 0 {
+		return fmt.Errorf("%w: slot_minutes must be positive, slot_capacity and prep_lead_minutes not negative", ErrInvalidSchedule)
+	}
+	if _, err := pkgusecase.ScheduleLocation(schedule.TZ); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	seen := make(map[int]bool, len(schedule.Hours))
+	for _, hours := range schedule.Hours {
+		if hours.Weekday < 0 || hours.Weekday > 6 {
+			return fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6", ErrInvalidSchedule)
+		}
+		if seen[int(hours.Weekday)] {
+			return fmt.Errorf("%w: weekday %d listed twice", ErrInvalidSchedule, hours.Weekday)
+		}
+		seen[int(hours.Weekday)] = true
+		if _, _, err := pkgusecase.ParseOpeningHours(hours); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+	}
+	return u.service.SaveSchedule(ctx, schedule)
+}